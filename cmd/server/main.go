@@ -9,7 +9,6 @@ import (
 	"flag"
 	"fmt"
 	"io/fs"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +16,9 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/giofahreza/AIProxyAPI/internal/access/config_access"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/copilot"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/vertex"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
 	"github.com/giofahreza/AIProxyAPI/internal/buildinfo"
 	"github.com/giofahreza/AIProxyAPI/internal/cmd"
 	"github.com/giofahreza/AIProxyAPI/internal/config"
@@ -61,10 +63,23 @@ func main() {
 	var iflowLogin bool
 	var iflowCookie bool
 	var copilotLogin bool
+	var githubHost string
+	var githubAPIHost string
+	var copilotAPIBase string
+	var githubClientID string
 	var noBrowser bool
 	var antigravityLogin bool
 	var projectID string
 	var vertexImport string
+	var vertexADC bool
+	var vertexRegions string
+	var vertexImpersonate string
+	var importLocalCreds bool
+	var reencryptAuths bool
+	var migrateStore bool
+	var migrateFrom string
+	var migrateTo string
+	var migrateDryRun bool
 	var configPath string
 	var password string
 
@@ -76,11 +91,24 @@ func main() {
 	flag.BoolVar(&iflowLogin, "iflow-login", false, "Login to iFlow using OAuth")
 	flag.BoolVar(&iflowCookie, "iflow-cookie", false, "Login to iFlow using Cookie")
 	flag.BoolVar(&copilotLogin, "copilot-login", false, "Login to GitHub Copilot using OAuth")
+	flag.StringVar(&githubHost, "github-host", "", "GitHub Enterprise host for -copilot-login (default github.com)")
+	flag.StringVar(&githubAPIHost, "github-api-host", "", "GitHub API host for -copilot-login (default derived from -github-host)")
+	flag.StringVar(&copilotAPIBase, "copilot-api-base", "", "Copilot API base URL for -copilot-login (default taken from the Copilot token)")
+	flag.StringVar(&githubClientID, "github-client-id", "", "OAuth app client ID on -github-host for -copilot-login (default the github.com Copilot app)")
 	flag.BoolVar(&noBrowser, "no-browser", false, "Don't open browser automatically for OAuth")
 	flag.BoolVar(&antigravityLogin, "antigravity-login", false, "Login to Antigravity using OAuth")
-	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
+	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini and -vertex-adc, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.BoolVar(&vertexADC, "vertex-adc", false, "Register a Vertex credential that uses Application Default Credentials (e.g. GKE workload identity)")
+	flag.StringVar(&vertexRegions, "vertex-regions", "", "Comma-separated Vertex regions to fail over between, for -vertex-import and -vertex-adc")
+	flag.StringVar(&vertexImpersonate, "vertex-impersonate", "", "Service account for -vertex-adc to impersonate")
+	flag.BoolVar(&importLocalCreds, "import-local-creds", false, "Import accounts signed in to the Claude Code, Codex and Gemini CLIs on this machine")
+	flag.BoolVar(&reencryptAuths, "reencrypt-auths", false, "Re-encrypt all stored auth records with the active encryption key")
+	flag.BoolVar(&migrateStore, "migrate-store", false, "Copy auth records, config and usage statistics between storage backends")
+	flag.StringVar(&migrateFrom, "migrate-from", "", "Source backend for -migrate-store: file, postgres, sqlite, git or object (default: the backend selected by the environment)")
+	flag.StringVar(&migrateTo, "migrate-to", "", "Destination backend for -migrate-store: file, postgres, sqlite, git or object")
+	flag.BoolVar(&migrateDryRun, "migrate-dry-run", false, "Report what -migrate-store would change without writing anything")
 	flag.StringVar(&password, "password", "", "")
 
 	flag.CommandLine.Usage = func() {
@@ -123,6 +151,10 @@ func main() {
 		pgStoreSchema        string
 		pgStoreLocalPath     string
 		pgStoreInst          *store.PostgresStore
+		useSQLiteStore       bool
+		sqliteStorePath      string
+		sqliteStoreLocalPath string
+		sqliteStoreInst      *store.SQLiteStore
 		useGitStore          bool
 		gitStoreRemoteURL    string
 		gitStoreUser         string
@@ -183,6 +215,13 @@ func main() {
 		}
 		useGitStore = false
 	}
+	if value, ok := lookupEnv("SQLITESTORE_PATH", "sqlitestore_path"); ok && !usePostgresStore {
+		useSQLiteStore = true
+		sqliteStorePath = value
+	}
+	if value, ok := lookupEnv("SQLITESTORE_LOCAL_PATH", "sqlitestore_local_path"); ok {
+		sqliteStoreLocalPath = value
+	}
 	if value, ok := lookupEnv("GITSTORE_GIT_URL", "gitstore_git_url"); ok {
 		useGitStore = true
 		gitStoreRemoteURL = value
@@ -213,6 +252,125 @@ func main() {
 		objectStoreLocalPath = value
 	}
 
+	// Load the auth record encryption keys before any store reads or writes records.
+	encryptionKeys, _ := lookupEnv("AUTH_ENCRYPTION_KEY", "auth_encryption_key")
+	encryptionKeyFile, _ := lookupEnv("AUTH_ENCRYPTION_KEY_FILE", "auth_encryption_key_file")
+	keyring, errKeyring := authcrypt.LoadKeyring(encryptionKeys, encryptionKeyFile)
+	if errKeyring != nil {
+		log.Errorf("failed to load auth encryption keys: %v", errKeyring)
+		return
+	}
+	authcrypt.SetKeyring(keyring)
+	if keyring != nil {
+		log.Infof("auth record encryption enabled, active key: %s", keyring.ActiveKeyID())
+	} else if useGitStore {
+		log.Warn("git-backed token store is pushing plaintext credentials; set AUTH_ENCRYPTION_KEY to encrypt them")
+	}
+
+	// Store migration reads both backends from the environment and exits without starting the server.
+	if migrateStore {
+		migrationEndpoint := func(kind string) (store.MigrationEndpoint, error) {
+			endpoint := store.MigrationEndpoint{Kind: strings.ToLower(strings.TrimSpace(kind))}
+			switch endpoint.Kind {
+			case store.BackendFile:
+				endpoint.ConfigPath = configPath
+				if endpoint.ConfigPath == "" {
+					endpoint.ConfigPath = filepath.Join(wd, "config.yaml")
+				}
+				fileCfg, errLoad := config.LoadConfigOptional(endpoint.ConfigPath, false)
+				if errLoad != nil {
+					return endpoint, fmt.Errorf("load %s: %w", endpoint.ConfigPath, errLoad)
+				}
+				if fileCfg == nil || strings.TrimSpace(fileCfg.AuthDir) == "" {
+					return endpoint, fmt.Errorf("auth-dir is not set in %s", endpoint.ConfigPath)
+				}
+				resolved, errResolve := util.ResolveAuthDir(fileCfg.AuthDir)
+				if errResolve != nil {
+					return endpoint, fmt.Errorf("resolve auth-dir: %w", errResolve)
+				}
+				endpoint.AuthDir = resolved
+			case store.BackendPostgres:
+				if pgStoreDSN == "" {
+					return endpoint, fmt.Errorf("PGSTORE_DSN is not set")
+				}
+				endpoint.Postgres = store.PostgresStoreConfig{DSN: pgStoreDSN, Schema: pgStoreSchema}
+			case store.BackendSQLite:
+				path := sqliteStorePath
+				if path == "" {
+					fileConfigPath := configPath
+					if fileConfigPath == "" {
+						fileConfigPath = filepath.Join(wd, "config.yaml")
+					}
+					if fileCfg, errLoad := config.LoadConfigOptional(fileConfigPath, false); errLoad == nil && fileCfg != nil {
+						path = fileCfg.SQLiteStorePath
+					}
+				}
+				if strings.TrimSpace(path) == "" {
+					return endpoint, fmt.Errorf("SQLITESTORE_PATH is not set and the config has no sqlite-store-path")
+				}
+				resolved, errResolve := util.ResolveAuthDir(path)
+				if errResolve != nil {
+					return endpoint, fmt.Errorf("resolve sqlite path: %w", errResolve)
+				}
+				endpoint.SQLitePath = resolved
+			case store.BackendGit:
+				if gitStoreRemoteURL == "" {
+					return endpoint, fmt.Errorf("GITSTORE_GIT_URL is not set")
+				}
+				endpoint.GitRemote, endpoint.GitUsername, endpoint.GitPassword = gitStoreRemoteURL, gitStoreUser, gitStorePassword
+			case store.BackendObject:
+				if objectStoreEndpoint == "" {
+					return endpoint, fmt.Errorf("OBJECTSTORE_ENDPOINT is not set")
+				}
+				resolvedEndpoint, useSSL, errEndpoint := store.ParseObjectEndpoint(objectStoreEndpoint)
+				if errEndpoint != nil {
+					return endpoint, errEndpoint
+				}
+				endpoint.Object = store.ObjectStoreConfig{
+					Endpoint:  resolvedEndpoint,
+					Bucket:    objectStoreBucket,
+					AccessKey: objectStoreAccess,
+					SecretKey: objectStoreSecret,
+					UseSSL:    useSSL,
+					PathStyle: true,
+				}
+			default:
+				return endpoint, fmt.Errorf("unknown backend %q (want file, postgres, sqlite, git or object)", kind)
+			}
+			return endpoint, nil
+		}
+		if migrateFrom == "" {
+			switch {
+			case usePostgresStore:
+				migrateFrom = store.BackendPostgres
+			case useSQLiteStore:
+				migrateFrom = store.BackendSQLite
+			case useObjectStore:
+				migrateFrom = store.BackendObject
+			case useGitStore:
+				migrateFrom = store.BackendGit
+			default:
+				migrateFrom = store.BackendFile
+			}
+		}
+		if migrateTo == "" {
+			log.Error("migrate-store: -migrate-to is required")
+			return
+		}
+		from, errFrom := migrationEndpoint(migrateFrom)
+		if errFrom != nil {
+			log.Errorf("migrate-store: source: %v", errFrom)
+			return
+		}
+		to, errTo := migrationEndpoint(migrateTo)
+		if errTo != nil {
+			log.Errorf("migrate-store: destination: %v", errTo)
+			return
+		}
+		cmd.DoMigrateStore(from, to, migrateDryRun)
+		return
+	}
+
 	// Check for cloud deploy mode only on first execution
 	// Read env var name in uppercase: DEPLOY
 	deployEnv := os.Getenv("DEPLOY")
@@ -253,6 +411,37 @@ func main() {
 			cfg.AuthDir = pgStoreInst.AuthDir()
 			log.Infof("postgres-backed token store enabled, workspace path: %s", pgStoreInst.WorkDir())
 		}
+	} else if useSQLiteStore {
+		resolvedPath, errResolve := util.ResolveAuthDir(sqliteStorePath)
+		if errResolve != nil {
+			log.Errorf("failed to resolve sqlite store path: %v", errResolve)
+			return
+		}
+		spoolDir := ""
+		if sqliteStoreLocalPath != "" {
+			spoolDir = filepath.Join(sqliteStoreLocalPath, "sqlitestore")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		sqliteStoreInst, err = store.NewSQLiteStore(ctx, store.SQLiteStoreConfig{Path: resolvedPath, SpoolDir: spoolDir})
+		cancel()
+		if err != nil {
+			log.Errorf("failed to initialize sqlite token store: %v", err)
+			return
+		}
+		examplePath := filepath.Join(wd, "config.example.yaml")
+		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+		if errBootstrap := sqliteStoreInst.Bootstrap(ctx, examplePath); errBootstrap != nil {
+			cancel()
+			log.Errorf("failed to bootstrap sqlite-backed config: %v", errBootstrap)
+			return
+		}
+		cancel()
+		configFilePath = sqliteStoreInst.ConfigPath()
+		cfg, err = config.LoadConfigOptional(configFilePath, isCloudDeploy)
+		if err == nil {
+			cfg.AuthDir = sqliteStoreInst.AuthDir()
+			log.Infof("sqlite-backed token store enabled, database: %s", resolvedPath)
+		}
 	} else if useObjectStore {
 		if objectStoreLocalPath == "" {
 			if writableBase != "" {
@@ -262,33 +451,11 @@ func main() {
 			}
 		}
 		objectStoreRoot := filepath.Join(objectStoreLocalPath, "objectstore")
-		resolvedEndpoint := strings.TrimSpace(objectStoreEndpoint)
-		useSSL := true
-		if strings.Contains(resolvedEndpoint, "://") {
-			parsed, errParse := url.Parse(resolvedEndpoint)
-			if errParse != nil {
-				log.Errorf("failed to parse object store endpoint %q: %v", objectStoreEndpoint, errParse)
-				return
-			}
-			switch strings.ToLower(parsed.Scheme) {
-			case "http":
-				useSSL = false
-			case "https":
-				useSSL = true
-			default:
-				log.Errorf("unsupported object store scheme %q (only http and https are allowed)", parsed.Scheme)
-				return
-			}
-			if parsed.Host == "" {
-				log.Errorf("object store endpoint %q is missing host information", objectStoreEndpoint)
-				return
-			}
-			resolvedEndpoint = parsed.Host
-			if parsed.Path != "" && parsed.Path != "/" {
-				resolvedEndpoint = strings.TrimSuffix(parsed.Host+parsed.Path, "/")
-			}
+		resolvedEndpoint, useSSL, errEndpoint := store.ParseObjectEndpoint(objectStoreEndpoint)
+		if errEndpoint != nil {
+			log.Errorf("failed to configure object store: %v", errEndpoint)
+			return
 		}
-		resolvedEndpoint = strings.TrimRight(resolvedEndpoint, "/")
 		objCfg := store.ObjectStoreConfig{
 			Endpoint:  resolvedEndpoint,
 			Bucket:    objectStoreBucket,
@@ -405,6 +572,7 @@ func main() {
 		}
 	}
 	usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
+	usage.ApplyRetention(cfg.UsageRetention)
 	coreauth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 
 	if err = logging.ConfigureLogOutput(cfg); err != nil {
@@ -423,6 +591,28 @@ func main() {
 	} else {
 		cfg.AuthDir = resolvedAuthDir
 	}
+
+	// A file-based config can move auth records and usage history into SQLite on its own.
+	if !usePostgresStore && !useSQLiteStore && !useObjectStore && !useGitStore && strings.TrimSpace(cfg.SQLiteStorePath) != "" {
+		resolvedPath, errResolve := util.ResolveAuthDir(cfg.SQLiteStorePath)
+		if errResolve != nil {
+			log.Errorf("failed to resolve sqlite-store-path: %v", errResolve)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		sqliteStoreInst, err = store.NewSQLiteStore(ctx, store.SQLiteStoreConfig{Path: resolvedPath, ConfigPath: configFilePath})
+		if err == nil {
+			err = sqliteStoreInst.Bootstrap(ctx, "")
+		}
+		cancel()
+		if err != nil {
+			log.Errorf("failed to initialize sqlite token store: %v", err)
+			return
+		}
+		useSQLiteStore = true
+		cfg.AuthDir = sqliteStoreInst.AuthDir()
+		log.Infof("sqlite-backed token store enabled, database: %s", resolvedPath)
+	}
 	managementasset.SetCurrentConfig(cfg)
 
 	// Create login options to be used in authentication flows.
@@ -433,6 +623,8 @@ func main() {
 	// Register the shared token store once so all components use the same persistence backend.
 	if usePostgresStore {
 		sdkAuth.RegisterTokenStore(pgStoreInst)
+	} else if useSQLiteStore {
+		sdkAuth.RegisterTokenStore(sqliteStoreInst)
 	} else if useObjectStore {
 		sdkAuth.RegisterTokenStore(objectStoreInst)
 	} else if useGitStore {
@@ -441,22 +633,36 @@ func main() {
 		sdkAuth.RegisterTokenStore(sdkAuth.NewFileTokenStore())
 	}
 
-	// Register the usage statistics store for file-based persistence.
-	if cfg.AuthDir != "" {
+	// Register the usage statistics store. SQLite keeps one row per request; the other backends
+	// journal into a file in the auth directory and checkpoint it periodically.
+	var usageStore usage.UsageStore
+	if useSQLiteStore {
+		usageStore = sqliteStoreInst
+	} else if cfg.AuthDir != "" {
 		fileStore, err := store.NewFileStore(cfg.AuthDir)
 		if err != nil {
 			log.WithError(err).Warn("failed to initialize file-based usage statistics store")
 		} else {
-			usage.RegisterUsageStore(fileStore)
-			// Load existing statistics from file
-			loadCtx, loadCancel := context.WithTimeout(context.Background(), 10*time.Second)
-			if err := usage.LoadStatistics(loadCtx); err != nil {
-				log.WithError(err).Warn("failed to load usage statistics from file")
-			} else {
-				log.Info("usage statistics loaded from file")
+			usageStore = fileStore
+		}
+	}
+	if usageStore != nil {
+		usage.RegisterUsageStore(usageStore)
+		// Load existing statistics from the store
+		loadCtx, loadCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := usage.LoadStatistics(loadCtx); err != nil {
+			log.WithError(err).Warn("failed to load usage statistics")
+		} else {
+			log.Info("usage statistics loaded")
+			// Restore today's usage into the credential caps so a restart does not reset them.
+			now := time.Now()
+			seeds := make(map[string]coreauth.UsageCapSeed)
+			for index, row := range usage.GetRequestStatistics().AuthTotalsOn(now) {
+				seeds[index] = coreauth.UsageCapSeed{Requests: row.Requests, Tokens: row.Tokens.TotalTokens}
 			}
-			loadCancel()
+			coreauth.SeedUsageCaps(now, seeds)
 		}
+		loadCancel()
 	}
 
 	// Register built-in access providers before constructing services.
@@ -464,9 +670,17 @@ func main() {
 
 	// Handle different command modes based on the provided flags.
 
-	if vertexImport != "" {
+	if reencryptAuths {
+		cmd.DoReencryptAuths(cfg)
+	} else if vertexImport != "" {
 		// Handle Vertex service account import
-		cmd.DoVertexImport(cfg, vertexImport)
+		cmd.DoVertexImport(cfg, vertexImport, vertex.ParseRegions(vertexRegions))
+	} else if vertexADC {
+		// Handle registering Vertex application default credentials
+		cmd.DoVertexADC(cfg, projectID, vertex.ParseRegions(vertexRegions), vertexImpersonate)
+	} else if importLocalCreds {
+		// Handle importing credentials from installed vendor CLIs
+		cmd.DoImportLocalCreds(cfg, projectID)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -482,7 +696,12 @@ func main() {
 	} else if qwenLogin {
 		cmd.DoQwenLogin(cfg, options)
 	} else if copilotLogin {
-		cmd.DoCopilotLogin(cfg, options)
+		cmd.DoCopilotLogin(cfg, options, copilot.Endpoints{
+			GitHubHost:     githubHost,
+			APIHost:        githubAPIHost,
+			CopilotAPIBase: copilotAPIBase,
+			ClientID:       githubClientID,
+		})
 	} else if iflowLogin {
 		cmd.DoIFlowLogin(cfg, options)
 	} else if iflowCookie {
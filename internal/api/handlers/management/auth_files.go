@@ -25,6 +25,7 @@ import (
 	geminiAuth "github.com/giofahreza/AIProxyAPI/internal/auth/gemini"
 	iflowauth "github.com/giofahreza/AIProxyAPI/internal/auth/iflow"
 	"github.com/giofahreza/AIProxyAPI/internal/auth/qwen"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
 	"github.com/giofahreza/AIProxyAPI/internal/interfaces"
 	"github.com/giofahreza/AIProxyAPI/internal/misc"
 	"github.com/giofahreza/AIProxyAPI/internal/registry"
@@ -353,7 +354,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 
 			// Read file to get type field
 			full := filepath.Join(h.cfg.AuthDir, name)
-			if data, errRead := os.ReadFile(full); errRead == nil {
+			if data, errRead := authcrypt.ReadFile(full); errRead == nil {
 				typeValue := gjson.GetBytes(data, "type").String()
 				emailValue := gjson.GetBytes(data, "email").String()
 				fileData["type"] = typeValue
@@ -438,6 +439,20 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if claims := extractCodexIDTokenClaims(auth); claims != nil {
 		entry["id_token"] = claims
 	}
+	if caps := coreauth.UsageCapCounters(auth); caps.Enabled() || caps.InFlight > 0 || caps.RequestsToday > 0 {
+		entry["usage_caps"] = caps
+	}
+	if rateLimits := coreauth.RateLimitSnapshots(auth); len(rateLimits) > 0 {
+		entry["rate_limits"] = rateLimits
+	}
+	if regionCooldowns := coreauth.RegionCooldowns(auth); len(regionCooldowns) > 0 {
+		entry["region_cooldowns"] = regionCooldowns
+	}
+	if h.authManager != nil {
+		if history := h.authManager.ProbeHistory(auth.ID); len(history) > 0 {
+			entry["health_probe"] = history[len(history)-1]
+		}
+	}
 	return entry
 }
 
@@ -521,7 +536,7 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 		return
 	}
 	full := filepath.Join(h.cfg.AuthDir, name)
-	data, err := os.ReadFile(full)
+	data, err := authcrypt.ReadFile(full)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(404, gin.H{"error": "file not found"})
@@ -699,6 +714,10 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 			return fmt.Errorf("failed to read auth file: %w", err)
 		}
 	}
+	data, errOpen := authcrypt.Open(data)
+	if errOpen != nil {
+		return fmt.Errorf("failed to decrypt auth file: %w", errOpen)
+	}
 	metadata := make(map[string]any)
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		return fmt.Errorf("invalid auth file: %w", err)
@@ -809,6 +828,15 @@ func (h *Handler) saveTokenRecord(ctx context.Context, record *coreauth.Auth) (s
 }
 
 func (h *Handler) RequestAnthropicToken(c *gin.Context) {
+	start, err := h.startAnthropicLogin(loginOptionsFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "url": start.URL, "state": start.State})
+}
+
+func (h *Handler) startAnthropicLogin(opts loginOptions) (*loginStart, error) {
 	ctx := context.Background()
 
 	fmt.Println("Initializing Claude authentication...")
@@ -817,16 +845,14 @@ func (h *Handler) RequestAnthropicToken(c *gin.Context) {
 	pkceCodes, err := claude.GeneratePKCECodes()
 	if err != nil {
 		log.Errorf("Failed to generate PKCE codes: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PKCE codes"})
-		return
+		return nil, errors.New("failed to generate PKCE codes")
 	}
 
 	// Generate random state parameter
 	state, err := misc.GenerateRandomState()
 	if err != nil {
 		log.Errorf("Failed to generate state parameter: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate state parameter"})
-		return
+		return nil, errors.New("failed to generate state parameter")
 	}
 
 	// Initialize Claude auth service
@@ -836,26 +862,23 @@ func (h *Handler) RequestAnthropicToken(c *gin.Context) {
 	authURL, state, err := anthropicAuth.GenerateAuthURL(state, pkceCodes)
 	if err != nil {
 		log.Errorf("Failed to generate authorization URL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate authorization url"})
-		return
+		return nil, errors.New("failed to generate authorization url")
 	}
 
 	RegisterOAuthSession(state, "anthropic")
 
-	isWebUI := isWebUIRequest(c)
+	isWebUI := opts.WebUI
 	var forwarder *callbackForwarder
 	if isWebUI {
 		targetURL, errTarget := h.managementCallbackURL("/anthropic/callback")
 		if errTarget != nil {
 			log.WithError(errTarget).Error("failed to compute anthropic callback target")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "callback server unavailable"})
-			return
+			return nil, errors.New("callback server unavailable")
 		}
 		var errStart error
 		if forwarder, errStart = startCallbackForwarder(anthropicCallbackPort, "anthropic", targetURL); errStart != nil {
 			log.WithError(errStart).Error("failed to start anthropic callback forwarder")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start callback server"})
-			return
+			return nil, errors.New("failed to start callback server")
 		}
 	}
 
@@ -1002,16 +1025,25 @@ func (h *Handler) RequestAnthropicToken(c *gin.Context) {
 		CompleteOAuthSessionsByProvider("anthropic")
 	}()
 
-	c.JSON(200, gin.H{"status": "ok", "url": authURL, "state": state})
+	return &loginStart{Provider: "anthropic", State: state, Mode: loginModeAuthURL, URL: authURL}, nil
 }
 
 func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
+	start, err := h.startGeminiCLILogin(loginOptionsFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "url": start.URL, "state": start.State})
+}
+
+func (h *Handler) startGeminiCLILogin(opts loginOptions) (*loginStart, error) {
 	ctx := context.Background()
 	proxyHTTPClient := util.SetProxy(&h.cfg.SDKConfig, &http.Client{Timeout: 30 * time.Second})
 	ctx = context.WithValue(ctx, oauth2.HTTPClient, proxyHTTPClient)
 
 	// Optional project ID from query
-	projectID := c.Query("project_id")
+	projectID := opts.ProjectID
 
 	fmt.Println("Initializing Google authentication...")
 
@@ -1034,20 +1066,18 @@ func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 
 	RegisterOAuthSession(state, "gemini")
 
-	isWebUI := isWebUIRequest(c)
+	isWebUI := opts.WebUI
 	var forwarder *callbackForwarder
 	if isWebUI {
 		targetURL, errTarget := h.managementCallbackURL("/google/callback")
 		if errTarget != nil {
 			log.WithError(errTarget).Error("failed to compute gemini callback target")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "callback server unavailable"})
-			return
+			return nil, errors.New("callback server unavailable")
 		}
 		var errStart error
 		if forwarder, errStart = startCallbackForwarder(geminiCallbackPort, "gemini", targetURL); errStart != nil {
 			log.WithError(errStart).Error("failed to start gemini callback forwarder")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start callback server"})
-			return
+			return nil, errors.New("failed to start callback server")
 		}
 	}
 
@@ -1244,10 +1274,19 @@ func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 		fmt.Printf("You can now use Gemini CLI services through this CLI; token saved to %s\n", savedPath)
 	}()
 
-	c.JSON(200, gin.H{"status": "ok", "url": authURL, "state": state})
+	return &loginStart{Provider: "gemini", State: state, Mode: loginModeAuthURL, URL: authURL}, nil
 }
 
 func (h *Handler) RequestCodexToken(c *gin.Context) {
+	start, err := h.startCodexLogin(loginOptionsFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "url": start.URL, "state": start.State})
+}
+
+func (h *Handler) startCodexLogin(opts loginOptions) (*loginStart, error) {
 	ctx := context.Background()
 
 	fmt.Println("Initializing Codex authentication...")
@@ -1256,16 +1295,14 @@ func (h *Handler) RequestCodexToken(c *gin.Context) {
 	pkceCodes, err := codex.GeneratePKCECodes()
 	if err != nil {
 		log.Errorf("Failed to generate PKCE codes: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PKCE codes"})
-		return
+		return nil, errors.New("failed to generate PKCE codes")
 	}
 
 	// Generate random state parameter
 	state, err := misc.GenerateRandomState()
 	if err != nil {
 		log.Errorf("Failed to generate state parameter: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate state parameter"})
-		return
+		return nil, errors.New("failed to generate state parameter")
 	}
 
 	// Initialize Codex auth service
@@ -1275,26 +1312,23 @@ func (h *Handler) RequestCodexToken(c *gin.Context) {
 	authURL, err := openaiAuth.GenerateAuthURL(state, pkceCodes)
 	if err != nil {
 		log.Errorf("Failed to generate authorization URL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate authorization url"})
-		return
+		return nil, errors.New("failed to generate authorization url")
 	}
 
 	RegisterOAuthSession(state, "codex")
 
-	isWebUI := isWebUIRequest(c)
+	isWebUI := opts.WebUI
 	var forwarder *callbackForwarder
 	if isWebUI {
 		targetURL, errTarget := h.managementCallbackURL("/codex/callback")
 		if errTarget != nil {
 			log.WithError(errTarget).Error("failed to compute codex callback target")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "callback server unavailable"})
-			return
+			return nil, errors.New("callback server unavailable")
 		}
 		var errStart error
 		if forwarder, errStart = startCallbackForwarder(codexCallbackPort, "codex", targetURL); errStart != nil {
 			log.WithError(errStart).Error("failed to start codex callback forwarder")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start callback server"})
-			return
+			return nil, errors.New("failed to start callback server")
 		}
 	}
 
@@ -1429,10 +1463,19 @@ func (h *Handler) RequestCodexToken(c *gin.Context) {
 		CompleteOAuthSessionsByProvider("codex")
 	}()
 
-	c.JSON(200, gin.H{"status": "ok", "url": authURL, "state": state})
+	return &loginStart{Provider: "codex", State: state, Mode: loginModeAuthURL, URL: authURL}, nil
 }
 
 func (h *Handler) RequestAntigravityToken(c *gin.Context) {
+	start, err := h.startAntigravityLogin(loginOptionsFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "url": start.URL, "state": start.State})
+}
+
+func (h *Handler) startAntigravityLogin(opts loginOptions) (*loginStart, error) {
 	const (
 		antigravityCallbackPort = 51121
 		antigravityClientID     = "1071006060591-tmhssin2h21lcre235vtolojh4g403ep.apps.googleusercontent.com"
@@ -1453,8 +1496,7 @@ func (h *Handler) RequestAntigravityToken(c *gin.Context) {
 	state, errState := misc.GenerateRandomState()
 	if errState != nil {
 		log.Errorf("Failed to generate state parameter: %v", errState)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate state parameter"})
-		return
+		return nil, errors.New("failed to generate state parameter")
 	}
 
 	redirectURI := fmt.Sprintf("http://localhost:%d/oauth-callback", antigravityCallbackPort)
@@ -1471,20 +1513,18 @@ func (h *Handler) RequestAntigravityToken(c *gin.Context) {
 
 	RegisterOAuthSession(state, "antigravity")
 
-	isWebUI := isWebUIRequest(c)
+	isWebUI := opts.WebUI
 	var forwarder *callbackForwarder
 	if isWebUI {
 		targetURL, errTarget := h.managementCallbackURL("/antigravity/callback")
 		if errTarget != nil {
 			log.WithError(errTarget).Error("failed to compute antigravity callback target")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "callback server unavailable"})
-			return
+			return nil, errors.New("callback server unavailable")
 		}
 		var errStart error
 		if forwarder, errStart = startCallbackForwarder(antigravityCallbackPort, "antigravity", targetURL); errStart != nil {
 			log.WithError(errStart).Error("failed to start antigravity callback forwarder")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start callback server"})
-			return
+			return nil, errors.New("failed to start callback server")
 		}
 	}
 
@@ -1670,10 +1710,19 @@ func (h *Handler) RequestAntigravityToken(c *gin.Context) {
 		fmt.Println("You can now use Antigravity services through this CLI")
 	}()
 
-	c.JSON(200, gin.H{"status": "ok", "url": authURL, "state": state})
+	return &loginStart{Provider: "antigravity", State: state, Mode: loginModeAuthURL, URL: authURL}, nil
 }
 
 func (h *Handler) RequestQwenToken(c *gin.Context) {
+	start, err := h.startQwenLogin(loginOptionsFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "url": start.URL, "state": start.State})
+}
+
+func (h *Handler) startQwenLogin(opts loginOptions) (*loginStart, error) {
 	ctx := context.Background()
 
 	fmt.Println("Initializing Qwen authentication...")
@@ -1686,8 +1735,7 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 	deviceFlow, err := qwenAuth.InitiateDeviceFlow(ctx)
 	if err != nil {
 		log.Errorf("Failed to generate authorization URL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate authorization url"})
-		return
+		return nil, errors.New("failed to generate authorization url")
 	}
 	authURL := deviceFlow.VerificationURIComplete
 
@@ -1701,6 +1749,9 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 			fmt.Printf("Authentication failed: %v\n", errPollForToken)
 			return
 		}
+		if loginSessionCancelled(state) {
+			return
+		}
 
 		// Create token storage
 		tokenStorage := qwenAuth.CreateTokenStorage(tokenData)
@@ -1725,7 +1776,15 @@ func (h *Handler) RequestQwenToken(c *gin.Context) {
 		CompleteOAuthSession(state)
 	}()
 
-	c.JSON(200, gin.H{"status": "ok", "url": authURL, "state": state})
+	return &loginStart{
+		Provider:        "qwen",
+		State:           state,
+		Mode:            loginModeDevice,
+		URL:             authURL,
+		UserCode:        deviceFlow.UserCode,
+		VerificationURI: deviceFlow.VerificationURI,
+		ExpiresIn:       deviceFlow.ExpiresIn,
+	}, nil
 }
 
 func (h *Handler) RequestCopilotAuthURL(c *gin.Context) {
@@ -1734,7 +1793,7 @@ func (h *Handler) RequestCopilotAuthURL(c *gin.Context) {
 	fmt.Println("Initializing GitHub Copilot authentication...")
 
 	// Initialize Copilot auth service
-	copilotAuth := copilot.NewCopilotAuth(h.cfg)
+	copilotAuth := copilot.NewCopilotAuthWithEndpoints(h.cfg, copilotEndpointsFromQuery(c))
 
 	// Generate device code for OAuth Device Flow
 	deviceFlow, err := copilotAuth.InitiateDeviceFlow(ctx)
@@ -1765,13 +1824,15 @@ func (h *Handler) RequestCopilotTokenStatus(c *gin.Context) {
 		return
 	}
 
+	endpoints := copilotEndpointsFromQuery(c)
+
 	// Poll GitHub API once to check status
 	data := url.Values{}
-	data.Set("client_id", copilot.GitHubClientID)
+	data.Set("client_id", endpoints.OAuthClientID())
 	data.Set("device_code", deviceCode)
 	data.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
 
-	req, err := http.NewRequest("POST", copilot.GitHubAccessTokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequest("POST", endpoints.AccessTokenURL(), strings.NewReader(data.Encode()))
 	if err != nil {
 		c.JSON(200, gin.H{"status": "error", "error": "failed to create request"})
 		return
@@ -1826,7 +1887,7 @@ func (h *Handler) RequestCopilotTokenStatus(c *gin.Context) {
 		return
 	}
 
-	copilotAuth := copilot.NewCopilotAuth(h.cfg)
+	copilotAuth := copilot.NewCopilotAuthWithEndpoints(h.cfg, endpoints)
 
 	// Got the GitHub token, now exchange it for Copilot token
 	copilotTokenData, err := copilotAuth.RefreshCopilotToken(ctx, githubToken)
@@ -1851,6 +1912,7 @@ func (h *Handler) RequestCopilotTokenStatus(c *gin.Context) {
 			"sku":   tokenStorage.SKU,
 		},
 	}
+	endpoints.ApplyToMetadata(record.Metadata)
 
 	savedPath, errSave := h.saveTokenRecord(ctx, record)
 	if errSave != nil {
@@ -1872,8 +1934,12 @@ func (h *Handler) RequestCopilotToken(c *gin.Context) {
 
 	// Frontend completes GitHub OAuth Device Flow and sends the GitHub token
 	var req struct {
-		GitHubToken string `json:"github_token" binding:"required"`
-		Email       string `json:"email"`
+		GitHubToken    string `json:"github_token" binding:"required"`
+		Email          string `json:"email"`
+		GitHubHost     string `json:"github_host"`
+		GitHubAPIHost  string `json:"github_api_host"`
+		CopilotBaseURL string `json:"copilot_base_url"`
+		GitHubClientID string `json:"github_client_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -1883,7 +1949,8 @@ func (h *Handler) RequestCopilotToken(c *gin.Context) {
 
 	fmt.Println("Validating GitHub token and exchanging for Copilot token...")
 
-	copilotAuth := copilot.NewCopilotAuth(h.cfg)
+	endpoints := copilot.Endpoints{GitHubHost: req.GitHubHost, APIHost: req.GitHubAPIHost, CopilotAPIBase: req.CopilotBaseURL, ClientID: req.GitHubClientID}
+	copilotAuth := copilot.NewCopilotAuthWithEndpoints(h.cfg, endpoints)
 
 	// Validate GitHub token by exchanging it for Copilot token
 	copilotTokenData, err := copilotAuth.RefreshCopilotToken(ctx, req.GitHubToken)
@@ -1912,6 +1979,7 @@ func (h *Handler) RequestCopilotToken(c *gin.Context) {
 			"sku":   tokenStorage.SKU,
 		},
 	}
+	endpoints.ApplyToMetadata(record.Metadata)
 
 	savedPath, errSave := h.saveTokenRecord(ctx, record)
 	if errSave != nil {
@@ -1930,7 +1998,27 @@ func (h *Handler) RequestCopilotToken(c *gin.Context) {
 	})
 }
 
+// copilotEndpointsFromQuery reads the GitHub Enterprise hosts of a Copilot login from the
+// github_host, github_api_host, copilot_base_url and github_client_id query parameters.
+func copilotEndpointsFromQuery(c *gin.Context) copilot.Endpoints {
+	return copilot.Endpoints{
+		GitHubHost:     c.Query("github_host"),
+		APIHost:        c.Query("github_api_host"),
+		CopilotAPIBase: c.Query("copilot_base_url"),
+		ClientID:       c.Query("github_client_id"),
+	}
+}
+
 func (h *Handler) RequestIFlowToken(c *gin.Context) {
+	start, err := h.startIFlowLogin(loginOptionsFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "url": start.URL, "state": start.State})
+}
+
+func (h *Handler) startIFlowLogin(opts loginOptions) (*loginStart, error) {
 	ctx := context.Background()
 
 	fmt.Println("Initializing iFlow authentication...")
@@ -1941,20 +2029,18 @@ func (h *Handler) RequestIFlowToken(c *gin.Context) {
 
 	RegisterOAuthSession(state, "iflow")
 
-	isWebUI := isWebUIRequest(c)
+	isWebUI := opts.WebUI
 	var forwarder *callbackForwarder
 	if isWebUI {
 		targetURL, errTarget := h.managementCallbackURL("/iflow/callback")
 		if errTarget != nil {
 			log.WithError(errTarget).Error("failed to compute iflow callback target")
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "callback server unavailable"})
-			return
+			return nil, errors.New("callback server unavailable")
 		}
 		var errStart error
 		if forwarder, errStart = startCallbackForwarder(iflowauth.CallbackPort, "iflow", targetURL); errStart != nil {
 			log.WithError(errStart).Error("failed to start iflow callback forwarder")
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "failed to start callback server"})
-			return
+			return nil, errors.New("failed to start callback server")
 		}
 	}
 
@@ -2040,7 +2126,7 @@ func (h *Handler) RequestIFlowToken(c *gin.Context) {
 		CompleteOAuthSessionsByProvider("iflow")
 	}()
 
-	c.JSON(http.StatusOK, gin.H{"status": "ok", "url": authURL, "state": state})
+	return &loginStart{Provider: "iflow", State: state, Mode: loginModeAuthURL, URL: authURL}, nil
 }
 
 func (h *Handler) RequestIFlowCookieToken(c *gin.Context) {
@@ -2485,13 +2571,13 @@ func (h *Handler) GetAuthStatus(c *gin.Context) {
 		return
 	}
 
-	_, status, ok := GetOAuthSession(state)
-	if !ok {
+	session, ok := oauthSessions.Get(state)
+	if !ok || session.Completed {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		return
 	}
-	if status != "" {
-		c.JSON(http.StatusOK, gin.H{"status": "error", "error": status})
+	if session.Status != "" {
+		c.JSON(http.StatusOK, gin.H{"status": "error", "error": session.Status})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "wait"})
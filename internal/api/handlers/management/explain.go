@@ -0,0 +1,208 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/api/modules/amp"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/constant"
+	"github.com/giofahreza/AIProxyAPI/internal/limits"
+	"github.com/giofahreza/AIProxyAPI/internal/runtime/executor"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	"github.com/giofahreza/AIProxyAPI/sdk/api/handlers"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	coreexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type explainRequest struct {
+	HandlerType string          `json:"handler_type"`
+	Model       string          `json:"model"`
+	APIKey      string          `json:"api_key"`
+	Amp         bool            `json:"amp"`
+	Stream      bool            `json:"stream"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+type explainCandidate struct {
+	coreauth.RouteCandidate
+	Format string `json:"format"`
+}
+
+type explainSelection struct {
+	AuthID          string                        `json:"auth_id"`
+	Provider        string                        `json:"provider"`
+	Format          string                        `json:"format"`
+	UpstreamModel   string                        `json:"upstream_model"`
+	PayloadRules    []executor.AppliedPayloadRule `json:"payload_rules"`
+	ValidationError string                        `json:"validation_error,omitempty"`
+	Payload         json.RawMessage               `json:"payload"`
+}
+
+var explainHandlerTypes = map[string]struct{}{
+	constant.OpenAI:         {},
+	constant.OpenaiResponse: {},
+	constant.Claude:         {},
+	constant.Anthropic:      {},
+	constant.Gemini:         {},
+	constant.GeminiCLI:      {},
+}
+
+// ExplainRoute performs a dry run of request routing: it resolves providers, candidate auths,
+// the upstream model and the translated provider payload without calling any upstream.
+func (h *Handler) ExplainRoute(c *gin.Context) {
+	var req explainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	handlerType := strings.ToLower(strings.TrimSpace(req.HandlerType))
+	if handlerType == "" {
+		handlerType = constant.OpenAI
+	}
+	if _, ok := explainHandlerTypes[handlerType]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported handler_type: " + req.HandlerType})
+		return
+	}
+	payload := []byte(req.Payload)
+	if !gjson.ValidBytes(payload) || !gjson.ParseBytes(payload).IsObject() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payload must be a JSON object"})
+		return
+	}
+	requestedModel := strings.TrimSpace(req.Model)
+	if requestedModel == "" {
+		requestedModel = strings.TrimSpace(gjson.GetBytes(payload, "model").String())
+	}
+	if requestedModel == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required (in payload or model field)"})
+		return
+	}
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+
+	h.mu.Lock()
+	cfg := h.cfg
+	h.mu.Unlock()
+
+	result := gin.H{
+		"handler_type":    handlerType,
+		"requested_model": requestedModel,
+	}
+
+	var filter coreauth.RouteFilter
+	if apiKey := strings.TrimSpace(req.APIKey); apiKey != "" && cfg != nil {
+		known := false
+		for _, key := range cfg.APIKeys {
+			if key == apiKey {
+				known = true
+				break
+			}
+		}
+		enforcer := limits.NewEnforcer(cfg.APIKeyLimits)
+		filter.AllowedProviders = enforcer.GetAllowedProviders(apiKey)
+		filter.AllowedCredentials = enforcer.GetAllowedCredentials(apiKey)
+		access := gin.H{
+			"known":               known,
+			"allowed":             true,
+			"allowed_providers":   filter.AllowedProviders,
+			"allowed_credentials": filter.AllowedCredentials,
+		}
+		if errAccess := enforcer.CheckAccess(apiKey, requestedModel); errAccess != nil {
+			access["allowed"] = false
+			access["error"] = errAccess.Error()
+		}
+		result["api_key"] = access
+	}
+
+	routeModel := requestedModel
+	if req.Amp && cfg != nil {
+		if mapped := explainAmpMapping(requestedModel, cfg.AmpCode.ModelMappings, cfg.AmpCode.ForceModelMappings); mapped != "" {
+			routeModel = mapped
+			result["amp_mapped_model"] = mapped
+			if gjson.GetBytes(payload, "model").Exists() {
+				if updated, errSet := sjson.SetBytes(payload, "model", mapped); errSet == nil {
+					payload = updated
+				}
+			}
+		}
+	}
+
+	providers, normalizedModel, metadata, errMsg := handlers.ResolveModelRoute(routeModel)
+	if errMsg != nil {
+		result["error"] = errMsg.Error.Error()
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	result["resolved_model"] = normalizedModel
+	result["thinking_metadata"] = metadata
+
+	routed, candidates := h.authManager.ExplainRoute(providers, normalizedModel, metadata, filter)
+	result["providers"] = routed
+
+	opts := coreexecutor.Options{
+		Stream:          req.Stream,
+		OriginalRequest: payload,
+		SourceFormat:    sdktranslator.FromString(handlerType),
+	}
+	entries := make([]explainCandidate, 0, len(candidates))
+	var selected *explainSelection
+	for _, candidate := range candidates {
+		execReq := coreexecutor.Request{Model: candidate.UpstreamModel, Payload: payload, Metadata: candidate.Metadata}
+		preview := executor.PreviewRequest(cfg, candidate.Auth, execReq, opts)
+		candidate.UpstreamModel = preview.UpstreamModel
+		entries = append(entries, explainCandidate{RouteCandidate: candidate, Format: preview.Format})
+		if selected == nil && candidate.Eligible {
+			selected = &explainSelection{
+				AuthID:          candidate.AuthID,
+				Provider:        candidate.Provider,
+				Format:          preview.Format,
+				UpstreamModel:   preview.UpstreamModel,
+				PayloadRules:    preview.PayloadRules,
+				ValidationError: preview.ValidationError,
+				Payload:         json.RawMessage(preview.Payload),
+			}
+			if !json.Valid(preview.Payload) {
+				selected.Payload, _ = json.Marshal(string(preview.Payload))
+			}
+		}
+	}
+	result["candidates"] = entries
+	result["selected"] = selected
+	result["evaluated_at"] = time.Now()
+	c.JSON(http.StatusOK, result)
+}
+
+// explainAmpMapping mirrors the Amp fallback handler: mappings apply when forced or when the
+// requested model has no local provider, and a thinking suffix on the request is preserved.
+func explainAmpMapping(requestedModel string, mappings []config.AmpModelMapping, force bool) string {
+	if len(mappings) == 0 {
+		return ""
+	}
+	normalizedModel, thinkingMetadata := util.NormalizeThinkingModel(requestedModel)
+	if !force && len(util.GetProviderName(normalizedModel)) > 0 {
+		return ""
+	}
+	mapper := amp.NewModelMapper(mappings)
+	mapped := strings.TrimSpace(mapper.MapModel(requestedModel))
+	if mapped == "" {
+		mapped = strings.TrimSpace(mapper.MapModel(normalizedModel))
+	}
+	if mapped == "" {
+		return ""
+	}
+	if thinkingMetadata != nil && strings.HasPrefix(requestedModel, normalizedModel) {
+		suffix := requestedModel[len(normalizedModel):]
+		if _, mappedMetadata := util.NormalizeThinkingModel(mapped); mappedMetadata == nil {
+			mapped += suffix
+		}
+	}
+	return mapped
+}
@@ -0,0 +1,58 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHealthProbes returns the recent background health probes per auth. The optional auth_id
+// query parameter limits the response to one auth.
+func (h *Handler) GetHealthProbes(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	if id := strings.TrimSpace(c.Query("auth_id")); id != "" {
+		if _, ok := h.authManager.GetByID(id); !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"enabled": h.cfg.HealthProbes.Enabled, "auth_id": id, "probes": h.authManager.ProbeHistory(id)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": h.cfg.HealthProbes.Enabled, "probes": h.authManager.ProbeHistories()})
+}
+
+type runHealthProbeRequest struct {
+	AuthID string `json:"auth_id"`
+}
+
+// RunHealthProbe probes one auth immediately, even when background probes are disabled.
+func (h *Handler) RunHealthProbe(c *gin.Context) {
+	var req runHealthProbeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	id := strings.TrimSpace(req.AuthID)
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auth_id is required"})
+		return
+	}
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	if _, ok := h.authManager.GetByID(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return
+	}
+	result, err := h.authManager.ProbeAuth(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,130 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	geminiAuth "github.com/giofahreza/AIProxyAPI/internal/auth/gemini"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/localcreds"
+)
+
+// ImportLocalCredentials imports the accounts the Claude Code, Codex and Gemini CLIs are signed
+// in with for the user running the server. Accounts that already have an auth record are skipped.
+func (h *Handler) ImportLocalCredentials(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	if h.cfg.AuthDir == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth directory not configured"})
+		return
+	}
+	var req struct {
+		ProjectID string `json:"project_id"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "home directory unavailable", "message": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	if reqCtx := c.Request.Context(); reqCtx != nil {
+		ctx = reqCtx
+	}
+	store := h.tokenStoreWithBaseDir()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store unavailable"})
+		return
+	}
+	existing, err := store.List(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_failed", "message": err.Error()})
+		return
+	}
+
+	accounts, detectErrs := localcreds.Detect(home)
+	fresh, duplicates := localcreds.FilterNew(accounts, existing)
+	failures := make([]gin.H, 0, len(detectErrs))
+	for _, errDetect := range detectErrs {
+		failures = append(failures, gin.H{"message": errDetect.Error()})
+	}
+	skipped := make([]gin.H, 0, len(duplicates))
+	for _, account := range duplicates {
+		skipped = append(skipped, gin.H{"provider": account.Provider, "email": account.Email, "source": account.Source, "reason": "already imported"})
+	}
+	imported := make([]gin.H, 0, len(fresh))
+	for _, account := range fresh {
+		if account.Provider == "gemini" {
+			if errProject := h.activateImportedGeminiAccount(ctx, account, req.ProjectID); errProject != nil {
+				failures = append(failures, gin.H{"provider": account.Provider, "email": account.Email, "source": account.Source, "message": errProject.Error()})
+				continue
+			}
+		}
+		savedPath, errSave := h.saveTokenRecord(ctx, account.Record)
+		if errSave != nil {
+			failures = append(failures, gin.H{"provider": account.Provider, "email": account.Email, "source": account.Source, "message": errSave.Error()})
+			continue
+		}
+		entry := gin.H{"provider": account.Provider, "email": account.Email, "source": account.Source, "auth-file": savedPath}
+		if account.Warning != "" {
+			entry["warning"] = account.Warning
+		}
+		imported = append(imported, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "ok",
+		"imported": imported,
+		"skipped":  skipped,
+		"errors":   failures,
+	})
+}
+
+// activateImportedGeminiAccount onboards the imported Gemini account for projectID, or for its
+// first project when empty, and names the record after the activated project.
+func (h *Handler) activateImportedGeminiAccount(ctx context.Context, account *localcreds.Account, projectID string) error {
+	storage, ok := account.Record.Storage.(*geminiAuth.GeminiTokenStorage)
+	if !ok || storage == nil {
+		return fmt.Errorf("unsupported token storage")
+	}
+	httpClient, err := geminiAuth.NewGeminiAuth().GetAuthenticatedClient(ctx, storage, h.cfg, &geminiAuth.WebLoginOptions{NoBrowser: true})
+	if err != nil {
+		return err
+	}
+	if err = ensureGeminiProjectAndOnboard(ctx, httpClient, storage, strings.TrimSpace(projectID)); err != nil {
+		return err
+	}
+	if strings.TrimSpace(storage.ProjectID) == "" {
+		return fmt.Errorf("onboarding did not return a project ID")
+	}
+	isChecked, err := checkCloudAPIIsEnabled(ctx, httpClient, storage.ProjectID)
+	if err != nil {
+		return fmt.Errorf("check Cloud AI API for %s: %w", storage.ProjectID, err)
+	}
+	if !isChecked {
+		return fmt.Errorf("cloud AI API is not enabled for project %s", storage.ProjectID)
+	}
+	storage.Checked = true
+
+	fileName := geminiAuth.CredentialFileName(storage.Email, storage.ProjectID, true)
+	account.Record.ID = fileName
+	account.Record.FileName = fileName
+	account.Record.Metadata = map[string]any{
+		"email":      storage.Email,
+		"project_id": storage.ProjectID,
+		"auto":       storage.Auto,
+		"checked":    storage.Checked,
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/copilot"
+	"github.com/giofahreza/AIProxyAPI/internal/misc"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// loginModeAuthURL sessions are finished by opening URL and pasting the redirect URL the
+	// browser lands on back to the session's callback endpoint.
+	loginModeAuthURL = "auth_url"
+	// loginModeDevice sessions are finished by entering UserCode at VerificationURI; the server
+	// polls the provider itself.
+	loginModeDevice = "device"
+)
+
+// loginOptions carries the provider-specific inputs of a login.
+type loginOptions struct {
+	// WebUI starts a local forwarder so provider redirects reach the management callback routes.
+	WebUI bool
+	// ProjectID is the Google Cloud project to onboard a Gemini login for.
+	ProjectID string
+	// Copilot holds the GitHub hosts of a Copilot login.
+	Copilot copilot.Endpoints
+}
+
+// loginStart describes a started login and what the client has to do to finish it.
+type loginStart struct {
+	Provider        string `json:"provider"`
+	State           string `json:"state"`
+	Mode            string `json:"mode"`
+	URL             string `json:"url"`
+	UserCode        string `json:"user_code,omitempty"`
+	VerificationURI string `json:"verification_uri,omitempty"`
+	ExpiresIn       int    `json:"expires_in,omitempty"`
+	CallbackPath    string `json:"callback_path,omitempty"`
+}
+
+type loginSessionRequest struct {
+	Provider       string `json:"provider"`
+	ProjectID      string `json:"project_id"`
+	GitHubHost     string `json:"github_host"`
+	GitHubAPIHost  string `json:"github_api_host"`
+	CopilotBaseURL string `json:"copilot_base_url"`
+	GitHubClientID string `json:"github_client_id"`
+}
+
+func loginOptionsFromQuery(c *gin.Context) loginOptions {
+	return loginOptions{
+		WebUI:     isWebUIRequest(c),
+		ProjectID: c.Query("project_id"),
+		Copilot:   copilotEndpointsFromQuery(c),
+	}
+}
+
+// startLogin starts the login flow of provider, which must already be normalized.
+func (h *Handler) startLogin(provider string, opts loginOptions) (*loginStart, error) {
+	switch provider {
+	case "anthropic":
+		return h.startAnthropicLogin(opts)
+	case "codex":
+		return h.startCodexLogin(opts)
+	case "gemini":
+		return h.startGeminiCLILogin(opts)
+	case "antigravity":
+		return h.startAntigravityLogin(opts)
+	case "iflow":
+		return h.startIFlowLogin(opts)
+	case "qwen":
+		return h.startQwenLogin(opts)
+	case "copilot":
+		return h.startCopilotLogin(opts)
+	default:
+		return nil, errUnsupportedOAuthFlow
+	}
+}
+
+// StartLoginSession starts a login for any OAuth provider without relying on a browser on the
+// server. Device-code providers return the code to enter; the others return an authorization URL
+// and the path the redirect URL the browser ends up on has to be posted to.
+func (h *Handler) StartLoginSession(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "handler not initialized"})
+		return
+	}
+	var req loginSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid body"})
+		return
+	}
+	provider, err := NormalizeOAuthProvider(req.Provider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "unsupported provider"})
+		return
+	}
+
+	start, err := h.startLogin(provider, loginOptions{
+		ProjectID: strings.TrimSpace(req.ProjectID),
+		Copilot: copilot.Endpoints{
+			GitHubHost:     req.GitHubHost,
+			APIHost:        req.GitHubAPIHost,
+			CopilotAPIBase: req.CopilotBaseURL,
+			ClientID:       req.GitHubClientID,
+		},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+	if start.Mode == loginModeAuthURL {
+		start.CallbackPath = fmt.Sprintf("/v0/management/login-sessions/%s/callback", start.State)
+	}
+	oauthSessions.SetLogin(start.State, start)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "session": start})
+}
+
+// GetLoginSession reports whether a login session is still waiting, failed or completed.
+func (h *Handler) GetLoginSession(c *gin.Context) {
+	session, ok := h.lookupLoginSession(c)
+	if !ok {
+		return
+	}
+	switch {
+	case session.Completed:
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "session": session.Login})
+	case session.Status != "":
+		c.JSON(http.StatusOK, gin.H{"status": "error", "error": session.Status, "session": session.Login})
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "wait", "session": session.Login})
+	}
+}
+
+// SubmitLoginSessionCallback finishes an authorization-URL session with the redirect URL, or the
+// code, the provider handed to the browser.
+func (h *Handler) SubmitLoginSessionCallback(c *gin.Context) {
+	session, ok := h.lookupLoginSession(c)
+	if !ok {
+		return
+	}
+	state := strings.TrimSpace(c.Param("state"))
+	if session.Login != nil && session.Login.Mode != loginModeAuthURL {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "session does not accept a callback"})
+		return
+	}
+
+	var req oauthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid body"})
+		return
+	}
+	callbackState, code, errMsg, err := req.resolve()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid redirect_url"})
+		return
+	}
+	if callbackState != "" && callbackState != state {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "state does not match session"})
+		return
+	}
+	if code == "" && errMsg == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "code or error is required"})
+		return
+	}
+
+	if _, errWrite := WriteOAuthCallbackFileForPendingSession(h.cfg.AuthDir, session.Provider, state, code, errMsg); errWrite != nil {
+		if errors.Is(errWrite, errOAuthSessionNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"status": "error", "error": "oauth flow is not pending"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "failed to persist oauth callback"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// CancelLoginSession stops a pending login session. Flows waiting for a callback give up at once;
+// device flows discard the credential if the user still approves the code.
+func (h *Handler) CancelLoginSession(c *gin.Context) {
+	session, ok := h.lookupLoginSession(c)
+	if !ok {
+		return
+	}
+	if session.Completed || session.Status != "" {
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "error": "oauth flow is not pending"})
+		return
+	}
+	SetOAuthSessionError(strings.TrimSpace(c.Param("state")), "Login cancelled")
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *Handler) lookupLoginSession(c *gin.Context) (oauthSession, bool) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "handler not initialized"})
+		return oauthSession{}, false
+	}
+	state := strings.TrimSpace(c.Param("state"))
+	if err := ValidateOAuthState(state); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid state"})
+		return oauthSession{}, false
+	}
+	session, ok := oauthSessions.Get(state)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "error": "unknown or expired state"})
+		return oauthSession{}, false
+	}
+	return session, true
+}
+
+// loginSessionCancelled reports whether the session of state ended with an error, such as a
+// cancellation, while a device flow was still polling.
+func loginSessionCancelled(state string) bool {
+	_, status, ok := GetOAuthSession(state)
+	return ok && status != ""
+}
+
+// startCopilotLogin runs the GitHub device flow on the server and saves the Copilot credential
+// once the user enters the code.
+func (h *Handler) startCopilotLogin(opts loginOptions) (*loginStart, error) {
+	ctx := context.Background()
+
+	fmt.Println("Initializing GitHub Copilot authentication...")
+
+	copilotAuth := copilot.NewCopilotAuthWithEndpoints(h.cfg, opts.Copilot)
+	deviceFlow, err := copilotAuth.InitiateDeviceFlow(ctx)
+	if err != nil {
+		log.Errorf("Failed to initiate device flow: %v", err)
+		return nil, errors.New("failed to initiate device flow")
+	}
+	state, err := misc.GenerateRandomState()
+	if err != nil {
+		log.Errorf("Failed to generate state parameter: %v", err)
+		return nil, errors.New("failed to generate state parameter")
+	}
+
+	RegisterOAuthSession(state, "copilot")
+
+	go func() {
+		fmt.Println("Waiting for authentication...")
+		githubToken, errPoll := copilotAuth.PollForGitHubToken(deviceFlow.DeviceCode, deviceFlow.Interval)
+		if errPoll != nil {
+			log.Errorf("Copilot device authorization failed: %v", errPoll)
+			SetOAuthSessionError(state, "Authentication failed")
+			return
+		}
+		if loginSessionCancelled(state) {
+			return
+		}
+
+		tokenData, errToken := copilotAuth.RefreshCopilotToken(ctx, githubToken)
+		if errToken != nil {
+			log.Errorf("Failed to exchange GitHub token for Copilot token: %v", errToken)
+			SetOAuthSessionError(state, "Failed to get Copilot token or no subscription")
+			return
+		}
+		tokenStorage := copilotAuth.CreateTokenStorage(tokenData)
+		tokenStorage.GitHubToken = githubToken
+		tokenStorage.Email = fmt.Sprintf("copilot-%d", time.Now().UnixMilli())
+		record := &coreauth.Auth{
+			ID:       fmt.Sprintf("copilot-%s.json", tokenStorage.Email),
+			Provider: "copilot",
+			FileName: fmt.Sprintf("copilot-%s.json", tokenStorage.Email),
+			Storage:  tokenStorage,
+			Metadata: map[string]any{
+				"email": tokenStorage.Email,
+				"sku":   tokenStorage.SKU,
+			},
+		}
+		copilotAuth.Endpoints().ApplyToMetadata(record.Metadata)
+
+		savedPath, errSave := h.saveTokenRecord(ctx, record)
+		if errSave != nil {
+			log.Errorf("Failed to save Copilot token: %v", errSave)
+			SetOAuthSessionError(state, "Failed to save authentication tokens")
+			return
+		}
+
+		fmt.Printf("GitHub Copilot token saved! (SKU: %s, Path: %s)\n", tokenStorage.SKU, savedPath)
+		CompleteOAuthSession(state)
+	}()
+
+	return &loginStart{
+		Provider:        "copilot",
+		State:           state,
+		Mode:            loginModeDevice,
+		URL:             deviceFlow.VerificationURI,
+		UserCode:        deviceFlow.UserCode,
+		VerificationURI: deviceFlow.VerificationURI,
+		ExpiresIn:       deviceFlow.ExpiresIn,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+)
+
+func serveLoginSession(h *Handler, method, path, body string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/login-sessions/:state", h.GetLoginSession)
+	router.POST("/login-sessions/:state/callback", h.SubmitLoginSessionCallback)
+	router.DELETE("/login-sessions/:state", h.CancelLoginSession)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func loginSessionStatus(t *testing.T, h *Handler, state string) string {
+	t.Helper()
+	rec := serveLoginSession(h, http.MethodGet, "/login-sessions/"+state, "")
+	var resp struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("parse status response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Status
+}
+
+func TestLoginSession_PastedRedirectReachesWaitingFlow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authDir := t.TempDir()
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}}
+	state := "paste-state"
+	RegisterOAuthSession(state, "codex")
+	oauthSessions.SetLogin(state, &loginStart{Provider: "codex", State: state, Mode: loginModeAuthURL})
+
+	if status := loginSessionStatus(t, h, state); status != "wait" {
+		t.Fatalf("status before callback = %q, want wait", status)
+	}
+
+	rec := serveLoginSession(h, http.MethodPost, "/login-sessions/other-state/callback", `{"redirect_url":"http://localhost:1455/auth/callback?code=abc&state=paste-state"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("callback for unknown session: status = %d, want 404", rec.Code)
+	}
+
+	rec = serveLoginSession(h, http.MethodPost, "/login-sessions/"+state+"/callback", `{"redirect_url":"http://localhost:1455/auth/callback?code=abc&state=paste-state"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	data, err := os.ReadFile(filepath.Join(authDir, ".oauth-codex-"+state+".oauth"))
+	if err != nil {
+		t.Fatalf("callback file not written: %v", err)
+	}
+	if !strings.Contains(string(data), `"code":"abc"`) {
+		t.Fatalf("callback file = %s", data)
+	}
+
+	CompleteOAuthSession(state)
+	if status := loginSessionStatus(t, h, state); status != "ok" {
+		t.Fatalf("status after completion = %q, want ok", status)
+	}
+	if IsOAuthSessionPending(state, "codex") {
+		t.Fatal("completed session is still pending")
+	}
+}
+
+func TestLoginSession_CancelAndDeviceCallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{AuthDir: t.TempDir()}}
+	state := "device-state"
+	RegisterOAuthSession(state, "copilot")
+	oauthSessions.SetLogin(state, &loginStart{Provider: "copilot", State: state, Mode: loginModeDevice, UserCode: "ABCD-1234"})
+
+	rec := serveLoginSession(h, http.MethodPost, "/login-sessions/"+state+"/callback", `{"code":"abc"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("callback on device session: status = %d, want 400", rec.Code)
+	}
+
+	rec = serveLoginSession(h, http.MethodDelete, "/login-sessions/"+state, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cancel: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if !loginSessionCancelled(state) {
+		t.Fatal("session not marked cancelled")
+	}
+	if status := loginSessionStatus(t, h, state); status != "error" {
+		t.Fatalf("status after cancel = %q, want error", status)
+	}
+	rec = serveLoginSession(h, http.MethodDelete, "/login-sessions/"+state, "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second cancel: status = %d, want 409", rec.Code)
+	}
+}
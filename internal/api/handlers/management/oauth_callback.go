@@ -17,33 +17,17 @@ type oauthCallbackRequest struct {
 	Error       string `json:"error"`
 }
 
-func (h *Handler) PostOAuthCallback(c *gin.Context) {
-	if h == nil || h.cfg == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "handler not initialized"})
-		return
-	}
-
-	var req oauthCallbackRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid body"})
-		return
-	}
-
-	canonicalProvider, err := NormalizeOAuthProvider(req.Provider)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "unsupported provider"})
-		return
-	}
-
-	state := strings.TrimSpace(req.State)
-	code := strings.TrimSpace(req.Code)
-	errMsg := strings.TrimSpace(req.Error)
+// resolve returns the state, code and error of the callback, filling fields missing from the
+// request body from the query of the pasted redirect URL.
+func (req *oauthCallbackRequest) resolve() (state, code, errMsg string, err error) {
+	state = strings.TrimSpace(req.State)
+	code = strings.TrimSpace(req.Code)
+	errMsg = strings.TrimSpace(req.Error)
 
 	if rawRedirect := strings.TrimSpace(req.RedirectURL); rawRedirect != "" {
 		u, errParse := url.Parse(rawRedirect)
 		if errParse != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid redirect_url"})
-			return
+			return "", "", "", errParse
 		}
 		q := u.Query()
 		if state == "" {
@@ -59,6 +43,32 @@ func (h *Handler) PostOAuthCallback(c *gin.Context) {
 			}
 		}
 	}
+	return state, code, errMsg, nil
+}
+
+func (h *Handler) PostOAuthCallback(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "error": "handler not initialized"})
+		return
+	}
+
+	var req oauthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid body"})
+		return
+	}
+
+	canonicalProvider, err := NormalizeOAuthProvider(req.Provider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "unsupported provider"})
+		return
+	}
+
+	state, code, errMsg, errResolve := req.resolve()
+	if errResolve != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "invalid redirect_url"})
+		return
+	}
 
 	if state == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "error": "state is required"})
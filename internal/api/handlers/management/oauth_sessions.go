@@ -23,8 +23,13 @@ var (
 )
 
 type oauthSession struct {
-	Provider  string
-	Status    string
+	Provider string
+	Status   string
+	// Completed is set once the credential was saved. Completed sessions stay queryable until
+	// they expire so clients polling a login session can observe the result.
+	Completed bool
+	// Login holds what the client needs to finish a session started through the login-session API.
+	Login     *loginStart
 	CreatedAt time.Time
 	ExpiresAt time.Time
 }
@@ -108,7 +113,33 @@ func (s *oauthSessionStore) Complete(state string) {
 	defer s.mu.Unlock()
 
 	s.purgeExpiredLocked(now)
-	delete(s.sessions, state)
+	session, ok := s.sessions[state]
+	if !ok {
+		return
+	}
+	session.Status = ""
+	session.Completed = true
+	session.ExpiresAt = now.Add(s.ttl)
+	s.sessions[state] = session
+}
+
+func (s *oauthSessionStore) SetLogin(state string, login *loginStart) {
+	state = strings.TrimSpace(state)
+	if state == "" || login == nil {
+		return
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpiredLocked(now)
+	session, ok := s.sessions[state]
+	if !ok {
+		return
+	}
+	session.Login = login
+	s.sessions[state] = session
 }
 
 func (s *oauthSessionStore) CompleteProvider(provider string) int {
@@ -124,7 +155,7 @@ func (s *oauthSessionStore) CompleteProvider(provider string) int {
 	s.purgeExpiredLocked(now)
 	removed := 0
 	for state, session := range s.sessions {
-		if strings.EqualFold(session.Provider, provider) {
+		if strings.EqualFold(session.Provider, provider) && !session.Completed {
 			delete(s.sessions, state)
 			removed++
 		}
@@ -157,7 +188,7 @@ func (s *oauthSessionStore) IsPending(state, provider string) bool {
 	if !ok {
 		return false
 	}
-	if session.Status != "" {
+	if session.Status != "" || session.Completed {
 		return false
 	}
 	if provider == "" {
@@ -231,6 +262,8 @@ func NormalizeOAuthProvider(provider string) (string, error) {
 		return "antigravity", nil
 	case "qwen":
 		return "qwen", nil
+	case "copilot", "github-copilot":
+		return "copilot", nil
 	default:
 		return "", errUnsupportedOAuthFlow
 	}
@@ -0,0 +1,202 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/runtime/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+type translateRequestBody struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Model   string          `json:"model"`
+	Stream  bool            `json:"stream"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type translateResponseBody struct {
+	ClientFormat      string          `json:"client_format"`
+	UpstreamFormat    string          `json:"upstream_format"`
+	Model             string          `json:"model"`
+	Stream            bool            `json:"stream"`
+	OriginalRequest   json.RawMessage `json:"original_request"`
+	TranslatedRequest json.RawMessage `json:"translated_request"`
+	// Response holds a captured non-stream JSON body.
+	Response json.RawMessage `json:"response"`
+	// Transcript holds a captured SSE stream, or a non-stream body that is not valid JSON.
+	Transcript string `json:"transcript"`
+}
+
+// translatorFormats returns every format that appears in a registered translator pair, sorted,
+// so formats added to the translator registry are accepted without further changes here.
+func translatorFormats() []sdktranslator.Format {
+	seen := make(map[sdktranslator.Format]struct{})
+	var formats []sdktranslator.Format
+	for _, pair := range sdktranslator.Pairs() {
+		for _, format := range []sdktranslator.Format{pair.From, pair.To} {
+			if _, ok := seen[format]; !ok {
+				seen[format] = struct{}{}
+				formats = append(formats, format)
+			}
+		}
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i] < formats[j] })
+	return formats
+}
+
+func parseTranslatorFormat(raw string) (sdktranslator.Format, bool) {
+	format := sdktranslator.FromString(strings.ToLower(strings.TrimSpace(raw)))
+	for _, known := range translatorFormats() {
+		if format == known {
+			return format, true
+		}
+	}
+	return format, false
+}
+
+// ListTranslators returns the known formats and every registered translator pair.
+func (h *Handler) ListTranslators(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"formats": translatorFormats(),
+		"pairs":   sdktranslator.Pairs(),
+	})
+}
+
+// TranslateRequest converts a request payload from one format to another without calling any upstream.
+func (h *Handler) TranslateRequest(c *gin.Context) {
+	var body translateRequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	from, okFrom := parseTranslatorFormat(body.From)
+	to, okTo := parseTranslatorFormat(body.To)
+	if !okFrom || !okTo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be one of the supported formats", "formats": translatorFormats()})
+		return
+	}
+	payload := []byte(body.Payload)
+	if !gjson.ValidBytes(payload) || !gjson.ParseBytes(payload).IsObject() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payload must be a JSON object"})
+		return
+	}
+	model := strings.TrimSpace(body.Model)
+	if model == "" {
+		model = gjson.GetBytes(payload, "model").String()
+	}
+
+	translated := sdktranslator.TranslateRequest(from, to, model, payload, body.Stream)
+	c.JSON(http.StatusOK, gin.H{
+		"from":       from,
+		"to":         to,
+		"model":      model,
+		"stream":     body.Stream,
+		"registered": hasRequestTranslator(from, to),
+		"payload":    rawOrString(translated),
+	})
+}
+
+// TranslateResponse replays a captured upstream response or SSE transcript through the response
+// translator for the upstream/client pair and returns the client-format output chunk by chunk.
+func (h *Handler) TranslateResponse(c *gin.Context) {
+	var body translateResponseBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	client, okClient := parseTranslatorFormat(body.ClientFormat)
+	upstream, okUpstream := parseTranslatorFormat(body.UpstreamFormat)
+	if !okClient || !okUpstream {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_format and upstream_format must be one of the supported formats", "formats": translatorFormats()})
+		return
+	}
+	originalRequest := []byte(body.OriginalRequest)
+	if len(originalRequest) == 0 {
+		originalRequest = []byte("{}")
+	}
+	if !gjson.ValidBytes(originalRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "original_request must be valid JSON"})
+		return
+	}
+	model := strings.TrimSpace(body.Model)
+	if model == "" {
+		model = gjson.GetBytes(originalRequest, "model").String()
+	}
+	// Translators read request fields (tools, thinking config) from the upstream request, so derive
+	// it the same way the executor would when the caller did not capture one.
+	translatedRequest := []byte(body.TranslatedRequest)
+	if len(translatedRequest) == 0 {
+		translatedRequest = sdktranslator.TranslateRequest(client, upstream, model, bytes.Clone(originalRequest), body.Stream)
+	}
+
+	result := gin.H{
+		"client_format":   client,
+		"upstream_format": upstream,
+		"model":           model,
+		"stream":          body.Stream,
+	}
+	if body.Stream {
+		if strings.TrimSpace(body.Transcript) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "transcript is required for stream replay"})
+			return
+		}
+		chunks, errReplay := executor.ReplayStream(c.Request.Context(), upstream, client, model, originalRequest, translatedRequest, []byte(body.Transcript))
+		result["registered"] = hasResponseTranslator(client, upstream, true)
+		result["chunks"] = chunks
+		if errReplay != nil {
+			result["error"] = errReplay.Error()
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	response := []byte(body.Response)
+	if len(response) == 0 {
+		response = []byte(body.Transcript)
+	}
+	if len(strings.TrimSpace(string(response))) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "response or transcript is required"})
+		return
+	}
+	output := executor.ReplayNonStream(c.Request.Context(), upstream, client, model, originalRequest, translatedRequest, response)
+	result["registered"] = hasResponseTranslator(client, upstream, false)
+	result["output"] = rawOrString([]byte(output))
+	c.JSON(http.StatusOK, result)
+}
+
+func hasRequestTranslator(from, to sdktranslator.Format) bool {
+	for _, pair := range sdktranslator.Pairs() {
+		if pair.From == from && pair.To == to {
+			return pair.Request
+		}
+	}
+	return false
+}
+
+func hasResponseTranslator(client, upstream sdktranslator.Format, stream bool) bool {
+	for _, pair := range sdktranslator.Pairs() {
+		if pair.From == client && pair.To == upstream {
+			if stream {
+				return pair.Stream
+			}
+			return pair.NonStream
+		}
+	}
+	return false
+}
+
+// rawOrString embeds JSON output as-is and falls back to a JSON string otherwise.
+func rawOrString(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return json.RawMessage(data)
+	}
+	encoded, _ := json.Marshal(string(data))
+	return encoded
+}
@@ -0,0 +1,80 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/giofahreza/AIProxyAPI/internal/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestTranslateResponse_ReplaysClaudeStreamIntoOpenAIChunks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	transcript := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-test","usage":{"input_tokens":3,"output_tokens":0}}}`,
+		``,
+		`event: content_block_start`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		``,
+		`event: content_block_delta`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi there"}}`,
+		``,
+	}, "\n")
+	reqBody, _ := json.Marshal(map[string]any{
+		"client_format":    "openai",
+		"upstream_format":  "claude",
+		"stream":           true,
+		"original_request": map[string]any{"model": "claude-test", "messages": []any{map[string]any{"role": "user", "content": "hi"}}},
+		"transcript":       transcript,
+	})
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/translate/response", strings.NewReader(string(reqBody)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	(&Handler{}).TranslateResponse(c)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.Bytes()
+	if !gjson.GetBytes(body, "registered").Bool() {
+		t.Fatalf("expected a registered claude->openai stream translator, got %s", body)
+	}
+	var text strings.Builder
+	for _, chunk := range gjson.GetBytes(body, "chunks").Array() {
+		for _, out := range chunk.Get("output").Array() {
+			text.WriteString(gjson.Get(out.String(), "choices.0.delta.content").String())
+		}
+	}
+	if text.String() != "Hi there" {
+		t.Fatalf("replayed content = %q, body = %s", text.String(), body)
+	}
+}
+
+func TestTranslateRequest_RejectsUnknownFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/translate/request", strings.NewReader(`{"from":"openai","to":"bogus","payload":{}}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	(&Handler{}).TranslateRequest(c)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestTranslatorFormats_IncludesRegisteredOllama(t *testing.T) {
+	for _, format := range translatorFormats() {
+		if format == "ollama" {
+			return
+		}
+	}
+	t.Fatalf("translatorFormats() = %v, want ollama", translatorFormats())
+}
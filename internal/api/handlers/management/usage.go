@@ -1,8 +1,12 @@
 package management
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -77,3 +81,185 @@ func (h *Handler) ImportUsageStatistics(c *gin.Context) {
 		"failed_requests": snapshot.FailureCount,
 	})
 }
+
+// QueryUsage filters and groups the usage rollups. It accepts from/to (RFC 3339 or YYYY-MM-DD,
+// where a date-only "to" includes that day) or month=YYYY-MM, the filters api_key, user, model,
+// provider and auth_index (repeated or comma-separated), failed=true|false, group_by (day, hour,
+// key, user, model, provider, auth) and format=json|csv.
+func (h *Handler) QueryUsage(c *gin.Context) {
+	if h == nil || h.usageStats == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "usage statistics unavailable"})
+		return
+	}
+	query, err := h.parseUsageQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result := h.usageStats.Query(query)
+
+	switch strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "json"))) {
+	case "json":
+		c.JSON(http.StatusOK, result)
+	case "csv":
+		writeUsageCSV(c, query, result)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+	}
+}
+
+func (h *Handler) parseUsageQuery(c *gin.Context) (usage.Query, error) {
+	query := usage.Query{
+		APIKeys:     queryList(c, "api_key"),
+		EndUsers:    queryList(c, "user"),
+		Models:      queryList(c, "model"),
+		Providers:   queryList(c, "provider"),
+		AuthIndexes: queryList(c, "auth_index"),
+	}
+	h.mu.Lock()
+	if h.cfg != nil {
+		query.Pricing = h.cfg.ModelPricing
+	}
+	h.mu.Unlock()
+
+	if month := strings.TrimSpace(c.Query("month")); month != "" {
+		start, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			return query, fmt.Errorf("invalid month %q, want YYYY-MM", month)
+		}
+		query.From, query.To = start, start.AddDate(0, 1, 0)
+	}
+	var err error
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		if query.From, _, err = parseUsageTime(raw); err != nil {
+			return query, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		var dateOnly bool
+		if query.To, dateOnly, err = parseUsageTime(raw); err != nil {
+			return query, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			query.To = query.To.AddDate(0, 0, 1)
+		}
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return query, fmt.Errorf("from must be before to")
+	}
+
+	if raw := strings.TrimSpace(c.Query("failed")); raw != "" {
+		failed, errParse := strconv.ParseBool(raw)
+		if errParse != nil {
+			return query, fmt.Errorf("failed must be true or false")
+		}
+		query.Failed = &failed
+	}
+	if query.GroupBy, err = usage.ParseGroupBy(c.Query("group_by")); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// parseUsageTime accepts RFC 3339 timestamps and local YYYY-MM-DD dates.
+func parseUsageTime(raw string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is neither RFC 3339 nor YYYY-MM-DD", raw)
+	}
+	return t, false, nil
+}
+
+// queryList collects a repeated and/or comma-separated query parameter.
+func queryList(c *gin.Context, name string) []string {
+	var out []string
+	for _, value := range c.QueryArray(name) {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func writeUsageCSV(c *gin.Context, query usage.Query, result usage.QueryResult) {
+	header := make([]string, 0, len(result.GroupBy)+10)
+	for _, group := range result.GroupBy {
+		switch group {
+		case usage.GroupDay, usage.GroupHour:
+			header = append(header, "period")
+		case usage.GroupKey:
+			header = append(header, "api_key")
+		case usage.GroupUser:
+			header = append(header, "end_user")
+		case usage.GroupAuth:
+			header = append(header, "auth_index")
+		default:
+			header = append(header, group)
+		}
+	}
+	header = append(header, "requests", "failures", "error_rate", "input_tokens", "output_tokens",
+		"reasoning_tokens", "cached_tokens", "cache_write_tokens", "total_tokens", "images", "cost")
+
+	name := "usage"
+	if !query.From.IsZero() {
+		name += "-" + query.From.Format("20060102")
+	}
+	if !query.To.IsZero() {
+		name += "-" + query.To.Format("20060102")
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	_ = writer.Write(header)
+	for _, row := range result.Rows {
+		record := make([]string, 0, len(header))
+		for _, group := range result.GroupBy {
+			switch group {
+			case usage.GroupDay, usage.GroupHour:
+				record = append(record, row.Period)
+			case usage.GroupKey:
+				record = append(record, csvCell(row.APIKey))
+			case usage.GroupUser:
+				record = append(record, csvCell(row.EndUser))
+			case usage.GroupModel:
+				record = append(record, csvCell(row.Model))
+			case usage.GroupProvider:
+				record = append(record, csvCell(row.Provider))
+			case usage.GroupAuth:
+				record = append(record, csvCell(row.AuthIndex))
+			}
+		}
+		tokens := row.Tokens
+		record = append(record,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.Failures, 10),
+			strconv.FormatFloat(row.ErrorRate, 'f', 4, 64),
+			strconv.FormatInt(tokens.InputTokens, 10),
+			strconv.FormatInt(tokens.OutputTokens, 10),
+			strconv.FormatInt(tokens.ReasoningTokens, 10),
+			strconv.FormatInt(tokens.CachedTokens, 10),
+			strconv.FormatInt(tokens.CacheWriteTokens, 10),
+			strconv.FormatInt(tokens.TotalTokens, 10),
+			strconv.FormatInt(tokens.Images, 10),
+			strconv.FormatFloat(row.Cost, 'f', 6, 64),
+		)
+		_ = writer.Write(record)
+	}
+	writer.Flush()
+}
+
+// csvCell quotes values a spreadsheet would otherwise evaluate as a formula. End-user names come
+// from clients, so an exported cell must never start with =, +, -, @, a tab or a carriage return.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,87 @@
+package management
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+func TestQueryUsage_WritesMonthlyChargebackCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stats := usage.NewRequestStatistics()
+	now := time.Now()
+	stats.Record(context.Background(), coreusage.Record{APIKey: "team-a", Model: "gpt-5", RequestedAt: now, Detail: coreusage.Detail{InputTokens: 2_000_000}})
+	stats.Record(context.Background(), coreusage.Record{APIKey: "team-b", Model: "gpt-5", RequestedAt: now, Detail: coreusage.Detail{InputTokens: 1_000_000}})
+	h := &Handler{
+		cfg:        &config.Config{ModelPricing: []config.ModelPrice{{Models: []string{"gpt-5"}, InputPerMillion: 1.25}}},
+		usageStats: stats,
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage/query?month="+now.Format("2006-01")+"&group_by=key&format=csv", nil)
+	h.QueryUsage(c)
+
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("status = %d, content type = %q, body = %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "api_key" || records[0][len(records[0])-1] != "cost" {
+		t.Fatalf("csv = %v", records)
+	}
+	if records[1][0] != "team-a" || records[1][1] != "1" || records[1][len(records[1])-1] != "2.500000" {
+		t.Fatalf("team-a row = %v", records[1])
+	}
+}
+
+func TestQueryUsage_CSVNeutralizesFormulas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stats := usage.NewRequestStatistics()
+	now := time.Now()
+	stats.Record(context.Background(), coreusage.Record{APIKey: "team-a", EndUser: "=HYPERLINK(\"http://evil\")", Model: "gpt-5", RequestedAt: now})
+	stats.Record(context.Background(), coreusage.Record{APIKey: "team-a", EndUser: "alice", Model: "gpt-5", RequestedAt: now})
+	h := &Handler{cfg: &config.Config{}, usageStats: stats}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage/query?group_by=user&format=csv", nil)
+	h.QueryUsage(c)
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	users := make(map[string]bool)
+	for _, record := range records[1:] {
+		users[record[0]] = true
+	}
+	if !users[`'=HYPERLINK("http://evil")`] || !users["alice"] {
+		t.Fatalf("end_user cells = %v, want the formula prefixed with a quote and plain names unchanged", users)
+	}
+}
+
+func TestQueryUsage_RejectsInvalidParameters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{usageStats: usage.NewRequestStatistics()}
+	for _, query := range []string{"from=yesterday", "group_by=team", "failed=maybe", "format=xml", "from=2026-10-02&to=2026-10-01"} {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage/query?"+query, nil)
+		h.QueryUsage(c)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -10,7 +10,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/giofahreza/AIProxyAPI/internal/auth/vertex"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
 	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	"golang.org/x/oauth2"
 )
 
 // ImportVertexCredential handles uploading a Vertex service account JSON and saving it as an auth record.
@@ -67,9 +69,12 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 	if location == "" {
 		location = strings.TrimSpace(c.Query("location"))
 	}
-	if location == "" {
-		location = "us-central1"
+	rawRegions := c.PostForm("regions")
+	if strings.TrimSpace(rawRegions) == "" {
+		rawRegions = c.Query("regions")
 	}
+	regions := vertex.NormalizeRegions(vertex.ParseRegions(rawRegions), location)
+	location = regions[0]
 
 	fileName := fmt.Sprintf("vertex-%s.json", sanitizeVertexFilePart(projectID))
 	label := labelForVertex(projectID, email)
@@ -78,6 +83,7 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 		ProjectID:      projectID,
 		Email:          email,
 		Location:       location,
+		Regions:        regions,
 		Type:           "vertex",
 	}
 	metadata := map[string]any{
@@ -85,6 +91,7 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 		"project_id":      projectID,
 		"email":           email,
 		"location":        location,
+		"regions":         regions,
 		"type":            "vertex",
 		"label":           label,
 	}
@@ -113,6 +120,97 @@ func (h *Handler) ImportVertexCredential(c *gin.Context) {
 		"project_id": projectID,
 		"email":      email,
 		"location":   location,
+		"regions":    regions,
+	})
+}
+
+// RegisterVertexADC saves a Vertex credential that authenticates with the server's Application
+// Default Credentials, such as GKE workload identity, optionally impersonating a service account.
+// The credentials are checked by fetching a token first.
+func (h *Handler) RegisterVertexADC(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "config unavailable"})
+		return
+	}
+	if h.cfg.AuthDir == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth directory not configured"})
+		return
+	}
+	var req struct {
+		ProjectID                 string   `json:"project_id"`
+		Regions                   []string `json:"regions"`
+		ImpersonateServiceAccount string   `json:"impersonate_service_account"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+
+	ctx := context.Background()
+	if reqCtx := c.Request.Context(); reqCtx != nil {
+		ctx = reqCtx
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, util.SetProxy(&h.cfg.SDKConfig, &http.Client{}))
+	impersonate := strings.TrimSpace(req.ImpersonateServiceAccount)
+	detectedProject, err := vertex.VerifyADC(ctx, impersonate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "adc_unavailable", "message": err.Error()})
+		return
+	}
+	projectID := strings.TrimSpace(req.ProjectID)
+	if projectID == "" {
+		projectID = detectedProject
+	}
+	if projectID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "project_id missing"})
+		return
+	}
+	regions := vertex.NormalizeRegions(req.Regions, "")
+
+	fileName := vertex.ADCFileName(projectID, impersonate)
+	label := labelForVertex(projectID, impersonate)
+	storage := &vertex.VertexCredentialStorage{
+		CredentialSource:          vertex.CredentialSourceADC,
+		ImpersonateServiceAccount: impersonate,
+		ProjectID:                 projectID,
+		Email:                     impersonate,
+		Location:                  regions[0],
+		Regions:                   regions,
+		Type:                      "vertex",
+	}
+	metadata := map[string]any{
+		"credential_source": vertex.CredentialSourceADC,
+		"project_id":        projectID,
+		"email":             impersonate,
+		"location":          regions[0],
+		"regions":           regions,
+		"type":              "vertex",
+		"label":             label,
+	}
+	if impersonate != "" {
+		metadata["impersonate_service_account"] = impersonate
+	}
+	record := &coreauth.Auth{
+		ID:       fileName,
+		Provider: "vertex",
+		FileName: fileName,
+		Storage:  storage,
+		Label:    label,
+		Metadata: metadata,
+	}
+	savedPath, err := h.saveTokenRecord(ctx, record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "save_failed", "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "ok",
+		"auth-file":  savedPath,
+		"project_id": projectID,
+		"regions":    regions,
 	})
 }
 
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/giofahreza/AIProxyAPI/internal/limits"
@@ -14,7 +15,8 @@ import (
 
 // LimitsMiddleware returns a Gin middleware that enforces API key model restrictions
 // and monthly quotas. It reads the model name from the request body and validates
-// access before allowing the request to proceed.
+// access before allowing the request to proceed. The end user the request is made for,
+// if any, is stored on the context as "endUser" for usage attribution.
 func LimitsMiddleware(enforcer *limits.Enforcer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Skip if no enforcer is configured
@@ -46,9 +48,22 @@ func LimitsMiddleware(enforcer *limits.Enforcer) gin.HandlerFunc {
 			c.Set("allowedProviders", allowedProvs)
 		}
 
-		// Extract model name from request body
-		modelName, err := extractModelFromRequest(c)
-		if err != nil || modelName == "" {
+		// Extract model name and end user from request body
+		modelName, body, err := extractModelFromRequest(c)
+		if err != nil {
+			// A body that cannot be read cannot be checked against the key's restrictions,
+			// so it is rejected rather than let through unchecked.
+			status := http.StatusBadRequest
+			errorBody := handlers.BuildErrorResponseBody(status, "invalid request body: "+err.Error())
+			c.Data(status, "application/json; charset=utf-8", errorBody)
+			c.Abort()
+			return
+		}
+		endUser := enforcer.EndUser(c.Request.Header, body)
+		if endUser != "" {
+			c.Set("endUser", endUser)
+		}
+		if modelName == "" {
 			// If we can't extract the model, let it proceed
 			// (This is common for GET requests like /v1/models)
 			c.Next()
@@ -56,7 +71,11 @@ func LimitsMiddleware(enforcer *limits.Enforcer) gin.HandlerFunc {
 		}
 
 		// Check access restrictions and quotas
-		if err := enforcer.CheckAccess(apiKey, modelName); err != nil {
+		err = enforcer.CheckAccess(apiKey, modelName)
+		if err == nil {
+			err = enforcer.CheckEndUserAccess(apiKey, endUser, modelName)
+		}
+		if err != nil {
 			status := http.StatusForbidden
 			errorBody := handlers.BuildErrorResponseBody(status, err.Error())
 			c.Data(status, "application/json; charset=utf-8", errorBody)
@@ -68,25 +87,46 @@ func LimitsMiddleware(enforcer *limits.Enforcer) gin.HandlerFunc {
 	}
 }
 
-// extractModelFromRequest attempts to extract the model name from the request body.
-// It preserves the body for downstream handlers by reading and restoring it.
-func extractModelFromRequest(c *gin.Context) (string, error) {
-	// Only process POST/PUT/PATCH requests with JSON bodies
+// multipartMemory is the part of a multipart body kept in memory; the rest spills to temporary files.
+const multipartMemory = 32 << 20
+
+// extractModelFromRequest attempts to extract the model name from the request body and
+// returns the body it read. It preserves the body for downstream handlers by reading and
+// restoring it. For multipart bodies the returned body is a JSON object holding the model and
+// user form fields, so end users are attributed as for JSON requests.
+func extractModelFromRequest(c *gin.Context) (string, []byte, error) {
+	// Only process POST/PUT/PATCH requests with JSON or multipart bodies
 	if c.Request.Method != http.MethodPost &&
 		c.Request.Method != http.MethodPut &&
 		c.Request.Method != http.MethodPatch {
-		return "", nil
+		return "", nil, nil
 	}
 
 	contentType := c.GetHeader("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		// Uploads such as /v1/images/edits name the model in a form field. The parsed form
+		// stays on the request, so the handler does not read the body again.
+		if err := c.Request.ParseMultipartForm(multipartMemory); err != nil {
+			return "", nil, err
+		}
+		fields := map[string]string{"model": c.Request.FormValue("model")}
+		if user := c.Request.FormValue("user"); user != "" {
+			fields["user"] = user
+		}
+		body, err := json.Marshal(fields)
+		if err != nil {
+			return "", nil, err
+		}
+		return fields["model"], body, nil
+	}
 	if contentType != "" && contentType != "application/json" {
-		return "", nil
+		return "", nil, nil
 	}
 
 	// Read the body
 	bodyBytes, err := io.ReadAll(io.LimitReader(c.Request.Body, 10<<20))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// Restore the body for downstream handlers
@@ -99,17 +139,17 @@ func extractModelFromRequest(c *gin.Context) (string, error) {
 
 	if err := json.Unmarshal(bodyBytes, &reqBody); err != nil {
 		// Not valid JSON or doesn't have a model field
-		return "", nil
+		return "", bodyBytes, nil
 	}
 
-	return reqBody.Model, nil
+	return reqBody.Model, bodyBytes, nil
 }
 
 // ExtractModelMiddleware extracts the model name from the request and sets it in the context.
 // This is useful for handlers that need to access the model name without parsing the body again.
 func ExtractModelMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		modelName, err := extractModelFromRequest(c)
+		modelName, _, err := extractModelFromRequest(c)
 		if err == nil && modelName != "" {
 			c.Set("requestedModel", modelName)
 		}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/limits"
+)
+
+// newLimitsRouter serves POST /upload behind LimitsMiddleware for apiKey and records what the
+// handler saw.
+func newLimitsRouter(enforcer *limits.Enforcer, apiKey string, reached *bool, endUser, model *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("apiKey", apiKey) }, LimitsMiddleware(enforcer))
+	router.POST("/upload", func(c *gin.Context) {
+		*reached = true
+		*endUser = c.GetString("endUser")
+		*model = c.PostForm("model")
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func multipartBody(t *testing.T, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatalf("WriteField(%s) error = %v", name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return &body, writer.FormDataContentType()
+}
+
+func TestLimitsMiddleware_RejectsMalformedMultipart(t *testing.T) {
+	enforcer := limits.NewEnforcer([]config.APIKeyLimit{{APIKey: "k", AllowedModels: []string{"gpt-image-1"}}})
+	var reached bool
+	var endUser, model string
+	router := newLimitsRouter(enforcer, "k", &reached, &endUser, &model)
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewBufferString("not a multipart body"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=missing")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest || reached {
+		t.Fatalf("status = %d, reached = %v, want 400 before the handler", rec.Code, reached)
+	}
+}
+
+func TestLimitsMiddleware_ChecksMultipartModelAndUser(t *testing.T) {
+	enforcer := limits.NewEnforcer([]config.APIKeyLimit{{APIKey: "k", AllowedModels: []string{"gpt-image-1"}, RequireEndUser: true}})
+	enforcer.SetEndUserAttribution(config.EndUserAttribution{FromBody: true})
+
+	var reached bool
+	var endUser, model string
+	router := newLimitsRouter(enforcer, "k", &reached, &endUser, &model)
+	body, contentType := multipartBody(t, map[string]string{"model": "gpt-image-1", "user": "alice"})
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || endUser != "alice" || model != "gpt-image-1" {
+		t.Fatalf("status = %d, endUser = %q, model = %q, want alice attributed and the form intact", rec.Code, endUser, model)
+	}
+
+	reached = false
+	body, contentType = multipartBody(t, map[string]string{"model": "dall-e-3", "user": "alice"})
+	req = httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || reached {
+		t.Fatalf("status = %d, reached = %v, want a disallowed model rejected", rec.Code, reached)
+	}
+}
@@ -115,6 +115,11 @@ func shouldLogRequest(path string) bool {
 	}
 
 	if strings.HasPrefix(path, "/api") {
+		switch path {
+		case "/api/chat", "/api/generate":
+			// Ollama-compatible inference routes.
+			return true
+		}
 		return strings.HasPrefix(path, "/api/provider")
 	}
 
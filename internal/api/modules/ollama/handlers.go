@@ -0,0 +1,366 @@
+package ollama
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/constant"
+	"github.com/giofahreza/AIProxyAPI/internal/interfaces"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	"github.com/giofahreza/AIProxyAPI/sdk/api/handlers"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// compatibleVersion is reported by /api/version. Clients use it to gate features, so it
+// names an Ollama release whose API matches what this module implements.
+const compatibleVersion = "0.9.0"
+
+// Handler serves the Ollama API endpoints. Requests are executed in the OpenAI chat format,
+// so HandlerType reports "openai" to the auth manager and translators.
+type Handler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewHandler creates an Ollama API handler backed by the shared base handler.
+func NewHandler(base *handlers.BaseAPIHandler) *Handler {
+	return &Handler{BaseAPIHandler: base}
+}
+
+// HandlerType returns the format requests are executed in after translation.
+func (h *Handler) HandlerType() string {
+	return constant.OpenAI
+}
+
+// Models returns the models available through the OpenAI handler type.
+func (h *Handler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels(constant.OpenAI)
+}
+
+// Chat handles POST /api/chat.
+func (h *Handler) Chat(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if modelName == "" {
+		writeError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	h.execute(c, modelName, rawJSON, streamRequested(rawJSON), false)
+}
+
+// Generate handles POST /api/generate by running the prompt as a single-turn chat.
+func (h *Handler) Generate(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	root := gjson.ParseBytes(rawJSON)
+	modelName := root.Get("model").String()
+	if modelName == "" {
+		writeError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	// An empty prompt asks Ollama to load the model; there is nothing to load here.
+	if root.Get("prompt").String() == "" && len(root.Get("images").Array()) == 0 {
+		out := `{"model":"","created_at":"","response":"","done":true,"done_reason":"load"}`
+		out, _ = sjson.Set(out, "model", modelName)
+		out, _ = sjson.Set(out, "created_at", time.Now().UTC().Format(time.RFC3339Nano))
+		c.Data(http.StatusOK, "application/json", []byte(out))
+		return
+	}
+	h.execute(c, modelName, generateToChat(root), streamRequested(rawJSON), true)
+}
+
+// generateToChat converts an /api/generate request into the equivalent /api/chat request.
+func generateToChat(root gjson.Result) []byte {
+	out := `{"model":"","messages":[]}`
+	out, _ = sjson.Set(out, "model", root.Get("model").String())
+	for _, key := range []string{"options", "format", "think", "stream"} {
+		if v := root.Get(key); v.Exists() {
+			out, _ = sjson.SetRaw(out, key, v.Raw)
+		}
+	}
+	if system := root.Get("system").String(); system != "" {
+		msg, _ := sjson.Set(`{"role":"system"}`, "content", system)
+		out, _ = sjson.SetRaw(out, "messages.-1", msg)
+	}
+	msg, _ := sjson.Set(`{"role":"user"}`, "content", root.Get("prompt").String())
+	if images := root.Get("images"); images.IsArray() {
+		msg, _ = sjson.SetRaw(msg, "images", images.Raw)
+	}
+	out, _ = sjson.SetRaw(out, "messages.-1", msg)
+	return []byte(out)
+}
+
+// chatToGenerate reshapes an Ollama chat response into an /api/generate response.
+func chatToGenerate(line string) string {
+	root := gjson.Parse(line)
+	out, _ := sjson.Delete(line, "message")
+	out, _ = sjson.Set(out, "response", root.Get("message.content").String())
+	if thinking := root.Get("message.thinking").String(); thinking != "" {
+		out, _ = sjson.Set(out, "thinking", thinking)
+	}
+	return out
+}
+
+// streamRequested reports whether the client wants a streamed response. Ollama streams
+// unless "stream" is explicitly false.
+func streamRequested(rawJSON []byte) bool {
+	return gjson.GetBytes(rawJSON, "stream").Type != gjson.False
+}
+
+// execute translates an Ollama chat request to OpenAI, runs it and writes the translated
+// response. When generate is set, responses are reshaped for /api/generate.
+func (h *Handler) execute(c *gin.Context, modelName string, ollamaJSON []byte, stream, generate bool) {
+	openAIJSON := sdktranslator.TranslateRequest(sdktranslator.FormatOllama, sdktranslator.FormatOpenAI, modelName, ollamaJSON, stream)
+	reshape := func(line string) string {
+		if generate {
+			return chatToGenerate(line)
+		}
+		return line
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	if !stream {
+		resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, openAIJSON, "")
+		if errMsg != nil {
+			writeErrorMessage(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		var param any
+		out := sdktranslator.TranslateNonStream(cliCtx, sdktranslator.FormatOpenAI, sdktranslator.FormatOllama, modelName, ollamaJSON, openAIJSON, resp, &param)
+		c.Data(http.StatusOK, "application/json", []byte(reshape(out)))
+		cliCancel()
+		return
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		writeError(c, http.StatusInternalServerError, "streaming not supported")
+		cliCancel(nil)
+		return
+	}
+	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, openAIJSON, "")
+
+	var param any
+	writeChunk := func(chunk []byte) {
+		lines := sdktranslator.TranslateStream(cliCtx, sdktranslator.FormatOpenAI, sdktranslator.FormatOllama, modelName, ollamaJSON, openAIJSON, chunk, &param)
+		for _, line := range lines {
+			handlers.WriteSSEFormat(c, "%s\n", reshape(line))
+		}
+	}
+	setHeaders := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Cache-Control", "no-cache")
+	}
+
+	// Wait for the first chunk so failures before any output keep their HTTP status.
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cliCancel(c.Request.Context().Err())
+			return
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			writeErrorMessage(c, errMsg)
+			if errMsg != nil {
+				cliCancel(errMsg.Error)
+			} else {
+				cliCancel(nil)
+			}
+			return
+		case chunk, ok := <-dataChan:
+			setHeaders()
+			if !ok {
+				writeChunk([]byte("[DONE]"))
+				flusher.Flush()
+				cliCancel(nil)
+				return
+			}
+			writeChunk(chunk)
+			flusher.Flush()
+
+			noKeepAlive := time.Duration(0)
+			h.ForwardStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, handlers.StreamForwardOptions{
+				// NDJSON has no comment syntax for heartbeats.
+				KeepAliveInterval: &noKeepAlive,
+				WriteChunk:        writeChunk,
+				WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
+					_, text := errorStatusText(errMsg)
+					body, _ := sjson.Set(`{}`, "error", text)
+					handlers.WriteSSEFormat(c, "%s\n", body)
+				},
+				WriteDone: func() {
+					writeChunk([]byte("[DONE]"))
+				},
+			})
+			return
+		}
+	}
+}
+
+// Tags handles GET /api/tags, listing every available model as a local Ollama model.
+func (h *Handler) Tags(c *gin.Context) {
+	models := filterByAllowedProviders(c, h.Models())
+	out := `{"models":[]}`
+	for _, model := range models {
+		id, _ := model["id"].(string)
+		if id == "" {
+			continue
+		}
+		ownedBy, _ := model["owned_by"].(string)
+		var created int64
+		switch v := model["created"].(type) {
+		case int64:
+			created = v
+		case int:
+			created = int64(v)
+		}
+		entry := `{"size":0}`
+		entry, _ = sjson.Set(entry, "name", id)
+		entry, _ = sjson.Set(entry, "model", id)
+		entry, _ = sjson.Set(entry, "modified_at", modifiedAt(created))
+		entry, _ = sjson.Set(entry, "digest", modelDigest(id))
+		entry, _ = sjson.SetRaw(entry, "details", modelDetails(ownedBy))
+		out, _ = sjson.SetRaw(out, "models.-1", entry)
+	}
+	c.Data(http.StatusOK, "application/json", []byte(out))
+}
+
+// Show handles POST /api/show with the capabilities known from the model registry.
+func (h *Handler) Show(c *gin.Context) {
+	rawJSON, _ := c.GetRawData()
+	name := gjson.GetBytes(rawJSON, "model").String()
+	if name == "" {
+		name = gjson.GetBytes(rawJSON, "name").String()
+	}
+	info := registry.GetGlobalRegistry().GetModelInfo(name)
+	if name == "" || info == nil || !modelAllowed(c, info.OwnedBy) {
+		writeError(c, http.StatusNotFound, fmt.Sprintf("model '%s' not found", name))
+		return
+	}
+
+	out := `{"modelfile":"","parameters":"","template":"{{ .Prompt }}"}`
+	out, _ = sjson.SetRaw(out, "details", modelDetails(info.OwnedBy))
+	arch := info.OwnedBy
+	if arch == "" {
+		arch = "unknown"
+	}
+	out, _ = sjson.Set(out, "model_info.general\\.architecture", arch)
+	contextLength := info.ContextLength
+	if contextLength == 0 {
+		contextLength = info.InputTokenLimit
+	}
+	if contextLength > 0 {
+		out, _ = sjson.Set(out, "model_info."+strings.ReplaceAll(arch, ".", "_")+"\\.context_length", contextLength)
+	}
+	capabilities := []string{"completion", "tools"}
+	if info.Thinking != nil {
+		capabilities = append(capabilities, "thinking")
+	}
+	out, _ = sjson.Set(out, "capabilities", capabilities)
+	out, _ = sjson.Set(out, "modified_at", modifiedAt(info.Created))
+	c.Data(http.StatusOK, "application/json", []byte(out))
+}
+
+// Version handles GET /api/version, which clients call to detect an Ollama server.
+func (h *Handler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"version": compatibleVersion})
+}
+
+func modelDetails(family string) string {
+	details := `{"parent_model":"","format":"","family":"","families":null,"parameter_size":"","quantization_level":""}`
+	if family != "" {
+		details, _ = sjson.Set(details, "family", family)
+		details, _ = sjson.Set(details, "families", []string{family})
+	}
+	return details
+}
+
+func modelDigest(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func modifiedAt(created int64) string {
+	if created <= 0 {
+		return time.Now().UTC().Format(time.RFC3339)
+	}
+	return time.Unix(created, 0).UTC().Format(time.RFC3339)
+}
+
+// filterByAllowedProviders applies the API key's allowed-providers restriction, matching
+// the filtering of GET /v1/models.
+func filterByAllowedProviders(c *gin.Context, models []map[string]any) []map[string]any {
+	filtered := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		ownedBy, _ := model["owned_by"].(string)
+		if modelAllowed(c, ownedBy) {
+			filtered = append(filtered, model)
+		}
+	}
+	return filtered
+}
+
+func modelAllowed(c *gin.Context, ownedBy string) bool {
+	raw, exists := c.Get("allowedProviders")
+	if !exists {
+		return true
+	}
+	allowed, ok := raw.([]string)
+	if !ok || len(allowed) == 0 {
+		return true
+	}
+	for _, provider := range allowed {
+		if strings.EqualFold(provider, ownedBy) {
+			return true
+		}
+	}
+	return false
+}
+
+func errorStatusText(errMsg *interfaces.ErrorMessage) (int, string) {
+	status := http.StatusInternalServerError
+	if errMsg != nil && errMsg.StatusCode > 0 {
+		status = errMsg.StatusCode
+	}
+	text := http.StatusText(status)
+	if errMsg != nil && errMsg.Error != nil && strings.TrimSpace(errMsg.Error.Error()) != "" {
+		text = strings.TrimSpace(errMsg.Error.Error())
+	}
+	return status, text
+}
+
+// writeErrorMessage writes an execution failure in Ollama's {"error": "..."} shape.
+func writeErrorMessage(c *gin.Context, errMsg *interfaces.ErrorMessage) {
+	if errMsg != nil {
+		for key, values := range errMsg.Addon {
+			c.Writer.Header().Del(key)
+			for _, value := range values {
+				c.Writer.Header().Add(key, value)
+			}
+		}
+	}
+	status, text := errorStatusText(errMsg)
+	writeError(c, status, text)
+}
+
+func writeError(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{"error": message})
+}
@@ -0,0 +1,85 @@
+// Package ollama implements a routing module that exposes an Ollama-compatible API surface
+// (/api/chat, /api/generate, /api/tags, /api/show) on top of the proxy's model registry, so
+// tools that only speak the Ollama protocol can reach every configured provider.
+package ollama
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/api/modules"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Option configures the OllamaModule.
+type Option func(*OllamaModule)
+
+// OllamaModule implements the RouteModuleV2 interface for Ollama-compatible clients.
+// Requests are translated through the "ollama" translator format into OpenAI chat requests
+// and executed like /v1/chat/completions calls.
+type OllamaModule struct {
+	authMiddleware gin.HandlerFunc
+	middleware     []gin.HandlerFunc
+	registerOnce   sync.Once
+}
+
+// New creates a new Ollama routing module with the given options.
+func New(opts ...Option) *OllamaModule {
+	m := &OllamaModule{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithAuthMiddleware sets the authentication middleware for the Ollama routes.
+func WithAuthMiddleware(middleware gin.HandlerFunc) Option {
+	return func(m *OllamaModule) {
+		m.authMiddleware = middleware
+	}
+}
+
+// WithMiddleware appends middleware that runs after authentication, such as API key limits.
+func WithMiddleware(middleware ...gin.HandlerFunc) Option {
+	return func(m *OllamaModule) {
+		m.middleware = append(m.middleware, middleware...)
+	}
+}
+
+// Name returns the module identifier
+func (m *OllamaModule) Name() string {
+	return "ollama-api"
+}
+
+// Register attaches the Ollama routes. Routes are registered only once.
+func (m *OllamaModule) Register(ctx modules.Context) error {
+	m.registerOnce.Do(func() {
+		auth := m.authMiddleware
+		if auth == nil {
+			auth = ctx.AuthMiddleware
+		}
+		h := NewHandler(ctx.BaseHandler)
+
+		api := ctx.Engine.Group("/api")
+		if auth != nil {
+			api.Use(auth)
+		} else {
+			log.Warn("ollama module: no auth middleware provided, allowing all requests")
+		}
+		api.Use(m.middleware...)
+		api.POST("/chat", h.Chat)
+		api.POST("/generate", h.Generate)
+		api.GET("/tags", h.Tags)
+		api.POST("/show", h.Show)
+		api.GET("/version", h.Version)
+		log.Debug("ollama API routes registered")
+	})
+	return nil
+}
+
+// OnConfigUpdated is a no-op; the Ollama routes read models and credentials from the shared
+// registry and auth manager, which are updated elsewhere.
+func (m *OllamaModule) OnConfigUpdated(_ *config.Config) error {
+	return nil
+}
@@ -0,0 +1,161 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/api/modules"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	_ "github.com/giofahreza/AIProxyAPI/internal/translator/openai/ollama"
+	"github.com/giofahreza/AIProxyAPI/sdk/api/handlers"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	coreexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdkconfig "github.com/giofahreza/AIProxyAPI/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type chatExecutor struct {
+	lastPayload []byte
+}
+
+func (e *chatExecutor) Identifier() string { return "ollama-test" }
+
+func (e *chatExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.lastPayload = req.Payload
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":4,"completion_tokens":1}}`)}, nil
+}
+
+func (e *chatExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.lastPayload = req.Payload
+	ch := make(chan coreexecutor.StreamChunk, 3)
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"choices":[{"index":0,"delta":{"content":"hel"}}]}`)}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`)}
+	close(ch)
+	return ch, nil
+}
+
+func (e *chatExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *chatExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func newTestEngine(t *testing.T) (*gin.Engine, *chatExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	executor := &chatExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "ollama-test-auth", Provider: "ollama-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "ollama-test-model", OwnedBy: "tester", ContextLength: 8192}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	engine := gin.New()
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	module := New(WithAuthMiddleware(func(c *gin.Context) { c.Next() }))
+	if err := module.Register(modules.Context{Engine: engine, BaseHandler: base}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return engine, executor
+}
+
+func TestChatStreamsNDJSON(t *testing.T) {
+	engine, executor := newTestEngine(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/chat", strings.NewReader(`{"model":"ollama-test-model","messages":[{"role":"user","content":"hi"}]}`))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("content type = %q", ct)
+	}
+	if got := gjson.GetBytes(executor.lastPayload, "messages.0.content").String(); got != "hi" {
+		t.Errorf("executor payload not translated to OpenAI: %s", executor.lastPayload)
+	}
+
+	var lines []gjson.Result
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		lines = append(lines, gjson.Parse(scanner.Text()))
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 NDJSON lines, got %d: %s", len(lines), w.Body.String())
+	}
+	if lines[0].Get("message.content").String() != "hel" || lines[1].Get("message.content").String() != "lo" {
+		t.Errorf("unexpected content lines: %v", lines)
+	}
+	if !lines[2].Get("done").Bool() || lines[2].Get("done_reason").String() != "stop" {
+		t.Errorf("unexpected final line: %s", lines[2].Raw)
+	}
+}
+
+func TestGenerateNonStream(t *testing.T) {
+	engine, executor := newTestEngine(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(`{"model":"ollama-test-model","system":"be nice","prompt":"say hi","stream":false}`))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := gjson.GetBytes(executor.lastPayload, "messages.1.content").String(); got != "say hi" {
+		t.Errorf("prompt not sent as user message: %s", executor.lastPayload)
+	}
+	out := gjson.ParseBytes(w.Body.Bytes())
+	if out.Get("response").String() != "hello" || out.Get("message").Exists() {
+		t.Errorf("unexpected generate response: %s", out.Raw)
+	}
+	if !out.Get("done").Bool() || out.Get("prompt_eval_count").Int() != 4 {
+		t.Errorf("unexpected completion fields: %s", out.Raw)
+	}
+}
+
+func TestTagsAndShow(t *testing.T) {
+	engine, _ := newTestEngine(t)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/tags", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("tags status = %d", w.Code)
+	}
+	found := false
+	gjson.GetBytes(w.Body.Bytes(), "models").ForEach(func(_, model gjson.Result) bool {
+		if model.Get("name").String() == "ollama-test-model" {
+			found = model.Get("details.family").String() == "tester" && model.Get("digest").String() != ""
+		}
+		return !found
+	})
+	if !found {
+		t.Errorf("model missing from tags: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/show", strings.NewReader(`{"model":"ollama-test-model"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("show status = %d", w.Code)
+	}
+	if got := gjson.GetBytes(w.Body.Bytes(), "model_info.tester\\.context_length").Int(); got != 8192 {
+		t.Errorf("context length = %d, body = %s", got, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/show", strings.NewReader(`{"model":"missing"}`)))
+	if w.Code != http.StatusNotFound || gjson.GetBytes(w.Body.Bytes(), "error").String() == "" {
+		t.Errorf("expected Ollama-style 404, got %d %s", w.Code, w.Body.String())
+	}
+}
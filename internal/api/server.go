@@ -25,6 +25,7 @@ import (
 	"github.com/giofahreza/AIProxyAPI/internal/api/middleware"
 	"github.com/giofahreza/AIProxyAPI/internal/api/modules"
 	ampmodule "github.com/giofahreza/AIProxyAPI/internal/api/modules/amp"
+	ollamamodule "github.com/giofahreza/AIProxyAPI/internal/api/modules/ollama"
 	"github.com/giofahreza/AIProxyAPI/internal/config"
 	"github.com/giofahreza/AIProxyAPI/internal/limits"
 	"github.com/giofahreza/AIProxyAPI/internal/logging"
@@ -161,6 +162,9 @@ type Server struct {
 	// ampModule is the Amp routing module for model mapping hot-reload
 	ampModule *ampmodule.AmpModule
 
+	// ollamaModule serves the Ollama-compatible /api routes
+	ollamaModule *ollamamodule.OllamaModule
+
 	// managementRoutesRegistered tracks whether the management routes have been attached to the engine.
 	managementRoutesRegistered atomic.Bool
 	// managementRoutesEnabled controls whether management endpoints serve real handlers.
@@ -254,6 +258,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		wsRoutes:            make(map[string]struct{}),
 	}
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
+	s.limitsEnforcer.SetEndUserAttribution(cfg.EndUserAttribution)
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 	s.applyAccessConfig(nil, cfg)
@@ -294,6 +299,12 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		log.Errorf("Failed to register Amp module: %v", err)
 	}
 
+	// Register the Ollama-compatible API surface
+	s.ollamaModule = ollamamodule.New(ollamamodule.WithMiddleware(middleware.LimitsMiddleware(s.limitsEnforcer)))
+	if err := modules.RegisterModule(ctx, s.ollamaModule); err != nil {
+		log.Errorf("Failed to register Ollama module: %v", err)
+	}
+
 	// Apply additional router configurators from options
 	if optionState.routerConfigurator != nil {
 		optionState.routerConfigurator(engine, s.handlers, cfg)
@@ -334,6 +345,7 @@ func (s *Server) setupRoutes() {
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	anthropicHandlers := anthropic.NewAnthropicAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiImagesHandlers := openai.NewOpenAIImagesAPIHandler(s.handlers)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
@@ -346,7 +358,11 @@ func (s *Server) setupRoutes() {
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.POST("/images/generations", openaiImagesHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiImagesHandlers.ImageEdits)
 	}
+	// Generated image URLs are fetched without an API key; their random IDs expire after an hour.
+	s.engine.GET(openai.GeneratedImagePath+":id", openaiImagesHandlers.GeneratedImage)
 
 	// Anthropic compatible API routes
 	anthropicAPI := s.engine.Group("/api/anthropic/v1")
@@ -515,6 +531,7 @@ func (s *Server) registerManagementRoutes() {
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
 	{
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
+		mgmt.GET("/usage/query", s.mgmt.QueryUsage)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/config", s.mgmt.GetConfig)
@@ -544,6 +561,12 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.DELETE("/proxy-url", s.mgmt.DeleteProxyURL)
 
 		mgmt.POST("/api-call", s.mgmt.APICall)
+		mgmt.POST("/explain", s.mgmt.ExplainRoute)
+		mgmt.GET("/health-probes", s.mgmt.GetHealthProbes)
+		mgmt.POST("/health-probes/run", s.mgmt.RunHealthProbe)
+		mgmt.GET("/translate/pairs", s.mgmt.ListTranslators)
+		mgmt.POST("/translate/request", s.mgmt.TranslateRequest)
+		mgmt.POST("/translate/response", s.mgmt.TranslateResponse)
 
 		mgmt.GET("/quota-exceeded/switch-project", s.mgmt.GetSwitchProject)
 		mgmt.PUT("/quota-exceeded/switch-project", s.mgmt.PutSwitchProject)
@@ -656,6 +679,8 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
+		mgmt.POST("/vertex/adc", s.mgmt.RegisterVertexADC)
+		mgmt.POST("/import-local-creds", s.mgmt.ImportLocalCredentials)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
 		mgmt.GET("/codex-auth-url", s.mgmt.RequestCodexToken)
@@ -669,6 +694,10 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.POST("/iflow-auth-url", s.mgmt.RequestIFlowCookieToken)
 		mgmt.POST("/oauth-callback", s.mgmt.PostOAuthCallback)
 		mgmt.GET("/get-auth-status", s.mgmt.GetAuthStatus)
+		mgmt.POST("/login-sessions", s.mgmt.StartLoginSession)
+		mgmt.GET("/login-sessions/:state", s.mgmt.GetLoginSession)
+		mgmt.POST("/login-sessions/:state/callback", s.mgmt.SubmitLoginSessionCallback)
+		mgmt.DELETE("/login-sessions/:state", s.mgmt.CancelLoginSession)
 	}
 }
 
@@ -964,6 +993,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if oldCfg == nil || oldCfg.UsageRetention != cfg.UsageRetention {
+		usage.ApplyRetention(cfg.UsageRetention)
+	}
+
 	if oldCfg == nil || oldCfg.DisableCooling != cfg.DisableCooling {
 		auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 		if oldCfg != nil {
@@ -1023,6 +1056,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	// Reload API key limits enforcer
 	if s.limitsEnforcer != nil {
 		s.limitsEnforcer.Reload(cfg.APIKeyLimits)
+		s.limitsEnforcer.SetEndUserAttribution(cfg.EndUserAttribution)
 		log.Debug("API key limits reloaded")
 	}
 
@@ -1055,6 +1089,11 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	} else {
 		log.Warnf("amp module is nil, skipping config update")
 	}
+	if s.ollamaModule != nil {
+		if err := s.ollamaModule.OnConfigUpdated(cfg); err != nil {
+			log.Errorf("failed to update Ollama module config: %v", err)
+		}
+	}
 
 	// Count client sources from configuration and auth store.
 	tokenStore := sdkAuth.GetTokenStore()
@@ -1066,20 +1105,26 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	claudeAPIKeyCount := len(cfg.ClaudeKey)
 	codexAPIKeyCount := len(cfg.CodexKey)
 	vertexAICompatCount := len(cfg.VertexCompatAPIKey)
+	bedrockKeyCount := len(cfg.BedrockKey)
+	azureOpenAICount := len(cfg.AzureOpenAI)
+	ollamaCount := len(cfg.Ollama)
 	openAICompatCount := 0
 	for i := range cfg.OpenAICompatibility {
 		entry := cfg.OpenAICompatibility[i]
 		openAICompatCount += len(entry.APIKeyEntries)
 	}
 
-	total := authEntries + geminiAPIKeyCount + claudeAPIKeyCount + codexAPIKeyCount + vertexAICompatCount + openAICompatCount
-	fmt.Printf("server clients and configuration updated: %d clients (%d auth entries + %d Gemini API keys + %d Claude API keys + %d Codex keys + %d Vertex-compat + %d OpenAI-compat)\n",
+	total := authEntries + geminiAPIKeyCount + claudeAPIKeyCount + codexAPIKeyCount + vertexAICompatCount + bedrockKeyCount + azureOpenAICount + ollamaCount + openAICompatCount
+	fmt.Printf("server clients and configuration updated: %d clients (%d auth entries + %d Gemini API keys + %d Claude API keys + %d Codex keys + %d Vertex-compat + %d Bedrock + %d Azure OpenAI + %d Ollama + %d OpenAI-compat)\n",
 		total,
 		authEntries,
 		geminiAPIKeyCount,
 		claudeAPIKeyCount,
 		codexAPIKeyCount,
 		vertexAICompatCount,
+		bedrockKeyCount,
+		azureOpenAICount,
+		ollamaCount,
 		openAICompatCount,
 	)
 }
@@ -0,0 +1,148 @@
+// Package azure provides Microsoft Entra ID client-credential tokens for calling Azure OpenAI
+// resources without an API key.
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// CognitiveServicesScope is the token scope accepted by Azure OpenAI resources.
+	CognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+
+	tokenEndpointFormat = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+
+	// refreshSkew renews cached tokens this long before they expire.
+	refreshSkew = 2 * time.Minute
+)
+
+// ClientCredentials identifies an Entra ID app registration.
+type ClientCredentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+// Token is an Entra ID access token and its expiry.
+type Token struct {
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+// TokenEndpoint returns the OAuth2 v2.0 token endpoint for the tenant.
+func TokenEndpoint(tenantID string) string {
+	return fmt.Sprintf(tokenEndpointFormat, url.PathEscape(tenantID))
+}
+
+// FetchToken requests an access token for scope using the client-credentials grant.
+func FetchToken(ctx context.Context, client *http.Client, tokenURL string, creds ClientCredentials, scope string) (Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", creds.ClientID)
+	form.Set("client_secret", creds.ClientSecret)
+	form.Set("scope", scope)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("azure: token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, fmt.Errorf("azure: read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		detail := gjson.GetBytes(body, "error_description").String()
+		if detail == "" {
+			detail = strings.TrimSpace(string(body))
+		}
+		return Token{}, &TokenError{StatusCode: resp.StatusCode, Message: detail}
+	}
+	accessToken := gjson.GetBytes(body, "access_token").String()
+	if accessToken == "" {
+		return Token{}, fmt.Errorf("azure: token response missing access_token")
+	}
+	expiresIn := gjson.GetBytes(body, "expires_in").Int()
+	if expiresIn <= 0 {
+		expiresIn = 3600
+	}
+	return Token{AccessToken: accessToken, ExpiresAt: time.Now().Add(time.Duration(expiresIn) * time.Second)}, nil
+}
+
+// TokenError reports a rejected token request.
+type TokenError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("azure: token request rejected (%d): %s", e.StatusCode, e.Message)
+}
+
+// TokenCache keeps one token per app registration and renews it shortly before expiry.
+type TokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]Token
+	// fetches deduplicates concurrent fetches per app registration; a slow tenant only holds up
+	// callers waiting for the same credentials.
+	fetches singleflight.Group
+}
+
+// NewTokenCache returns an empty cache.
+func NewTokenCache() *TokenCache {
+	return &TokenCache{tokens: make(map[string]Token)}
+}
+
+// Token returns a cached token for creds or fetches a new one with fetch. Concurrent callers
+// for the same credentials share a single fetch.
+func (c *TokenCache) Token(creds ClientCredentials, fetch func() (Token, error)) (string, error) {
+	key := creds.TenantID + "|" + creds.ClientID + "|" + creds.ClientSecret
+	if accessToken, ok := c.cached(key); ok {
+		return accessToken, nil
+	}
+	value, err, _ := c.fetches.Do(key, func() (any, error) {
+		// A fetch that finished while this caller was arriving already stored a fresh token.
+		if accessToken, ok := c.cached(key); ok {
+			return accessToken, nil
+		}
+		tok, errFetch := fetch()
+		if errFetch != nil {
+			return "", errFetch
+		}
+		c.mu.Lock()
+		c.tokens[key] = tok
+		c.mu.Unlock()
+		return tok.AccessToken, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (c *TokenCache) cached(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tok, ok := c.tokens[key]; ok && time.Now().Add(refreshSkew).Before(tok.ExpiresAt) {
+		return tok.AccessToken, true
+	}
+	return "", false
+}
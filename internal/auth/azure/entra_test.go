@@ -0,0 +1,143 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchTokenClientCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		if got := r.PostForm.Get("grant_type"); got != "client_credentials" {
+			t.Errorf("grant_type = %q", got)
+		}
+		if got := r.PostForm.Get("scope"); got != CognitiveServicesScope {
+			t.Errorf("scope = %q", got)
+		}
+		if r.PostForm.Get("client_id") != "app" || r.PostForm.Get("client_secret") != "secret" {
+			t.Errorf("unexpected client credentials: %v", r.PostForm)
+		}
+		_, _ = w.Write([]byte(`{"access_token":"tok-1","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer srv.Close()
+
+	creds := ClientCredentials{TenantID: "tenant", ClientID: "app", ClientSecret: "secret"}
+	tok, err := FetchToken(context.Background(), srv.Client(), srv.URL, creds, CognitiveServicesScope)
+	if err != nil {
+		t.Fatalf("FetchToken: %v", err)
+	}
+	if tok.AccessToken != "tok-1" {
+		t.Fatalf("access token = %q", tok.AccessToken)
+	}
+	if until := time.Until(tok.ExpiresAt); until < 59*time.Minute || until > time.Hour {
+		t.Fatalf("unexpected expiry in %s", until)
+	}
+}
+
+func TestFetchTokenRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad secret"}`))
+	}))
+	defer srv.Close()
+
+	_, err := FetchToken(context.Background(), srv.Client(), srv.URL, ClientCredentials{ClientID: "app"}, CognitiveServicesScope)
+	tokenErr, ok := err.(*TokenError)
+	if !ok {
+		t.Fatalf("expected TokenError, got %v", err)
+	}
+	if tokenErr.StatusCode != http.StatusUnauthorized || tokenErr.Message != "bad secret" {
+		t.Fatalf("unexpected error: %+v", tokenErr)
+	}
+}
+
+func TestTokenCacheReusesUntilNearExpiry(t *testing.T) {
+	cache := NewTokenCache()
+	creds := ClientCredentials{TenantID: "t", ClientID: "c", ClientSecret: "s"}
+	calls := 0
+	fetch := func(expiresIn time.Duration) func() (Token, error) {
+		return func() (Token, error) {
+			calls++
+			return Token{AccessToken: "tok", ExpiresAt: time.Now().Add(expiresIn)}, nil
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Token(creds, fetch(time.Hour)); err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected one fetch for a fresh token, got %d", calls)
+	}
+
+	other := ClientCredentials{TenantID: "t", ClientID: "c2", ClientSecret: "s"}
+	if _, err := cache.Token(other, fetch(time.Minute)); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if _, err := cache.Token(other, fetch(time.Minute)); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected near-expiry token to be refetched, got %d fetches", calls)
+	}
+}
+
+func TestTokenCacheSharesConcurrentFetches(t *testing.T) {
+	cache := NewTokenCache()
+	slow := ClientCredentials{TenantID: "t", ClientID: "slow", ClientSecret: "s"}
+	fast := ClientCredentials{TenantID: "t", ClientID: "fast", ClientSecret: "s"}
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var calls atomic.Int32
+	slowFetch := func() (Token, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return Token{AccessToken: "slow", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tok, err := cache.Token(slow, slowFetch); err != nil || tok != "slow" {
+				t.Errorf("Token(slow) = %q, %v, want %q", tok, err, "slow")
+			}
+		}()
+	}
+	<-started
+
+	// A slow fetch for one app registration must not block another.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tok, err := cache.Token(fast, func() (Token, error) {
+			return Token{AccessToken: "fast", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		})
+		if err != nil || tok != "fast" {
+			t.Errorf("Token(fast) = %q, %v, want %q", tok, err, "fast")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Token(fast) blocked behind a slow fetch for other credentials")
+	}
+
+	close(release)
+	wg.Wait()
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("slow fetches = %d, want 1 shared by concurrent callers", got)
+	}
+}
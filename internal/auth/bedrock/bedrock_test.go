@@ -0,0 +1,82 @@
+package bedrock
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSignRequest_MatchesAWSExample(t *testing.T) {
+	// Example request from the AWS Signature Version 4 documentation.
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	SignRequest(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Fatalf("X-Amz-Date = %q, want 20150830T123600Z", got)
+	}
+}
+
+func TestSignRequest_SessionTokenIsSigned(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/model/m/invoke", nil)
+	SignRequest(req, []byte("{}"), Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}, "us-east-1", ServiceName, time.Now())
+
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Fatalf("X-Amz-Security-Token = %q, want token", got)
+	}
+	if auth := req.Header.Get("Authorization"); !bytes.Contains([]byte(auth), []byte("SignedHeaders=host;x-amz-date;x-amz-security-token,")) {
+		t.Fatalf("Authorization = %q, want session token in signed headers", auth)
+	}
+}
+
+func TestEscapePathSegment(t *testing.T) {
+	if got := EscapePathSegment("anthropic.claude-3-5-sonnet-20240620-v1:0"); got != "anthropic.claude-3-5-sonnet-20240620-v1%3A0" {
+		t.Fatalf("EscapePathSegment() = %q", got)
+	}
+}
+
+func TestEventStreamReader_RoundTrip(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(EncodeMessage(map[string]string{":event-type": "chunk", ":message-type": "event"}, []byte(`{"bytes":"e30="}`)))
+	stream.Write(EncodeMessage(map[string]string{":exception-type": "throttlingException", ":message-type": "exception"}, []byte(`{"message":"slow down"}`)))
+
+	reader := NewEventStreamReader(&stream)
+	first, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if first.Headers[":event-type"] != "chunk" || string(first.Payload) != `{"bytes":"e30="}` {
+		t.Fatalf("first message = %+v", first)
+	}
+	second, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if second.Headers[":exception-type"] != "throttlingException" {
+		t.Fatalf("second message headers = %v", second.Headers)
+	}
+	if _, err = reader.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next() at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestEventStreamReader_RejectsCorruptMessage(t *testing.T) {
+	msg := EncodeMessage(map[string]string{":event-type": "chunk"}, []byte(`{}`))
+	msg[len(msg)-6] ^= 0xff
+
+	if _, err := NewEventStreamReader(bytes.NewReader(msg)).Next(); err == nil {
+		t.Fatalf("Next() error = nil, want checksum error")
+	}
+}
@@ -0,0 +1,135 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxEventStreamMessage bounds a single event-stream message, matching the AWS limit.
+const maxEventStreamMessage = 16 * 1024 * 1024
+
+// Message is one decoded message of the AWS event-stream binary framing.
+type Message struct {
+	// Headers holds the string-valued headers such as ":event-type" and ":message-type".
+	Headers map[string]string
+	Payload []byte
+}
+
+// EventStreamReader decodes messages from an application/vnd.amazon.eventstream body.
+type EventStreamReader struct {
+	r io.Reader
+}
+
+// NewEventStreamReader returns a reader that decodes event-stream messages from r.
+func NewEventStreamReader(r io.Reader) *EventStreamReader {
+	return &EventStreamReader{r: r}
+}
+
+// Next reads the next message. It returns io.EOF when the stream ends cleanly between messages.
+func (d *EventStreamReader) Next() (Message, error) {
+	var prelude [12]byte
+	if _, err := io.ReadFull(d.r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return Message{}, fmt.Errorf("bedrock: truncated event-stream prelude")
+		}
+		return Message{}, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return Message{}, fmt.Errorf("bedrock: event-stream prelude checksum mismatch")
+	}
+	if totalLen < 16 || totalLen > maxEventStreamMessage || headersLen > totalLen-16 {
+		return Message{}, fmt.Errorf("bedrock: invalid event-stream message length %d", totalLen)
+	}
+
+	buf := make([]byte, totalLen)
+	copy(buf, prelude[:])
+	if _, err := io.ReadFull(d.r, buf[12:]); err != nil {
+		return Message{}, fmt.Errorf("bedrock: truncated event-stream message: %w", err)
+	}
+	if crc32.ChecksumIEEE(buf[:totalLen-4]) != binary.BigEndian.Uint32(buf[totalLen-4:]) {
+		return Message{}, fmt.Errorf("bedrock: event-stream message checksum mismatch")
+	}
+
+	headers, err := decodeEventStreamHeaders(buf[12 : 12+headersLen])
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Headers: headers, Payload: buf[12+headersLen : totalLen-4]}, nil
+}
+
+// decodeEventStreamHeaders parses the header block, keeping string values and skipping others.
+func decodeEventStreamHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	errMalformed := fmt.Errorf("bedrock: malformed event-stream headers")
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, errMalformed
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true, bool false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // int16
+			size = 2
+		case 4: // int32
+			size = 4
+		case 5, 8: // int64, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(b) < 2 {
+				return nil, errMalformed
+			}
+			valueLen := int(binary.BigEndian.Uint16(b[:2]))
+			if len(b) < 2+valueLen {
+				return nil, errMalformed
+			}
+			if valueType == 7 {
+				headers[name] = string(b[2 : 2+valueLen])
+			}
+			b = b[2+valueLen:]
+			continue
+		default:
+			return nil, fmt.Errorf("bedrock: unknown event-stream header type %d", valueType)
+		}
+		if len(b) < size {
+			return nil, errMalformed
+		}
+		b = b[size:]
+	}
+	return headers, nil
+}
+
+// EncodeMessage frames headers and payload as an event-stream message with string headers.
+// The proxy only decodes streams; this exists for tests and local stubs.
+func EncodeMessage(headers map[string]string, payload []byte) []byte {
+	var headerBlock []byte
+	for name, value := range headers {
+		headerBlock = append(headerBlock, byte(len(name)))
+		headerBlock = append(headerBlock, name...)
+		headerBlock = append(headerBlock, 7)
+		headerBlock = binary.BigEndian.AppendUint16(headerBlock, uint16(len(value)))
+		headerBlock = append(headerBlock, value...)
+	}
+	totalLen := 16 + len(headerBlock) + len(payload)
+	out := make([]byte, 0, totalLen)
+	out = binary.BigEndian.AppendUint32(out, uint32(totalLen))
+	out = binary.BigEndian.AppendUint32(out, uint32(len(headerBlock)))
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[:8]))
+	out = append(out, headerBlock...)
+	out = append(out, payload...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+}
@@ -74,3 +74,13 @@ func (ts *ClaudeTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// EncodeToken implements auth.TokenEncoder.
+func (ts *ClaudeTokenStorage) EncodeToken() ([]byte, error) {
+	ts.Type = "claude"
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
+	}
+	return data, nil
+}
@@ -67,3 +67,13 @@ func (ts *CodexTokenStorage) SaveTokenToFile(authFilePath string) error {
 	return nil
 
 }
+
+// EncodeToken implements auth.TokenEncoder.
+func (ts *CodexTokenStorage) EncodeToken() ([]byte, error) {
+	ts.Type = "codex"
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
+	}
+	return data, nil
+}
@@ -1,5 +1,7 @@
 package copilot
 
+import "strings"
+
 // GitHub OAuth Device Flow Constants
 const (
 	// GitHubDeviceCodeURL is the URL for initiating the OAuth 2.0 device authorization flow.
@@ -10,12 +12,149 @@ const (
 	GitHubCopilotTokenURL = "https://api.github.com/copilot_internal/v2/token"
 	// GitHubUserURL is the URL for fetching authenticated user information.
 	GitHubUserURL = "https://api.github.com/user"
-	// GitHubClientID is the official GitHub Copilot Client ID.
+	// GitHubClientID is the official GitHub Copilot Client ID. It is registered on github.com
+	// only; Enterprise hosts need the client ID of an OAuth app registered on that host.
 	GitHubClientID = "Iv1.b507a08c87ecfe98"
 	// GitHubScope defines the permissions requested by the application.
 	GitHubScope = "read:user"
+	// DefaultGitHubHost is the host of github.com accounts.
+	DefaultGitHubHost = "github.com"
+	// DefaultCopilotAPIBase is used when neither the credential nor the Copilot token names an API base.
+	DefaultCopilotAPIBase = "https://api.individual.githubcopilot.com"
 )
 
+// Endpoints holds the hosts a Copilot credential talks to. The zero value targets github.com.
+// GitHub Enterprise Server and GHE.com accounts set GitHubHost; the API host is derived from
+// it unless set explicitly. ClientID names the OAuth app on that host.
+type Endpoints struct {
+	// GitHubHost serves the device flow (e.g., "github.example.com").
+	GitHubHost string
+	// APIHost serves the Copilot token exchange and user API (e.g., "github.example.com/api/v3").
+	APIHost string
+	// CopilotAPIBase overrides the Copilot API base URL returned with the Copilot token.
+	CopilotAPIBase string
+	// ClientID is the OAuth app used for the device flow. Defaults to GitHubClientID.
+	ClientID string
+}
+
+// EndpointsFromMetadata reads the endpoints stored in a Copilot auth record.
+func EndpointsFromMetadata(metadata map[string]any) Endpoints {
+	var e Endpoints
+	e.GitHubHost, _ = metadata["github_host"].(string)
+	e.APIHost, _ = metadata["github_api_host"].(string)
+	e.CopilotAPIBase, _ = metadata["copilot_base_url"].(string)
+	e.ClientID, _ = metadata["github_client_id"].(string)
+	return e.Normalize()
+}
+
+// Normalize trims the endpoints and fills in the API host for the GitHub host.
+func (e Endpoints) Normalize() Endpoints {
+	e.GitHubHost = trimHost(e.GitHubHost)
+	e.APIHost = trimHost(e.APIHost)
+	e.CopilotAPIBase = strings.TrimRight(strings.TrimSpace(e.CopilotAPIBase), "/")
+	e.ClientID = strings.TrimSpace(e.ClientID)
+	if e.ClientID == GitHubClientID {
+		e.ClientID = ""
+	}
+	if e.GitHubHost == "" {
+		e.GitHubHost = DefaultGitHubHost
+	}
+	if e.APIHost == "" {
+		host := hostName(e.GitHubHost)
+		switch {
+		case strings.EqualFold(host, DefaultGitHubHost):
+			e.APIHost = "api.github.com"
+		case strings.HasSuffix(strings.ToLower(host), ".ghe.com"):
+			e.APIHost = "api." + host
+		default:
+			// GitHub Enterprise Server serves its REST API under /api/v3.
+			e.APIHost = e.GitHubHost + "/api/v3"
+		}
+	}
+	return e
+}
+
+// IsDefault reports whether the endpoints are those of github.com.
+func (e Endpoints) IsDefault() bool {
+	n := e.Normalize()
+	return n.GitHubHost == DefaultGitHubHost && n.APIHost == "api.github.com" && n.CopilotAPIBase == "" && n.ClientID == ""
+}
+
+// OAuthClientID returns the client ID of the OAuth app used for the device flow.
+func (e Endpoints) OAuthClientID() string {
+	if id := e.Normalize().ClientID; id != "" {
+		return id
+	}
+	return GitHubClientID
+}
+
+// DeviceCodeURL is the URL for initiating the OAuth 2.0 device authorization flow.
+func (e Endpoints) DeviceCodeURL() string {
+	return baseURL(e.Normalize().GitHubHost) + "/login/device/code"
+}
+
+// AccessTokenURL is the URL for exchanging device codes for access tokens.
+func (e Endpoints) AccessTokenURL() string {
+	return baseURL(e.Normalize().GitHubHost) + "/login/oauth/access_token"
+}
+
+// CopilotTokenURL is the URL for exchanging GitHub tokens for Copilot tokens.
+func (e Endpoints) CopilotTokenURL() string {
+	return baseURL(e.Normalize().APIHost) + "/copilot_internal/v2/token"
+}
+
+// UserURL is the URL for fetching authenticated user information.
+func (e Endpoints) UserURL() string {
+	return baseURL(e.Normalize().APIHost) + "/user"
+}
+
+// APIBase returns the Copilot API base URL: the configured override, else the one returned with
+// the Copilot token, else the individual endpoint.
+func (e Endpoints) APIBase(tokenAPIBase string) string {
+	if base := e.Normalize().CopilotAPIBase; base != "" {
+		return base
+	}
+	if base := strings.TrimRight(strings.TrimSpace(tokenAPIBase), "/"); base != "" {
+		return base
+	}
+	return DefaultCopilotAPIBase
+}
+
+// ApplyToMetadata stores non-default endpoints in an auth record's metadata.
+func (e Endpoints) ApplyToMetadata(metadata map[string]any) {
+	if metadata == nil || e.IsDefault() {
+		return
+	}
+	n := e.Normalize()
+	metadata["github_host"] = n.GitHubHost
+	metadata["github_api_host"] = n.APIHost
+	if n.CopilotAPIBase != "" {
+		metadata["copilot_base_url"] = n.CopilotAPIBase
+	}
+	if n.ClientID != "" {
+		metadata["github_client_id"] = n.ClientID
+	}
+}
+
+func trimHost(raw string) string {
+	return strings.TrimRight(strings.TrimSpace(raw), "/")
+}
+
+func hostName(host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	if idx := strings.IndexAny(host, "/:"); idx >= 0 {
+		host = host[:idx]
+	}
+	return host
+}
+
+func baseURL(host string) string {
+	if strings.HasPrefix(host, "http://") || strings.HasPrefix(host, "https://") {
+		return host
+	}
+	return "https://" + host
+}
+
 // DeviceCodeResponse represents the response from GitHub's device authorization endpoint.
 type DeviceCodeResponse struct {
 	// DeviceCode is the code that the client uses to poll for an access token.
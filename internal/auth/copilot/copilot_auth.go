@@ -23,6 +23,7 @@ import (
 // polling for GitHub token, and exchanging for Copilot API token.
 type CopilotAuth struct {
 	httpClient *http.Client
+	endpoints  Endpoints
 }
 
 // NewCopilotAuth creates a new GitHub Copilot authentication service.
@@ -34,11 +35,30 @@ type CopilotAuth struct {
 // Returns:
 //   - *CopilotAuth: A new Copilot authentication service instance
 func NewCopilotAuth(cfg *config.Config) *CopilotAuth {
+	return NewCopilotAuthWithEndpoints(cfg, Endpoints{})
+}
+
+// NewCopilotAuthWithEndpoints creates a Copilot authentication service for a GitHub Enterprise
+// host or a custom Copilot API base.
+//
+// Parameters:
+//   - cfg: The application configuration containing proxy settings
+//   - endpoints: The GitHub and Copilot hosts of the credential
+//
+// Returns:
+//   - *CopilotAuth: A new Copilot authentication service instance
+func NewCopilotAuthWithEndpoints(cfg *config.Config, endpoints Endpoints) *CopilotAuth {
 	return &CopilotAuth{
 		httpClient: util.SetProxy(&cfg.SDKConfig, &http.Client{Timeout: 30 * time.Second}),
+		endpoints:  endpoints.Normalize(),
 	}
 }
 
+// Endpoints returns the hosts this service talks to.
+func (ca *CopilotAuth) Endpoints() Endpoints {
+	return ca.endpoints
+}
+
 // InitiateDeviceFlow starts the OAuth 2.0 device authorization flow with GitHub.
 // It requests a device code that the user will use to authorize the application.
 //
@@ -50,10 +70,10 @@ func NewCopilotAuth(cfg *config.Config) *CopilotAuth {
 //   - error: An error if the request fails
 func (ca *CopilotAuth) InitiateDeviceFlow(ctx context.Context) (*DeviceCodeResponse, error) {
 	data := url.Values{}
-	data.Set("client_id", GitHubClientID)
+	data.Set("client_id", ca.endpoints.OAuthClientID())
 	data.Set("scope", GitHubScope)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", GitHubDeviceCodeURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", ca.endpoints.DeviceCodeURL(), strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create device code request: %w", err)
 	}
@@ -108,11 +128,18 @@ func (ca *CopilotAuth) PollForGitHubToken(deviceCode string, interval int) (stri
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		data := url.Values{}
-		data.Set("client_id", GitHubClientID)
+		data.Set("client_id", ca.endpoints.OAuthClientID())
 		data.Set("device_code", deviceCode)
 		data.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
 
-		resp, err := http.PostForm(GitHubAccessTokenURL, data)
+		req, err := http.NewRequest(http.MethodPost, ca.endpoints.AccessTokenURL(), strings.NewReader(data.Encode()))
+		if err != nil {
+			return "", fmt.Errorf("failed to create token request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := ca.httpClient.Do(req)
 		if err != nil {
 			fmt.Printf("Polling attempt %d/%d failed: %v\n", attempt+1, maxAttempts, err)
 			time.Sleep(pollInterval)
@@ -189,7 +216,7 @@ func (ca *CopilotAuth) ExchangeGitHubTokenForCopilot(ctx context.Context, github
 		return nil, fmt.Errorf("GitHub token is required")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", GitHubCopilotTokenURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", ca.endpoints.CopilotTokenURL(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Copilot token request: %w", err)
 	}
@@ -244,7 +271,7 @@ func (ca *CopilotAuth) RefreshCopilotToken(ctx context.Context, githubToken stri
 	return &CopilotTokenData{
 		GitHubToken:    githubToken,
 		CopilotToken:   copilotResp.Token,
-		CopilotAPIBase: copilotResp.Endpoints.API,
+		CopilotAPIBase: ca.endpoints.APIBase(copilotResp.Endpoints.API),
 		CopilotExpire:  time.Unix(copilotResp.ExpiresAt, 0).Format(time.RFC3339),
 		SKU:            copilotResp.SKU,
 	}, nil
@@ -302,6 +329,12 @@ func (ca *CopilotAuth) CreateTokenStorage(tokenData *CopilotTokenData) *CopilotT
 		Email:          tokenData.Email,
 		SKU:            tokenData.SKU,
 	}
+	if !ca.endpoints.IsDefault() {
+		storage.GitHubHost = ca.endpoints.GitHubHost
+		storage.GitHubAPIHost = ca.endpoints.APIHost
+		storage.CopilotBaseURL = ca.endpoints.CopilotAPIBase
+		storage.GitHubClientID = ca.endpoints.ClientID
+	}
 
 	return storage
 }
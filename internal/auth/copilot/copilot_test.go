@@ -0,0 +1,61 @@
+package copilot
+
+import "testing"
+
+func TestEndpoints_DefaultsToGitHubCom(t *testing.T) {
+	var e Endpoints
+	if got := e.DeviceCodeURL(); got != "https://github.com/login/device/code" {
+		t.Fatalf("unexpected device code URL: %s", got)
+	}
+	if got := e.CopilotTokenURL(); got != GitHubCopilotTokenURL {
+		t.Fatalf("unexpected Copilot token URL: %s", got)
+	}
+	if got := e.APIBase(""); got != DefaultCopilotAPIBase {
+		t.Fatalf("unexpected API base: %s", got)
+	}
+	if !e.IsDefault() {
+		t.Fatal("expected zero endpoints to be the default")
+	}
+	metadata := map[string]any{}
+	e.ApplyToMetadata(metadata)
+	if len(metadata) != 0 {
+		t.Fatalf("expected no metadata for github.com, got %v", metadata)
+	}
+}
+
+func TestEndpoints_EnterpriseHosts(t *testing.T) {
+	server := Endpoints{GitHubHost: "https://github.example.com/"}
+	if got := server.AccessTokenURL(); got != "https://github.example.com/login/oauth/access_token" {
+		t.Fatalf("unexpected access token URL: %s", got)
+	}
+	if got := server.CopilotTokenURL(); got != "https://github.example.com/api/v3/copilot_internal/v2/token" {
+		t.Fatalf("unexpected Copilot token URL: %s", got)
+	}
+
+	cloud := Endpoints{GitHubHost: "acme.ghe.com", CopilotAPIBase: "https://copilot-api.acme.ghe.com/"}
+	if got := cloud.UserURL(); got != "https://api.acme.ghe.com/user" {
+		t.Fatalf("unexpected user URL: %s", got)
+	}
+	if got := cloud.APIBase("https://api.enterprise.githubcopilot.com"); got != "https://copilot-api.acme.ghe.com" {
+		t.Fatalf("expected the configured API base to win, got %s", got)
+	}
+
+	metadata := map[string]any{}
+	cloud.ApplyToMetadata(metadata)
+	if restored := EndpointsFromMetadata(metadata); restored != cloud.Normalize() {
+		t.Fatalf("expected endpoints to round-trip through metadata, got %+v", restored)
+	}
+}
+
+func TestEndpoints_ClientIDRoundTripsThroughMetadata(t *testing.T) {
+	if got := (Endpoints{GitHubHost: "github.example.com"}).OAuthClientID(); got != GitHubClientID {
+		t.Fatalf("expected the default client ID, got %s", got)
+	}
+	server := Endpoints{GitHubHost: "github.example.com", ClientID: " Iv1.enterprise "}
+	metadata := map[string]any{}
+	server.ApplyToMetadata(metadata)
+	restored := EndpointsFromMetadata(metadata)
+	if got := restored.OAuthClientID(); got != "Iv1.enterprise" {
+		t.Fatalf("expected the stored client ID, got %s", got)
+	}
+}
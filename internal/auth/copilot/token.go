@@ -41,6 +41,18 @@ type CopilotTokenStorage struct {
 
 	// SKU is the Copilot subscription type (e.g., "free_educational_quota", "copilot_individual").
 	SKU string `json:"sku,omitempty"`
+
+	// GitHubHost is the GitHub Enterprise host the account belongs to; empty for github.com.
+	GitHubHost string `json:"github_host,omitempty"`
+
+	// GitHubAPIHost is the REST API host used for the Copilot token exchange; empty for github.com.
+	GitHubAPIHost string `json:"github_api_host,omitempty"`
+
+	// CopilotBaseURL overrides the Copilot API base URL returned with the Copilot token.
+	CopilotBaseURL string `json:"copilot_base_url,omitempty"`
+
+	// GitHubClientID is the OAuth app the account authorized; empty for the github.com Copilot app.
+	GitHubClientID string `json:"github_client_id,omitempty"`
 }
 
 // SaveTokenToFile serializes the Copilot token storage to a JSON file.
@@ -78,3 +90,13 @@ func (ts *CopilotTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// EncodeToken implements auth.TokenEncoder.
+func (ts *CopilotTokenStorage) EncodeToken() ([]byte, error) {
+	ts.Type = "copilot"
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
+	}
+	return data, nil
+}
@@ -182,10 +182,15 @@ func (g *GeminiAuth) createTokenStorage(ctx context.Context, config *oauth2.Conf
 		fmt.Println("Failed to get user email from token")
 	}
 
+	return NewTokenStorage(token, emailResult.String(), projectID)
+}
+
+// NewTokenStorage wraps an OAuth2 token issued to the Gemini CLI client in a GeminiTokenStorage,
+// adding the client details needed to refresh it.
+func NewTokenStorage(token *oauth2.Token, email, projectID string) (*GeminiTokenStorage, error) {
 	var ifToken map[string]any
 	jsonData, _ := json.Marshal(token)
-	err = json.Unmarshal(jsonData, &ifToken)
-	if err != nil {
+	if err := json.Unmarshal(jsonData, &ifToken); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
 	}
 
@@ -198,7 +203,7 @@ func (g *GeminiAuth) createTokenStorage(ctx context.Context, config *oauth2.Conf
 	ts := GeminiTokenStorage{
 		Token:     ifToken,
 		ProjectID: projectID,
-		Email:     emailResult.String(),
+		Email:     email,
 	}
 
 	return &ts, nil
@@ -69,6 +69,16 @@ func (ts *GeminiTokenStorage) SaveTokenToFile(authFilePath string) error {
 	return nil
 }
 
+// EncodeToken implements auth.TokenEncoder.
+func (ts *GeminiTokenStorage) EncodeToken() ([]byte, error) {
+	ts.Type = "gemini"
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
+	}
+	return data, nil
+}
+
 // CredentialFileName returns the filename used to persist Gemini CLI credentials.
 // When projectID represents multiple projects (comma-separated or literal ALL),
 // the suffix is normalized to "all" and a "gemini-" prefix is enforced to keep
@@ -47,3 +47,13 @@ func (ts *IFlowTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// EncodeToken implements auth.TokenEncoder.
+func (ts *IFlowTokenStorage) EncodeToken() ([]byte, error) {
+	ts.Type = "iflow"
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return nil, fmt.Errorf("iflow token: encode token failed: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,335 @@
+// Package localcreds finds the OAuth credentials that the Claude Code, Codex and Gemini CLIs
+// keep in the user's home directory and converts them into this proxy's auth records, so
+// accounts already signed in on a machine can be used without another browser login.
+package localcreds
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/auth/claude"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/codex"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/gemini"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"golang.org/x/oauth2"
+)
+
+// Account is a credential found in a vendor CLI's files, converted to an auth record.
+type Account struct {
+	// Provider is the proxy provider the credential belongs to: claude, codex or gemini.
+	Provider string
+	// Email identifies the account.
+	Email string
+	// Source is the vendor CLI file the credential was read from.
+	Source string
+	// Record is the auth record to save. Gemini records have no project yet.
+	Record *coreauth.Auth
+	// Warning explains a caveat of the import, such as a refresh token shared with the CLI.
+	Warning string
+}
+
+// sharedRefreshTokenWarning is attached to imports whose vendor rotates refresh tokens on use.
+// The proxy and the CLI then hold the same token, and whichever refreshes first signs the other out.
+const sharedRefreshTokenWarning = "the refresh token is shared with %[1]s and rotated on every refresh; " +
+	"whichever refreshes first signs the other out, so stop using %[1]s with this account or sign in to it again afterwards"
+
+// Detect reads the credential files of every supported CLI under home. CLIs that are not
+// installed or not signed in are skipped; files that exist but cannot be converted are
+// reported as errors.
+func Detect(home string) ([]*Account, []error) {
+	detectors := []func(string) (*Account, error){
+		DetectClaudeCode,
+		DetectCodexCLI,
+		DetectGeminiCLI,
+	}
+	accounts := make([]*Account, 0, len(detectors))
+	var errs []error
+	for _, detect := range detectors {
+		account, err := detect(home)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if account != nil {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, errs
+}
+
+// DetectClaudeCode converts the OAuth login stored by Claude Code in .claude/.credentials.json.
+// The account email comes from .claude.json. Returns nil when Claude Code is not signed in.
+func DetectClaudeCode(home string) (*Account, error) {
+	configDir := strings.TrimSpace(os.Getenv("CLAUDE_CONFIG_DIR"))
+	if configDir == "" {
+		configDir = filepath.Join(home, ".claude")
+	}
+	source := filepath.Join(configDir, ".credentials.json")
+	data, err := readOptional(source)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	oauth := gjson.GetBytes(data, "claudeAiOauth")
+	refreshToken := oauth.Get("refreshToken").String()
+	if refreshToken == "" {
+		return nil, fmt.Errorf("%s: no OAuth refresh token", source)
+	}
+
+	email := ""
+	for _, path := range []string{filepath.Join(configDir, ".claude.json"), filepath.Join(home, ".claude.json")} {
+		if profile, errProfile := readOptional(path); errProfile == nil && profile != nil {
+			if email = gjson.GetBytes(profile, "oauthAccount.emailAddress").String(); email != "" {
+				break
+			}
+		}
+	}
+	if email == "" {
+		return nil, fmt.Errorf("%s: account email not found in .claude.json", source)
+	}
+
+	storage := &claude.ClaudeTokenStorage{
+		AccessToken:  oauth.Get("accessToken").String(),
+		RefreshToken: refreshToken,
+		LastRefresh:  time.Now().Format(time.RFC3339),
+		Email:        email,
+		Type:         "claude",
+		Expire:       formatMillis(oauth.Get("expiresAt").Int()),
+	}
+	fileName := fmt.Sprintf("claude-%s.json", email)
+	return &Account{
+		Provider: "claude",
+		Email:    email,
+		Source:   source,
+		Warning:  fmt.Sprintf(sharedRefreshTokenWarning, "Claude Code"),
+		Record: &coreauth.Auth{
+			ID:       fileName,
+			Provider: "claude",
+			FileName: fileName,
+			Storage:  storage,
+			Metadata: map[string]any{"email": email},
+		},
+	}, nil
+}
+
+// DetectCodexCLI converts the ChatGPT login stored by the Codex CLI in auth.json under
+// CODEX_HOME (default .codex). Returns nil when the Codex CLI is not signed in.
+func DetectCodexCLI(home string) (*Account, error) {
+	codexHome := strings.TrimSpace(os.Getenv("CODEX_HOME"))
+	if codexHome == "" {
+		codexHome = filepath.Join(home, ".codex")
+	}
+	source := filepath.Join(codexHome, "auth.json")
+	data, err := readOptional(source)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	tokens := gjson.GetBytes(data, "tokens")
+	refreshToken := tokens.Get("refresh_token").String()
+	if refreshToken == "" {
+		if gjson.GetBytes(data, "OPENAI_API_KEY").String() != "" {
+			return nil, fmt.Errorf("%s: signed in with an API key, not a ChatGPT account", source)
+		}
+		return nil, fmt.Errorf("%s: no OAuth refresh token", source)
+	}
+	idToken := tokens.Get("id_token").String()
+	claims, errClaims := codex.ParseJWTToken(idToken)
+	if errClaims != nil {
+		return nil, fmt.Errorf("%s: parse id token: %w", source, errClaims)
+	}
+	email := claims.GetUserEmail()
+	if email == "" {
+		return nil, fmt.Errorf("%s: id token has no email", source)
+	}
+	accountID := tokens.Get("account_id").String()
+	if accountID == "" {
+		accountID = claims.GetAccountID()
+	}
+	accessToken := tokens.Get("access_token").String()
+	expire := ""
+	if accessClaims, errAccess := codex.ParseJWTToken(accessToken); errAccess == nil && accessClaims.Exp > 0 {
+		expire = time.Unix(int64(accessClaims.Exp), 0).Format(time.RFC3339)
+	}
+	lastRefresh := gjson.GetBytes(data, "last_refresh").String()
+	if lastRefresh == "" {
+		lastRefresh = time.Now().Format(time.RFC3339)
+	}
+
+	storage := &codex.CodexTokenStorage{
+		IDToken:      idToken,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		AccountID:    accountID,
+		LastRefresh:  lastRefresh,
+		Email:        email,
+		Type:         "codex",
+		Expire:       expire,
+	}
+	fileName := fmt.Sprintf("codex-%s.json", email)
+	return &Account{
+		Provider: "codex",
+		Email:    email,
+		Source:   source,
+		Warning:  fmt.Sprintf(sharedRefreshTokenWarning, "the Codex CLI"),
+		Record: &coreauth.Auth{
+			ID:       fileName,
+			Provider: "codex",
+			FileName: fileName,
+			Storage:  storage,
+			Metadata: map[string]any{"email": email},
+		},
+	}, nil
+}
+
+// DetectGeminiCLI converts the Google login stored by the Gemini CLI in .gemini/oauth_creds.json.
+// The record has no project; callers pick one before saving. Returns nil when the Gemini CLI is
+// not signed in with Google.
+func DetectGeminiCLI(home string) (*Account, error) {
+	geminiDir := filepath.Join(home, ".gemini")
+	source := filepath.Join(geminiDir, "oauth_creds.json")
+	data, err := readOptional(source)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	refreshToken := gjson.GetBytes(data, "refresh_token").String()
+	if refreshToken == "" {
+		return nil, fmt.Errorf("%s: no OAuth refresh token", source)
+	}
+
+	email := ""
+	if accounts, errAccounts := readOptional(filepath.Join(geminiDir, "google_accounts.json")); errAccounts == nil && accounts != nil {
+		email = gjson.GetBytes(accounts, "active").String()
+	}
+	if email == "" {
+		email = jwtEmail(gjson.GetBytes(data, "id_token").String())
+	}
+	if email == "" {
+		return nil, fmt.Errorf("%s: account email not found", source)
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  gjson.GetBytes(data, "access_token").String(),
+		TokenType:    gjson.GetBytes(data, "token_type").String(),
+		RefreshToken: refreshToken,
+	}
+	if expiry := gjson.GetBytes(data, "expiry_date").Int(); expiry > 0 {
+		token.Expiry = time.UnixMilli(expiry)
+	}
+	storage, errStorage := gemini.NewTokenStorage(token, email, "")
+	if errStorage != nil {
+		return nil, fmt.Errorf("%s: %w", source, errStorage)
+	}
+	storage.Type = "gemini"
+	return &Account{
+		Provider: "gemini",
+		Email:    email,
+		Source:   source,
+		Record: &coreauth.Auth{
+			Provider: "gemini",
+			Storage:  storage,
+			Metadata: map[string]any{"email": email},
+		},
+	}, nil
+}
+
+// FilterNew splits accounts into those not yet present in existing and those that duplicate an
+// existing auth of the same provider by email or refresh token.
+func FilterNew(accounts []*Account, existing []*coreauth.Auth) (fresh, duplicates []*Account) {
+	known := make(map[string]struct{}, len(existing)*2)
+	for _, auth := range existing {
+		if auth == nil {
+			continue
+		}
+		provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+		if email, ok := auth.Metadata["email"].(string); ok && email != "" {
+			known[provider+"|email|"+strings.ToLower(email)] = struct{}{}
+		}
+		if token := recordRefreshToken(auth.Metadata); token != "" {
+			known[provider+"|token|"+token] = struct{}{}
+		}
+	}
+	for _, account := range accounts {
+		emailKey := account.Provider + "|email|" + strings.ToLower(account.Email)
+		tokenKey := account.Provider + "|token|" + storageRefreshToken(account.Record)
+		_, emailSeen := known[emailKey]
+		_, tokenSeen := known[tokenKey]
+		if emailSeen || tokenSeen {
+			duplicates = append(duplicates, account)
+			continue
+		}
+		known[emailKey] = struct{}{}
+		fresh = append(fresh, account)
+	}
+	return fresh, duplicates
+}
+
+func recordRefreshToken(metadata map[string]any) string {
+	if token, ok := metadata["refresh_token"].(string); ok && token != "" {
+		return token
+	}
+	if nested, ok := metadata["token"].(map[string]any); ok {
+		if token, okToken := nested["refresh_token"].(string); okToken {
+			return token
+		}
+	}
+	return ""
+}
+
+func storageRefreshToken(record *coreauth.Auth) string {
+	if record == nil {
+		return ""
+	}
+	switch storage := record.Storage.(type) {
+	case *claude.ClaudeTokenStorage:
+		return storage.RefreshToken
+	case *codex.CodexTokenStorage:
+		return storage.RefreshToken
+	case *gemini.GeminiTokenStorage:
+		if token, ok := storage.Token.(map[string]any); ok {
+			if refresh, okRefresh := token["refresh_token"].(string); okRefresh {
+				return refresh
+			}
+		}
+	}
+	return ""
+}
+
+func readOptional(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%s: invalid json", path)
+	}
+	return data, nil
+}
+
+func formatMillis(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).Format(time.RFC3339)
+}
+
+// jwtEmail returns the email claim of an unverified JWT.
+func jwtEmail(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	return gjson.GetBytes(payload, "email").String()
+}
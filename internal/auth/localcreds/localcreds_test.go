@@ -0,0 +1,104 @@
+package localcreds
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/giofahreza/AIProxyAPI/internal/auth/claude"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/codex"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/gemini"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func fakeJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestDetect_ConvertsVendorCredentials(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "")
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".claude", ".credentials.json"),
+		`{"claudeAiOauth":{"accessToken":"sk-ant-oat","refreshToken":"sk-ant-ort","expiresAt":1767225600000}}`)
+	writeFile(t, filepath.Join(home, ".claude.json"), `{"oauthAccount":{"emailAddress":"dev@example.com"}}`)
+	writeFile(t, filepath.Join(home, ".codex", "auth.json"),
+		`{"OPENAI_API_KEY":null,"tokens":{"id_token":"`+fakeJWT(`{"email":"dev@example.com","https://api.openai.com/auth":{"chatgpt_account_id":"acct-1"}}`)+`","access_token":"`+fakeJWT(`{"exp":1767225600}`)+`","refresh_token":"rt-codex"},"last_refresh":"2026-01-01T00:00:00Z"}`)
+	writeFile(t, filepath.Join(home, ".gemini", "oauth_creds.json"),
+		`{"access_token":"ya29","refresh_token":"1//rt","token_type":"Bearer","expiry_date":1767225600000}`)
+	writeFile(t, filepath.Join(home, ".gemini", "google_accounts.json"), `{"active":"dev@gmail.com","old":[]}`)
+
+	accounts, errs := Detect(home)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(accounts) != 3 {
+		t.Fatalf("expected 3 accounts, got %d", len(accounts))
+	}
+
+	claudeStorage := accounts[0].Record.Storage.(*claude.ClaudeTokenStorage)
+	if accounts[0].Record.FileName != "claude-dev@example.com.json" || claudeStorage.RefreshToken != "sk-ant-ort" || claudeStorage.Expire == "" {
+		t.Fatalf("unexpected claude record: %+v %+v", accounts[0].Record, claudeStorage)
+	}
+	if !strings.Contains(accounts[0].Warning, "Claude Code") || !strings.Contains(accounts[1].Warning, "Codex CLI") || accounts[2].Warning != "" {
+		t.Fatalf("expected shared refresh token warnings for claude and codex only, got %q, %q, %q", accounts[0].Warning, accounts[1].Warning, accounts[2].Warning)
+	}
+	codexStorage := accounts[1].Record.Storage.(*codex.CodexTokenStorage)
+	if codexStorage.Email != "dev@example.com" || codexStorage.AccountID != "acct-1" || codexStorage.Expire == "" {
+		t.Fatalf("unexpected codex storage: %+v", codexStorage)
+	}
+	geminiStorage := accounts[2].Record.Storage.(*gemini.GeminiTokenStorage)
+	token := geminiStorage.Token.(map[string]any)
+	if geminiStorage.Email != "dev@gmail.com" || token["refresh_token"] != "1//rt" || token["client_id"] == nil {
+		t.Fatalf("unexpected gemini storage: %+v", geminiStorage)
+	}
+}
+
+func TestDetect_SkipsMissingAndReportsUnusable(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "")
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".codex", "auth.json"), `{"OPENAI_API_KEY":"sk-test"}`)
+
+	accounts, errs := Detect(home)
+	if len(accounts) != 0 {
+		t.Fatalf("expected no accounts, got %d", len(accounts))
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected one error for the API key login, got %v", errs)
+	}
+}
+
+func TestFilterNew_DeduplicatesByEmailAndRefreshToken(t *testing.T) {
+	accounts := []*Account{
+		{Provider: "claude", Email: "a@example.com", Record: &coreauth.Auth{Storage: &claude.ClaudeTokenStorage{RefreshToken: "rt-a"}}},
+		{Provider: "codex", Email: "b@example.com", Record: &coreauth.Auth{Storage: &codex.CodexTokenStorage{RefreshToken: "rt-b"}}},
+		{Provider: "codex", Email: "c@example.com", Record: &coreauth.Auth{Storage: &codex.CodexTokenStorage{RefreshToken: "rt-c"}}},
+	}
+	existing := []*coreauth.Auth{
+		{Provider: "claude", Metadata: map[string]any{"email": "A@example.com"}},
+		{Provider: "codex", Metadata: map[string]any{"email": "old@example.com", "refresh_token": "rt-b"}},
+		{Provider: "claude", Metadata: map[string]any{"email": "c@example.com"}},
+	}
+
+	fresh, duplicates := FilterNew(accounts, existing)
+	if len(fresh) != 1 || fresh[0].Email != "c@example.com" {
+		t.Fatalf("expected only the codex c@example.com account to be new, got %+v", fresh)
+	}
+	if len(duplicates) != 2 {
+		t.Fatalf("expected 2 duplicates, got %d", len(duplicates))
+	}
+}
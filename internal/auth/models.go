@@ -2,6 +2,8 @@
 // It includes interfaces and implementations for token storage and authentication methods.
 package auth
 
+import "fmt"
+
 // TokenStorage defines the interface for storing authentication tokens.
 // Implementations of this interface should provide methods to persist
 // authentication tokens to a file system location.
@@ -15,3 +17,21 @@ type TokenStorage interface {
 	//   - error: An error if the save operation fails, nil otherwise
 	SaveTokenToFile(authFilePath string) error
 }
+
+// TokenEncoder is implemented by token storages that can serialize themselves in memory.
+// Stores encode a record this way instead of calling SaveTokenToFile so they can seal it
+// with the active encryption key before anything reaches disk, git, S3 or a database.
+type TokenEncoder interface {
+	// EncodeToken returns the bytes SaveTokenToFile would write, without writing them.
+	EncodeToken() ([]byte, error)
+}
+
+// EncodeTokenStorage returns the serialized form of storage. Storages that do not implement
+// TokenEncoder are rejected rather than guessed at, since their file format may not be JSON.
+func EncodeTokenStorage(storage TokenStorage) ([]byte, error) {
+	encoder, ok := storage.(TokenEncoder)
+	if !ok {
+		return nil, fmt.Errorf("token storage %T does not implement TokenEncoder", storage)
+	}
+	return encoder.EncodeToken()
+}
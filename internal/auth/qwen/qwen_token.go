@@ -64,3 +64,13 @@ func (ts *QwenTokenStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// EncodeToken implements auth.TokenEncoder.
+func (ts *QwenTokenStorage) EncodeToken() ([]byte, error) {
+	ts.Type = "qwen"
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,185 @@
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// CloudPlatformScope is the OAuth scope requested for Vertex AI access tokens.
+	CloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// CredentialSourceADC marks credentials that authenticate with Application Default
+	// Credentials instead of an embedded service account key.
+	CredentialSourceADC = "adc"
+
+	// DefaultLocation is the region used when a credential names none.
+	DefaultLocation = "us-central1"
+)
+
+// iamCredentialsBaseURL is the IAM Credentials API that issues impersonated access tokens.
+var iamCredentialsBaseURL = "https://iamcredentials.googleapis.com"
+
+// DefaultCredentials finds Application Default Credentials: the file named by
+// GOOGLE_APPLICATION_CREDENTIALS, the gcloud user credentials, or the GCE/GKE metadata server
+// (GCE_METADATA_HOST points at another metadata host). The project ID is empty when the
+// credentials do not name one. HTTP calls use the oauth2.HTTPClient of ctx, if any.
+func DefaultCredentials(ctx context.Context) (oauth2.TokenSource, string, error) {
+	creds, err := google.FindDefaultCredentials(ctx, CloudPlatformScope)
+	if err != nil {
+		return nil, "", fmt.Errorf("vertex credential: %w", err)
+	}
+	return creds.TokenSource, creds.ProjectID, nil
+}
+
+// VerifyADC resolves Application Default Credentials, impersonating impersonate when set, and
+// fetches a token to prove they work. It returns the project ID the credentials name, if any.
+func VerifyADC(ctx context.Context, impersonate string) (string, error) {
+	source, projectID, err := DefaultCredentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	if impersonate = strings.TrimSpace(impersonate); impersonate != "" {
+		source = ImpersonateTokenSource(ctx, source, impersonate)
+	}
+	if _, err = source.Token(); err != nil {
+		return "", fmt.Errorf("vertex credential: get access token failed: %w", err)
+	}
+	return projectID, nil
+}
+
+// ADCFileName returns the auth file name of an ADC credential for projectID, distinguishing
+// credentials that impersonate different service accounts.
+func ADCFileName(projectID, impersonate string) string {
+	name := "vertex-adc-" + sanitizeFilePart(projectID)
+	if account, _, _ := strings.Cut(strings.TrimSpace(impersonate), "@"); account != "" {
+		name += "-" + sanitizeFilePart(account)
+	}
+	return name + ".json"
+}
+
+func sanitizeFilePart(s string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_", " ", "-").Replace(strings.TrimSpace(s))
+}
+
+// ServiceAccountCredentials returns a token source for a service account key JSON.
+func ServiceAccountCredentials(ctx context.Context, serviceAccountJSON []byte) (oauth2.TokenSource, error) {
+	creds, err := google.CredentialsFromJSON(ctx, serviceAccountJSON, CloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("vertex credential: parse service account json failed: %w", err)
+	}
+	return creds.TokenSource, nil
+}
+
+// ImpersonateTokenSource returns a token source for targetServiceAccount that exchanges tokens
+// of base through the IAM Credentials API. The identity behind base needs the Service Account
+// Token Creator role on the target. Tokens are reused until they expire.
+func ImpersonateTokenSource(ctx context.Context, base oauth2.TokenSource, targetServiceAccount string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &impersonatedTokenSource{
+		ctx:    ctx,
+		base:   base,
+		target: strings.TrimSpace(targetServiceAccount),
+	})
+}
+
+type impersonatedTokenSource struct {
+	ctx    context.Context
+	base   oauth2.TokenSource
+	target string
+}
+
+func (s *impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	baseToken, err := s.base.Token()
+	if err != nil {
+		return nil, fmt.Errorf("vertex credential: source token for impersonation: %w", err)
+	}
+	body, err := json.Marshal(map[string]any{
+		"scope":    []string{CloudPlatformScope},
+		"lifetime": "3600s",
+	})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/-/serviceAccounts/%s:generateAccessToken", iamCredentialsBaseURL, url.PathEscape(s.target))
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	baseToken.SetAuthHeader(req)
+
+	client := http.DefaultClient
+	if c, ok := s.ctx.Value(oauth2.HTTPClient).(*http.Client); ok && c != nil {
+		client = c
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vertex credential: impersonate %s: %w", s.target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("vertex credential: impersonate %s: %w", s.target, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vertex credential: impersonate %s: status %d: %s", s.target, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var result struct {
+		AccessToken string `json:"accessToken"`
+		ExpireTime  string `json:"expireTime"`
+	}
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("vertex credential: impersonate %s: %w", s.target, err)
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("vertex credential: impersonate %s: empty access token", s.target)
+	}
+	token := &oauth2.Token{AccessToken: result.AccessToken, TokenType: "Bearer"}
+	if expiry, errParse := time.Parse(time.RFC3339, result.ExpireTime); errParse == nil {
+		token.Expiry = expiry
+	}
+	return token, nil
+}
+
+// NormalizeRegions trims, lower-cases and de-duplicates regions, keeping their order. When none
+// remain it returns location, or DefaultLocation when location is empty as well.
+func NormalizeRegions(regions []string, location string) []string {
+	seen := make(map[string]struct{}, len(regions))
+	out := make([]string, 0, len(regions))
+	for _, region := range regions {
+		region = strings.ToLower(strings.TrimSpace(region))
+		if region == "" {
+			continue
+		}
+		if _, ok := seen[region]; ok {
+			continue
+		}
+		seen[region] = struct{}{}
+		out = append(out, region)
+	}
+	if len(out) > 0 {
+		return out
+	}
+	if location = strings.ToLower(strings.TrimSpace(location)); location != "" {
+		return []string{location}
+	}
+	return []string{DefaultLocation}
+}
+
+// ParseRegions splits a comma-separated region list.
+func ParseRegions(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	return NormalizeRegions(strings.Split(raw, ","), "")
+}
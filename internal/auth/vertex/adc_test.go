@@ -0,0 +1,94 @@
+package vertex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyADC_MetadataServerWithImpersonation(t *testing.T) {
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing Metadata-Flavor", http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.URL.Path == "/computeMetadata/v1/project/project-id":
+			_, _ = w.Write([]byte("gke-project"))
+		case r.URL.Path == "/computeMetadata/v1/instance/service-accounts/default/token":
+			if !strings.Contains(r.URL.RawQuery, "cloud-platform") {
+				http.Error(w, "missing scope", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "workload-token", "expires_in": 3600, "token_type": "Bearer"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer metadataServer.Close()
+
+	var impersonated string
+	iamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer workload-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		impersonated = r.URL.Path
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"accessToken": "impersonated-token",
+			"expireTime":  time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	}))
+	defer iamServer.Close()
+
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadataServer.URL, "http://"))
+	previousIAM := iamCredentialsBaseURL
+	iamCredentialsBaseURL = iamServer.URL
+	defer func() { iamCredentialsBaseURL = previousIAM }()
+
+	source, projectID, err := DefaultCredentials(context.Background())
+	if err != nil {
+		t.Fatalf("DefaultCredentials: %v", err)
+	}
+	if projectID != "gke-project" {
+		t.Fatalf("project = %q", projectID)
+	}
+	token, err := source.Token()
+	if err != nil || token.AccessToken != "workload-token" {
+		t.Fatalf("metadata token = %+v, %v", token, err)
+	}
+
+	target := "vertex-runner@gke-project.iam.gserviceaccount.com"
+	if projectID, err = VerifyADC(context.Background(), target); err != nil {
+		t.Fatalf("VerifyADC: %v", err)
+	}
+	if impersonated != "/v1/projects/-/serviceAccounts/"+target+":generateAccessToken" {
+		t.Fatalf("impersonation path = %q", impersonated)
+	}
+	token, err = ImpersonateTokenSource(context.Background(), source, target).Token()
+	if err != nil || token.AccessToken != "impersonated-token" || token.Expiry.IsZero() {
+		t.Fatalf("impersonated token = %+v, %v", token, err)
+	}
+}
+
+func TestNormalizeRegions(t *testing.T) {
+	if got := NormalizeRegions([]string{" US-Central1", "europe-west4", "us-central1", ""}, "asia-east1"); !reflect.DeepEqual(got, []string{"us-central1", "europe-west4"}) {
+		t.Fatalf("regions = %v", got)
+	}
+	if got := NormalizeRegions(nil, "asia-east1"); !reflect.DeepEqual(got, []string{"asia-east1"}) {
+		t.Fatalf("regions from location = %v", got)
+	}
+	if got := NormalizeRegions(ParseRegions(" , "), ""); !reflect.DeepEqual(got, []string{DefaultLocation}) {
+		t.Fatalf("default regions = %v", got)
+	}
+	if got := ADCFileName("gke-project", "vertex-runner@gke-project.iam.gserviceaccount.com"); got != "vertex-adc-gke-project-vertex-runner.json" {
+		t.Fatalf("file name = %q", got)
+	}
+}
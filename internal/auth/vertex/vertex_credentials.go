@@ -1,5 +1,6 @@
-// Package vertex provides token storage for Google Vertex AI Gemini via service account credentials.
-// It serialises service account JSON into an auth file that is consumed by the runtime executor.
+// Package vertex provides token storage for Google Vertex AI Gemini via service account credentials
+// or Application Default Credentials. It serialises them into an auth file that is consumed by the
+// runtime executor.
 package vertex
 
 import (
@@ -16,8 +17,15 @@ import (
 // The content is persisted verbatim under the "service_account" key, together with
 // helper fields for project, location and email to improve logging and discovery.
 type VertexCredentialStorage struct {
-	// ServiceAccount holds the parsed service account JSON content.
-	ServiceAccount map[string]any `json:"service_account"`
+	// ServiceAccount holds the parsed service account JSON content. Empty for ADC credentials.
+	ServiceAccount map[string]any `json:"service_account,omitempty"`
+
+	// CredentialSource is CredentialSourceADC for credentials resolved from the environment.
+	CredentialSource string `json:"credential_source,omitempty"`
+
+	// ImpersonateServiceAccount is the service account whose tokens are used instead of the
+	// credential's own identity.
+	ImpersonateServiceAccount string `json:"impersonate_service_account,omitempty"`
 
 	// ProjectID is derived from the service account JSON (project_id).
 	ProjectID string `json:"project_id"`
@@ -28,6 +36,9 @@ type VertexCredentialStorage struct {
 	// Location optionally sets a default region (e.g., us-central1) for Vertex endpoints.
 	Location string `json:"location,omitempty"`
 
+	// Regions lists the regions requests fail over between, in order of preference.
+	Regions []string `json:"regions,omitempty"`
+
 	// Type is the provider identifier stored alongside credentials. Always "vertex".
 	Type string `json:"type"`
 }
@@ -39,7 +50,7 @@ func (s *VertexCredentialStorage) SaveTokenToFile(authFilePath string) error {
 	if s == nil {
 		return fmt.Errorf("vertex credential: storage is nil")
 	}
-	if s.ServiceAccount == nil {
+	if s.ServiceAccount == nil && s.CredentialSource != CredentialSourceADC {
 		return fmt.Errorf("vertex credential: service account content is empty")
 	}
 	// Ensure we tag the file with the provider type.
@@ -64,3 +75,19 @@ func (s *VertexCredentialStorage) SaveTokenToFile(authFilePath string) error {
 	}
 	return nil
 }
+
+// EncodeToken implements auth.TokenEncoder.
+func (s *VertexCredentialStorage) EncodeToken() ([]byte, error) {
+	if s == nil {
+		return nil, fmt.Errorf("vertex credential: storage is nil")
+	}
+	if s.ServiceAccount == nil && s.CredentialSource != CredentialSourceADC {
+		return nil, fmt.Errorf("vertex credential: service account content is empty")
+	}
+	s.Type = "vertex"
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("vertex credential: encode failed: %w", err)
+	}
+	return data, nil
+}
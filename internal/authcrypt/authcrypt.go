@@ -0,0 +1,336 @@
+// Package authcrypt provides envelope encryption for persisted auth records.
+//
+// Records are sealed with AES-256-GCM into a small JSON envelope that names the key it was
+// sealed with, so keys can be rotated while older records stay readable. Records that are not
+// envelopes are treated as legacy plaintext and returned unchanged, which keeps existing auth
+// directories working until they are re-encrypted.
+package authcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Algorithm identifies the envelope format written by Seal.
+const Algorithm = "aes-256-gcm"
+
+// ErrDecrypt is wrapped by every error Open returns for a sealed record, for example when the
+// key it references is not loaded or authentication of the ciphertext fails.
+var ErrDecrypt = errors.New("authcrypt: cannot decrypt record")
+
+// envelope is the on-disk representation of a sealed record. It is valid JSON so stores that
+// require JSON content (for example JSONB columns) and the *.json file filters keep working.
+type envelope struct {
+	Envelope   string `json:"envelope"`
+	KeyID      string `json:"kid"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Keyring holds the keys used to seal and open records. The first key is the active one;
+// the remaining keys are only used to open records sealed before a rotation.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+	order  []string
+}
+
+// NewKeyring builds a keyring from "kid:key" or bare "key" entries. Keys are 32 bytes encoded
+// as base64 or hex. Entries without an explicit key ID get one derived from the key material.
+func NewKeyring(entries []string) (*Keyring, error) {
+	ring := &Keyring{keys: make(map[string][]byte)}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		kid, encoded := "", entry
+		if idx := strings.Index(entry, ":"); idx > 0 {
+			kid, encoded = strings.TrimSpace(entry[:idx]), strings.TrimSpace(entry[idx+1:])
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			if kid != "" {
+				return nil, fmt.Errorf("authcrypt: key %s: %w", kid, err)
+			}
+			return nil, fmt.Errorf("authcrypt: %w", err)
+		}
+		if kid == "" {
+			sum := sha256.Sum256(key)
+			kid = hex.EncodeToString(sum[:4])
+		}
+		if _, exists := ring.keys[kid]; exists {
+			return nil, fmt.Errorf("authcrypt: duplicate key id %s", kid)
+		}
+		ring.keys[kid] = key
+		ring.order = append(ring.order, kid)
+	}
+	if len(ring.order) == 0 {
+		return nil, fmt.Errorf("authcrypt: no keys provided")
+	}
+	ring.active = ring.order[0]
+	return ring, nil
+}
+
+// ActiveKeyID returns the ID of the key used for new records.
+func (k *Keyring) ActiveKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.active
+}
+
+// KeyIDs returns every loaded key ID, active key first.
+func (k *Keyring) KeyIDs() []string {
+	if k == nil {
+		return nil
+	}
+	return append([]string(nil), k.order...)
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	if len(encoded) == 64 {
+		if key, err := hex.DecodeString(encoded); err == nil {
+			return key, nil
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(encoded); err == nil {
+			if len(key) != 32 {
+				return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key must be 32 bytes encoded as base64 or hex")
+}
+
+var (
+	mu          sync.RWMutex
+	defaultRing *Keyring
+)
+
+// SetKeyring installs the process-wide keyring. A nil keyring disables encryption for new
+// writes; existing envelopes then fail to open.
+func SetKeyring(ring *Keyring) {
+	mu.Lock()
+	defaultRing = ring
+	mu.Unlock()
+}
+
+// CurrentKeyring returns the process-wide keyring, or nil when encryption is disabled.
+func CurrentKeyring() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultRing
+}
+
+// Enabled reports whether new records are sealed.
+func Enabled() bool {
+	return CurrentKeyring() != nil
+}
+
+// LoadKeyring builds a keyring from an inline key list and/or a key file. Inline entries are
+// comma separated; key files hold one entry per line and may contain # comments. Inline keys
+// come first, so the first inline key is active when both are given. It returns nil when neither
+// source is set.
+func LoadKeyring(inline, keyFile string) (*Keyring, error) {
+	var entries []string
+	for _, part := range strings.Split(inline, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			entries = append(entries, part)
+		}
+	}
+	if keyFile = strings.TrimSpace(keyFile); keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("authcrypt: read key file: %w", err)
+		}
+		entries = append(entries, strings.Split(string(data), "\n")...)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return NewKeyring(entries)
+}
+
+// IsSealed reports whether data is an encryption envelope.
+func IsSealed(data []byte) bool {
+	_, ok := parseEnvelope(data)
+	return ok
+}
+
+func parseEnvelope(data []byte) (envelope, bool) {
+	var env envelope
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "{") || !strings.Contains(trimmed, `"envelope"`) {
+		return env, false
+	}
+	if err := json.Unmarshal([]byte(trimmed), &env); err != nil {
+		return env, false
+	}
+	if env.Envelope != Algorithm || env.Ciphertext == "" || env.Nonce == "" {
+		return env, false
+	}
+	return env, true
+}
+
+// Seal encrypts plain with the active key of the process-wide keyring. Without a keyring the
+// data is returned unchanged.
+func Seal(plain []byte) ([]byte, error) {
+	return CurrentKeyring().Seal(plain)
+}
+
+// Seal encrypts plain with the active key. A nil keyring returns plain unchanged.
+func (k *Keyring) Seal(plain []byte) ([]byte, error) {
+	if k == nil {
+		return plain, nil
+	}
+	aead, err := newAEAD(k.keys[k.active])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	ciphertext := aead.Seal(nil, nonce, plain, []byte(k.active))
+	return json.Marshal(envelope{
+		Envelope:   Algorithm,
+		KeyID:      k.active,
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+}
+
+// Open returns the plaintext of a sealed record using the process-wide keyring. Data that is
+// not an envelope is legacy plaintext and is returned unchanged.
+func Open(data []byte) ([]byte, error) {
+	return CurrentKeyring().Open(data)
+}
+
+// Open decrypts data when it is an envelope and returns it unchanged otherwise.
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	env, ok := parseEnvelope(data)
+	if !ok {
+		return data, nil
+	}
+	if k == nil {
+		return nil, fmt.Errorf("%w: sealed with key %s but encryption is not configured", ErrDecrypt, env.KeyID)
+	}
+	key, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: key %s is not loaded", ErrDecrypt, env.KeyID)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode nonce: %v", ErrDecrypt, err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode ciphertext: %v", ErrDecrypt, err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce length", ErrDecrypt)
+	}
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: sealed with key %s: %v", ErrDecrypt, env.KeyID, err)
+	}
+	return plain, nil
+}
+
+// IsCurrent reports whether data is already in the form Seal would produce: sealed with the
+// active key when encryption is enabled. Stores use it to avoid skipping writes that would
+// otherwise leave plaintext or an old key on disk.
+func IsCurrent(data []byte) bool {
+	ring := CurrentKeyring()
+	if ring == nil {
+		return true
+	}
+	env, ok := parseEnvelope(data)
+	return ok && env.KeyID == ring.active
+}
+
+// SealFile rewrites the file at path so it is sealed with the active key. It is a no-op when
+// encryption is disabled or the file is already current. The write is atomic.
+func SealFile(path string) error {
+	if !Enabled() {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("authcrypt: read %s: %w", filepath.Base(path), err)
+	}
+	if len(data) == 0 || IsCurrent(data) {
+		return nil
+	}
+	plain, err := Open(data)
+	if err != nil {
+		return err
+	}
+	sealed, err := Seal(plain)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, sealed)
+}
+
+// ReadFile reads a record from disk and returns its plaintext.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data)
+}
+
+// WriteSealedFile seals plain with the active key and atomically writes it to path, so the
+// plaintext never reaches disk when encryption is enabled.
+func WriteSealedFile(path string, plain []byte) error {
+	sealed, err := Seal(plain)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, sealed)
+}
+
+// WriteFile atomically writes data to path with owner-only permissions.
+func WriteFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("authcrypt: write temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("authcrypt: rename temp file: %w", err)
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: init cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: init gcm: %w", err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,94 @@
+package authcrypt
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func TestKeyring_SealOpenAndRotation(t *testing.T) {
+	oldRing, err := NewKeyring([]string{"old:" + testKey('a')})
+	if err != nil {
+		t.Fatalf("NewKeyring(old): %v", err)
+	}
+	plain := []byte(`{"type":"claude","refresh_token":"secret"}`)
+	sealed, err := oldRing.Seal(plain)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if strings.Contains(string(sealed), "secret") || !IsSealed(sealed) {
+		t.Fatalf("expected an opaque envelope, got %s", sealed)
+	}
+
+	rotated, err := NewKeyring([]string{"new:" + testKey('b'), "old:" + testKey('a')})
+	if err != nil {
+		t.Fatalf("NewKeyring(rotated): %v", err)
+	}
+	SetKeyring(rotated)
+	t.Cleanup(func() { SetKeyring(nil) })
+
+	opened, err := Open(sealed)
+	if err != nil || string(opened) != string(plain) {
+		t.Fatalf("Open after rotation = %q, %v", opened, err)
+	}
+	if IsCurrent(sealed) {
+		t.Fatal("record sealed with a retired key should not be current")
+	}
+	if IsCurrent(plain) {
+		t.Fatal("plaintext should not be current while encryption is enabled")
+	}
+
+	legacy, err := Open(plain)
+	if err != nil || string(legacy) != string(plain) {
+		t.Fatalf("legacy plaintext should pass through, got %q, %v", legacy, err)
+	}
+
+	SetKeyring(mustKeyring(t, "other:"+testKey('c')))
+	if _, err = Open(sealed); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt for an unknown key, got %v", err)
+	}
+}
+
+func TestSealFile_ReencryptsWithActiveKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude.json")
+	plain := []byte(`{"type":"claude"}`)
+	if err := os.WriteFile(path, plain, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	SetKeyring(mustKeyring(t, "k1:"+testKey('a')))
+	t.Cleanup(func() { SetKeyring(nil) })
+
+	if err := SealFile(path); err != nil {
+		t.Fatalf("SealFile: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !IsCurrent(raw) {
+		t.Fatalf("expected file sealed with the active key, got %s", raw)
+	}
+	got, err := ReadFile(path)
+	if err != nil || string(got) != string(plain) {
+		t.Fatalf("ReadFile = %q, %v", got, err)
+	}
+}
+
+func TestNewKeyring_RejectsShortKeys(t *testing.T) {
+	if _, err := NewKeyring([]string{"k:" + base64.StdEncoding.EncodeToString([]byte("short"))}); err == nil {
+		t.Fatal("expected an error for a key that is not 32 bytes")
+	}
+}
+
+func mustKeyring(t *testing.T, entries ...string) *Keyring {
+	t.Helper()
+	ring, err := NewKeyring(entries)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return ring
+}
@@ -0,0 +1,251 @@
+// Package cluster shares credential availability state and request counters between proxy
+// replicas that use the same Postgres store.
+//
+// State changes recorded by the core auth manager (cooldowns, quota hits, disables and
+// recoveries) are broadcast with Postgres NOTIFY and merged into every peer's manager. Per-key
+// request counters are exchanged through a shared table so monthly quotas are enforced
+// against the cluster-wide total rather than the count seen by a single replica.
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultChannel is the NOTIFY channel used when none is configured.
+	DefaultChannel = "aiproxy_cluster"
+	// DefaultCounterSync is the counter exchange interval used when none is configured.
+	DefaultCounterSync = 5 * time.Second
+
+	// maxPayload stays below the 8000 byte NOTIFY payload limit.
+	maxPayload       = 7900
+	publishQueue     = 256
+	maxListenBackoff = 30 * time.Second
+)
+
+// Backend is the transport a Node uses. The Postgres store implements it.
+type Backend interface {
+	Notify(ctx context.Context, channel, payload string) error
+	Listen(ctx context.Context, channel string, handle func(payload string)) error
+	UpsertClusterCounters(ctx context.Context, nodeID, month string, counts map[string]map[string]int64) error
+	LoadPeerCounters(ctx context.Context, nodeID, month string) (map[string]map[string]int64, error)
+}
+
+// message is the NOTIFY payload exchanged between nodes.
+type message struct {
+	Origin string               `json:"origin"`
+	Update coreauth.StateUpdate `json:"update"`
+}
+
+// Node is one replica's membership in the cluster. It implements coreauth.StatePublisher.
+type Node struct {
+	backend         Backend
+	manager         *coreauth.Manager
+	stats           *usage.RequestStatistics
+	nodeID          string
+	channel         string
+	counterInterval time.Duration
+
+	queue  chan coreauth.StateUpdate
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewNode builds a node from the cluster configuration. stats may be nil to skip counter sharing.
+func NewNode(backend Backend, manager *coreauth.Manager, stats *usage.RequestStatistics, cfg config.ClusterConfig) *Node {
+	nodeID := strings.TrimSpace(cfg.NodeID)
+	if nodeID == "" {
+		if host, err := os.Hostname(); err == nil && host != "" {
+			nodeID = host
+		} else {
+			nodeID = "node"
+		}
+	}
+	channel := strings.TrimSpace(cfg.Channel)
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	interval := DefaultCounterSync
+	if cfg.CounterSyncSeconds > 0 {
+		interval = time.Duration(cfg.CounterSyncSeconds) * time.Second
+	}
+	return &Node{
+		backend:         backend,
+		manager:         manager,
+		stats:           stats,
+		nodeID:          nodeID,
+		channel:         channel,
+		counterInterval: interval,
+		queue:           make(chan coreauth.StateUpdate, publishQueue),
+	}
+}
+
+// NodeID returns the identifier this node publishes under.
+func (n *Node) NodeID() string {
+	if n == nil {
+		return ""
+	}
+	return n.nodeID
+}
+
+// Start launches the publish, listen and counter loops and installs the node as the manager's
+// state publisher.
+func (n *Node) Start(ctx context.Context) {
+	if n == nil || n.backend == nil || n.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	n.cancel = cancel
+	if n.manager != nil {
+		n.manager.SetStatePublisher(n)
+	}
+	n.wg.Add(2)
+	go n.publishLoop(ctx)
+	go n.listenLoop(ctx)
+	if n.stats != nil {
+		n.wg.Add(1)
+		go n.counterLoop(ctx)
+	}
+	log.Infof("cluster: node %s joined channel %s", n.nodeID, n.channel)
+}
+
+// Stop detaches the node from the manager and waits for its loops to exit.
+func (n *Node) Stop() {
+	if n == nil || n.cancel == nil {
+		return
+	}
+	if n.manager != nil {
+		n.manager.SetStatePublisher(nil)
+	}
+	n.cancel()
+	n.wg.Wait()
+	n.cancel = nil
+}
+
+// PublishState queues update for broadcast. It never blocks; updates are dropped when the queue is full.
+func (n *Node) PublishState(_ context.Context, update coreauth.StateUpdate) {
+	if n == nil {
+		return
+	}
+	select {
+	case n.queue <- update:
+	default:
+		log.Warnf("cluster: publish queue full, dropping state update for %s", update.AuthID)
+	}
+}
+
+func (n *Node) publishLoop(ctx context.Context) {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-n.queue:
+			payload, ok := n.encode(update)
+			if !ok {
+				continue
+			}
+			if err := n.backend.Notify(ctx, n.channel, payload); err != nil && ctx.Err() == nil {
+				log.Warnf("cluster: publish state for %s: %v", update.AuthID, err)
+			}
+		}
+	}
+}
+
+// encode marshals update, dropping free-form error text when the payload would exceed the NOTIFY limit.
+func (n *Node) encode(update coreauth.StateUpdate) (string, bool) {
+	data, err := json.Marshal(message{Origin: n.nodeID, Update: update})
+	if err == nil && len(data) > maxPayload {
+		update.StatusMessage = ""
+		if update.ModelState != nil {
+			state := *update.ModelState
+			state.StatusMessage = ""
+			state.LastError = nil
+			update.ModelState = &state
+		}
+		data, err = json.Marshal(message{Origin: n.nodeID, Update: update})
+	}
+	if err != nil || len(data) > maxPayload {
+		log.Warnf("cluster: state update for %s cannot be encoded", update.AuthID)
+		return "", false
+	}
+	return string(data), true
+}
+
+func (n *Node) listenLoop(ctx context.Context) {
+	defer n.wg.Done()
+	backoff := time.Second
+	for {
+		err := n.backend.Listen(ctx, n.channel, n.handle)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("cluster: listen on %s failed, retrying in %s: %v", n.channel, backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxListenBackoff {
+			backoff = maxListenBackoff
+		}
+	}
+}
+
+// handle merges a notification from a peer into the local manager.
+func (n *Node) handle(payload string) {
+	var msg message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Debugf("cluster: ignoring malformed notification: %v", err)
+		return
+	}
+	if msg.Origin == n.nodeID || n.manager == nil {
+		return
+	}
+	if n.manager.ApplyStateUpdate(msg.Update) {
+		log.Debugf("cluster: applied state for %s %s from %s", msg.Update.AuthID, msg.Update.Model, msg.Origin)
+	}
+}
+
+func (n *Node) counterLoop(ctx context.Context) {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.counterInterval)
+	defer ticker.Stop()
+	for {
+		if err := n.SyncCounters(ctx); err != nil && ctx.Err() == nil {
+			log.Warnf("cluster: sync request counters: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncCounters publishes this node's monthly request counts and loads the peers' totals.
+func (n *Node) SyncCounters(ctx context.Context) error {
+	if n == nil || n.stats == nil {
+		return nil
+	}
+	month, local := n.stats.MonthlyUsageSnapshot()
+	if err := n.backend.UpsertClusterCounters(ctx, n.nodeID, month, local); err != nil {
+		return err
+	}
+	peers, err := n.backend.LoadPeerCounters(ctx, n.nodeID, month)
+	if err != nil {
+		return err
+	}
+	n.stats.SetPeerMonthlyUsage(month, peers)
+	return nil
+}
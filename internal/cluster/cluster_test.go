@@ -0,0 +1,222 @@
+package cluster
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+// memoryBus is an in-process stand-in for the Postgres store.
+type memoryBus struct {
+	mu       sync.Mutex
+	handlers map[int]func(string)
+	nextID   int
+	counters map[string]map[string]map[string]int64
+}
+
+func newMemoryBus() *memoryBus {
+	return &memoryBus{handlers: make(map[int]func(string)), counters: make(map[string]map[string]map[string]int64)}
+}
+
+func (b *memoryBus) Notify(_ context.Context, _ string, payload string) error {
+	b.mu.Lock()
+	handlers := make([]func(string), 0, len(b.handlers))
+	for _, handle := range b.handlers {
+		handlers = append(handlers, handle)
+	}
+	b.mu.Unlock()
+	for _, handle := range handlers {
+		handle(payload)
+	}
+	return nil
+}
+
+func (b *memoryBus) Listen(ctx context.Context, _ string, handle func(string)) error {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handle
+	b.mu.Unlock()
+	<-ctx.Done()
+	b.mu.Lock()
+	delete(b.handlers, id)
+	b.mu.Unlock()
+	return ctx.Err()
+}
+
+func (b *memoryBus) listeners() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.handlers)
+}
+
+func (b *memoryBus) UpsertClusterCounters(_ context.Context, nodeID, _ string, counts map[string]map[string]int64) error {
+	b.mu.Lock()
+	b.counters[nodeID] = counts
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memoryBus) LoadPeerCounters(_ context.Context, nodeID, _ string) (map[string]map[string]int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[string]map[string]int64)
+	for node, keys := range b.counters {
+		if node == nodeID {
+			continue
+		}
+		for apiKey, models := range keys {
+			if out[apiKey] == nil {
+				out[apiKey] = make(map[string]int64)
+			}
+			for model, count := range models {
+				out[apiKey][model] += count
+			}
+		}
+	}
+	return out, nil
+}
+
+func startNode(t *testing.T, bus *memoryBus, nodeID string, stats *usage.RequestStatistics) *coreauth.Manager {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "shared-auth", Provider: "cluster-test", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	node := NewNode(bus, manager, stats, config.ClusterConfig{NodeID: nodeID})
+	node.Start(context.Background())
+	t.Cleanup(node.Stop)
+	return manager
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func modelState(manager *coreauth.Manager, model string) *coreauth.ModelState {
+	auth, ok := manager.GetByID("shared-auth")
+	if !ok || auth == nil {
+		return nil
+	}
+	return auth.ModelStates[model]
+}
+
+func TestNode_SharesCooldownAndRecovery(t *testing.T) {
+	bus := newMemoryBus()
+	a := startNode(t, bus, "node-a", nil)
+	b := startNode(t, bus, "node-b", nil)
+	waitFor(t, "both listeners", func() bool { return bus.listeners() == 2 })
+
+	retryAfter := 10 * time.Minute
+	a.MarkResult(context.Background(), coreauth.Result{
+		AuthID:     "shared-auth",
+		Provider:   "cluster-test",
+		Model:      "cluster-model",
+		RetryAfter: &retryAfter,
+		Error:      &coreauth.Error{Message: "rate limited", HTTPStatus: http.StatusTooManyRequests},
+	})
+	waitFor(t, "cooldown on node-b", func() bool {
+		state := modelState(b, "cluster-model")
+		return state != nil && state.Unavailable && state.Quota.Exceeded
+	})
+	local, remote := modelState(a, "cluster-model"), modelState(b, "cluster-model")
+	if !remote.NextRetryAfter.Equal(local.NextRetryAfter) {
+		t.Fatalf("NextRetryAfter = %v, want %v", remote.NextRetryAfter, local.NextRetryAfter)
+	}
+
+	a.MarkResult(context.Background(), coreauth.Result{AuthID: "shared-auth", Provider: "cluster-test", Model: "cluster-model", Success: true})
+	waitFor(t, "recovery on node-b", func() bool {
+		state := modelState(b, "cluster-model")
+		return state != nil && !state.Unavailable && !state.Quota.Exceeded
+	})
+}
+
+func TestNode_SharesDisableAndReenable(t *testing.T) {
+	bus := newMemoryBus()
+	a := startNode(t, bus, "node-a", nil)
+	b := startNode(t, bus, "node-b", nil)
+	waitFor(t, "both listeners", func() bool { return bus.listeners() == 2 })
+
+	auth, _ := a.GetByID("shared-auth")
+	auth.Disabled = true
+	auth.Status = coreauth.StatusDisabled
+	auth.StatusMessage = "removed via management API"
+	if _, err := a.Update(context.Background(), auth); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	waitFor(t, "disable on node-b", func() bool {
+		remote, ok := b.GetByID("shared-auth")
+		return ok && remote.Disabled && remote.Status == coreauth.StatusDisabled
+	})
+
+	auth, _ = a.GetByID("shared-auth")
+	auth.Disabled = false
+	auth.Status = coreauth.StatusActive
+	auth.StatusMessage = ""
+	if _, err := a.Update(context.Background(), auth); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	waitFor(t, "re-enable on node-b", func() bool {
+		remote, ok := b.GetByID("shared-auth")
+		return ok && !remote.Disabled && remote.Status == coreauth.StatusActive
+	})
+}
+
+func TestManagerApplyStateUpdate_IgnoresStaleUpdates(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "shared-auth", Provider: "cluster-test", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	now := time.Now()
+	fresh := coreauth.StateUpdate{AuthID: "shared-auth", Model: "m", ModelState: &coreauth.ModelState{Status: coreauth.StatusActive, UpdatedAt: now}}
+	if !manager.ApplyStateUpdate(fresh) {
+		t.Fatal("fresh update was not applied")
+	}
+	stale := coreauth.StateUpdate{AuthID: "shared-auth", Model: "m", SuspendReason: "quota", ModelState: &coreauth.ModelState{
+		Status: coreauth.StatusError, Unavailable: true, NextRetryAfter: now.Add(time.Hour), UpdatedAt: now.Add(-time.Minute),
+	}}
+	if manager.ApplyStateUpdate(stale) {
+		t.Fatal("stale update was applied")
+	}
+	if state := modelState(manager, "m"); state == nil || state.Unavailable {
+		t.Fatalf("state = %+v, want available", state)
+	}
+}
+
+func TestNode_SyncCountersAddsPeerUsage(t *testing.T) {
+	bus := newMemoryBus()
+	statsA, statsB := usage.NewRequestStatistics(), usage.NewRequestStatistics()
+	nodeA := NewNode(bus, nil, statsA, config.ClusterConfig{NodeID: "node-a"})
+	nodeB := NewNode(bus, nil, statsB, config.ClusterConfig{NodeID: "node-b"})
+
+	for i := 0; i < 3; i++ {
+		statsA.Record(context.Background(), coreusage.Record{APIKey: "key-1", Model: "gpt-test", RequestedAt: time.Now()})
+	}
+	statsB.Record(context.Background(), coreusage.Record{APIKey: "key-1", Model: "gpt-test", RequestedAt: time.Now()})
+
+	for _, node := range []*Node{nodeA, nodeB, nodeA} {
+		if err := node.SyncCounters(context.Background()); err != nil {
+			t.Fatalf("SyncCounters(%s): %v", node.NodeID(), err)
+		}
+	}
+	if got := statsA.GetMonthlyUsage("key-1", "gpt-test"); got != 4 {
+		t.Fatalf("node-a usage = %d, want 4", got)
+	}
+	if got := statsB.GetMonthlyUsageAllModels("key-1")["gpt-test"]; got != 4 {
+		t.Fatalf("node-b usage = %d, want 4", got)
+	}
+}
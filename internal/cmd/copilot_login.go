@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/giofahreza/AIProxyAPI/internal/auth/copilot"
 	"github.com/giofahreza/AIProxyAPI/internal/config"
 	sdkAuth "github.com/giofahreza/AIProxyAPI/sdk/auth"
 	log "github.com/sirupsen/logrus"
@@ -17,7 +18,8 @@ import (
 // Parameters:
 //   - cfg: The application configuration
 //   - options: Login options including browser behavior and prompts
-func DoCopilotLogin(cfg *config.Config, options *LoginOptions) {
+//   - endpoints: The GitHub Enterprise hosts of the account; the zero value targets github.com
+func DoCopilotLogin(cfg *config.Config, options *LoginOptions, endpoints copilot.Endpoints) {
 	if options == nil {
 		options = &LoginOptions{}
 	}
@@ -37,8 +39,13 @@ func DoCopilotLogin(cfg *config.Config, options *LoginOptions) {
 
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser: options.NoBrowser,
-		Metadata:  map[string]string{},
-		Prompt:    promptFn,
+		Metadata: map[string]string{
+			"github_host":      endpoints.GitHubHost,
+			"github_api_host":  endpoints.APIHost,
+			"copilot_base_url": endpoints.CopilotAPIBase,
+			"github_client_id": endpoints.ClientID,
+		},
+		Prompt: promptFn,
 	}
 
 	_, savedPath, err := manager.Login(context.Background(), "copilot", cfg, authOpts)
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/giofahreza/AIProxyAPI/internal/auth/iflow"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
 	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/misc"
 )
 
 // DoIFlowCookieAuth performs the iFlow cookie-based authentication.
@@ -65,11 +67,21 @@ func DoIFlowCookieAuth(cfg *config.Config, options *LoginOptions) {
 	// Get auth file path using email in filename
 	authFilePath := getAuthFilePath(cfg, "iflow", tokenData.Email)
 
-	// Save token to file
-	if err := tokenStorage.SaveTokenToFile(authFilePath); err != nil {
+	// Save token to file, sealed before it is written
+	raw, err := tokenStorage.EncodeToken()
+	if err != nil {
+		fmt.Printf("Failed to save authentication: %v\n", err)
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0o700); err != nil {
 		fmt.Printf("Failed to save authentication: %v\n", err)
 		return
 	}
+	misc.LogSavingCredentials(authFilePath)
+	if err = authcrypt.WriteSealedFile(authFilePath, raw); err != nil {
+		fmt.Printf("Failed to encrypt authentication: %v\n", err)
+		return
+	}
 
 	fmt.Printf("Authentication successful! API key: %s\n", maskAPIKey(tokenData.APIKey))
 	fmt.Printf("Expires at: %s\n", tokenData.Expire)
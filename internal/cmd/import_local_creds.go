@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/auth/gemini"
+	"github.com/giofahreza/AIProxyAPI/internal/auth/localcreds"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	sdkAuth "github.com/giofahreza/AIProxyAPI/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoImportLocalCreds imports the accounts the Claude Code, Codex and Gemini CLIs are signed in
+// with on this machine into the auth store. Accounts that already have an auth record are
+// skipped. Gemini accounts are activated for projectID, or for the account's default project
+// when projectID is empty.
+func DoImportLocalCreds(cfg *config.Config, projectID string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	home, errHome := os.UserHomeDir()
+	if errHome != nil {
+		log.Errorf("import-local-creds: resolve home directory: %v", errHome)
+		return
+	}
+
+	accounts, errs := localcreds.Detect(home)
+	for _, err := range errs {
+		log.Warnf("import-local-creds: %v", err)
+	}
+	if len(accounts) == 0 {
+		fmt.Println("No signed-in Claude Code, Codex or Gemini CLI accounts found.")
+		return
+	}
+
+	ctx := context.Background()
+	store := sdkAuth.GetTokenStore()
+	if setter, ok := store.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+	existing, errList := store.List(ctx)
+	if errList != nil {
+		log.Errorf("import-local-creds: list existing auths: %v", errList)
+		return
+	}
+	fresh, duplicates := localcreds.FilterNew(accounts, existing)
+	for _, account := range duplicates {
+		fmt.Printf("Skipped %s account %s: already imported\n", account.Provider, account.Email)
+	}
+
+	imported := 0
+	for _, account := range fresh {
+		if account.Provider == "gemini" {
+			if errProject := activateImportedGeminiAccount(ctx, cfg, account, projectID); errProject != nil {
+				log.Errorf("import-local-creds: gemini account %s: %v", account.Email, errProject)
+				continue
+			}
+		}
+		savedPath, errSave := store.Save(ctx, account.Record)
+		if errSave != nil {
+			log.Errorf("import-local-creds: save %s account %s: %v", account.Provider, account.Email, errSave)
+			continue
+		}
+		imported++
+		fmt.Printf("Imported %s account %s from %s to %s\n", account.Provider, account.Email, account.Source, savedPath)
+		if account.Warning != "" {
+			log.Warnf("import-local-creds: %s account %s: %s", account.Provider, account.Email, account.Warning)
+		}
+	}
+	fmt.Printf("Imported %d of %d accounts found.\n", imported, len(accounts))
+}
+
+// activateImportedGeminiAccount runs the Gemini CLI onboarding for the imported account and
+// names its record after the activated project.
+func activateImportedGeminiAccount(ctx context.Context, cfg *config.Config, account *localcreds.Account, projectID string) error {
+	storage, ok := account.Record.Storage.(*gemini.GeminiTokenStorage)
+	if !ok || storage == nil {
+		return fmt.Errorf("unsupported token storage")
+	}
+	httpClient, errClient := gemini.NewGeminiAuth().GetAuthenticatedClient(ctx, storage, cfg, &gemini.WebLoginOptions{NoBrowser: true})
+	if errClient != nil {
+		return errClient
+	}
+	requested := strings.TrimSpace(projectID)
+	if errSetup := performGeminiCLISetup(ctx, httpClient, storage, requested); errSetup != nil {
+		var projectErr *projectSelectionRequiredError
+		if errors.As(errSetup, &projectErr) {
+			return fmt.Errorf("a project ID is required; rerun with -project_id")
+		}
+		return errSetup
+	}
+	if strings.TrimSpace(storage.ProjectID) == "" {
+		storage.ProjectID = requested
+	}
+	if strings.TrimSpace(storage.ProjectID) == "" {
+		return fmt.Errorf("onboarding did not return a project ID")
+	}
+	storage.Auto = requested == ""
+	if !storage.Auto {
+		isChecked, errCheck := checkCloudAPIIsEnabled(ctx, httpClient, storage.ProjectID)
+		if errCheck != nil {
+			return fmt.Errorf("check Cloud AI API for %s: %w", storage.ProjectID, errCheck)
+		}
+		if !isChecked {
+			return fmt.Errorf("cloud AI API is not enabled for project %s", storage.ProjectID)
+		}
+		storage.Checked = true
+	}
+	updateAuthRecord(account.Record, storage)
+	return nil
+}
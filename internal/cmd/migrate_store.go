@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/store"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoMigrateStore copies auth records, config.yaml and the usage statistics snapshot from one
+// storage backend to another and verifies the copy by checksum. With dryRun it only reports
+// what would change.
+func DoMigrateStore(from, to store.MigrationEndpoint, dryRun bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	mode := "migrating"
+	if dryRun {
+		mode = "dry run"
+	}
+	log.Infof("migrate-store: %s from %s to %s", mode, from, to)
+
+	report, err := store.MigrateStores(ctx, from, to, dryRun)
+	if report != nil {
+		for _, item := range report.Items {
+			entry := log.WithField("checksum", shortSum(item.Checksum))
+			if item.Note != "" {
+				entry = entry.WithField("note", item.Note)
+			}
+			entry.Infof("migrate-store: %-9s %s %s", item.Action, item.Category, item.Name)
+		}
+		for _, id := range report.DestinationOnly {
+			log.Warnf("migrate-store: auth %s exists only in the destination and was left untouched", id)
+		}
+		for _, mismatch := range report.Mismatches {
+			log.Errorf("migrate-store: verification failed: %s", mismatch)
+		}
+		log.Infof("migrate-store: %d to create, %d to update, %d unchanged, %d skipped",
+			report.Count(store.MigrationCreate), report.Count(store.MigrationUpdate),
+			report.Count(store.MigrationUnchanged), report.Count(store.MigrationSkipped))
+	}
+	if err != nil {
+		log.Errorf("migrate-store: %v", err)
+		return
+	}
+	if dryRun {
+		log.Info("migrate-store: dry run finished, nothing was written")
+		return
+	}
+	log.Info("migrate-store: migration finished and verified")
+}
+
+func shortSum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/store"
+	sdkAuth "github.com/giofahreza/AIProxyAPI/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoReencryptAuths rewrites every auth record in the registered token store so it is sealed
+// with the active encryption key. Plaintext records and records sealed with an older key are
+// re-encrypted; records that are already current are left untouched.
+func DoReencryptAuths(cfg *config.Config) {
+	ring := authcrypt.CurrentKeyring()
+	if ring == nil {
+		log.Error("reencrypt-auths: no encryption key configured (set AUTH_ENCRYPTION_KEY or AUTH_ENCRYPTION_KEY_FILE)")
+		return
+	}
+	tokenStore := sdkAuth.GetTokenStore()
+	if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok && cfg != nil {
+		dirSetter.SetBaseDir(cfg.AuthDir)
+	}
+
+	ctx := context.Background()
+	auths, err := tokenStore.List(ctx)
+	if err != nil {
+		log.Errorf("reencrypt-auths: list auth records: %v", err)
+		return
+	}
+	var sealed, current, failed int
+	for _, auth := range auths {
+		if auth == nil || auth.Metadata == nil {
+			continue
+		}
+		if path := strings.TrimSpace(auth.Attributes["path"]); path != "" {
+			if raw, errRead := os.ReadFile(path); errRead == nil && authcrypt.IsCurrent(raw) {
+				current++
+				continue
+			}
+		}
+		if _, errSave := tokenStore.Save(ctx, auth); errSave != nil {
+			log.Errorf("reencrypt-auths: %s: %v", auth.ID, errSave)
+			failed++
+			continue
+		}
+		sealed++
+	}
+	log.Infof("reencrypt-auths: %d re-encrypted, %d already current, %d failed (active key %s)", sealed, current, failed, ring.ActiveKeyID())
+	if _, isGit := tokenStore.(*store.GitTokenStore); isGit && sealed > 0 {
+		// Sealing only rewrites the current files; earlier commits keep the old contents.
+		log.Warn("reencrypt-auths: earlier commits in the git remote still hold the previous plaintext or old-key records; purge them from the history and rotate the affected tokens")
+	}
+}
@@ -1,11 +1,13 @@
 // Package cmd contains CLI helpers. This file implements importing a Vertex AI
-// service account JSON into the auth store as a dedicated "vertex" credential.
+// service account JSON, or registering Application Default Credentials, into the
+// auth store as a dedicated "vertex" credential.
 package cmd
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -15,12 +17,14 @@ import (
 	sdkAuth "github.com/giofahreza/AIProxyAPI/sdk/auth"
 	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
 )
 
 // DoVertexImport imports a Google Cloud service account key JSON and persists
 // it as a "vertex" provider credential. The file content is embedded in the auth
-// file to allow portable deployment across stores.
-func DoVertexImport(cfg *config.Config, keyPath string) {
+// file to allow portable deployment across stores. Requests fail over between regions
+// in the given order; us-central1 is used when regions is empty.
+func DoVertexImport(cfg *config.Config, keyPath string, regions []string) {
 	if cfg == nil {
 		cfg = &config.Config{}
 	}
@@ -60,7 +64,8 @@ func DoVertexImport(cfg *config.Config, keyPath string) {
 		log.Warn("vertex-import: client_email missing in service account json")
 	}
 	// Default location if not provided by user. Can be edited in the saved file later.
-	location := "us-central1"
+	regions = vertex.NormalizeRegions(regions, "")
+	location := regions[0]
 
 	fileName := fmt.Sprintf("vertex-%s.json", sanitizeFilePart(projectID))
 	// Build auth record
@@ -69,12 +74,14 @@ func DoVertexImport(cfg *config.Config, keyPath string) {
 		ProjectID:      projectID,
 		Email:          email,
 		Location:       location,
+		Regions:        regions,
 	}
 	metadata := map[string]any{
 		"service_account": sa,
 		"project_id":      projectID,
 		"email":           email,
 		"location":        location,
+		"regions":         regions,
 		"type":            "vertex",
 		"label":           labelForVertex(projectID, email),
 	}
@@ -98,6 +105,78 @@ func DoVertexImport(cfg *config.Config, keyPath string) {
 	fmt.Printf("Vertex credentials imported: %s\n", path)
 }
 
+// DoVertexADC registers a "vertex" credential that authenticates with Application Default
+// Credentials, such as the GKE workload identity served by the metadata server, instead of a
+// stored key. When impersonate is set, requests use that service account's tokens. The
+// credentials are checked by fetching a token before the record is saved.
+func DoVertexADC(cfg *config.Config, projectID string, regions []string, impersonate string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	impersonate = strings.TrimSpace(impersonate)
+	ctx := context.Background()
+	if httpClient := util.SetProxy(&cfg.SDKConfig, &http.Client{}); httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+	detectedProject, errVerify := vertex.VerifyADC(ctx, impersonate)
+	if errVerify != nil {
+		log.Errorf("vertex-adc: %v", errVerify)
+		return
+	}
+	projectID = strings.TrimSpace(projectID)
+	if projectID == "" {
+		projectID = detectedProject
+	}
+	if projectID == "" {
+		log.Errorf("vertex-adc: application default credentials name no project; pass -project_id")
+		return
+	}
+	regions = vertex.NormalizeRegions(regions, "")
+
+	fileName := vertex.ADCFileName(projectID, impersonate)
+	storage := &vertex.VertexCredentialStorage{
+		CredentialSource:          vertex.CredentialSourceADC,
+		ImpersonateServiceAccount: impersonate,
+		ProjectID:                 projectID,
+		Email:                     impersonate,
+		Location:                  regions[0],
+		Regions:                   regions,
+	}
+	metadata := map[string]any{
+		"credential_source": vertex.CredentialSourceADC,
+		"project_id":        projectID,
+		"email":             impersonate,
+		"location":          regions[0],
+		"regions":           regions,
+		"type":              "vertex",
+		"label":             labelForVertex(projectID, impersonate),
+	}
+	if impersonate != "" {
+		metadata["impersonate_service_account"] = impersonate
+	}
+	record := &coreauth.Auth{
+		ID:       fileName,
+		Provider: "vertex",
+		FileName: fileName,
+		Storage:  storage,
+		Metadata: metadata,
+	}
+
+	store := sdkAuth.GetTokenStore()
+	if setter, ok := store.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+	path, errSave := store.Save(ctx, record)
+	if errSave != nil {
+		log.Errorf("vertex-adc: save credential failed: %v", errSave)
+		return
+	}
+	fmt.Printf("Vertex application default credentials registered: %s\n", path)
+}
+
 func sanitizeFilePart(s string) string {
 	out := strings.TrimSpace(s)
 	replacers := []string{"/", "_", "\\", "_", ":", "_", " ", "-"}
@@ -0,0 +1,113 @@
+package config
+
+import "strings"
+
+// DefaultAzureOpenAIAPIVersion is used when an azure-openai entry does not set api-version.
+const DefaultAzureOpenAIAPIVersion = "2025-04-01-preview"
+
+// AzureOpenAIKey represents an Azure OpenAI resource. Requests are routed to the deployment
+// mapped for each model and authenticated with either the resource API key or a Microsoft
+// Entra ID token obtained through the client-credentials flow.
+type AzureOpenAIKey struct {
+	// Endpoint is the resource endpoint (e.g., "https://my-resource.openai.azure.com").
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+
+	// APIVersion is the api-version query parameter sent with every request.
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// APIKey authenticates with the resource key via the api-key header.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// TenantID, ClientID and ClientSecret authenticate with an Entra ID app registration
+	// when no APIKey is set.
+	TenantID     string `yaml:"tenant-id,omitempty" json:"tenant-id,omitempty"`
+	ClientID     string `yaml:"client-id,omitempty" json:"client-id,omitempty"`
+	ClientSecret string `yaml:"client-secret,omitempty" json:"client-secret,omitempty"`
+
+	// Prefix optionally namespaces models for this resource (e.g., "azure/gpt-4o").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this resource if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps client-facing model names to deployments. Only listed models are served.
+	Models []AzureOpenAIModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent to this resource.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this resource.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this resource.
+	CredentialCaps `yaml:",inline"`
+}
+
+// AzureOpenAIModel maps a client-facing model name to an Azure deployment.
+type AzureOpenAIModel struct {
+	// Name is the client-facing model name (e.g., "gpt-4o").
+	Name string `yaml:"name" json:"name"`
+
+	// Deployment is the Azure deployment serving Name. Defaults to Name.
+	Deployment string `yaml:"deployment,omitempty" json:"deployment,omitempty"`
+}
+
+func (m AzureOpenAIModel) GetName() string  { return m.Name }
+func (m AzureOpenAIModel) GetAlias() string { return m.Name }
+
+// UsesEntraID reports whether the resource authenticates with client credentials.
+func (k AzureOpenAIKey) UsesEntraID() bool {
+	return k.APIKey == "" && k.TenantID != "" && k.ClientID != "" && k.ClientSecret != ""
+}
+
+// SanitizeAzureOpenAIKeys trims Azure OpenAI resources and drops entries without an endpoint
+// or usable credentials.
+func (cfg *Config) SanitizeAzureOpenAIKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.AzureOpenAI))
+	out := cfg.AzureOpenAI[:0]
+	for i := range cfg.AzureOpenAI {
+		entry := cfg.AzureOpenAI[i]
+		entry.Endpoint = strings.TrimRight(strings.TrimSpace(entry.Endpoint), "/")
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.TenantID = strings.TrimSpace(entry.TenantID)
+		entry.ClientID = strings.TrimSpace(entry.ClientID)
+		entry.ClientSecret = strings.TrimSpace(entry.ClientSecret)
+		if entry.Endpoint == "" || (entry.APIKey == "" && !entry.UsesEntraID()) {
+			continue
+		}
+		entry.APIVersion = strings.TrimSpace(entry.APIVersion)
+		if entry.APIVersion == "" {
+			entry.APIVersion = DefaultAzureOpenAIAPIVersion
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]AzureOpenAIModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Deployment = strings.TrimSpace(model.Deployment)
+			if model.Name == "" {
+				continue
+			}
+			if model.Deployment == "" {
+				model.Deployment = model.Name
+			}
+			models = append(models, model)
+		}
+		entry.Models = models
+
+		uniqueKey := entry.Endpoint + "|" + entry.APIKey + "|" + entry.ClientID
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.AzureOpenAI = out
+}
@@ -0,0 +1,98 @@
+package config
+
+import "strings"
+
+// BedrockKey represents AWS credentials for calling Anthropic models on Amazon Bedrock.
+// Requests are signed with AWS Signature Version 4 using the access key pair and, for
+// temporary credentials, the session token.
+type BedrockKey struct {
+	// Region is the AWS region hosting the Bedrock runtime endpoint (e.g., "us-east-1").
+	Region string `yaml:"region" json:"region"`
+
+	// AccessKeyID is the AWS access key ID used to sign requests.
+	AccessKeyID string `yaml:"access-key-id" json:"access-key-id"`
+
+	// SecretAccessKey is the AWS secret access key used to sign requests.
+	SecretAccessKey string `yaml:"secret-access-key" json:"secret-access-key"`
+
+	// SessionToken is the optional session token for temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Endpoint overrides the Bedrock runtime URL, mainly for testing against a local stub.
+	// Defaults to https://bedrock-runtime.{region}.amazonaws.com.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps client-facing aliases to Bedrock model IDs or inference profile IDs
+	// (e.g., "us.anthropic.claude-sonnet-4-20250514-v1:0"). Only listed models are served.
+	Models []BedrockModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this credential.
+	CredentialCaps `yaml:",inline"`
+}
+
+// BedrockModel maps a client-facing alias to a Bedrock model ID.
+type BedrockModel struct {
+	// Name is the Bedrock model ID or inference profile ID used when issuing requests.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias" json:"alias"`
+}
+
+func (m BedrockModel) GetName() string  { return m.Name }
+func (m BedrockModel) GetAlias() string { return m.Alias }
+
+// SanitizeBedrockKeys trims Bedrock credentials and drops entries without a region or key pair.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.BedrockKey))
+	out := cfg.BedrockKey[:0]
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		entry.Region = strings.ToLower(strings.TrimSpace(entry.Region))
+		entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+		entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+		entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+		if entry.Region == "" || entry.AccessKeyID == "" || entry.SecretAccessKey == "" {
+			continue
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Endpoint = strings.TrimRight(strings.TrimSpace(entry.Endpoint), "/")
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]BedrockModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+
+		uniqueKey := entry.AccessKeyID + "|" + entry.Region + "|" + entry.Endpoint
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.BedrockKey = out
+}
@@ -35,6 +35,10 @@ type Config struct {
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
+	// SQLiteStorePath keeps auth records and per-request usage history in a SQLite database at
+	// this path instead of plain files. The config file itself stays where it is.
+	SQLiteStorePath string `yaml:"sqlite-store-path" json:"-"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
@@ -51,6 +55,12 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageRetention bounds how much usage history is kept in memory and in the usage store.
+	UsageRetention UsageRetention `yaml:"usage-retention" json:"usage-retention"`
+
+	// ModelPricing sets per-token prices used to compute cost in usage reports.
+	ModelPricing []ModelPrice `yaml:"model-pricing,omitempty" json:"model-pricing,omitempty"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -65,6 +75,12 @@ type Config struct {
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// Cluster shares credential cooldowns and quota counters between replicas.
+	Cluster ClusterConfig `yaml:"cluster" json:"cluster"`
+
+	// HealthProbes periodically checks each enabled credential in the background.
+	HealthProbes HealthProbeConfig `yaml:"health-probes" json:"health-probes"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -84,6 +100,15 @@ type Config struct {
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
 
+	// BedrockKey defines AWS credentials for Anthropic models served through Amazon Bedrock.
+	BedrockKey []BedrockKey `yaml:"bedrock-api-key" json:"bedrock-api-key"`
+
+	// AzureOpenAI defines Azure OpenAI resources routed by deployment.
+	AzureOpenAI []AzureOpenAIKey `yaml:"azure-openai" json:"azure-openai"`
+
+	// Ollama defines local Ollama or llama.cpp servers whose models are discovered at runtime.
+	Ollama []OllamaKey `yaml:"ollama" json:"ollama"`
+
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
@@ -104,6 +129,10 @@ type Config struct {
 	// APIKeyLimits defines per-API-key model restrictions and monthly request quotas.
 	APIKeyLimits []APIKeyLimit `yaml:"api-key-limits" json:"api-key-limits,omitempty"`
 
+	// EndUserAttribution configures how requests made with a shared API key are attributed to
+	// individual end users for usage reporting and per-user limits.
+	EndUserAttribution EndUserAttribution `yaml:"end-user-attribution" json:"end-user-attribution"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
 }
 
@@ -139,6 +168,25 @@ type APIKeyLimit struct {
 	// AllowedProviders lists the provider/executor identifiers this API key can route through.
 	// If empty or nil, all providers are allowed.
 	AllowedProviders []string `yaml:"allowed-providers,omitempty" json:"allowed-providers,omitempty"`
+
+	// EndUserMonthlyQuotas caps the monthly requests each end user of this API key may make.
+	// Keys are model names or wildcard patterns, like MonthlyQuotas, but every end user is
+	// counted separately. Requests without an end user are not subject to these quotas.
+	EndUserMonthlyQuotas map[string]int `yaml:"end-user-monthly-quotas,omitempty" json:"end-user-monthly-quotas,omitempty"`
+
+	// RequireEndUser rejects requests made with this API key that do not identify an end user.
+	RequireEndUser bool `yaml:"require-end-user,omitempty" json:"require-end-user,omitempty"`
+}
+
+// EndUserAttribution selects where the end user behind a request is read from. The header is
+// checked first; the request body is only consulted when FromBody is set.
+type EndUserAttribution struct {
+	// Header names the request header carrying the end user identifier (e.g., "X-User-Id").
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+
+	// FromBody reads the end user from the OpenAI "user" field or the Anthropic
+	// "metadata.user_id" field when the header is absent.
+	FromBody bool `yaml:"from-body,omitempty" json:"from-body,omitempty"`
 }
 
 // RemoteManagement holds management API configuration under 'remote-management'.
@@ -169,6 +217,108 @@ type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
 	// Supported values: "round-robin" (default), "fill-first".
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// Hedging enables hedged non-streaming requests for matching models.
+	// When the first attempt produces no response within the delay, a second attempt
+	// starts on a different credential and the first success wins.
+	Hedging []HedgingRule `yaml:"hedging,omitempty" json:"hedging,omitempty"`
+}
+
+// ClusterConfig configures state sharing between proxy replicas that use the same Postgres store.
+type ClusterConfig struct {
+	// Enabled turns on cluster coordination. It requires the Postgres store.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// NodeID identifies this replica. Defaults to the host name; it must be stable across restarts.
+	NodeID string `yaml:"node-id,omitempty" json:"node-id,omitempty"`
+
+	// Channel is the Postgres LISTEN/NOTIFY channel used to broadcast state. Defaults to "aiproxy_cluster".
+	Channel string `yaml:"channel,omitempty" json:"channel,omitempty"`
+
+	// CounterSyncSeconds is how often per-key request counters are exchanged. Defaults to 5.
+	CounterSyncSeconds int `yaml:"counter-sync-seconds,omitempty" json:"counter-sync-seconds,omitempty"`
+}
+
+// HealthProbeConfig configures background health probes. Each enabled credential is checked
+// through its provider's models or quota endpoint where the executor supports one, and with a
+// one-token completion otherwise.
+type HealthProbeConfig struct {
+	// Enabled turns on background probes.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// IntervalSeconds is how often each credential is probed. Defaults to 600.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+
+	// TimeoutSeconds bounds a single probe. Defaults to 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+
+	// Providers overrides probe behavior per provider (e.g., "claude", "gemini-cli").
+	Providers map[string]HealthProbeProvider `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// HealthProbeProvider overrides health probes for one provider.
+type HealthProbeProvider struct {
+	// Disabled opts every credential of the provider out of probes.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// Model is the model used for completion probes. Defaults to the first model the credential serves.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+
+	// SkipModels lists models (wildcards allowed) never used for completion probes.
+	SkipModels []string `yaml:"skip-models,omitempty" json:"skip-models,omitempty"`
+}
+
+// UsageRetention bounds usage history. Zero values use the defaults noted on each field.
+type UsageRetention struct {
+	// RecentRequests is how many individual requests are kept for request-level views. Defaults to 10000.
+	RecentRequests int `yaml:"recent-requests,omitempty" json:"recent-requests,omitempty"`
+
+	// MinuteRollupHours is how long per-minute rollups are kept. Defaults to 48.
+	MinuteRollupHours int `yaml:"minute-rollup-hours,omitempty" json:"minute-rollup-hours,omitempty"`
+
+	// HourRollupDays is how long per-hour rollups are kept. Defaults to 31.
+	HourRollupDays int `yaml:"hour-rollup-days,omitempty" json:"hour-rollup-days,omitempty"`
+
+	// DayRollupDays is how long per-day rollups are kept. Defaults to 400.
+	DayRollupDays int `yaml:"day-rollup-days,omitempty" json:"day-rollup-days,omitempty"`
+
+	// EventRetentionDays is how long the SQLite store keeps per-request rows. Defaults to 90.
+	EventRetentionDays int `yaml:"event-retention-days,omitempty" json:"event-retention-days,omitempty"`
+}
+
+// ModelPrice prices the tokens of a set of models, in currency units per million tokens.
+type ModelPrice struct {
+	// Models lists model names or wildcard patterns (e.g., "claude-sonnet-*", "gpt-5").
+	Models []string `yaml:"models" json:"models"`
+
+	// InputPerMillion is the price of input tokens that were not served from cache.
+	InputPerMillion float64 `yaml:"input-per-million" json:"input-per-million"`
+
+	// OutputPerMillion is the price of output tokens.
+	OutputPerMillion float64 `yaml:"output-per-million" json:"output-per-million"`
+
+	// CachedPerMillion is the price of cached input tokens. Cached tokens are counted as part of
+	// the input tokens and billed at this price instead of InputPerMillion.
+	CachedPerMillion float64 `yaml:"cached-per-million,omitempty" json:"cached-per-million,omitempty"`
+
+	// CacheWritePerMillion is the price of input tokens written to the prompt cache, which
+	// Claude bills above the input price. Defaults to InputPerMillion.
+	CacheWritePerMillion float64 `yaml:"cache-write-per-million,omitempty" json:"cache-write-per-million,omitempty"`
+
+	// ReasoningPerMillion is the price of reasoning tokens. Defaults to OutputPerMillion.
+	ReasoningPerMillion float64 `yaml:"reasoning-per-million,omitempty" json:"reasoning-per-million,omitempty"`
+
+	// PerImage is the price of each generated image, charged on top of the tokens.
+	PerImage float64 `yaml:"per-image,omitempty" json:"per-image,omitempty"`
+}
+
+// HedgingRule ties a set of model name patterns to a hedge delay.
+type HedgingRule struct {
+	// Models lists model names or wildcard patterns (e.g., "claude-*-haiku-*", "gpt-5-mini").
+	Models []string `yaml:"models" json:"models"`
+
+	// DelayMS is the time in milliseconds to wait for the first attempt before hedging.
+	DelayMS int `yaml:"delay-ms" json:"delay-ms"`
 }
 
 // ModelNameMapping defines a model ID mapping for a specific channel.
@@ -262,6 +412,19 @@ type PayloadModelRule struct {
 	Protocol string `yaml:"protocol" json:"protocol"`
 }
 
+// CredentialCaps holds optional self-imposed usage ceilings for a single credential.
+// Zero values disable the corresponding cap.
+type CredentialCaps struct {
+	// MaxConcurrent limits the number of in-flight requests routed through the credential.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MaxRequestsPerDay limits the number of requests sent with the credential per calendar day.
+	MaxRequestsPerDay int64 `yaml:"max-requests-per-day,omitempty" json:"max-requests-per-day,omitempty"`
+
+	// MaxTokensPerDay limits the total tokens consumed by the credential per calendar day.
+	MaxTokensPerDay int64 `yaml:"max-tokens-per-day,omitempty" json:"max-tokens-per-day,omitempty"`
+}
+
 // ClaudeKey represents the configuration for a Claude API key,
 // including the API key itself and an optional base URL for the API endpoint.
 type ClaudeKey struct {
@@ -286,6 +449,9 @@ type ClaudeKey struct {
 
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this key.
+	CredentialCaps `yaml:",inline"`
 }
 
 // ClaudeModel describes a mapping between an alias and the actual upstream model name.
@@ -324,6 +490,9 @@ type CodexKey struct {
 
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this key.
+	CredentialCaps `yaml:",inline"`
 }
 
 // CodexModel describes a mapping between an alias and the actual upstream model name.
@@ -361,6 +530,9 @@ type GeminiKey struct {
 
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this key.
+	CredentialCaps `yaml:",inline"`
 }
 
 // GeminiModel describes a mapping between an alias and the actual upstream model name.
@@ -391,12 +563,31 @@ type OpenAICompatibility struct {
 	APIKeyEntries []OpenAICompatibilityAPIKey `yaml:"api-key-entries,omitempty" json:"api-key-entries,omitempty"`
 
 	// Models defines the model configurations including aliases for routing.
+	// With DiscoverModels, entries whose name contains one "*" are alias rules for discovered
+	// models instead (e.g., name "accounts/acme/models/*" with alias "acme-*").
 	Models []OpenAICompatibilityModel `yaml:"models" json:"models"`
 
+	// DiscoverModels also serves the models listed by the provider's GET {base-url}/models
+	// endpoint, refreshed every DiscoveryIntervalSeconds.
+	DiscoverModels bool `yaml:"discover-models,omitempty" json:"discover-models,omitempty"`
+
+	// DiscoveryIntervalSeconds is how often discovered models are refreshed. Defaults to 600.
+	DiscoveryIntervalSeconds int `yaml:"discovery-interval-seconds,omitempty" json:"discovery-interval-seconds,omitempty"`
+
+	// IncludeModels limits discovered models to those matching one of these wildcard patterns.
+	IncludeModels []string `yaml:"include-models,omitempty" json:"include-models,omitempty"`
+
+	// ExcludedModels drops discovered models matching one of these wildcard patterns.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 }
 
+// DefaultOpenAICompatDiscoveryIntervalSeconds is how often discovered models of an
+// openai-compatibility provider are refreshed when discovery-interval-seconds is not set.
+const DefaultOpenAICompatDiscoveryIntervalSeconds = 600
+
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
 type OpenAICompatibilityAPIKey struct {
 	// APIKey is the authentication key for accessing the external API services.
@@ -404,6 +595,9 @@ type OpenAICompatibilityAPIKey struct {
 
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this key.
+	CredentialCaps `yaml:",inline"`
 }
 
 // OpenAICompatibilityModel represents a model configuration for OpenAI compatibility,
@@ -416,6 +610,51 @@ type OpenAICompatibilityModel struct {
 	Alias string `yaml:"alias" json:"alias"`
 }
 
+// IsAliasRule reports whether the entry is a wildcard rule for discovered models rather than a
+// single model. Rules have exactly one "*" in the name.
+func (m OpenAICompatibilityModel) IsAliasRule() bool {
+	return strings.Count(m.Name, "*") == 1
+}
+
+// ApplyAliasRule returns the alias a rule gives a discovered model name. The text matched by
+// "*" in the name replaces "*" in the alias; a rule without an aliased "*" keeps the name.
+func (m OpenAICompatibilityModel) ApplyAliasRule(name string) (string, bool) {
+	captured, ok := matchSingleWildcard(m.Name, name)
+	if !ok {
+		return "", false
+	}
+	if strings.Count(m.Alias, "*") != 1 {
+		return name, true
+	}
+	return strings.Replace(m.Alias, "*", captured, 1), true
+}
+
+// ReverseAliasRule maps an alias produced by ApplyAliasRule back to the provider's model name.
+func (m OpenAICompatibilityModel) ReverseAliasRule(alias string) (string, bool) {
+	if strings.Count(m.Alias, "*") != 1 {
+		return "", false
+	}
+	captured, ok := matchSingleWildcard(m.Alias, alias)
+	if !ok {
+		return "", false
+	}
+	return strings.Replace(m.Name, "*", captured, 1), true
+}
+
+func matchSingleWildcard(pattern, value string) (string, bool) {
+	prefix, suffix, found := strings.Cut(pattern, "*")
+	if !found || strings.Contains(suffix, "*") {
+		return "", false
+	}
+	lowerValue := strings.ToLower(value)
+	if len(value) < len(prefix)+len(suffix) ||
+		!strings.HasPrefix(lowerValue, strings.ToLower(prefix)) ||
+		!strings.HasSuffix(lowerValue, strings.ToLower(suffix)) {
+		return "", false
+	}
+	return value[len(prefix) : len(value)-len(suffix)], true
+}
+
 // LoadConfig reads a YAML configuration file from the given path,
 // unmarshals it into a Config struct, applies environment variable overrides,
 // and returns it.
@@ -508,6 +747,15 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Vertex-compatible API keys: drop entries without base-url
 	cfg.SanitizeVertexCompatKeys()
 
+	// Drop Bedrock credentials without a region or key pair
+	cfg.SanitizeBedrockKeys()
+
+	// Drop Azure OpenAI resources without an endpoint or credentials
+	cfg.SanitizeAzureOpenAIKeys()
+
+	// Drop Ollama servers without a base URL and apply interval defaults
+	cfg.SanitizeOllamaKeys()
+
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
 
@@ -526,6 +774,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize API key limits configuration.
 	cfg.SanitizeAPIKeyLimits()
 
+	// Drop hedging rules without models or a positive delay.
+	cfg.SanitizeHedgingRules()
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
@@ -624,7 +875,7 @@ func (cfg *Config) SanitizeAPIKeyLimits() {
 			allowedModels = append(allowedModels, m)
 		}
 
-		// Normalize monthly quotas
+		// Normalize monthly quotas, per API key and per end user
 		quotas := make(map[string]int)
 		for model, quota := range limit.MonthlyQuotas {
 			m := strings.TrimSpace(model)
@@ -634,6 +885,15 @@ func (cfg *Config) SanitizeAPIKeyLimits() {
 			quotas[m] = quota
 		}
 
+		endUserQuotas := make(map[string]int)
+		for model, quota := range limit.EndUserMonthlyQuotas {
+			m := strings.TrimSpace(model)
+			if m == "" || quota <= 0 {
+				continue
+			}
+			endUserQuotas[m] = quota
+		}
+
 		// Normalize allowed credentials
 		allowedCreds := make([]string, 0, len(limit.AllowedCredentials))
 		seenCreds := make(map[string]struct{})
@@ -665,13 +925,16 @@ func (cfg *Config) SanitizeAPIKeyLimits() {
 		}
 
 		// Only add if there are restrictions, quotas, credential restrictions, or provider restrictions
-		if len(allowedModels) > 0 || len(quotas) > 0 || len(allowedCreds) > 0 || len(allowedProviders) > 0 {
+		if len(allowedModels) > 0 || len(quotas) > 0 || len(allowedCreds) > 0 || len(allowedProviders) > 0 ||
+			len(endUserQuotas) > 0 || limit.RequireEndUser {
 			out = append(out, APIKeyLimit{
-				APIKey:             apiKey,
-				AllowedModels:      allowedModels,
-				MonthlyQuotas:      quotas,
-				AllowedCredentials: allowedCreds,
-				AllowedProviders:   allowedProviders,
+				APIKey:               apiKey,
+				AllowedModels:        allowedModels,
+				MonthlyQuotas:        quotas,
+				AllowedCredentials:   allowedCreds,
+				AllowedProviders:     allowedProviders,
+				EndUserMonthlyQuotas: endUserQuotas,
+				RequireEndUser:       limit.RequireEndUser,
 			})
 		}
 	}
@@ -679,6 +942,30 @@ func (cfg *Config) SanitizeAPIKeyLimits() {
 	cfg.APIKeyLimits = out
 }
 
+// SanitizeHedgingRules trims model patterns and drops hedging rules that cannot trigger.
+func (cfg *Config) SanitizeHedgingRules() {
+	if cfg == nil || len(cfg.Routing.Hedging) == 0 {
+		return
+	}
+	out := make([]HedgingRule, 0, len(cfg.Routing.Hedging))
+	for _, rule := range cfg.Routing.Hedging {
+		if rule.DelayMS <= 0 {
+			continue
+		}
+		models := make([]string, 0, len(rule.Models))
+		for _, model := range rule.Models {
+			if trimmed := strings.TrimSpace(model); trimmed != "" {
+				models = append(models, trimmed)
+			}
+		}
+		if len(models) == 0 {
+			continue
+		}
+		out = append(out, HedgingRule{Models: models, DelayMS: rule.DelayMS})
+	}
+	cfg.Routing.Hedging = out
+}
+
 // SanitizeOpenAICompatibility removes OpenAI-compatibility provider entries that are
 // not actionable, specifically those missing a BaseURL. It trims whitespace before
 // evaluation and preserves the relative order of remaining entries.
@@ -693,6 +980,11 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 		e.Prefix = normalizeModelPrefix(e.Prefix)
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
+		e.IncludeModels = NormalizeExcludedModels(e.IncludeModels)
+		e.ExcludedModels = NormalizeExcludedModels(e.ExcludedModels)
+		if e.DiscoverModels && e.DiscoveryIntervalSeconds <= 0 {
+			e.DiscoveryIntervalSeconds = DefaultOpenAICompatDiscoveryIntervalSeconds
+		}
 		if e.BaseURL == "" {
 			// Skip providers with no base-url; treated as removed
 			continue
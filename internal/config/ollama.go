@@ -0,0 +1,118 @@
+package config
+
+import "strings"
+
+const (
+	// DefaultOllamaDiscoveryIntervalSeconds is how often an Ollama server's model list is
+	// refreshed when discovery-interval-seconds is not set.
+	DefaultOllamaDiscoveryIntervalSeconds = 300
+
+	// DefaultOllamaLoadTimeoutSeconds bounds how long a request waits for a model to load
+	// into memory when load-timeout-seconds is not set.
+	DefaultOllamaLoadTimeoutSeconds = 300
+)
+
+// OllamaKey represents a local inference server speaking the Ollama API, such as Ollama itself
+// or llama.cpp's server. Models are discovered from the server instead of being listed by hand,
+// and requests are sent to its OpenAI-compatible chat endpoint.
+type OllamaKey struct {
+	// BaseURL is the server root (e.g., "http://gpu-1:11434"), without the /v1 suffix.
+	BaseURL string `yaml:"base-url" json:"base-url"`
+
+	// APIKey is sent as a bearer token when the server sits behind an authenticating proxy.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Prefix optionally namespaces models for this server (e.g., "gpu-1/qwen3:32b").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this server if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models optionally restricts the served models and gives them aliases. When empty, every
+	// model the server reports is served under its own name.
+	Models []OllamaModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// DiscoveryIntervalSeconds is how often the model list is refreshed. Defaults to 300.
+	DiscoveryIntervalSeconds int `yaml:"discovery-interval-seconds,omitempty" json:"discovery-interval-seconds,omitempty"`
+
+	// LoadTimeoutSeconds bounds the wait for a model that is not yet in memory. Defaults to 300.
+	LoadTimeoutSeconds int `yaml:"load-timeout-seconds,omitempty" json:"load-timeout-seconds,omitempty"`
+
+	// ContextLength is the context window the server runs its models with, for servers started
+	// with OLLAMA_CONTEXT_LENGTH. When unset, the window discovered from the server is advertised.
+	ContextLength int `yaml:"context-length,omitempty" json:"context-length,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent to this server.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this server.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this server.
+	CredentialCaps `yaml:",inline"`
+}
+
+// OllamaModel maps a client-facing alias to a model installed on the server.
+type OllamaModel struct {
+	// Name is the model name as reported by the server (e.g., "qwen3:32b").
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name. Defaults to Name.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+
+	// ContextLength overrides the advertised context window of this model, for models whose
+	// num_ctx is raised outside their Modelfile.
+	ContextLength int `yaml:"context-length,omitempty" json:"context-length,omitempty"`
+}
+
+func (m OllamaModel) GetName() string  { return m.Name }
+func (m OllamaModel) GetAlias() string { return m.Alias }
+
+// SanitizeOllamaKeys trims Ollama servers, applies interval defaults and drops entries
+// without a base URL.
+func (cfg *Config) SanitizeOllamaKeys() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.Ollama))
+	out := cfg.Ollama[:0]
+	for i := range cfg.Ollama {
+		entry := cfg.Ollama[i]
+		entry.BaseURL = strings.TrimRight(strings.TrimSpace(entry.BaseURL), "/")
+		entry.BaseURL = strings.TrimSuffix(entry.BaseURL, "/v1")
+		if entry.BaseURL == "" {
+			continue
+		}
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		if entry.DiscoveryIntervalSeconds <= 0 {
+			entry.DiscoveryIntervalSeconds = DefaultOllamaDiscoveryIntervalSeconds
+		}
+		if entry.LoadTimeoutSeconds <= 0 {
+			entry.LoadTimeoutSeconds = DefaultOllamaLoadTimeoutSeconds
+		}
+
+		models := make([]OllamaModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name == "" {
+				continue
+			}
+			models = append(models, model)
+		}
+		entry.Models = models
+
+		uniqueKey := entry.BaseURL + "|" + entry.APIKey
+		if _, exists := seen[uniqueKey]; exists {
+			continue
+		}
+		seen[uniqueKey] = struct{}{}
+		out = append(out, entry)
+	}
+	cfg.Ollama = out
+}
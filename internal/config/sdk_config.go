@@ -9,6 +9,11 @@ type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// PublicBaseURL is the URL clients reach this server at, such as "https://proxy.example.com",
+	// used to build links to generated images. When empty, links use the request's own scheme and
+	// Host; X-Forwarded-* headers are never trusted.
+	PublicBaseURL string `yaml:"public-base-url,omitempty" json:"public-base-url,omitempty"`
+
 	// ForceModelPrefix requires explicit model prefixes (e.g., "teamA/gemini-3-pro-preview")
 	// to target prefixed credentials. When false, unprefixed model requests may use prefixed
 	// credentials as well.
@@ -23,7 +28,8 @@ type SDKConfig struct {
 	// Access holds request authentication provider configuration.
 	Access AccessConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
 
-	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
+	// Streaming configures server-side streaming behavior (keep-alives, safe bootstrap retries and
+	// mid-stream continuation).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 }
 
@@ -37,6 +43,15 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// ContinuationRetries controls how many times a stream that breaks after bytes were sent may be
+	// resumed on another auth, using the text already delivered as an assistant prefill. It applies
+	// to OpenAI chat completions, OpenAI Responses, Claude and Gemini clients. A stream that already
+	// delivered a complete tool call is not resumed but ended as a tool-call turn. While enabled,
+	// every auth that served an attempt of the request (including bootstrap retries) is skipped by
+	// the later attempts of the same request.
+	// <= 0 disables continuation. Default is 0.
+	ContinuationRetries int `yaml:"continuation-retries,omitempty" json:"continuation-retries,omitempty"`
 }
 
 // AccessConfig groups request authentication providers.
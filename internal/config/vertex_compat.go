@@ -30,6 +30,9 @@ type VertexCompatKey struct {
 
 	// Models defines the model configurations including aliases for routing.
 	Models []VertexCompatModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// CredentialCaps optionally limits concurrency and daily usage for this key.
+	CredentialCaps `yaml:",inline"`
 }
 
 // VertexCompatModel represents a model configuration for Vertex compatibility,
@@ -25,6 +25,9 @@ const (
 	// OpenaiResponse represents the OpenAI response format identifier.
 	OpenaiResponse = "openai-response"
 
+	// Ollama represents the Ollama API format identifier.
+	Ollama = "ollama"
+
 	// Antigravity represents the Antigravity response format identifier.
 	Antigravity = "antigravity"
 )
@@ -0,0 +1,112 @@
+package limits
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	"github.com/tidwall/gjson"
+)
+
+// maxEndUserLength bounds end user identifiers so clients cannot bloat usage history.
+const maxEndUserLength = 128
+
+// SetEndUserAttribution updates where end users are read from.
+func (e *Enforcer) SetEndUserAttribution(attribution config.EndUserAttribution) {
+	if e != nil {
+		e.mu.Lock()
+		e.attribution = attribution
+		e.mu.Unlock()
+	}
+}
+
+// EndUser returns the end user a request is made on behalf of, or "" when attribution is not
+// configured or the request does not name one. The configured header takes precedence over
+// the OpenAI "user" and Anthropic "metadata.user_id" body fields.
+func (e *Enforcer) EndUser(header http.Header, body []byte) string {
+	if e == nil {
+		return ""
+	}
+
+	e.mu.RLock()
+	attribution := e.attribution
+	e.mu.RUnlock()
+
+	if name := strings.TrimSpace(attribution.Header); name != "" {
+		if user := normalizeEndUser(header.Get(name)); user != "" {
+			return user
+		}
+	}
+	if !attribution.FromBody || len(body) == 0 || !gjson.ValidBytes(body) {
+		return ""
+	}
+	for _, path := range []string{"user", "metadata.user_id"} {
+		if value := gjson.GetBytes(body, path); value.Type == gjson.String {
+			if user := normalizeEndUser(value.String()); user != "" {
+				return user
+			}
+		}
+	}
+	return ""
+}
+
+func normalizeEndUser(raw string) string {
+	user := strings.TrimSpace(raw)
+	if len(user) > maxEndUserLength {
+		user = user[:maxEndUserLength]
+	}
+	return user
+}
+
+// CheckEndUserAccess enforces the per-end-user settings of an API key: whether an end user is
+// required and the monthly quotas each end user gets.
+func (e *Enforcer) CheckEndUserAccess(apiKey, endUser, modelName string) error {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var limit *config.APIKeyLimit
+	for i := range e.limits {
+		if e.limits[i].APIKey == apiKey {
+			limit = &e.limits[i]
+			break
+		}
+	}
+	if limit == nil {
+		return nil
+	}
+
+	if endUser == "" {
+		if limit.RequireEndUser {
+			return fmt.Errorf("this API key requires requests to identify an end user")
+		}
+		return nil
+	}
+	if len(limit.EndUserMonthlyQuotas) == 0 {
+		return nil
+	}
+
+	userUsage := usage.GetRequestStatistics().GetMonthlyEndUserUsage(apiKey, endUser)
+	for pattern, quota := range limit.EndUserMonthlyQuotas {
+		if !matchModel(pattern, modelName) {
+			continue
+		}
+		var aggregatedUsage int64
+		for model, count := range userUsage {
+			if matchModel(pattern, model) {
+				aggregatedUsage += count
+			}
+		}
+		if aggregatedUsage >= int64(quota) {
+			return fmt.Errorf("monthly quota for end user %q exceeded for model %q (limit: %d, current: %d)",
+				endUser, modelName, quota, aggregatedUsage)
+		}
+		break
+	}
+	return nil
+}
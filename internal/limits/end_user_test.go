@@ -0,0 +1,58 @@
+package limits
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+func TestEnforcer_EndUser(t *testing.T) {
+	e := NewEnforcer(nil)
+	header := http.Header{}
+	header.Set("X-User-Id", " alice ")
+	anthropic := []byte(`{"model":"claude-sonnet-4","metadata":{"user_id":"bob"}}`)
+
+	if got := e.EndUser(header, anthropic); got != "" {
+		t.Fatalf("EndUser without attribution = %q, want none", got)
+	}
+	e.SetEndUserAttribution(config.EndUserAttribution{Header: "X-User-Id", FromBody: true})
+	if got := e.EndUser(header, anthropic); got != "alice" {
+		t.Fatalf("EndUser = %q, want the header to win", got)
+	}
+	if got := e.EndUser(http.Header{}, anthropic); got != "bob" {
+		t.Fatalf("EndUser = %q, want Anthropic metadata.user_id", got)
+	}
+	if got := e.EndUser(http.Header{}, []byte(`{"model":"gpt-5","user":"carol"}`)); got != "carol" {
+		t.Fatalf("EndUser = %q, want OpenAI user", got)
+	}
+}
+
+func TestEnforcer_CheckEndUserAccess(t *testing.T) {
+	e := NewEnforcer([]config.APIKeyLimit{{
+		APIKey:               "shared-end-user-key",
+		EndUserMonthlyQuotas: map[string]int{"gpt-*": 2},
+		RequireEndUser:       true,
+	}})
+	if err := e.CheckEndUserAccess("shared-end-user-key", "", "gpt-5"); err == nil {
+		t.Fatal("expected requests without an end user to be rejected")
+	}
+
+	stats := usage.GetRequestStatistics()
+	for i := 0; i < 2; i++ {
+		stats.Record(context.Background(), coreusage.Record{APIKey: "shared-end-user-key", EndUser: "alice", Model: "gpt-5", RequestedAt: time.Now()})
+	}
+	if err := e.CheckEndUserAccess("shared-end-user-key", "alice", "gpt-5-mini"); err == nil {
+		t.Fatal("expected alice to be over her quota")
+	}
+	if err := e.CheckEndUserAccess("shared-end-user-key", "bob", "gpt-5"); err != nil {
+		t.Fatalf("bob should have his own quota: %v", err)
+	}
+	if err := e.CheckEndUserAccess("other-key", "", "gpt-5"); err != nil {
+		t.Fatalf("keys without limits are unrestricted: %v", err)
+	}
+}
@@ -13,8 +13,9 @@ import (
 
 // Enforcer validates API key access restrictions and monthly quotas.
 type Enforcer struct {
-	mu     sync.RWMutex
-	limits []config.APIKeyLimit
+	mu          sync.RWMutex
+	limits      []config.APIKeyLimit
+	attribution config.EndUserAttribution
 }
 
 // NewEnforcer creates a new limit enforcer with the given API key limits.
@@ -321,14 +321,24 @@ type translatedPayload struct {
 }
 
 func (e *AIStudioExecutor) translateRequest(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) ([]byte, translatedPayload, error) {
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("gemini")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
+	built := e.buildRequestBody(req, opts, stream)
+	metadataAction := "generateContent"
+	if req.Metadata != nil {
+		if action, _ := req.Metadata["action"].(string); action == "countTokens" {
+			metadataAction = action
+		}
 	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, stream)
-	payload := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), stream)
+	action := metadataAction
+	if stream && action != "countTokens" {
+		action = "streamGenerateContent"
+	}
+	return built.body, translatedPayload{payload: built.body, action: action, toFormat: built.format}, nil
+}
+
+// buildRequestBody prepares the Gemini request body sent through the AI Studio websocket relay.
+func (e *AIStudioExecutor) buildRequestBody(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) upstreamBody {
+	to := sdktranslator.FromString("gemini")
+	payload, originalTranslated := translateForUpstream(req, opts, to, req.Model, stream)
 	payload = ApplyThinkingMetadata(payload, req.Metadata, req.Model)
 	payload = util.ApplyGemini3ThinkingLevelFromMetadata(req.Model, req.Metadata, payload)
 	payload = util.ApplyDefaultThinkingIfNeeded(req.Model, payload)
@@ -336,22 +346,12 @@ func (e *AIStudioExecutor) translateRequest(req cliproxyexecutor.Request, opts c
 	payload = util.NormalizeGeminiThinkingBudget(req.Model, payload, true)
 	payload = util.StripThinkingConfigIfUnsupported(req.Model, payload)
 	payload = fixGeminiImageAspectRatio(req.Model, payload)
-	payload = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", payload, originalTranslated)
+	payload, rules := applyPayloadConfigTraced(e.cfg, req.Model, to.String(), "", payload, originalTranslated)
 	payload, _ = sjson.DeleteBytes(payload, "generationConfig.maxOutputTokens")
 	payload, _ = sjson.DeleteBytes(payload, "generationConfig.responseMimeType")
 	payload, _ = sjson.DeleteBytes(payload, "generationConfig.responseJsonSchema")
-	metadataAction := "generateContent"
-	if req.Metadata != nil {
-		if action, _ := req.Metadata["action"].(string); action == "countTokens" {
-			metadataAction = action
-		}
-	}
-	action := metadataAction
-	if stream && action != "countTokens" {
-		action = "streamGenerateContent"
-	}
 	payload, _ = sjson.DeleteBytes(payload, "session_id")
-	return payload, translatedPayload{payload: payload, action: action, toFormat: to}, nil
+	return upstreamBody{format: to, model: req.Model, body: payload, rules: rules}
 }
 
 func (e *AIStudioExecutor) buildEndpoint(model, action, alt string) string {
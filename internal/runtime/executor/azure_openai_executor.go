@@ -0,0 +1,359 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/auth/azure"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// azureTokenCache holds Entra ID tokens across executor instances so configuration reloads
+// do not force a new token request.
+var azureTokenCache = azure.NewTokenCache()
+
+// AzureOpenAIExecutor calls Azure OpenAI resources. Chat requests are sent to the deployment
+// mapped for the requested model; OpenAI Responses requests use the resource-level Responses
+// API with the deployment as the model. Requests authenticate with the api-key header or an
+// Entra ID bearer token.
+type AzureOpenAIExecutor struct {
+	cfg *config.Config
+}
+
+// NewAzureOpenAIExecutor creates an executor for resources from azure-openai.
+func NewAzureOpenAIExecutor(cfg *config.Config) *AzureOpenAIExecutor {
+	return &AzureOpenAIExecutor{cfg: cfg}
+}
+
+func (e *AzureOpenAIExecutor) Identifier() string { return "azure-openai" }
+
+func (e *AzureOpenAIExecutor) PrepareRequest(_ *http.Request, _ *cliproxyauth.Auth) error { return nil }
+
+func (e *AzureOpenAIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	built, err := e.buildRequestBody(auth, req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+	to, body := built.format, built.body
+	httpResp, err := e.send(ctx, auth, e.requestURL(auth, req.Model, to), body, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+	if to == sdktranslator.FormatOpenAIResponse {
+		if detail, ok := parseResponsesUsage(data); ok {
+			reporter.publish(ctx, detail)
+		}
+	} else {
+		reporter.publish(ctx, parseOpenAIUsage(data))
+	}
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *AzureOpenAIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	built, err := e.buildRequestBody(auth, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	to, body := built.format, built.body
+	httpResp, err := e.send(ctx, auth, e.requestURL(auth, req.Model, to), body, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("azure openai executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if len(line) == 0 {
+				continue
+			}
+			if to == sdktranslator.FormatOpenAIResponse {
+				// Responses events are already in the client's format; forward them as-is.
+				if bytes.HasPrefix(line, dataTag) {
+					data := bytes.TrimSpace(line[5:])
+					if gjson.GetBytes(data, "type").String() == "response.completed" {
+						if detail, ok := parseCodexUsage(data); ok {
+							reporter.publish(ctx, detail)
+						}
+					}
+				}
+				out <- cliproxyexecutor.StreamChunk{Payload: bytes.Clone(line)}
+				continue
+			}
+			if detail, ok := parseOpenAIStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return stream, nil
+}
+
+// CountTokens estimates tokens locally; Azure OpenAI has no token counting endpoint.
+func (e *AzureOpenAIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
+
+	enc, err := tokenizerForModel(req.Model)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+}
+
+// Refresh is a no-op; Entra ID tokens are fetched and cached per request.
+func (e *AzureOpenAIExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+// Probe checks the credential by listing the models available to the resource.
+func (e *AzureOpenAIExecutor) Probe(ctx context.Context, auth *cliproxyauth.Auth) error {
+	baseURL, apiVersion := azureOpenAIEndpoint(auth)
+	if baseURL == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing azure openai endpoint"}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/openai/models?api-version="+url.QueryEscape(apiVersion), nil)
+	if err != nil {
+		return err
+	}
+	if err = e.authorize(ctx, httpReq, auth); err != nil {
+		return err
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, auth.Attributes)
+	return doProbeRequest(e.cfg, auth, httpReq)
+}
+
+// buildRequestBody translates the request for the endpoint it will be sent to: Responses requests
+// stay in the Responses format, everything else becomes an OpenAI chat completion.
+func (e *AzureOpenAIExecutor) buildRequestBody(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (upstreamBody, error) {
+	to := sdktranslator.FromString("openai")
+	effortField := "reasoning_effort"
+	if opts.SourceFormat == sdktranslator.FormatOpenAIResponse {
+		to = sdktranslator.FormatOpenAIResponse
+		effortField = "reasoning.effort"
+	}
+	deployment := e.resolveDeployment(req.Model, auth)
+	body, originalTranslated := translateForUpstream(req, opts, to, req.Model, stream)
+	body, rules := applyPayloadConfigTraced(e.cfg, req.Model, to.String(), "", body, originalTranslated)
+	body = ApplyReasoningEffortMetadata(body, req.Metadata, req.Model, effortField, true)
+	body = NormalizeThinkingConfig(body, req.Model, true)
+	if errValidate := ValidateThinkingConfig(body, req.Model); errValidate != nil {
+		return upstreamBody{format: to, model: deployment, body: body, rules: rules}, errValidate
+	}
+	body, _ = sjson.SetBytes(body, "stream", stream)
+	if to == sdktranslator.FormatOpenAIResponse {
+		// The Responses API addresses deployments through the model field.
+		body, _ = sjson.SetBytes(body, "model", deployment)
+	} else if stream {
+		// Streamed chat completions only report usage when asked to.
+		body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
+	}
+	return upstreamBody{format: to, model: deployment, body: body, rules: rules}, nil
+}
+
+// requestURL returns the deployment chat completions URL or, for Responses requests, the
+// resource-level Responses URL.
+func (e *AzureOpenAIExecutor) requestURL(auth *cliproxyauth.Auth, model string, to sdktranslator.Format) string {
+	baseURL, apiVersion := azureOpenAIEndpoint(auth)
+	query := "?api-version=" + url.QueryEscape(apiVersion)
+	if to == sdktranslator.FormatOpenAIResponse {
+		return baseURL + "/openai/responses" + query
+	}
+	deployment := e.resolveDeployment(model, auth)
+	return baseURL + "/openai/deployments/" + url.PathEscape(deployment) + "/chat/completions" + query
+}
+
+// send issues the request and returns the response when it succeeded.
+func (e *AzureOpenAIExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, requestURL string, body []byte, stream bool) (*http.Response, error) {
+	if baseURL, _ := azureOpenAIEndpoint(auth); baseURL == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing azure openai endpoint"}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	if err = e.authorize(ctx, httpReq, auth); err != nil {
+		return nil, err
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, auth.Attributes)
+
+	authType, authValue := auth.AccountInfo()
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       requestURL,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    auth.ID,
+		AuthLabel: auth.Label,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+// authorize sets the api-key header or, for Entra ID app registrations, a bearer token.
+func (e *AzureOpenAIExecutor) authorize(ctx context.Context, httpReq *http.Request, auth *cliproxyauth.Auth) error {
+	if auth == nil || auth.Attributes == nil {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing azure openai credentials"}
+	}
+	if apiKey := strings.TrimSpace(auth.Attributes["api_key"]); apiKey != "" {
+		httpReq.Header.Set("api-key", apiKey)
+		return nil
+	}
+	creds := azure.ClientCredentials{
+		TenantID:     strings.TrimSpace(auth.Attributes["tenant_id"]),
+		ClientID:     strings.TrimSpace(auth.Attributes["client_id"]),
+		ClientSecret: strings.TrimSpace(auth.Attributes["client_secret"]),
+	}
+	if creds.TenantID == "" || creds.ClientID == "" || creds.ClientSecret == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing azure openai credentials"}
+	}
+	token, err := azureTokenCache.Token(creds, func() (azure.Token, error) {
+		client := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+		return azure.FetchToken(ctx, client, azure.TokenEndpoint(creds.TenantID), creds, azure.CognitiveServicesScope)
+	})
+	if err != nil {
+		var tokenErr *azure.TokenError
+		if errors.As(err, &tokenErr) && tokenErr.StatusCode >= 400 && tokenErr.StatusCode < 500 {
+			return statusErr{code: http.StatusUnauthorized, msg: err.Error()}
+		}
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func azureOpenAIEndpoint(a *cliproxyauth.Auth) (baseURL, apiVersion string) {
+	if a == nil || a.Attributes == nil {
+		return "", config.DefaultAzureOpenAIAPIVersion
+	}
+	baseURL = strings.TrimRight(strings.TrimSpace(a.Attributes["base_url"]), "/")
+	apiVersion = strings.TrimSpace(a.Attributes["api_version"])
+	if apiVersion == "" {
+		apiVersion = config.DefaultAzureOpenAIAPIVersion
+	}
+	return baseURL, apiVersion
+}
+
+// resolveDeployment maps a client model name to its configured deployment. Unknown names are
+// used as the deployment name directly.
+func (e *AzureOpenAIExecutor) resolveDeployment(model string, auth *cliproxyauth.Auth) string {
+	trimmed := strings.TrimSpace(model)
+	entry := e.resolveAzureOpenAIConfig(auth)
+	if entry == nil || trimmed == "" {
+		return trimmed
+	}
+	normalized, _ := util.NormalizeThinkingModel(trimmed)
+	for _, candidate := range []string{trimmed, normalized} {
+		for i := range entry.Models {
+			if strings.EqualFold(entry.Models[i].Name, candidate) {
+				return entry.Models[i].Deployment
+			}
+		}
+	}
+	return trimmed
+}
+
+func (e *AzureOpenAIExecutor) resolveAzureOpenAIConfig(auth *cliproxyauth.Auth) *config.AzureOpenAIKey {
+	if auth == nil || auth.Attributes == nil || e.cfg == nil {
+		return nil
+	}
+	endpoint := strings.TrimSpace(auth.Attributes["base_url"])
+	apiKey := strings.TrimSpace(auth.Attributes["api_key"])
+	clientID := strings.TrimSpace(auth.Attributes["client_id"])
+	for i := range e.cfg.AzureOpenAI {
+		entry := &e.cfg.AzureOpenAI[i]
+		if strings.EqualFold(entry.Endpoint, endpoint) && entry.APIKey == apiKey && (apiKey != "" || entry.ClientID == clientID) {
+			return entry
+		}
+	}
+	return nil
+}
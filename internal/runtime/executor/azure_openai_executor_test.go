@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/auth/azure"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// azureRequest is what the stand-in Azure resource saw.
+type azureRequest struct {
+	path, apiVersion, apiKey, authorization string
+	body                                    []byte
+}
+
+func newAzureServer(t *testing.T, response string) (*httptest.Server, *azureRequest) {
+	t.Helper()
+	seen := &azureRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen.path = r.URL.Path
+		seen.apiVersion = r.URL.Query().Get("api-version")
+		seen.apiKey = r.Header.Get("api-key")
+		seen.authorization = r.Header.Get("Authorization")
+		seen.body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, seen
+}
+
+const azureChatResponse = `{"id":"c1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`
+
+func TestAzureOpenAIExecutor_ChatUsesMappedDeploymentAndAPIKey(t *testing.T) {
+	srv, seen := newAzureServer(t, azureChatResponse)
+	cfg := &config.Config{AzureOpenAI: []config.AzureOpenAIKey{{
+		Endpoint: srv.URL,
+		APIKey:   "resource-key",
+		Models:   []config.AzureOpenAIModel{{Name: "gpt-4o", Deployment: "prod-gpt4o"}},
+	}}}
+	auth := &cliproxyauth.Auth{ID: "azure-1", Provider: "azure-openai", Attributes: map[string]string{
+		"base_url": srv.URL, "api_key": "resource-key", "api_version": "2024-10-21",
+	}}
+	payload := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+
+	_, err := NewAzureOpenAIExecutor(cfg).Execute(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "gpt-4o", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if seen.path != "/openai/deployments/prod-gpt4o/chat/completions" || seen.apiVersion != "2024-10-21" {
+		t.Fatalf("request = %s?api-version=%s, want the mapped deployment's chat completions path", seen.path, seen.apiVersion)
+	}
+	if seen.apiKey != "resource-key" || seen.authorization != "" {
+		t.Fatalf("api-key = %q, Authorization = %q, want only the api-key header", seen.apiKey, seen.authorization)
+	}
+}
+
+func TestAzureOpenAIExecutor_UnmappedModelIsTheDeployment(t *testing.T) {
+	e := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": "https://r.openai.azure.com/", "api_key": "k"}}
+	got := e.requestURL(auth, "my-deployment", sdktranslator.FromString("openai"))
+	want := "https://r.openai.azure.com/openai/deployments/my-deployment/chat/completions?api-version=" + config.DefaultAzureOpenAIAPIVersion
+	if got != want {
+		t.Fatalf("requestURL() = %q, want %q", got, want)
+	}
+}
+
+func TestAzureOpenAIExecutor_EntraBearerToken(t *testing.T) {
+	srv, seen := newAzureServer(t, azureChatResponse)
+	creds := azure.ClientCredentials{TenantID: "tenant-x", ClientID: "client-x", ClientSecret: "secret-x"}
+	// Prime the shared cache so the test never reaches login.microsoftonline.com.
+	if _, err := azureTokenCache.Token(creds, func() (azure.Token, error) {
+		return azure.Token{AccessToken: "entra-token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	auth := &cliproxyauth.Auth{ID: "azure-entra", Provider: "azure-openai", Attributes: map[string]string{
+		"base_url": srv.URL, "tenant_id": creds.TenantID, "client_id": creds.ClientID, "client_secret": creds.ClientSecret,
+	}}
+	payload := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+
+	_, err := NewAzureOpenAIExecutor(&config.Config{}).Execute(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "gpt-4o", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if seen.authorization != "Bearer entra-token" || seen.apiKey != "" {
+		t.Fatalf("Authorization = %q, api-key = %q, want only the Entra bearer token", seen.authorization, seen.apiKey)
+	}
+}
+
+func TestAzureOpenAIExecutor_ResponsesRouteCarriesDeploymentAsModel(t *testing.T) {
+	srv, seen := newAzureServer(t, `{"id":"r1","object":"response","status":"completed","output":[],"usage":{"input_tokens":3,"output_tokens":1,"total_tokens":4}}`)
+	cfg := &config.Config{AzureOpenAI: []config.AzureOpenAIKey{{
+		Endpoint: srv.URL,
+		APIKey:   "resource-key",
+		Models:   []config.AzureOpenAIModel{{Name: "gpt-5", Deployment: "prod-gpt5"}},
+	}}}
+	auth := &cliproxyauth.Auth{ID: "azure-2", Provider: "azure-openai", Attributes: map[string]string{
+		"base_url": srv.URL, "api_key": "resource-key",
+	}}
+	payload := []byte(`{"model":"gpt-5","input":"hi"}`)
+
+	_, err := NewAzureOpenAIExecutor(cfg).Execute(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "gpt-5", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAIResponse, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if seen.path != "/openai/responses" || seen.apiVersion != config.DefaultAzureOpenAIAPIVersion {
+		t.Fatalf("request = %s?api-version=%s, want the resource-level Responses route", seen.path, seen.apiVersion)
+	}
+	if got := gjson.GetBytes(seen.body, "model").String(); got != "prod-gpt5" {
+		t.Fatalf("body model = %q, want the deployment %q", got, "prod-gpt5")
+	}
+}
+
+func TestAzureOpenAIExecutor_StreamedChatRequestsUsage(t *testing.T) {
+	e := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": "https://r.openai.azure.com", "api_key": "k"}}
+	payload := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	built, err := e.buildRequestBody(auth, cliproxyexecutor.Request{Model: "gpt-4o", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload}, true)
+	if err != nil {
+		t.Fatalf("buildRequestBody() error = %v", err)
+	}
+	if !gjson.GetBytes(built.body, "stream_options.include_usage").Bool() {
+		t.Fatalf("body = %s, want stream_options.include_usage for a streamed chat completion", built.body)
+	}
+}
@@ -0,0 +1,374 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/auth/bedrock"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const bedrockAnthropicVersion = "bedrock-2023-05-31"
+
+// BedrockExecutor calls Anthropic models on Amazon Bedrock through the InvokeModel APIs.
+// Requests are built in Claude messages format and signed with SigV4; streamed responses
+// are decoded from the AWS event-stream framing back into Claude SSE events so the claude
+// translators serve every inbound format.
+type BedrockExecutor struct {
+	cfg *config.Config
+}
+
+// NewBedrockExecutor creates an executor for Bedrock credentials from bedrock-api-key.
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor { return &BedrockExecutor{cfg: cfg} }
+
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+func (e *BedrockExecutor) PrepareRequest(_ *http.Request, _ *cliproxyauth.Auth) error { return nil }
+
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	// As with the Claude executor, other formats are translated from the streamed events.
+	stream := from != to
+	body := e.buildRequestBody(auth, req, opts, stream).body
+	action := "invoke"
+	if stream {
+		action = "invoke-with-response-stream"
+	}
+	httpResp, err := e.send(ctx, auth, req.Model, action, body)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+	}()
+
+	var data []byte
+	if stream {
+		var buf bytes.Buffer
+		err = readBedrockEventStream(httpResp.Body, func(line []byte) {
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		})
+		data = buf.Bytes()
+	} else {
+		data, err = io.ReadAll(httpResp.Body)
+		if err == nil {
+			appendAPIResponseChunk(ctx, e.cfg, data)
+			reporter.publish(ctx, parseClaudeUsage(data))
+		}
+	}
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body := e.buildRequestBody(auth, req, opts, true).body
+	httpResp, err := e.send(ctx, auth, req.Model, "invoke-with-response-stream", body)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("bedrock executor: close response body error: %v", errClose)
+			}
+		}()
+		var param any
+		errRead := readBedrockEventStream(httpResp.Body, func(line []byte) {
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseClaudeStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			if from == to {
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				return
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		})
+		if errRead != nil {
+			recordAPIResponseError(ctx, e.cfg, errRead)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errRead}
+		}
+	}()
+	return stream, nil
+}
+
+// CountTokens estimates tokens locally; Bedrock has no token counting endpoint for every model.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
+
+	enc, err := tokenizerForModel(req.Model)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+}
+
+// Refresh is a no-op; Bedrock credentials are static key pairs from the configuration.
+func (e *BedrockExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+// buildRequestBody translates the request to a Bedrock Anthropic messages body. The model and
+// stream flags travel in the URL, and betas move into the anthropic_beta field.
+func (e *BedrockExecutor) buildRequestBody(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) upstreamBody {
+	to := sdktranslator.FromString("claude")
+	body, originalTranslated := translateForUpstream(req, opts, to, req.Model, stream)
+	if budget, ok := util.ResolveClaudeThinkingConfig(req.Model, req.Metadata); ok {
+		body = util.ApplyClaudeThinkingConfig(body, budget)
+	}
+	body, rules := applyPayloadConfigTraced(e.cfg, req.Model, to.String(), "", body, originalTranslated)
+	body = finalizeBedrockBody(req.Model, body)
+	return upstreamBody{format: to, model: e.resolveModelID(req.Model, auth), body: body, rules: rules}
+}
+
+// finalizeBedrockBody applies the Anthropic thinking constraints and reshapes a Claude
+// messages body for InvokeModel.
+func finalizeBedrockBody(model string, body []byte) []byte {
+	body = disableThinkingIfToolChoiceForced(body)
+	body = ensureMaxTokensForThinking(model, body)
+
+	var betas []string
+	betas, body = extractAndRemoveBetas(body)
+	if len(betas) > 0 {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	// Bedrock rejects keys it does not know, including the end-user metadata block.
+	for _, key := range []string{"model", "stream", "metadata"} {
+		body, _ = sjson.DeleteBytes(body, key)
+	}
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	return body
+}
+
+// send signs and issues an InvokeModel call and returns the response when it succeeded.
+func (e *BedrockExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, model, action string, body []byte) (*http.Response, error) {
+	creds, region, baseURL := bedrockCreds(auth)
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" || region == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing bedrock credentials"}
+	}
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region)
+	}
+	modelID := e.resolveModelID(model, auth)
+	url := fmt.Sprintf("%s/model/%s/%s", strings.TrimRight(baseURL, "/"), bedrock.EscapePathSegment(modelID), action)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if action == "invoke" {
+		httpReq.Header.Set("Accept", "application/json")
+	} else {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	bedrock.SignRequest(httpReq, body, creds, region, bedrock.ServiceName, time.Now())
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+// readBedrockEventStream decodes an InvokeModelWithResponseStream body and passes each
+// resulting Claude SSE line to emit: an "event:" line, a "data:" line and a blank separator
+// per event. Stream exceptions are returned as status errors.
+func readBedrockEventStream(body io.Reader, emit func(line []byte)) error {
+	reader := bedrock.NewEventStreamReader(body)
+	for {
+		msg, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch msg.Headers[":message-type"] {
+		case "exception", "error":
+			kind := msg.Headers[":exception-type"]
+			if kind == "" {
+				kind = msg.Headers[":error-code"]
+			}
+			message := gjson.GetBytes(msg.Payload, "message").String()
+			if message == "" {
+				message = string(msg.Payload)
+			}
+			return statusErr{code: bedrockExceptionStatus(kind), msg: fmt.Sprintf("bedrock %s: %s", kind, message)}
+		}
+		if msg.Headers[":event-type"] != "chunk" {
+			continue
+		}
+		event, err := base64.StdEncoding.DecodeString(gjson.GetBytes(msg.Payload, "bytes").String())
+		if err != nil {
+			return fmt.Errorf("bedrock executor: decode stream chunk: %w", err)
+		}
+		if len(event) == 0 {
+			continue
+		}
+		emit([]byte("event: " + gjson.GetBytes(event, "type").String()))
+		emit(append([]byte("data: "), event...))
+		emit([]byte{})
+	}
+}
+
+// bedrockExceptionStatus maps event-stream exception types to the HTTP status Bedrock uses
+// for the same error outside a stream.
+func bedrockExceptionStatus(kind string) int {
+	switch kind {
+	case "throttlingException":
+		return http.StatusTooManyRequests
+	case "validationException":
+		return http.StatusBadRequest
+	case "accessDeniedException":
+		return http.StatusForbidden
+	case "modelTimeoutException":
+		return http.StatusRequestTimeout
+	case "modelStreamErrorException":
+		return http.StatusFailedDependency
+	case "serviceUnavailableException":
+		return http.StatusServiceUnavailable
+	case "internalServerException":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func bedrockCreds(a *cliproxyauth.Auth) (creds bedrock.Credentials, region, baseURL string) {
+	if a == nil || a.Attributes == nil {
+		return
+	}
+	creds = bedrock.Credentials{
+		AccessKeyID:     strings.TrimSpace(a.Attributes["access_key_id"]),
+		SecretAccessKey: strings.TrimSpace(a.Attributes["secret_access_key"]),
+		SessionToken:    strings.TrimSpace(a.Attributes["session_token"]),
+	}
+	region = strings.TrimSpace(a.Attributes["region"])
+	baseURL = strings.TrimSpace(a.Attributes["base_url"])
+	return
+}
+
+// resolveModelID maps a client model alias to the configured Bedrock model ID. Unknown
+// names are passed through so Bedrock model IDs can also be requested directly.
+func (e *BedrockExecutor) resolveModelID(alias string, auth *cliproxyauth.Auth) string {
+	trimmed := strings.TrimSpace(alias)
+	entry := e.resolveBedrockConfig(auth)
+	if entry == nil || trimmed == "" {
+		return trimmed
+	}
+	normalized, _ := util.NormalizeThinkingModel(trimmed)
+	for _, candidate := range []string{trimmed, normalized} {
+		for i := range entry.Models {
+			model := entry.Models[i]
+			if strings.EqualFold(model.Alias, candidate) || strings.EqualFold(model.Name, candidate) {
+				return model.Name
+			}
+		}
+	}
+	return trimmed
+}
+
+func (e *BedrockExecutor) resolveBedrockConfig(auth *cliproxyauth.Auth) *config.BedrockKey {
+	if auth == nil || auth.Attributes == nil || e.cfg == nil {
+		return nil
+	}
+	accessKey := strings.TrimSpace(auth.Attributes["access_key_id"])
+	region := strings.TrimSpace(auth.Attributes["region"])
+	baseURL := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range e.cfg.BedrockKey {
+		entry := &e.cfg.BedrockKey[i]
+		if entry.AccessKeyID == accessKey && strings.EqualFold(entry.Region, region) && strings.EqualFold(entry.Endpoint, baseURL) {
+			return entry
+		}
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 
 	copilotauth "github.com/giofahreza/AIProxyAPI/internal/auth/copilot"
 	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
 	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
 	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
 	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
@@ -39,28 +40,14 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 		return resp, fmt.Errorf("failed to get valid Copilot token: %w", err)
 	}
 
-	if baseURL == "" {
-		baseURL = "https://api.individual.githubcopilot.com"
-	}
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("openai")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
-	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
-	body = ApplyReasoningEffortMetadata(body, req.Metadata, req.Model, "reasoning_effort", false)
-	body, _ = sjson.SetBytes(body, "model", req.Model)
-	body = NormalizeThinkingConfig(body, req.Model, false)
-	if errValidate := ValidateThinkingConfig(body, req.Model); errValidate != nil {
+	built, errValidate := e.buildRequestBody(req, opts, false)
+	if errValidate != nil {
 		return resp, errValidate
 	}
-	body = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", body, originalTranslated)
-	body = applyCopilotBodyOptimizations(body)
+	from, to, body := opts.SourceFormat, built.format, built.body
 
 	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
@@ -128,30 +115,14 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 		return nil, fmt.Errorf("failed to get valid Copilot token: %w", err)
 	}
 
-	if baseURL == "" {
-		baseURL = "https://api.individual.githubcopilot.com"
-	}
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("openai")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
-	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
-
-	body = ApplyReasoningEffortMetadata(body, req.Metadata, req.Model, "reasoning_effort", false)
-	body, _ = sjson.SetBytes(body, "model", req.Model)
-	body = NormalizeThinkingConfig(body, req.Model, false)
-	if errValidate := ValidateThinkingConfig(body, req.Model); errValidate != nil {
+	built, errValidate := e.buildRequestBody(req, opts, true)
+	if errValidate != nil {
 		return nil, errValidate
 	}
-	body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
-	body = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", body, originalTranslated)
-	body = applyCopilotBodyOptimizations(body)
+	from, to, body := opts.SourceFormat, built.format, built.body
 
 	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
@@ -240,35 +211,17 @@ func (e *CopilotExecutor) ensureValidToken(ctx context.Context, auth *cliproxyau
 		return "", "", fmt.Errorf("auth metadata is required")
 	}
 
-	// Get stored tokens
 	githubToken, _ := auth.Metadata["github_token"].(string)
-	copilotToken, _ := auth.Metadata["copilot_token"].(string)
-	copilotExpire, _ := auth.Metadata["copilot_expire"].(string)
-	copilotAPIBase, _ := auth.Metadata["copilot_api_base"].(string)
-
 	if githubToken == "" {
 		return "", "", fmt.Errorf("github_token not found in metadata")
 	}
 
 	// Check if Copilot token needs refresh
-	needsRefresh := false
-	if copilotToken == "" {
-		needsRefresh = true
-	} else if copilotExpire != "" {
-		expireTime, parseErr := time.Parse(time.RFC3339, copilotExpire)
-		if parseErr != nil {
-			needsRefresh = true
-		} else {
-			// Refresh if token expires in less than 5 minutes
-			if time.Until(expireTime) < 5*time.Minute {
-				needsRefresh = true
-			}
-		}
-	}
-
-	if needsRefresh {
+	copilotToken, copilotAPIBase, fresh := storedCopilotToken(auth)
+	endpoints := copilotauth.EndpointsFromMetadata(auth.Metadata)
+	if !fresh {
 		log.Infof("Copilot token expired or missing, refreshing using GitHub token")
-		copilotAuth := copilotauth.NewCopilotAuth(e.cfg)
+		copilotAuth := copilotauth.NewCopilotAuthWithEndpoints(e.cfg, endpoints)
 		tokenData, refreshErr := copilotAuth.RefreshCopilotToken(ctx, githubToken)
 		if refreshErr != nil {
 			return "", "", fmt.Errorf("failed to refresh Copilot token: %w", refreshErr)
@@ -289,7 +242,103 @@ func (e *CopilotExecutor) ensureValidToken(ctx context.Context, auth *cliproxyau
 		log.Infof("Copilot token refreshed successfully (expires: %s, sku: %s)", tokenData.CopilotExpire, tokenData.SKU)
 	}
 
-	return copilotToken, copilotAPIBase, nil
+	return copilotToken, endpoints.APIBase(copilotAPIBase), nil
+}
+
+// storedCopilotToken returns the Copilot token and API base held in the auth metadata, and
+// whether the token stays valid for at least five more minutes.
+func storedCopilotToken(auth *cliproxyauth.Auth) (token, apiBase string, fresh bool) {
+	if auth == nil || auth.Metadata == nil {
+		return "", "", false
+	}
+	token, _ = auth.Metadata["copilot_token"].(string)
+	apiBase, _ = auth.Metadata["copilot_api_base"].(string)
+	if token == "" {
+		return "", apiBase, false
+	}
+	expire, _ := auth.Metadata["copilot_expire"].(string)
+	if expire == "" {
+		return token, apiBase, true
+	}
+	expireTime, err := time.Parse(time.RFC3339, expire)
+	if err != nil {
+		return token, apiBase, false
+	}
+	return token, apiBase, time.Until(expireTime) >= 5*time.Minute
+}
+
+// CopilotTokenNeedsRefresh reports whether the auth's Copilot token is missing or about to
+// expire, so it must be refreshed before FetchCopilotModels can list models.
+func CopilotTokenNeedsRefresh(auth *cliproxyauth.Auth) bool {
+	_, _, fresh := storedCopilotToken(auth)
+	return !fresh
+}
+
+// FetchCopilotModels lists the chat models the credential's Copilot plan offers through the
+// Copilot /models endpoint. It only reads the stored token and never refreshes it, since the
+// auth is not persisted here; callers refresh through the auth manager first. It returns nil
+// when the list cannot be fetched so callers can fall back to the built-in model list.
+func FetchCopilotModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	token, apiBase, fresh := storedCopilotToken(auth)
+	if !fresh {
+		log.Debugf("copilot executor: model listing skipped: copilot token missing or expiring")
+		return nil
+	}
+	baseURL := copilotauth.EndpointsFromMetadata(auth.Metadata).APIBase(apiBase)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return nil
+	}
+	applyCopilotHeaders(httpReq, token)
+	httpResp, err := newProxyAwareHTTPClient(ctx, cfg, auth, 0).Do(httpReq)
+	if err != nil {
+		log.Debugf("copilot executor: model listing failed: %v", err)
+		return nil
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("copilot executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil || httpResp.StatusCode != http.StatusOK || !gjson.GetBytes(body, "data").IsArray() {
+		log.Debugf("copilot executor: model listing failed: status %d, error %v", httpResp.StatusCode, err)
+		return nil
+	}
+
+	now := time.Now().Unix()
+	seen := make(map[string]struct{})
+	models := make([]*registry.ModelInfo, 0)
+	gjson.GetBytes(body, "data").ForEach(func(_, model gjson.Result) bool {
+		id := model.Get("id").String()
+		if id == "" || model.Get("capabilities.type").String() != "chat" || model.Get("policy.state").String() == "disabled" {
+			return true
+		}
+		if _, dup := seen[id]; dup {
+			return true
+		}
+		seen[id] = struct{}{}
+		displayName := model.Get("name").String()
+		if displayName == "" {
+			displayName = id
+		}
+		info := &registry.ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             now,
+			OwnedBy:             "copilot",
+			Type:                "copilot",
+			DisplayName:         displayName,
+			ContextLength:       int(model.Get("capabilities.limits.max_context_window_tokens").Int()),
+			MaxCompletionTokens: int(model.Get("capabilities.limits.max_output_tokens").Int()),
+		}
+		if vendor := model.Get("vendor").String(); vendor != "" {
+			info.Description = displayName + " by " + vendor + " (via Copilot)"
+		}
+		models = append(models, info)
+		return true
+	})
+	return models
 }
 
 // applyCopilotHeaders adds required GitHub Copilot headers
@@ -412,7 +461,7 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 	}
 
 	log.Infof("copilot executor: refreshing Copilot token using GitHub token")
-	copilotAuth := copilotauth.NewCopilotAuth(e.cfg)
+	copilotAuth := copilotauth.NewCopilotAuthWithEndpoints(e.cfg, copilotauth.EndpointsFromMetadata(auth.Metadata))
 	tokenData, err := copilotAuth.RefreshCopilotToken(ctx, githubToken)
 	if err != nil {
 		return nil, fmt.Errorf("copilot executor: failed to refresh Copilot token: %w", err)
@@ -434,6 +483,25 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 	return auth, nil
 }
 
+// buildRequestBody prepares the OpenAI chat completions body sent to Copilot. The body built so
+// far is returned with thinking validation errors.
+func (e *CopilotExecutor) buildRequestBody(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (upstreamBody, error) {
+	to := sdktranslator.FromString("openai")
+	body, originalTranslated := translateForUpstream(req, opts, to, req.Model, stream)
+	body = ApplyReasoningEffortMetadata(body, req.Metadata, req.Model, "reasoning_effort", false)
+	body, _ = sjson.SetBytes(body, "model", req.Model)
+	body = NormalizeThinkingConfig(body, req.Model, false)
+	if errValidate := ValidateThinkingConfig(body, req.Model); errValidate != nil {
+		return upstreamBody{format: to, model: req.Model, body: body}, errValidate
+	}
+	if stream {
+		body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
+	}
+	body, rules := applyPayloadConfigTraced(e.cfg, req.Model, to.String(), "", body, originalTranslated)
+	body = applyCopilotBodyOptimizations(body)
+	return upstreamBody{format: to, model: req.Model, body: body, rules: rules}, nil
+}
+
 // CountTokens returns the token count for the given request
 func (e *CopilotExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	from := opts.SourceFormat
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+)
+
+const copilotModelsResponse = `{"data":[
+	{"id":"gpt-4.1","name":"GPT-4.1","vendor":"OpenAI","capabilities":{"type":"chat","limits":{"max_context_window_tokens":128000,"max_output_tokens":16384}},"policy":{"state":"enabled"}},
+	{"id":"claude-sonnet-4","capabilities":{"type":"chat"}},
+	{"id":"gpt-4.1","name":"GPT-4.1 duplicate","capabilities":{"type":"chat"}},
+	{"id":"o3","capabilities":{"type":"chat"},"policy":{"state":"disabled"}},
+	{"id":"text-embedding-3-small","capabilities":{"type":"embeddings"}}
+]}`
+
+func newCopilotModelsServer(t *testing.T) (*httptest.Server, *atomic.Int32, *atomic.Value) {
+	t.Helper()
+	var calls atomic.Int32
+	var authorization atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		authorization.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, copilotModelsResponse)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &authorization
+}
+
+func TestFetchCopilotModels_ListsChatModelsFromEnterpriseBase(t *testing.T) {
+	srv, calls, authorization := newCopilotModelsServer(t)
+	auth := &cliproxyauth.Auth{ID: "copilot-ent", Provider: "copilot", Metadata: map[string]any{
+		"github_token":     "gho_x",
+		"copilot_token":    "tid=enterprise",
+		"copilot_expire":   time.Now().Add(time.Hour).Format(time.RFC3339),
+		"copilot_api_base": srv.URL,
+	}}
+
+	models := FetchCopilotModels(context.Background(), auth, &config.Config{})
+	if calls.Load() != 1 || authorization.Load() != "Bearer tid=enterprise" {
+		t.Fatalf("calls = %d, Authorization = %v, want one listing against the token's API base", calls.Load(), authorization.Load())
+	}
+	got := make([]string, 0, len(models))
+	for _, info := range models {
+		got = append(got, info.ID)
+	}
+	if len(got) != 2 || got[0] != "gpt-4.1" || got[1] != "claude-sonnet-4" {
+		t.Fatalf("models = %v, want enabled chat models once each", got)
+	}
+	if models[0].ContextLength != 128000 || models[0].MaxCompletionTokens != 16384 || models[0].DisplayName != "GPT-4.1" {
+		t.Fatalf("gpt-4.1 = %+v, want the listed limits and name", models[0])
+	}
+}
+
+func TestFetchCopilotModels_DoesNotRefreshExpiredToken(t *testing.T) {
+	srv, calls, _ := newCopilotModelsServer(t)
+	expire := time.Now().Add(time.Minute).Format(time.RFC3339)
+	auth := &cliproxyauth.Auth{ID: "copilot-stale", Provider: "copilot", Metadata: map[string]any{
+		"github_token":     "gho_x",
+		"copilot_token":    "tid=stale",
+		"copilot_expire":   expire,
+		"copilot_api_base": srv.URL,
+	}}
+
+	if !CopilotTokenNeedsRefresh(auth) {
+		t.Fatalf("CopilotTokenNeedsRefresh() = false, want true for a token expiring within five minutes")
+	}
+	if models := FetchCopilotModels(context.Background(), auth, &config.Config{}); models != nil {
+		t.Fatalf("FetchCopilotModels() = %v, want nil so the caller refreshes first", models)
+	}
+	if calls.Load() != 0 || auth.Metadata["copilot_token"] != "tid=stale" || auth.Metadata["copilot_expire"] != expire {
+		t.Fatalf("calls = %d, metadata = %v, want no request and the metadata untouched", calls.Load(), auth.Metadata)
+	}
+}
@@ -1,17 +1,20 @@
 // Package executor provides runtime execution capabilities for various AI service providers.
 // This file implements the Vertex AI Gemini executor that talks to Google Vertex AI
-// endpoints using service account credentials or API keys.
+// endpoints using service account credentials, Application Default Credentials or API keys.
 package executor
 
 import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 
 	vertexauth "github.com/giofahreza/AIProxyAPI/internal/auth/vertex"
 	"github.com/giofahreza/AIProxyAPI/internal/config"
@@ -23,7 +26,6 @@ import (
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
 const (
@@ -31,6 +33,8 @@ const (
 	vertexAPIVersion = "v1"
 )
 
+var errVertexNoRegion = statusErr{code: http.StatusServiceUnavailable, msg: "vertex executor: no region available"}
+
 // GeminiVertexExecutor sends requests to Vertex AI Gemini endpoints using service account credentials.
 type GeminiVertexExecutor struct {
 	cfg *config.Config
@@ -62,11 +66,11 @@ func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 
 	// If no API key found, fall back to service account authentication
 	if apiKey == "" {
-		projectID, location, saJSON, errCreds := vertexCreds(auth)
+		creds, errCreds := vertexCreds(auth)
 		if errCreds != nil {
 			return resp, errCreds
 		}
-		return e.executeWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
+		return e.executeWithServiceAccount(ctx, auth, req, opts, creds)
 	}
 
 	// Use API key authentication
@@ -80,11 +84,11 @@ func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 
 	// If no API key found, fall back to service account authentication
 	if apiKey == "" {
-		projectID, location, saJSON, errCreds := vertexCreds(auth)
+		creds, errCreds := vertexCreds(auth)
 		if errCreds != nil {
 			return nil, errCreds
 		}
-		return e.executeStreamWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
+		return e.executeStreamWithServiceAccount(ctx, auth, req, opts, creds)
 	}
 
 	// Use API key authentication
@@ -98,11 +102,11 @@ func (e *GeminiVertexExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 
 	// If no API key found, fall back to service account authentication
 	if apiKey == "" {
-		projectID, location, saJSON, errCreds := vertexCreds(auth)
+		creds, errCreds := vertexCreds(auth)
 		if errCreds != nil {
 			return cliproxyexecutor.Response{}, errCreds
 		}
-		return e.countTokensWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
+		return e.countTokensWithServiceAccount(ctx, auth, req, opts, creds)
 	}
 
 	// Use API key authentication
@@ -114,33 +118,41 @@ func (e *GeminiVertexExecutor) Refresh(_ context.Context, auth *cliproxyauth.Aut
 	return auth, nil
 }
 
-// executeWithServiceAccount handles authentication using service account credentials.
-// This method contains the original service account authentication logic.
-func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (resp cliproxyexecutor.Response, err error) {
-	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
-	defer reporter.trackFailure(ctx, &err)
-
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("gemini")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
+// buildRequestBody builds the generateContent payload sent for req. Model aliases apply only to
+// API key credentials; service accounts call the model by its own name.
+func (e *GeminiVertexExecutor) buildRequestBody(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) upstreamBody {
+	model := req.Model
+	if apiKey, _ := vertexAPICreds(auth); apiKey != "" {
+		if override := e.resolveUpstreamModel(req.Model, auth); override != "" {
+			model = override
+		}
 	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
-	if budgetOverride, includeOverride, ok := util.ResolveThinkingConfigFromMetadata(req.Model, req.Metadata); ok && util.ModelSupportsThinking(req.Model) {
+	to := sdktranslator.FromString("gemini")
+	body, originalTranslated := translateForUpstream(req, opts, to, model, stream)
+	if budgetOverride, includeOverride, ok := util.ResolveThinkingConfigFromMetadata(model, req.Metadata); ok && util.ModelSupportsThinking(model) {
 		if budgetOverride != nil {
-			norm := util.NormalizeThinkingBudget(req.Model, *budgetOverride)
+			norm := util.NormalizeThinkingBudget(model, *budgetOverride)
 			budgetOverride = &norm
 		}
 		body = util.ApplyGeminiThinkingConfig(body, budgetOverride, includeOverride)
 	}
-	body = util.ApplyDefaultThinkingIfNeeded(req.Model, body)
-	body = util.NormalizeGeminiThinkingBudget(req.Model, body)
-	body = util.StripThinkingConfigIfUnsupported(req.Model, body)
-	body = fixGeminiImageAspectRatio(req.Model, body)
-	body = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", body, originalTranslated)
-	body, _ = sjson.SetBytes(body, "model", req.Model)
+	body = util.ApplyDefaultThinkingIfNeeded(model, body)
+	body = util.NormalizeGeminiThinkingBudget(model, body)
+	body = util.StripThinkingConfigIfUnsupported(model, body)
+	body = fixGeminiImageAspectRatio(model, body)
+	body, rules := applyPayloadConfigTraced(e.cfg, model, to.String(), "", body, originalTranslated)
+	body, _ = sjson.SetBytes(body, "model", model)
+	return upstreamBody{format: to, model: model, body: body, rules: rules}
+}
+
+// executeWithServiceAccount handles authentication using service account credentials or ADC.
+// Requests move on to the next region of the credential when a region is exhausted.
+func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, creds *vertexCredentials) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	built := e.buildRequestBody(auth, req, opts, false)
+	from, to, body := opts.SourceFormat, built.format, built.body
 
 	action := "generateContent"
 	if req.Metadata != nil {
@@ -148,25 +160,13 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 			action = "countTokens"
 		}
 	}
-	baseURL := vertexBaseURL(location)
-	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, projectID, location, req.Model, action)
-	if opts.Alt != "" && action != "countTokens" {
-		url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
-	}
 	body, _ = sjson.DeleteBytes(body, "session_id")
 
-	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if errNewReq != nil {
-		return resp, errNewReq
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON); errTok == nil && token != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+token)
-	} else if errTok != nil {
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, creds)
+	if errTok != nil {
 		log.Errorf("vertex executor: access token error: %v", errTok)
 		return resp, statusErr{code: 500, msg: "internal server error"}
 	}
-	applyGeminiHeaders(httpReq, auth)
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -174,48 +174,68 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
-	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
-		URL:       url,
-		Method:    http.MethodPost,
-		Headers:   httpReq.Header.Clone(),
-		Body:      body,
-		Provider:  e.Identifier(),
-		AuthID:    authID,
-		AuthLabel: authLabel,
-		AuthType:  authType,
-		AuthValue: authValue,
-	})
 
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
-	if errDo != nil {
-		recordAPIResponseError(ctx, e.cfg, errDo)
-		return resp, errDo
-	}
-	defer func() {
+	regions := cliproxyauth.OrderRegions(authID, req.Model, creds.regions)
+	for idx, location := range regions {
+		url := vertexModelURL(creds.projectID, location, req.Model, action)
+		if opts.Alt != "" && action != "countTokens" {
+			url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
+		}
+		httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if errNewReq != nil {
+			return resp, errNewReq
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		applyGeminiHeaders(httpReq, auth)
+		recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+			URL:       url,
+			Method:    http.MethodPost,
+			Headers:   httpReq.Header.Clone(),
+			Body:      body,
+			Provider:  e.Identifier(),
+			AuthID:    authID,
+			AuthLabel: authLabel,
+			AuthType:  authType,
+			AuthValue: authValue,
+		})
+
+		httpResp, errDo := httpClient.Do(httpReq)
+		if errDo != nil {
+			recordAPIResponseError(ctx, e.cfg, errDo)
+			return resp, errDo
+		}
+		recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+		data, errRead := io.ReadAll(httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("vertex executor: close response body error: %v", errClose)
 		}
-	}()
-	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
-		appendAPIResponseChunk(ctx, e.cfg, b)
-		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
-		return resp, err
-	}
-	data, errRead := io.ReadAll(httpResp.Body)
-	if errRead != nil {
-		recordAPIResponseError(ctx, e.cfg, errRead)
-		return resp, errRead
+		if errRead != nil {
+			recordAPIResponseError(ctx, e.cfg, errRead)
+			return resp, errRead
+		}
+		appendAPIResponseChunk(ctx, e.cfg, data)
+		if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+			log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+			err = statusErr{code: httpResp.StatusCode, msg: string(data)}
+			if vertexRegionExhausted(httpResp.StatusCode) {
+				cliproxyauth.MarkRegionExhausted(authID, location, req.Model, httpResp.Header)
+				if idx+1 < len(regions) {
+					log.Debugf("vertex executor: region %s exhausted, retrying in region %s", location, regions[idx+1])
+					continue
+				}
+			}
+			return resp, err
+		}
+		cliproxyauth.MarkRegionAvailable(authID, location, req.Model)
+		reporter.publish(ctx, parseGeminiUsage(data))
+		var param any
+		out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
+		resp = cliproxyexecutor.Response{Payload: []byte(out)}
+		return resp, nil
 	}
-	appendAPIResponseChunk(ctx, e.cfg, data)
-	reporter.publish(ctx, parseGeminiUsage(data))
-	var param any
-	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
-	resp = cliproxyexecutor.Response{Payload: []byte(out)}
-	return resp, nil
+	return resp, errVertexNoRegion
 }
 
 // executeWithAPIKey handles authentication using API key credentials.
@@ -223,32 +243,8 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	model := req.Model
-	if override := e.resolveUpstreamModel(req.Model, auth); override != "" {
-		model = override
-	}
-
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("gemini")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
-	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, model, originalPayload, false)
-	body := sdktranslator.TranslateRequest(from, to, model, bytes.Clone(req.Payload), false)
-	if budgetOverride, includeOverride, ok := util.ResolveThinkingConfigFromMetadata(model, req.Metadata); ok && util.ModelSupportsThinking(model) {
-		if budgetOverride != nil {
-			norm := util.NormalizeThinkingBudget(model, *budgetOverride)
-			budgetOverride = &norm
-		}
-		body = util.ApplyGeminiThinkingConfig(body, budgetOverride, includeOverride)
-	}
-	body = util.ApplyDefaultThinkingIfNeeded(model, body)
-	body = util.NormalizeGeminiThinkingBudget(model, body)
-	body = util.StripThinkingConfigIfUnsupported(model, body)
-	body = fixGeminiImageAspectRatio(model, body)
-	body = applyPayloadConfigWithRoot(e.cfg, model, to.String(), "", body, originalTranslated)
-	body, _ = sjson.SetBytes(body, "model", model)
+	built := e.buildRequestBody(auth, req, opts, false)
+	from, to, model, body := opts.SourceFormat, built.format, built.model, built.body
 
 	action := "generateContent"
 	if req.Metadata != nil {
@@ -327,54 +323,20 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	return resp, nil
 }
 
-// executeStreamWithServiceAccount handles streaming authentication using service account credentials.
-func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+// executeStreamWithServiceAccount handles streaming authentication using service account credentials or ADC.
+func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, creds *vertexCredentials) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("gemini")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
-	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
-	if budgetOverride, includeOverride, ok := util.ResolveThinkingConfigFromMetadata(req.Model, req.Metadata); ok && util.ModelSupportsThinking(req.Model) {
-		if budgetOverride != nil {
-			norm := util.NormalizeThinkingBudget(req.Model, *budgetOverride)
-			budgetOverride = &norm
-		}
-		body = util.ApplyGeminiThinkingConfig(body, budgetOverride, includeOverride)
-	}
-	body = util.ApplyDefaultThinkingIfNeeded(req.Model, body)
-	body = util.NormalizeGeminiThinkingBudget(req.Model, body)
-	body = util.StripThinkingConfigIfUnsupported(req.Model, body)
-	body = fixGeminiImageAspectRatio(req.Model, body)
-	body = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", body, originalTranslated)
-	body, _ = sjson.SetBytes(body, "model", req.Model)
-
-	baseURL := vertexBaseURL(location)
-	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, projectID, location, req.Model, "streamGenerateContent")
-	if opts.Alt == "" {
-		url = url + "?alt=sse"
-	} else {
-		url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
-	}
+	built := e.buildRequestBody(auth, req, opts, true)
+	from, to, body := opts.SourceFormat, built.format, built.body
 	body, _ = sjson.DeleteBytes(body, "session_id")
 
-	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if errNewReq != nil {
-		return nil, errNewReq
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON); errTok == nil && token != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+token)
-	} else if errTok != nil {
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, creds)
+	if errTok != nil {
 		log.Errorf("vertex executor: access token error: %v", errTok)
 		return nil, statusErr{code: 500, msg: "internal server error"}
 	}
-	applyGeminiHeaders(httpReq, auth)
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -382,33 +344,64 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
-	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
-		URL:       url,
-		Method:    http.MethodPost,
-		Headers:   httpReq.Header.Clone(),
-		Body:      body,
-		Provider:  e.Identifier(),
-		AuthID:    authID,
-		AuthLabel: authLabel,
-		AuthType:  authType,
-		AuthValue: authValue,
-	})
 
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
-	if errDo != nil {
-		recordAPIResponseError(ctx, e.cfg, errDo)
-		return nil, errDo
-	}
-	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
+	regions := cliproxyauth.OrderRegions(authID, req.Model, creds.regions)
+	var httpResp *http.Response
+	for idx, location := range regions {
+		url := vertexModelURL(creds.projectID, location, req.Model, "streamGenerateContent")
+		if opts.Alt == "" {
+			url = url + "?alt=sse"
+		} else {
+			url = url + fmt.Sprintf("?$alt=%s", opts.Alt)
+		}
+		httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if errNewReq != nil {
+			return nil, errNewReq
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		applyGeminiHeaders(httpReq, auth)
+		recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+			URL:       url,
+			Method:    http.MethodPost,
+			Headers:   httpReq.Header.Clone(),
+			Body:      body,
+			Provider:  e.Identifier(),
+			AuthID:    authID,
+			AuthLabel: authLabel,
+			AuthType:  authType,
+			AuthValue: authValue,
+		})
+
+		resp, errDo := httpClient.Do(httpReq)
+		if errDo != nil {
+			recordAPIResponseError(ctx, e.cfg, errDo)
+			return nil, errDo
+		}
+		recordAPIResponseMetadata(ctx, e.cfg, resp.StatusCode, resp.Header.Clone())
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			cliproxyauth.MarkRegionAvailable(authID, location, req.Model)
+			httpResp = resp
+			break
+		}
+		b, _ := io.ReadAll(resp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
-		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		if errClose := httpResp.Body.Close(); errClose != nil {
+		log.Debugf("request error, error status: %d, error body: %s", resp.StatusCode, summarizeErrorBody(resp.Header.Get("Content-Type"), b))
+		if errClose := resp.Body.Close(); errClose != nil {
 			log.Errorf("vertex executor: close response body error: %v", errClose)
 		}
-		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+		if vertexRegionExhausted(resp.StatusCode) {
+			cliproxyauth.MarkRegionExhausted(authID, location, req.Model, resp.Header)
+			if idx+1 < len(regions) {
+				log.Debugf("vertex executor: region %s exhausted, retrying in region %s", location, regions[idx+1])
+				continue
+			}
+		}
+		return nil, statusErr{code: resp.StatusCode, msg: string(b)}
+	}
+	if httpResp == nil {
+		return nil, errVertexNoRegion
 	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
@@ -452,32 +445,8 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
 	defer reporter.trackFailure(ctx, &err)
 
-	model := req.Model
-	if override := e.resolveUpstreamModel(req.Model, auth); override != "" {
-		model = override
-	}
-
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("gemini")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
-	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, model, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, model, bytes.Clone(req.Payload), true)
-	if budgetOverride, includeOverride, ok := util.ResolveThinkingConfigFromMetadata(model, req.Metadata); ok && util.ModelSupportsThinking(model) {
-		if budgetOverride != nil {
-			norm := util.NormalizeThinkingBudget(model, *budgetOverride)
-			budgetOverride = &norm
-		}
-		body = util.ApplyGeminiThinkingConfig(body, budgetOverride, includeOverride)
-	}
-	body = util.ApplyDefaultThinkingIfNeeded(model, body)
-	body = util.NormalizeGeminiThinkingBudget(model, body)
-	body = util.StripThinkingConfigIfUnsupported(model, body)
-	body = fixGeminiImageAspectRatio(model, body)
-	body = applyPayloadConfigWithRoot(e.cfg, model, to.String(), "", body, originalTranslated)
-	body, _ = sjson.SetBytes(body, "model", model)
+	built := e.buildRequestBody(auth, req, opts, true)
+	from, to, model, body := opts.SourceFormat, built.format, built.model, built.body
 
 	// For API key auth, use simpler URL format without project/location
 	if baseURL == "" {
@@ -572,8 +541,8 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	return stream, nil
 }
 
-// countTokensWithServiceAccount counts tokens using service account credentials.
-func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location string, saJSON []byte) (cliproxyexecutor.Response, error) {
+// countTokensWithServiceAccount counts tokens using service account credentials or ADC.
+func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, creds *vertexCredentials) (cliproxyexecutor.Response, error) {
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("gemini")
 	translatedReq := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
@@ -592,21 +561,11 @@ func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context
 	translatedReq, _ = sjson.DeleteBytes(translatedReq, "generationConfig")
 	translatedReq, _ = sjson.DeleteBytes(translatedReq, "safetySettings")
 
-	baseURL := vertexBaseURL(location)
-	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, projectID, location, req.Model, "countTokens")
-
-	httpReq, errNewReq := http.NewRequestWithContext(respCtx, http.MethodPost, url, bytes.NewReader(translatedReq))
-	if errNewReq != nil {
-		return cliproxyexecutor.Response{}, errNewReq
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON); errTok == nil && token != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+token)
-	} else if errTok != nil {
+	token, errTok := vertexAccessToken(ctx, e.cfg, auth, creds)
+	if errTok != nil {
 		log.Errorf("vertex executor: access token error: %v", errTok)
 		return cliproxyexecutor.Response{}, statusErr{code: 500, msg: "internal server error"}
 	}
-	applyGeminiHeaders(httpReq, auth)
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -614,49 +573,64 @@ func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context
 		authLabel = auth.Label
 		authType, authValue = auth.AccountInfo()
 	}
-	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
-		URL:       url,
-		Method:    http.MethodPost,
-		Headers:   httpReq.Header.Clone(),
-		Body:      translatedReq,
-		Provider:  e.Identifier(),
-		AuthID:    authID,
-		AuthLabel: authLabel,
-		AuthType:  authType,
-		AuthValue: authValue,
-	})
 
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
-	httpResp, errDo := httpClient.Do(httpReq)
-	if errDo != nil {
-		recordAPIResponseError(ctx, e.cfg, errDo)
-		return cliproxyexecutor.Response{}, errDo
-	}
-	defer func() {
+	regions := cliproxyauth.OrderRegions(authID, req.Model, creds.regions)
+	var lastErr error = errVertexNoRegion
+	for idx, location := range regions {
+		url := vertexModelURL(creds.projectID, location, req.Model, "countTokens")
+		httpReq, errNewReq := http.NewRequestWithContext(respCtx, http.MethodPost, url, bytes.NewReader(translatedReq))
+		if errNewReq != nil {
+			return cliproxyexecutor.Response{}, errNewReq
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		applyGeminiHeaders(httpReq, auth)
+		recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+			URL:       url,
+			Method:    http.MethodPost,
+			Headers:   httpReq.Header.Clone(),
+			Body:      translatedReq,
+			Provider:  e.Identifier(),
+			AuthID:    authID,
+			AuthLabel: authLabel,
+			AuthType:  authType,
+			AuthValue: authValue,
+		})
+
+		httpResp, errDo := httpClient.Do(httpReq)
+		if errDo != nil {
+			recordAPIResponseError(ctx, e.cfg, errDo)
+			return cliproxyexecutor.Response{}, errDo
+		}
+		recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+		data, errRead := io.ReadAll(httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("vertex executor: close response body error: %v", errClose)
 		}
-	}()
-	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
-		appendAPIResponseChunk(ctx, e.cfg, b)
-		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		return cliproxyexecutor.Response{}, statusErr{code: httpResp.StatusCode, msg: string(b)}
-	}
-	data, errRead := io.ReadAll(httpResp.Body)
-	if errRead != nil {
-		recordAPIResponseError(ctx, e.cfg, errRead)
-		return cliproxyexecutor.Response{}, errRead
-	}
-	appendAPIResponseChunk(ctx, e.cfg, data)
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
-		return cliproxyexecutor.Response{}, statusErr{code: httpResp.StatusCode, msg: string(data)}
+		if errRead != nil {
+			recordAPIResponseError(ctx, e.cfg, errRead)
+			return cliproxyexecutor.Response{}, errRead
+		}
+		appendAPIResponseChunk(ctx, e.cfg, data)
+		if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+			log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+			lastErr = statusErr{code: httpResp.StatusCode, msg: string(data)}
+			if vertexRegionExhausted(httpResp.StatusCode) {
+				cliproxyauth.MarkRegionExhausted(authID, location, req.Model, httpResp.Header)
+				if idx+1 < len(regions) {
+					log.Debugf("vertex executor: region %s exhausted, retrying in region %s", location, regions[idx+1])
+					continue
+				}
+			}
+			return cliproxyexecutor.Response{}, lastErr
+		}
+		cliproxyauth.MarkRegionAvailable(authID, location, req.Model)
+		count := gjson.GetBytes(data, "totalTokens").Int()
+		out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
+		return cliproxyexecutor.Response{Payload: []byte(out)}, nil
 	}
-	count := gjson.GetBytes(data, "totalTokens").Int()
-	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
-	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+	return cliproxyexecutor.Response{}, lastErr
 }
 
 // countTokensWithAPIKey handles token counting using API key credentials.
@@ -751,44 +725,96 @@ func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *
 	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
 }
 
-// vertexCreds extracts project, location and raw service account JSON from auth metadata.
-func vertexCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccountJSON []byte, err error) {
+// vertexCredentials describes how a Vertex auth authenticates and which regions it is served from.
+type vertexCredentials struct {
+	// projectID may be empty for ADC credentials until the environment supplies it.
+	projectID          string
+	regions            []string
+	serviceAccountJSON []byte
+	adc                bool
+	impersonate        string
+}
+
+// fingerprint identifies the identity of the credentials, so cached token sources are rebuilt
+// when an auth file changes.
+func (c *vertexCredentials) fingerprint() string {
+	sum := sha256.New()
+	sum.Write(c.serviceAccountJSON)
+	_, _ = fmt.Fprintf(sum, "|%t|%s", c.adc, c.impersonate)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// vertexCreds extracts project, regions and the credential source from auth metadata.
+func vertexCreds(a *cliproxyauth.Auth) (*vertexCredentials, error) {
 	if a == nil || a.Metadata == nil {
-		return "", "", nil, fmt.Errorf("vertex executor: missing auth metadata")
+		return nil, fmt.Errorf("vertex executor: missing auth metadata")
 	}
+	creds := &vertexCredentials{}
 	if v, ok := a.Metadata["project_id"].(string); ok {
-		projectID = strings.TrimSpace(v)
+		creds.projectID = strings.TrimSpace(v)
 	}
-	if projectID == "" {
+	if creds.projectID == "" {
 		// Some service accounts may use "project"; still prefer standard field
 		if v, ok := a.Metadata["project"].(string); ok {
-			projectID = strings.TrimSpace(v)
+			creds.projectID = strings.TrimSpace(v)
 		}
 	}
-	if projectID == "" {
-		return "", "", nil, fmt.Errorf("vertex executor: missing project_id in credentials")
+	location, _ := a.Metadata["location"].(string)
+	creds.regions = vertexauth.NormalizeRegions(vertexMetadataRegions(a.Metadata["regions"]), location)
+	if v, ok := a.Metadata["impersonate_service_account"].(string); ok {
+		creds.impersonate = strings.TrimSpace(v)
 	}
-	if v, ok := a.Metadata["location"].(string); ok && strings.TrimSpace(v) != "" {
-		location = strings.TrimSpace(v)
-	} else {
-		location = "us-central1"
+	if source, ok := a.Metadata["credential_source"].(string); ok && strings.EqualFold(strings.TrimSpace(source), vertexauth.CredentialSourceADC) {
+		creds.adc = true
+		return creds, nil
+	}
+
+	if creds.projectID == "" {
+		return nil, fmt.Errorf("vertex executor: missing project_id in credentials")
 	}
 	var sa map[string]any
 	if raw, ok := a.Metadata["service_account"].(map[string]any); ok {
 		sa = raw
 	}
 	if sa == nil {
-		return "", "", nil, fmt.Errorf("vertex executor: missing service_account in credentials")
+		return nil, fmt.Errorf("vertex executor: missing service_account in credentials")
 	}
 	normalized, errNorm := vertexauth.NormalizeServiceAccountMap(sa)
 	if errNorm != nil {
-		return "", "", nil, fmt.Errorf("vertex executor: %w", errNorm)
+		return nil, fmt.Errorf("vertex executor: %w", errNorm)
 	}
 	saJSON, errMarshal := json.Marshal(normalized)
 	if errMarshal != nil {
-		return "", "", nil, fmt.Errorf("vertex executor: marshal service_account failed: %w", errMarshal)
+		return nil, fmt.Errorf("vertex executor: marshal service_account failed: %w", errMarshal)
 	}
-	return projectID, location, saJSON, nil
+	creds.serviceAccountJSON = saJSON
+	return creds, nil
+}
+
+// vertexMetadataRegions reads the regions of an auth file, given as a list or a comma-separated string.
+func vertexMetadataRegions(v any) []string {
+	switch regions := v.(type) {
+	case []string:
+		return regions
+	case []any:
+		out := make([]string, 0, len(regions))
+		for _, region := range regions {
+			if s, ok := region.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		return strings.Split(regions, ",")
+	default:
+		return nil
+	}
+}
+
+// vertexRegionExhausted reports whether status means the region is out of capacity or quota, so
+// the request can be retried in another region.
+func vertexRegionExhausted(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
 }
 
 // vertexAPICreds extracts API key and base URL from auth attributes following the claudeCreds pattern.
@@ -811,27 +837,88 @@ func vertexAPICreds(a *cliproxyauth.Auth) (apiKey, baseURL string) {
 func vertexBaseURL(location string) string {
 	loc := strings.TrimSpace(location)
 	if loc == "" {
-		loc = "us-central1"
+		loc = vertexauth.DefaultLocation
+	}
+	if loc == "global" {
+		return "https://aiplatform.googleapis.com"
 	}
 	return fmt.Sprintf("https://%s-aiplatform.googleapis.com", loc)
 }
 
-func vertexAccessToken(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, saJSON []byte) (string, error) {
-	if httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0); httpClient != nil {
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+func vertexModelURL(projectID, location, model, action string) string {
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", vertexBaseURL(location), vertexAPIVersion, projectID, location, model, action)
+}
+
+// vertexTokenSources caches one token source per auth ID, so service account, ADC and
+// impersonated tokens are reused until they expire instead of being minted per request.
+var vertexTokenSources sync.Map
+
+type vertexTokenSource struct {
+	fingerprint string
+	source      oauth2.TokenSource
+	projectID   string
+}
+
+// vertexAccessToken returns an access token for creds and fills in the project ID from the ADC
+// environment when the credential does not name one.
+func vertexAccessToken(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, creds *vertexCredentials) (string, error) {
+	fingerprint := creds.fingerprint()
+	var cacheKey string
+	if auth != nil {
+		cacheKey = auth.ID
+	}
+	var entry *vertexTokenSource
+	if cached, ok := vertexTokenSources.Load(cacheKey); ok && cacheKey != "" {
+		if candidate := cached.(*vertexTokenSource); candidate.fingerprint == fingerprint {
+			entry = candidate
+		}
+	}
+	if entry == nil {
+		built, errBuild := newVertexTokenSource(ctx, cfg, auth, creds)
+		if errBuild != nil {
+			return "", errBuild
+		}
+		built.fingerprint = fingerprint
+		entry = built
+		if cacheKey != "" {
+			vertexTokenSources.Store(cacheKey, entry)
+		}
+	}
+	if creds.projectID == "" {
+		creds.projectID = entry.projectID
 	}
-	// Use cloud-platform scope for Vertex AI.
-	creds, errCreds := google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
-	if errCreds != nil {
-		return "", fmt.Errorf("vertex executor: parse service account json failed: %w", errCreds)
+	if creds.projectID == "" {
+		return "", fmt.Errorf("vertex executor: missing project_id in credentials and application default credentials")
 	}
-	tok, errTok := creds.TokenSource.Token()
+	tok, errTok := entry.source.Token()
 	if errTok != nil {
 		return "", fmt.Errorf("vertex executor: get access token failed: %w", errTok)
 	}
 	return tok.AccessToken, nil
 }
 
+func newVertexTokenSource(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, creds *vertexCredentials) (*vertexTokenSource, error) {
+	// Token sources outlive the request that created them, so they get a context of their own.
+	tokenCtx := context.Background()
+	if httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0); httpClient != nil {
+		tokenCtx = context.WithValue(tokenCtx, oauth2.HTTPClient, httpClient)
+	}
+	entry := &vertexTokenSource{}
+	var err error
+	if creds.adc {
+		entry.source, entry.projectID, err = vertexauth.DefaultCredentials(tokenCtx)
+	} else {
+		entry.source, err = vertexauth.ServiceAccountCredentials(tokenCtx, creds.serviceAccountJSON)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vertex executor: %w", err)
+	}
+	if creds.impersonate != "" {
+		entry.source = vertexauth.ImpersonateTokenSource(tokenCtx, entry.source, creds.impersonate)
+	}
+	return entry, nil
+}
+
 // resolveUpstreamModel resolves the upstream model name from vertex-api-key configuration.
 // It matches the requested model alias against configured models and returns the actual upstream name.
 func (e *GeminiVertexExecutor) resolveUpstreamModel(alias string, auth *cliproxyauth.Auth) string {
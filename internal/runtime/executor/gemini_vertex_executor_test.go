@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	"golang.org/x/oauth2"
+)
+
+// vertexRegionTransport stands in for the regional Vertex endpoints. Regions listed in exhausted
+// answer with their status; the others succeed.
+type vertexRegionTransport struct {
+	mu        sync.Mutex
+	exhausted map[string]int
+	hosts     []string
+}
+
+func (rt *vertexRegionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.hosts = append(rt.hosts, req.URL.Host)
+	region := strings.TrimSuffix(req.URL.Host, "-aiplatform.googleapis.com")
+	status := rt.exhausted[region]
+	rt.mu.Unlock()
+	if status != 0 {
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"error":{"status":"RESOURCE_EXHAUSTED"}}`)), Request: req}, nil
+	}
+	body := `{"candidates":[{"content":{"role":"model","parts":[{"text":"hi"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":2,"candidatesTokenCount":1,"totalTokenCount":3}}`
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func (rt *vertexRegionTransport) takeHosts() []string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	hosts := rt.hosts
+	rt.hosts = nil
+	return hosts
+}
+
+func TestGeminiVertexExecutor_FailsOverExhaustedRegion(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			auth := &cliproxyauth.Auth{ID: "vertex-regions-" + strconv.Itoa(status), Provider: "vertex", Metadata: map[string]any{
+				"project_id":        "proj",
+				"credential_source": "adc",
+				"regions":           []any{"us-central1", "europe-west4"},
+			}}
+			creds, err := vertexCreds(auth)
+			if err != nil {
+				t.Fatalf("vertexCreds() error = %v", err)
+			}
+			// Cache a token source so the test never looks for real application default credentials.
+			vertexTokenSources.Store(auth.ID, &vertexTokenSource{
+				fingerprint: creds.fingerprint(),
+				source:      oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "vertex-token"}),
+			})
+			t.Cleanup(func() { vertexTokenSources.Delete(auth.ID) })
+
+			rt := &vertexRegionTransport{exhausted: map[string]int{"us-central1": status}}
+			ctx := context.WithValue(context.Background(), "cliproxy.roundtripper", http.RoundTripper(rt))
+			payload := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+			execute := func() error {
+				_, errExec := NewGeminiVertexExecutor(&config.Config{}).Execute(ctx, auth,
+					cliproxyexecutor.Request{Model: "gemini-2.5-pro", Payload: payload},
+					cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("gemini"), OriginalRequest: payload})
+				return errExec
+			}
+
+			if err = execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if hosts := rt.takeHosts(); len(hosts) != 2 || hosts[0] != "us-central1-aiplatform.googleapis.com" || hosts[1] != "europe-west4-aiplatform.googleapis.com" {
+				t.Fatalf("hosts = %v, want a retry in europe-west4 after us-central1 was exhausted", hosts)
+			}
+			cooldowns := cliproxyauth.RegionCooldowns(auth)
+			if len(cooldowns) != 1 || cooldowns[0].Region != "us-central1" || cooldowns[0].Model != "gemini-2.5-pro" {
+				t.Fatalf("RegionCooldowns() = %+v, want us-central1 cooling down for the model", cooldowns)
+			}
+
+			if err = execute(); err != nil {
+				t.Fatalf("second Execute() error = %v", err)
+			}
+			if hosts := rt.takeHosts(); len(hosts) != 1 || hosts[0] != "europe-west4-aiplatform.googleapis.com" {
+				t.Fatalf("hosts = %v, want the cooling region skipped while another is available", hosts)
+			}
+		})
+	}
+}
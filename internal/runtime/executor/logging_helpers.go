@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/giofahreza/AIProxyAPI/internal/config"
 	"github.com/giofahreza/AIProxyAPI/internal/util"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
 )
 
 const (
@@ -94,7 +95,9 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 }
 
 // recordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
+// It also feeds any rate-limit headers into the quota snapshot of the auth being used.
 func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
+	cliproxyauth.ObserveRateLimitHeaders(ctx, headers)
 	if cfg == nil || !cfg.RequestLog {
 		return
 	}
@@ -0,0 +1,675 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ollamaResidentTTL is how long a model is assumed to stay in memory after it was last used.
+// It is kept below Ollama's default five minute keep-alive.
+const ollamaResidentTTL = 4 * time.Minute
+
+// ollamaDefaultContextLength is the num_ctx Ollama runs a model with when neither its Modelfile
+// nor the server's OLLAMA_CONTEXT_LENGTH sets one. The OpenAI-compatible endpoint cannot raise
+// it per request, so it bounds what a model can take regardless of what it was trained for.
+const ollamaDefaultContextLength = 4096
+
+// ollamaModels tracks model residency across executor instances so configuration reloads do
+// not force another warm-up.
+var ollamaModels = newOllamaLoader()
+
+// OllamaExecutor calls local Ollama or llama.cpp servers through their OpenAI-compatible chat
+// endpoint. Before the first request to a cold model it asks the server to load the model and
+// waits up to the configured load timeout, so slow loads do not count against generation.
+type OllamaExecutor struct {
+	cfg *config.Config
+}
+
+// NewOllamaExecutor creates an executor for servers from ollama.
+func NewOllamaExecutor(cfg *config.Config) *OllamaExecutor {
+	return &OllamaExecutor{cfg: cfg}
+}
+
+func (e *OllamaExecutor) Identifier() string { return "ollama" }
+
+func (e *OllamaExecutor) PrepareRequest(_ *http.Request, _ *cliproxyauth.Auth) error { return nil }
+
+func (e *OllamaExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	built, err := e.buildRequestBody(auth, req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+	body := built.body
+	httpResp, err := e.send(ctx, auth, built.model, body, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	appendAPIResponseChunk(ctx, e.cfg, data)
+	reporter.publish(ctx, parseOpenAIUsage(data))
+	reporter.ensurePublished(ctx)
+	data = normalizeOllamaResponse(data)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, data, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+func (e *OllamaExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (stream <-chan cliproxyexecutor.StreamChunk, err error) {
+	reporter := newUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	built, err := e.buildRequestBody(auth, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	body := built.body
+	httpResp, err := e.send(ctx, auth, built.model, body, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	stream = out
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("ollama executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		var state ollamaStreamState
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			appendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := parseOpenAIStreamUsage(line); ok {
+				reporter.publish(ctx, detail)
+			}
+			if len(line) == 0 {
+				continue
+			}
+			line = state.normalize(bytes.Clone(line))
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, line, &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			recordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.publishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+		reporter.ensurePublished(ctx)
+	}()
+	return stream, nil
+}
+
+// CountTokens estimates tokens locally; the OpenAI-compatible endpoint cannot count tokens.
+func (e *OllamaExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), false)
+
+	enc, err := tokenizerForModel(e.resolveUpstreamModel(req.Model, auth))
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("ollama executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, body)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("ollama executor: token counting failed: %w", err)
+	}
+	usageJSON := buildOpenAIUsageJSON(count)
+	translated := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
+}
+
+// Refresh is a no-op; local servers have no expiring credentials.
+func (e *OllamaExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+// Probe checks the server by listing its models.
+func (e *OllamaExecutor) Probe(ctx context.Context, auth *cliproxyauth.Auth) error {
+	baseURL := ollamaBaseURL(auth)
+	if baseURL == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing ollama base url"}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/v1/models", nil)
+	if err != nil {
+		return err
+	}
+	applyOllamaHeaders(httpReq, auth)
+	return doProbeRequest(e.cfg, auth, httpReq)
+}
+
+// buildRequestBody translates the request to an OpenAI chat completion for the server's model
+// name and maps thinking options onto reasoning_effort for models that report thinking support.
+func (e *OllamaExecutor) buildRequestBody(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (upstreamBody, error) {
+	to := sdktranslator.FromString("openai")
+	upstreamModel := e.resolveUpstreamModel(req.Model, auth)
+	body, originalTranslated := translateForUpstream(req, opts, to, req.Model, stream)
+	body, rules := applyPayloadConfigTraced(e.cfg, req.Model, to.String(), "", body, originalTranslated)
+	body = ApplyReasoningEffortMetadata(body, req.Metadata, req.Model, "reasoning_effort", false)
+	body = NormalizeThinkingConfig(body, req.Model, false)
+	body = dropAutoReasoningEffort(body)
+	if errValidate := ValidateThinkingConfig(body, req.Model); errValidate != nil {
+		return upstreamBody{format: to, model: upstreamModel, body: body, rules: rules}, errValidate
+	}
+	body, _ = sjson.SetBytes(body, "model", upstreamModel)
+	body, _ = sjson.SetBytes(body, "stream", stream)
+	if stream {
+		body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
+	}
+	return upstreamBody{format: to, model: upstreamModel, body: body, rules: rules}, nil
+}
+
+// dropAutoReasoningEffort removes an "auto" effort. Ollama has no automatic level, and thinking
+// models reason by default when the effort is omitted.
+func dropAutoReasoningEffort(body []byte) []byte {
+	if strings.EqualFold(gjson.GetBytes(body, "reasoning_effort").String(), "auto") {
+		body, _ = sjson.DeleteBytes(body, "reasoning_effort")
+	}
+	return body
+}
+
+// send waits for the model to be resident, then issues the chat request and returns the
+// response when it succeeded.
+func (e *OllamaExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, model string, body []byte, stream bool) (*http.Response, error) {
+	baseURL := ollamaBaseURL(auth)
+	if baseURL == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing ollama base url"}
+	}
+	if err := e.ensureLoaded(ctx, auth, baseURL, model); err != nil {
+		return nil, err
+	}
+
+	requestURL := baseURL + "/v1/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	applyOllamaHeaders(httpReq, auth)
+
+	authType, authValue := auth.AccountInfo()
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       requestURL,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    auth.ID,
+		AuthLabel: auth.Label,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpResp, err := e.httpClient(ctx, auth).Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		appendAPIResponseChunk(ctx, e.cfg, b)
+		log.Debugf("request error, error status: %d, error body: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	ollamaModels.touch(baseURL, model)
+	return httpResp, nil
+}
+
+// httpClient returns a client without the default overall timeout: local generation routinely
+// runs for minutes, and the request context already bounds it.
+func (e *OllamaExecutor) httpClient(ctx context.Context, auth *cliproxyauth.Auth) *http.Client {
+	client := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	client.Timeout = 0
+	return client
+}
+
+// ensureLoaded makes sure the model is in the server's memory before a request is sent. Cold
+// models are loaded once per server, with concurrent requests waiting on the same load; the
+// load runs detached from the request so a client that gives up does not abort it.
+func (e *OllamaExecutor) ensureLoaded(ctx context.Context, auth *cliproxyauth.Auth, baseURL, model string) error {
+	load, owner, ok := ollamaModels.begin(baseURL, model)
+	if !ok {
+		return nil
+	}
+	if owner {
+		go e.load(auth, baseURL, model, load)
+	}
+	select {
+	case <-load.done:
+		return load.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *OllamaExecutor) load(auth *cliproxyauth.Auth, baseURL, model string, load *ollamaLoad) {
+	timeout := ollamaLoadTimeout(auth)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	supported, err := e.requestLoad(ctx, auth, baseURL, model)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = statusErr{code: http.StatusGatewayTimeout, msg: fmt.Sprintf("ollama model %s did not load within %s", model, timeout)}
+	}
+	if err == nil && supported {
+		log.Debugf("ollama executor: loaded model %s on %s in %s", model, baseURL, time.Since(started).Round(time.Millisecond))
+	}
+	ollamaModels.finish(baseURL, model, load, supported, err)
+}
+
+// requestLoad asks the server to load the model with an empty generate request. It reports
+// supported=false for servers without the Ollama generate API, such as llama.cpp, which load
+// their model at startup.
+func (e *OllamaExecutor) requestLoad(ctx context.Context, auth *cliproxyauth.Auth, baseURL, model string) (supported bool, err error) {
+	payload, _ := sjson.SetBytes([]byte(`{}`), "model", model)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/generate", bytes.NewReader(payload))
+	if err != nil {
+		return true, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	applyOllamaHeaders(httpReq, auth)
+	httpResp, err := e.httpClient(ctx, auth).Do(httpReq)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return true, err
+	}
+	switch {
+	case httpResp.StatusCode >= 200 && httpResp.StatusCode < 300:
+		return true, nil
+	case httpResp.StatusCode == http.StatusNotFound:
+		// Ollama answers 404 with a JSON error for unknown models; other servers 404 the route.
+		if msg := gjson.GetBytes(body, "error").String(); strings.Contains(msg, "not found") {
+			return true, statusErr{code: http.StatusNotFound, msg: string(body)}
+		}
+		return false, nil
+	case httpResp.StatusCode == http.StatusMethodNotAllowed || httpResp.StatusCode == http.StatusNotImplemented:
+		return false, nil
+	default:
+		return true, statusErr{code: httpResp.StatusCode, msg: string(body)}
+	}
+}
+
+// resolveUpstreamModel maps a configured alias to the server's model name. Names without an
+// alias are sent as-is.
+func (e *OllamaExecutor) resolveUpstreamModel(model string, auth *cliproxyauth.Auth) string {
+	trimmed := strings.TrimSpace(model)
+	entry := e.resolveOllamaConfig(auth)
+	if entry == nil || trimmed == "" {
+		return trimmed
+	}
+	normalized, _ := util.NormalizeThinkingModel(trimmed)
+	for _, candidate := range []string{trimmed, normalized} {
+		for i := range entry.Models {
+			if entry.Models[i].Alias != "" && strings.EqualFold(entry.Models[i].Alias, candidate) {
+				return entry.Models[i].Name
+			}
+		}
+	}
+	return normalized
+}
+
+func (e *OllamaExecutor) resolveOllamaConfig(auth *cliproxyauth.Auth) *config.OllamaKey {
+	if auth == nil || auth.Attributes == nil || e.cfg == nil {
+		return nil
+	}
+	baseURL := ollamaBaseURL(auth)
+	apiKey := strings.TrimSpace(auth.Attributes["api_key"])
+	for i := range e.cfg.Ollama {
+		entry := &e.cfg.Ollama[i]
+		if strings.EqualFold(entry.BaseURL, baseURL) && entry.APIKey == apiKey {
+			return entry
+		}
+	}
+	return nil
+}
+
+// FetchOllamaModels lists the models installed on a server with their effective context length
+// and thinking support. Ollama servers are queried through /api/tags and /api/show; servers
+// without that API, such as llama.cpp, through /v1/models and /props. It returns nil when the
+// server cannot be reached.
+func FetchOllamaModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	baseURL := ollamaBaseURL(auth)
+	if baseURL == "" {
+		return nil
+	}
+	client := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+
+	tags, status, err := ollamaRequest(ctx, client, auth, http.MethodGet, baseURL+"/api/tags", nil)
+	if err == nil && status == http.StatusOK && gjson.GetBytes(tags, "models").IsArray() {
+		models := make([]*registry.ModelInfo, 0)
+		gjson.GetBytes(tags, "models").ForEach(func(_, tag gjson.Result) bool {
+			name := tag.Get("name").String()
+			if name == "" {
+				name = tag.Get("model").String()
+			}
+			if name == "" {
+				return true
+			}
+			info := newOllamaModelInfo(name)
+			if modified, errParse := time.Parse(time.RFC3339Nano, tag.Get("modified_at").String()); errParse == nil {
+				info.Created = modified.Unix()
+			}
+			if size := tag.Get("details.parameter_size").String(); size != "" {
+				info.Description = strings.TrimSpace(size + " " + tag.Get("details.quantization_level").String())
+			}
+			showBody, _ := sjson.SetBytes([]byte(`{}`), "model", name)
+			if show, showStatus, errShow := ollamaRequest(ctx, client, auth, http.MethodPost, baseURL+"/api/show", showBody); errShow == nil && showStatus == http.StatusOK {
+				applyOllamaShow(info, show)
+			}
+			models = append(models, info)
+			return true
+		})
+		return models
+	}
+
+	list, status, err := ollamaRequest(ctx, client, auth, http.MethodGet, baseURL+"/v1/models", nil)
+	if err != nil || status != http.StatusOK {
+		log.Debugf("ollama executor: model discovery failed for %s: status %d, error %v", baseURL, status, err)
+		return nil
+	}
+	// llama.cpp reports the context each slot was started with, which is what a request can use.
+	var slotCtx int64
+	if props, propsStatus, errProps := ollamaRequest(ctx, client, auth, http.MethodGet, baseURL+"/props", nil); errProps == nil && propsStatus == http.StatusOK {
+		slotCtx = gjson.GetBytes(props, "default_generation_settings.n_ctx").Int()
+	}
+	models := make([]*registry.ModelInfo, 0)
+	gjson.GetBytes(list, "data").ForEach(func(_, model gjson.Result) bool {
+		id := model.Get("id").String()
+		if id == "" {
+			return true
+		}
+		info := newOllamaModelInfo(id)
+		if created := model.Get("created").Int(); created > 0 {
+			info.Created = created
+		}
+		if slotCtx > 0 {
+			info.ContextLength = int(slotCtx)
+		} else if ctxLen := model.Get("meta.n_ctx_train").Int(); ctxLen > 0 {
+			info.ContextLength = int(ctxLen)
+		}
+		models = append(models, info)
+		return true
+	})
+	return models
+}
+
+func newOllamaModelInfo(name string) *registry.ModelInfo {
+	return &registry.ModelInfo{
+		ID:          name,
+		Name:        name,
+		Object:      "model",
+		Created:     time.Now().Unix(),
+		OwnedBy:     "ollama",
+		Type:        "ollama",
+		DisplayName: name,
+	}
+}
+
+// applyOllamaShow copies the context length and capabilities reported by /api/show. The
+// context length is the num_ctx the model runs with: the Modelfile's if it sets one, otherwise
+// Ollama's default, capped at the length the model was trained for.
+func applyOllamaShow(info *registry.ModelInfo, show []byte) {
+	ctxLen := int64(ollamaDefaultContextLength)
+	for _, line := range strings.Split(gjson.GetBytes(show, "parameters").String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if numCtx, err := strconv.ParseInt(fields[1], 10, 64); err == nil && numCtx > 0 {
+				ctxLen = numCtx
+			}
+		}
+	}
+	modelInfo := gjson.GetBytes(show, "model_info")
+	if arch := modelInfo.Get(gjson.Escape("general.architecture")).String(); arch != "" {
+		if trained := modelInfo.Get(gjson.Escape(arch + ".context_length")).Int(); trained > 0 && trained < ctxLen {
+			ctxLen = trained
+		}
+	}
+	info.ContextLength = int(ctxLen)
+	gjson.GetBytes(show, "capabilities").ForEach(func(_, capability gjson.Result) bool {
+		if capability.String() == "thinking" {
+			// Ollama maps these levels onto its think option; "none" turns thinking off.
+			info.Thinking = &registry.ThinkingSupport{ZeroAllowed: true, Levels: []string{"none", "low", "medium", "high"}}
+			return false
+		}
+		return true
+	})
+}
+
+func ollamaRequest(ctx context.Context, client *http.Client, auth *cliproxyauth.Auth, method, requestURL string, body []byte) ([]byte, int, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	applyOllamaHeaders(httpReq, auth)
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	return data, httpResp.StatusCode, err
+}
+
+func applyOllamaHeaders(httpReq *http.Request, auth *cliproxyauth.Auth) {
+	if auth == nil {
+		return
+	}
+	if apiKey := strings.TrimSpace(auth.Attributes["api_key"]); apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, auth.Attributes)
+}
+
+func ollamaBaseURL(a *cliproxyauth.Auth) string {
+	if a == nil || a.Attributes == nil {
+		return ""
+	}
+	return strings.TrimRight(strings.TrimSpace(a.Attributes["base_url"]), "/")
+}
+
+func ollamaLoadTimeout(a *cliproxyauth.Auth) time.Duration {
+	seconds := config.DefaultOllamaLoadTimeoutSeconds
+	if a != nil && a.Attributes != nil {
+		if v, err := strconv.Atoi(strings.TrimSpace(a.Attributes["load_timeout_seconds"])); err == nil && v > 0 {
+			seconds = v
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// normalizeOllamaResponse rewrites an Ollama chat completion into the shape the OpenAI
+// translators expect.
+func normalizeOllamaResponse(data []byte) []byte {
+	message := gjson.GetBytes(data, "choices.0.message")
+	if reasoning := message.Get("reasoning"); reasoning.Type == gjson.String && !message.Get("reasoning_content").Exists() {
+		data, _ = sjson.SetBytes(data, "choices.0.message.reasoning_content", reasoning.String())
+		data, _ = sjson.DeleteBytes(data, "choices.0.message.reasoning")
+	}
+	if len(message.Get("tool_calls").Array()) > 0 && gjson.GetBytes(data, "choices.0.finish_reason").String() == "stop" {
+		data, _ = sjson.SetBytes(data, "choices.0.finish_reason", "tool_calls")
+	}
+	return data
+}
+
+// ollamaStreamState normalizes streamed chunks: reasoning deltas are renamed to
+// reasoning_content, tool call deltas get the index older Ollama versions omit, and a
+// "stop" finish after tool calls is reported as "tool_calls".
+type ollamaStreamState struct {
+	toolCalls int
+}
+
+func (s *ollamaStreamState) normalize(line []byte) []byte {
+	if !bytes.HasPrefix(line, dataTag) {
+		return line
+	}
+	data := bytes.TrimSpace(line[len(dataTag):])
+	if len(data) == 0 || data[0] != '{' {
+		return line
+	}
+	delta := gjson.GetBytes(data, "choices.0.delta")
+	changed := false
+	if reasoning := delta.Get("reasoning"); reasoning.Type == gjson.String && !delta.Get("reasoning_content").Exists() {
+		data, _ = sjson.SetBytes(data, "choices.0.delta.reasoning_content", reasoning.String())
+		data, _ = sjson.DeleteBytes(data, "choices.0.delta.reasoning")
+		changed = true
+	}
+	for i, call := range delta.Get("tool_calls").Array() {
+		index := call.Get("index")
+		if !index.Exists() {
+			// Without an index each delta carries one complete call.
+			data, _ = sjson.SetBytes(data, fmt.Sprintf("choices.0.delta.tool_calls.%d.index", i), s.toolCalls)
+			s.toolCalls++
+			changed = true
+			continue
+		}
+		if int(index.Int()) >= s.toolCalls {
+			s.toolCalls = int(index.Int()) + 1
+		}
+	}
+	if s.toolCalls > 0 && gjson.GetBytes(data, "choices.0.finish_reason").String() == "stop" {
+		data, _ = sjson.SetBytes(data, "choices.0.finish_reason", "tool_calls")
+		changed = true
+	}
+	if !changed {
+		return line
+	}
+	return append([]byte("data: "), data...)
+}
+
+// ollamaLoader records which models are resident on each server and coalesces concurrent
+// loads of the same model.
+type ollamaLoader struct {
+	mu          sync.Mutex
+	resident    map[string]time.Time
+	inflight    map[string]*ollamaLoad
+	unsupported map[string]struct{}
+}
+
+type ollamaLoad struct {
+	done chan struct{}
+	err  error
+}
+
+func newOllamaLoader() *ollamaLoader {
+	return &ollamaLoader{
+		resident:    make(map[string]time.Time),
+		inflight:    make(map[string]*ollamaLoad),
+		unsupported: make(map[string]struct{}),
+	}
+}
+
+// begin returns the load to wait on, or ok=false when the model is already resident or the
+// server cannot be asked to load it. The first caller for a cold model owns the load and
+// must run it.
+func (l *ollamaLoader) begin(baseURL, model string) (load *ollamaLoad, owner, ok bool) {
+	key := baseURL + "|" + model
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, skip := l.unsupported[baseURL]; skip {
+		return nil, false, false
+	}
+	if last, resident := l.resident[key]; resident && time.Since(last) < ollamaResidentTTL {
+		return nil, false, false
+	}
+	if running, inflight := l.inflight[key]; inflight {
+		return running, false, true
+	}
+	load = &ollamaLoad{done: make(chan struct{})}
+	l.inflight[key] = load
+	return load, true, true
+}
+
+func (l *ollamaLoader) finish(baseURL, model string, load *ollamaLoad, supported bool, err error) {
+	key := baseURL + "|" + model
+	l.mu.Lock()
+	delete(l.inflight, key)
+	switch {
+	case !supported:
+		l.unsupported[baseURL] = struct{}{}
+	case err == nil:
+		l.resident[key] = time.Now()
+	}
+	load.err = err
+	l.mu.Unlock()
+	close(load.done)
+}
+
+func (l *ollamaLoader) touch(baseURL, model string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, skip := l.unsupported[baseURL]; skip {
+		return
+	}
+	l.resident[baseURL+"|"+model] = time.Now()
+}
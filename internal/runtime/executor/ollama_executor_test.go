@@ -0,0 +1,143 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const ollamaChatResponse = `{"id":"c1","object":"chat.completion","model":"llama3","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`
+
+// ollamaServer stands in for a local model server. generate handles /api/generate.
+func ollamaServer(t *testing.T, generate http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var chats atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/generate", generate)
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		chats.Add(1)
+		_, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, ollamaChatResponse)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &chats
+}
+
+func executeOllama(auth *cliproxyauth.Auth) error {
+	payload := []byte(`{"model":"llama3","messages":[{"role":"user","content":"hi"}]}`)
+	_, err := NewOllamaExecutor(&config.Config{}).Execute(context.Background(), auth,
+		cliproxyexecutor.Request{Model: "llama3", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+	return err
+}
+
+func TestOllamaExecutor_ConcurrentRequestsShareOneLoad(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	srv, chats := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		loads.Add(1)
+		<-release
+		_, _ = io.WriteString(w, `{"done":true}`)
+	})
+	auth := &cliproxyauth.Auth{ID: "ollama-shared", Provider: "ollama", Attributes: map[string]string{"base_url": srv.URL}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// The manager hands every request its own copy of the auth.
+			errs <- executeOllama(auth.Clone())
+		}()
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+	if got := loads.Load(); got != 1 {
+		t.Fatalf("loads = %d, want one load shared by concurrent requests", got)
+	}
+	if got := chats.Load(); got != 5 {
+		t.Fatalf("chat requests = %d, want 5", got)
+	}
+}
+
+func TestOllamaExecutor_LoadTimeoutReturnsGatewayTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv, chats := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	})
+	// Runs before the server's cleanup, which waits for the handler to return.
+	t.Cleanup(func() { close(release) })
+	auth := &cliproxyauth.Auth{ID: "ollama-slow", Provider: "ollama", Attributes: map[string]string{
+		"base_url": srv.URL, "load_timeout_seconds": "1",
+	}}
+
+	err := executeOllama(auth)
+	var status statusErr
+	if !errors.As(err, &status) || status.StatusCode() != http.StatusGatewayTimeout {
+		t.Fatalf("Execute() error = %v, want a 504 after the load timeout", err)
+	}
+	if got := chats.Load(); got != 0 {
+		t.Fatalf("chat requests = %d, want none while the model failed to load", got)
+	}
+}
+
+func TestOllamaExecutor_ServerWithoutGenerateFallsThrough(t *testing.T) {
+	var loads atomic.Int32
+	srv, chats := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		loads.Add(1)
+		http.NotFound(w, r)
+	})
+	auth := &cliproxyauth.Auth{ID: "llamacpp", Provider: "ollama", Attributes: map[string]string{"base_url": srv.URL}}
+
+	for i := 0; i < 2; i++ {
+		if err := executeOllama(auth); err != nil {
+			t.Fatalf("Execute() #%d error = %v", i, err)
+		}
+	}
+	if got := loads.Load(); got != 1 {
+		t.Fatalf("load attempts = %d, want the missing generate route remembered after one try", got)
+	}
+	if got := chats.Load(); got != 2 {
+		t.Fatalf("chat requests = %d, want 2", got)
+	}
+}
+
+func TestOllamaExecutor_MapsThinkingAndTools(t *testing.T) {
+	if got := dropAutoReasoningEffort([]byte(`{"reasoning_effort":"auto"}`)); gjson.GetBytes(got, "reasoning_effort").Exists() {
+		t.Fatalf("dropAutoReasoningEffort() = %s, want the auto effort removed", got)
+	}
+	if got := dropAutoReasoningEffort([]byte(`{"reasoning_effort":"high"}`)); gjson.GetBytes(got, "reasoning_effort").String() != "high" {
+		t.Fatalf("dropAutoReasoningEffort() = %s, want explicit efforts kept", got)
+	}
+
+	out := normalizeOllamaResponse([]byte(`{"choices":[{"message":{"role":"assistant","reasoning":"think","tool_calls":[{"id":"t1","type":"function","function":{"name":"f","arguments":"{}"}}]},"finish_reason":"stop"}]}`))
+	if got := gjson.GetBytes(out, "choices.0.message.reasoning_content").String(); got != "think" {
+		t.Fatalf("reasoning_content = %q, want %q", got, "think")
+	}
+	if got := gjson.GetBytes(out, "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", got)
+	}
+}
@@ -11,11 +11,13 @@ import (
 	"time"
 
 	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
 	"github.com/giofahreza/AIProxyAPI/internal/util"
 	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
 	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
 	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
@@ -51,25 +53,12 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	}
 
 	// Translate inbound request to OpenAI format
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("openai")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
-	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, opts.Stream)
-	translated := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), opts.Stream)
-	modelOverride := e.resolveUpstreamModel(req.Model, auth)
-	if modelOverride != "" {
-		translated = e.overrideModel(translated, modelOverride)
-	}
-	translated = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", translated, originalTranslated)
-	allowCompat := e.allowCompatReasoningEffort(req.Model, auth)
-	translated = ApplyReasoningEffortMetadata(translated, req.Metadata, req.Model, "reasoning_effort", allowCompat)
-	translated = NormalizeThinkingConfig(translated, req.Model, allowCompat)
-	if errValidate := ValidateThinkingConfig(translated, req.Model); errValidate != nil {
+	built, errValidate := e.buildRequestBody(auth, req, opts, opts.Stream)
+	if errValidate != nil {
 		return resp, errValidate
 	}
+	from, to := opts.SourceFormat, built.format
+	translated := built.body
 
 	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
@@ -148,25 +137,12 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		err = statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
 		return nil, err
 	}
-	from := opts.SourceFormat
-	to := sdktranslator.FromString("openai")
-	originalPayload := bytes.Clone(req.Payload)
-	if len(opts.OriginalRequest) > 0 {
-		originalPayload = bytes.Clone(opts.OriginalRequest)
-	}
-	originalTranslated := sdktranslator.TranslateRequest(from, to, req.Model, originalPayload, true)
-	translated := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), true)
-	modelOverride := e.resolveUpstreamModel(req.Model, auth)
-	if modelOverride != "" {
-		translated = e.overrideModel(translated, modelOverride)
-	}
-	translated = applyPayloadConfigWithRoot(e.cfg, req.Model, to.String(), "", translated, originalTranslated)
-	allowCompat := e.allowCompatReasoningEffort(req.Model, auth)
-	translated = ApplyReasoningEffortMetadata(translated, req.Metadata, req.Model, "reasoning_effort", allowCompat)
-	translated = NormalizeThinkingConfig(translated, req.Model, allowCompat)
-	if errValidate := ValidateThinkingConfig(translated, req.Model); errValidate != nil {
+	built, errValidate := e.buildRequestBody(auth, req, opts, true)
+	if errValidate != nil {
 		return nil, errValidate
 	}
+	from, to := opts.SourceFormat, built.format
+	translated := built.body
 
 	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
@@ -259,6 +235,24 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	return stream, nil
 }
 
+// buildRequestBody prepares the OpenAI chat completions body. The validation error is returned
+// alongside the body so callers that only inspect the payload still see it.
+func (e *OpenAICompatExecutor) buildRequestBody(auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (upstreamBody, error) {
+	to := sdktranslator.FromString("openai")
+	body, originalTranslated := translateForUpstream(req, opts, to, req.Model, stream)
+	model := req.Model
+	if modelOverride := e.resolveUpstreamModel(req.Model, auth); modelOverride != "" {
+		body = e.overrideModel(body, modelOverride)
+		model = modelOverride
+	}
+	body, rules := applyPayloadConfigTraced(e.cfg, req.Model, to.String(), "", body, originalTranslated)
+	allowCompat := e.allowCompatReasoningEffort(req.Model, auth)
+	body = ApplyReasoningEffortMetadata(body, req.Metadata, req.Model, "reasoning_effort", allowCompat)
+	body = NormalizeThinkingConfig(body, req.Model, allowCompat)
+	built := upstreamBody{format: to, model: model, body: body, rules: rules}
+	return built, ValidateThinkingConfig(body, req.Model)
+}
+
 func (e *OpenAICompatExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("openai")
@@ -292,6 +286,87 @@ func (e *OpenAICompatExecutor) Refresh(ctx context.Context, auth *cliproxyauth.A
 	return auth, nil
 }
 
+// Probe checks the credential by listing the provider's models.
+func (e *OpenAICompatExecutor) Probe(ctx context.Context, auth *cliproxyauth.Auth) error {
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
+	util.ApplyCustomHeadersFromAttrs(httpReq, auth.Attributes)
+	return doProbeRequest(e.cfg, auth, httpReq)
+}
+
+// FetchOpenAICompatModels lists the models served by an OpenAI-compatible provider through
+// GET {base_url}/models. It returns nil when the list cannot be fetched so callers can keep the
+// previously discovered models.
+func FetchOpenAICompatModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	e := &OpenAICompatExecutor{cfg: cfg}
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		return nil
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return nil
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
+	util.ApplyCustomHeadersFromAttrs(httpReq, auth.Attributes)
+
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		log.Debugf("openai compat executor: model discovery failed for %s: %v", baseURL, err)
+		return nil
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("openai compat executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil || httpResp.StatusCode != http.StatusOK || !gjson.GetBytes(body, "data").IsArray() {
+		log.Debugf("openai compat executor: model discovery failed for %s: status %d, error %v", baseURL, httpResp.StatusCode, err)
+		return nil
+	}
+
+	models := make([]*registry.ModelInfo, 0)
+	gjson.GetBytes(body, "data").ForEach(func(_, model gjson.Result) bool {
+		id := strings.TrimSpace(model.Get("id").String())
+		if id == "" {
+			return true
+		}
+		info := &registry.ModelInfo{
+			ID:          id,
+			Name:        id,
+			Object:      "model",
+			Created:     model.Get("created").Int(),
+			OwnedBy:     model.Get("owned_by").String(),
+			Type:        "openai-compatibility",
+			DisplayName: id,
+		}
+		if info.Created <= 0 {
+			info.Created = time.Now().Unix()
+		}
+		if ctxLen := model.Get("context_length").Int(); ctxLen > 0 {
+			info.ContextLength = int(ctxLen)
+		}
+		models = append(models, info)
+		return true
+	})
+	return models
+}
+
 func (e *OpenAICompatExecutor) resolveCredentials(auth *cliproxyauth.Auth) (baseURL, apiKey string) {
 	if auth == nil {
 		return "", ""
@@ -313,6 +388,9 @@ func (e *OpenAICompatExecutor) resolveUpstreamModel(alias string, auth *cliproxy
 	}
 	for i := range compat.Models {
 		model := compat.Models[i]
+		if compat.DiscoverModels && model.IsAliasRule() {
+			continue
+		}
 		if model.Alias != "" {
 			if strings.EqualFold(model.Alias, alias) {
 				if model.Name != "" {
@@ -326,6 +404,16 @@ func (e *OpenAICompatExecutor) resolveUpstreamModel(alias string, auth *cliproxy
 			return model.Name
 		}
 	}
+	if compat.DiscoverModels {
+		for i := range compat.Models {
+			if !compat.Models[i].IsAliasRule() {
+				continue
+			}
+			if name, ok := compat.Models[i].ReverseAliasRule(alias); ok {
+				return name
+			}
+		}
+	}
 	return ""
 }
 
@@ -335,9 +423,13 @@ func (e *OpenAICompatExecutor) allowCompatReasoningEffort(model string, auth *cl
 		return false
 	}
 	compat := e.resolveCompatConfig(auth)
-	if compat == nil || len(compat.Models) == 0 {
+	if compat == nil {
 		return false
 	}
+	if compat.DiscoverModels {
+		// Discovered models are not listed in config, so any model served by the provider qualifies.
+		return true
+	}
 	for i := range compat.Models {
 		entry := compat.Models[i]
 		if strings.EqualFold(strings.TrimSpace(entry.Alias), trimmed) {
@@ -104,27 +104,47 @@ func ApplyReasoningEffortMetadata(payload []byte, metadata map[string]any, model
 	return payload
 }
 
+// AppliedPayloadRule records a single payload rule parameter written into an upstream payload.
+type AppliedPayloadRule struct {
+	// Kind is either "default" or "override".
+	Kind string `json:"kind"`
+	// Rule is the index of the rule within its list in the payload config.
+	Rule int `json:"rule"`
+	// Path is the full JSON path that was written.
+	Path string `json:"path"`
+	// Value is the value written at Path.
+	Value any `json:"value"`
+}
+
 // applyPayloadConfigWithRoot behaves like applyPayloadConfig but treats all parameter
 // paths as relative to the provided root path (for example, "request" for Gemini CLI)
 // and restricts matches to the given protocol when supplied. Defaults are checked
 // against the original payload when provided.
 func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload, original []byte) []byte {
+	out, _ := applyPayloadConfigTraced(cfg, model, protocol, root, payload, original)
+	return out
+}
+
+// applyPayloadConfigTraced applies payload rules like applyPayloadConfigWithRoot and also
+// reports every parameter it wrote.
+func applyPayloadConfigTraced(cfg *config.Config, model, protocol, root string, payload, original []byte) ([]byte, []AppliedPayloadRule) {
 	if cfg == nil || len(payload) == 0 {
-		return payload
+		return payload, nil
 	}
 	rules := cfg.Payload
 	if len(rules.Default) == 0 && len(rules.Override) == 0 {
-		return payload
+		return payload, nil
 	}
 	model = strings.TrimSpace(model)
 	if model == "" {
-		return payload
+		return payload, nil
 	}
 	out := payload
 	source := original
 	if len(source) == 0 {
 		source = payload
 	}
+	var applied []AppliedPayloadRule
 	appliedDefaults := make(map[string]struct{})
 	// Apply default rules: first write wins per field across all matching rules.
 	for i := range rules.Default {
@@ -149,6 +169,7 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 			}
 			out = updated
 			appliedDefaults[fullPath] = struct{}{}
+			applied = append(applied, AppliedPayloadRule{Kind: "default", Rule: i, Path: fullPath, Value: value})
 		}
 	}
 	// Apply override rules: last write wins per field across all matching rules.
@@ -167,9 +188,10 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 				continue
 			}
 			out = updated
+			applied = append(applied, AppliedPayloadRule{Kind: "override", Rule: i, Path: fullPath, Value: value})
 		}
 	}
-	return out
+	return out, applied
 }
 
 func payloadRuleMatchesModel(rule *config.PayloadRule, model, protocol string) bool {
@@ -187,7 +209,7 @@ func payloadRuleMatchesModel(rule *config.PayloadRule, model, protocol string) b
 		if ep := strings.TrimSpace(entry.Protocol); ep != "" && protocol != "" && !strings.EqualFold(ep, protocol) {
 			continue
 		}
-		if matchModelPattern(name, model) {
+		if util.MatchWildcard(name, model) {
 			return true
 		}
 	}
@@ -212,51 +234,6 @@ func buildPayloadPath(root, path string) string {
 	return r + "." + p
 }
 
-// matchModelPattern performs simple wildcard matching where '*' matches zero or more characters.
-// Examples:
-//
-//	"*-5" matches "gpt-5"
-//	"gpt-*" matches "gpt-5" and "gpt-4"
-//	"gemini-*-pro" matches "gemini-2.5-pro" and "gemini-3-pro".
-func matchModelPattern(pattern, model string) bool {
-	pattern = strings.TrimSpace(pattern)
-	model = strings.TrimSpace(model)
-	if pattern == "" {
-		return false
-	}
-	if pattern == "*" {
-		return true
-	}
-	// Iterative glob-style matcher supporting only '*' wildcard.
-	pi, si := 0, 0
-	starIdx := -1
-	matchIdx := 0
-	for si < len(model) {
-		if pi < len(pattern) && (pattern[pi] == model[si]) {
-			pi++
-			si++
-			continue
-		}
-		if pi < len(pattern) && pattern[pi] == '*' {
-			starIdx = pi
-			matchIdx = si
-			pi++
-			continue
-		}
-		if starIdx != -1 {
-			pi = starIdx + 1
-			matchIdx++
-			si = matchIdx
-			continue
-		}
-		return false
-	}
-	for pi < len(pattern) && pattern[pi] == '*' {
-		pi++
-	}
-	return pi == len(pattern)
-}
-
 // NormalizeThinkingConfig normalizes thinking-related fields in the payload
 // based on model capabilities. For models without thinking support, it strips
 // reasoning fields. For models with level-based thinking, it validates and
@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+)
+
+// RequestPreview describes the upstream request an executor would build for an auth,
+// without sending it.
+type RequestPreview struct {
+	// Format is the upstream payload schema.
+	Format string `json:"format"`
+	// UpstreamModel is the model name after per-credential aliases are resolved.
+	UpstreamModel string `json:"upstream_model"`
+	// PayloadRules lists the payload config parameters written into the payload.
+	PayloadRules []AppliedPayloadRule `json:"payload_rules,omitempty"`
+	// Payload is the translated provider payload.
+	Payload []byte `json:"-"`
+	// ValidationError reports thinking validation failures the executor would reject the request with.
+	ValidationError string `json:"validation_error,omitempty"`
+}
+
+// upstreamBody is the payload an executor sends upstream for a request, together with the
+// details of how it was built that RequestPreview reports.
+type upstreamBody struct {
+	format sdktranslator.Format
+	model  string
+	body   []byte
+	rules  []AppliedPayloadRule
+	// betas are the Anthropic beta flags moved from the body to the anthropic-beta header.
+	betas []string
+}
+
+// translateForUpstream translates the request and the original client request into to.
+func translateForUpstream(req cliproxyexecutor.Request, opts cliproxyexecutor.Options, to sdktranslator.Format, model string, stream bool) (body, original []byte) {
+	originalPayload := bytes.Clone(req.Payload)
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = bytes.Clone(opts.OriginalRequest)
+	}
+	original = sdktranslator.TranslateRequest(opts.SourceFormat, to, model, originalPayload, stream)
+	body = sdktranslator.TranslateRequest(opts.SourceFormat, to, model, bytes.Clone(req.Payload), stream)
+	return body, original
+}
+
+// PreviewRequest builds the payload the executor registered for auth.Provider would send, using
+// the same body builder as the executor itself. Transport-level steps (headers, token refresh,
+// endpoint selection) are not part of the preview.
+func PreviewRequest(cfg *config.Config, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) RequestPreview {
+	provider := ""
+	if auth != nil {
+		provider = strings.ToLower(strings.TrimSpace(auth.Provider))
+	}
+	stream := opts.Stream
+
+	var built upstreamBody
+	var errValidate error
+	switch provider {
+	case "claude":
+		built = NewClaudeExecutor(cfg).buildRequestBody(auth, req, opts, stream)
+	case "codex":
+		built, errValidate = NewCodexExecutor(cfg).buildRequestBody(auth, req, opts, stream)
+	case "bedrock":
+		built = NewBedrockExecutor(cfg).buildRequestBody(auth, req, opts, stream)
+	case "azure-openai":
+		built, errValidate = NewAzureOpenAIExecutor(cfg).buildRequestBody(auth, req, opts, stream)
+	case "ollama":
+		built, errValidate = NewOllamaExecutor(cfg).buildRequestBody(auth, req, opts, stream)
+	case "gemini":
+		built = NewGeminiExecutor(cfg).buildRequestBody(auth, req, opts, stream)
+	case "vertex":
+		built = NewGeminiVertexExecutor(cfg).buildRequestBody(auth, req, opts, stream)
+	case "aistudio":
+		built = NewAIStudioExecutor(cfg, provider, nil).buildRequestBody(req, opts, stream)
+	case "gemini-cli":
+		built = NewGeminiCLIExecutor(cfg).buildRequestBody(req, opts, stream)
+	case antigravityAuthType:
+		built = NewAntigravityExecutor(cfg).buildRequestBody(req, opts, stream)
+	case "qwen":
+		built, errValidate = NewQwenExecutor(cfg).buildRequestBody(req, opts, stream)
+	case "iflow":
+		built, errValidate = NewIFlowExecutor(cfg).buildRequestBody(req, opts, stream)
+	case "copilot":
+		built, errValidate = NewCopilotExecutor(cfg).buildRequestBody(req, opts, stream)
+	default:
+		// Every other provider identifier is an OpenAI-compatible upstream.
+		built, errValidate = NewOpenAICompatExecutor(provider, cfg).buildRequestBody(auth, req, opts, stream)
+	}
+
+	preview := RequestPreview{
+		Format:        built.format.String(),
+		UpstreamModel: built.model,
+		PayloadRules:  built.rules,
+		Payload:       built.body,
+	}
+	if errValidate != nil {
+		preview.ValidationError = errValidate.Error()
+	}
+	return preview
+}
@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// doProbeRequest sends a health probe request and maps non-2xx responses to statusErr so the
+// auth manager can classify them like regular request failures.
+func doProbeRequest(cfg *config.Config, auth *cliproxyauth.Auth, httpReq *http.Request) error {
+	ctx := httpReq.Context()
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("health probe: close response body error: %v", errClose)
+		}
+	}()
+	recordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		return statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	_, _ = io.Copy(io.Discard, httpResp.Body)
+	return nil
+}
@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+)
+
+// ReplayChunk pairs one upstream stream line with the client chunks the translator produced for it.
+type ReplayChunk struct {
+	Input  string   `json:"input"`
+	Output []string `json:"output"`
+}
+
+// ReplayStream feeds a captured upstream SSE transcript through the registered stream translator,
+// framing lines the same way the executor for the upstream format does: which lines are skipped,
+// whether the "data:" prefix is stripped and whether a trailing [DONE] is injected.
+func ReplayStream(ctx context.Context, upstream, client sdktranslator.Format, model string, originalRequest, translatedRequest, transcript []byte) ([]ReplayChunk, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(transcript))
+	scanner.Buffer(nil, streamScannerBuffer)
+	var param any
+	chunks := make([]ReplayChunk, 0)
+	feed := func(line []byte) {
+		out := sdktranslator.TranslateStream(ctx, upstream, client, model, bytes.Clone(originalRequest), translatedRequest, bytes.Clone(line), &param)
+		if out == nil {
+			out = []string{}
+		}
+		chunks = append(chunks, ReplayChunk{Input: string(line), Output: out})
+	}
+
+	injectDone := false
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch upstream {
+		case sdktranslator.FormatGemini:
+			injectDone = true
+			if payload := jsonPayload(FilterSSEUsageMetadata(line)); len(payload) > 0 {
+				feed(payload)
+			}
+		case sdktranslator.FormatAntigravity:
+			injectDone = true
+			if payload := jsonPayload(line); len(payload) > 0 {
+				feed(payload)
+			}
+		case sdktranslator.FormatGeminiCLI:
+			injectDone = true
+			if bytes.HasPrefix(line, dataTag) {
+				feed(line)
+			}
+		case sdktranslator.FormatOpenAI:
+			if len(line) > 0 {
+				feed(line)
+			}
+		default:
+			// Claude and Codex executors hand every line, blank separators included, to the translator.
+			feed(line)
+		}
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return chunks, errScan
+	}
+	if injectDone {
+		feed([]byte("[DONE]"))
+	}
+	return chunks, nil
+}
+
+// ReplayNonStream runs a captured upstream response body through the registered non-stream translator.
+func ReplayNonStream(ctx context.Context, upstream, client sdktranslator.Format, model string, originalRequest, translatedRequest, body []byte) string {
+	var param any
+	return sdktranslator.TranslateNonStream(ctx, upstream, client, model, bytes.Clone(originalRequest), translatedRequest, bytes.Clone(body), &param)
+}
@@ -21,6 +21,7 @@ type usageReporter struct {
 	authID      string
 	authIndex   string
 	apiKey      string
+	endUser     string
 	source      string
 	requestedAt time.Time
 	userPrompt  string
@@ -34,6 +35,7 @@ func newUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		model:       model,
 		requestedAt: time.Now(),
 		apiKey:      apiKey,
+		endUser:     ginContextString(ctx, "endUser"),
 		source:      resolveUsageSource(auth, apiKey),
 		userPrompt:  extractUserPromptFromContext(ctx),
 	}
@@ -71,7 +73,7 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 			detail.TotalTokens = total
 		}
 	}
-	if detail.InputTokens == 0 && detail.OutputTokens == 0 && detail.ReasoningTokens == 0 && detail.CachedTokens == 0 && detail.TotalTokens == 0 && !failed {
+	if detail.InputTokens == 0 && detail.OutputTokens == 0 && detail.ReasoningTokens == 0 && detail.CachedTokens == 0 && detail.CacheWriteTokens == 0 && detail.TotalTokens == 0 && detail.Images == 0 && !failed {
 		return
 	}
 	r.once.Do(func() {
@@ -80,6 +82,7 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 			Model:       r.model,
 			Source:      r.source,
 			APIKey:      r.apiKey,
+			EndUser:     r.endUser,
 			AuthID:      r.authID,
 			AuthIndex:   r.authIndex,
 			RequestedAt: r.requestedAt,
@@ -104,6 +107,7 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 			Model:       r.model,
 			Source:      r.source,
 			APIKey:      r.apiKey,
+			EndUser:     r.endUser,
 			AuthID:      r.authID,
 			AuthIndex:   r.authIndex,
 			RequestedAt: r.requestedAt,
@@ -115,6 +119,11 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 }
 
 func apiKeyFromContext(ctx context.Context) string {
+	return ginContextString(ctx, "apiKey")
+}
+
+// ginContextString returns the value stored under key on the gin context carried by ctx.
+func ginContextString(ctx context.Context, key string) string {
 	if ctx == nil {
 		return ""
 	}
@@ -122,7 +131,7 @@ func apiKeyFromContext(ctx context.Context) string {
 	if !ok || ginCtx == nil {
 		return ""
 	}
-	if v, exists := ginCtx.Get("apiKey"); exists {
+	if v, exists := ginCtx.Get(key); exists {
 		switch value := v.(type) {
 		case string:
 			return value
@@ -273,7 +282,15 @@ func truncateString(s string, maxLen int) string {
 }
 
 func parseCodexUsage(data []byte) (usage.Detail, bool) {
-	usageNode := gjson.ParseBytes(data).Get("response.usage")
+	return responsesUsageDetail(gjson.ParseBytes(data).Get("response.usage"))
+}
+
+// parseResponsesUsage reads usage from a non-streamed OpenAI Responses API object.
+func parseResponsesUsage(data []byte) (usage.Detail, bool) {
+	return responsesUsageDetail(gjson.ParseBytes(data).Get("usage"))
+}
+
+func responsesUsageDetail(usageNode gjson.Result) (usage.Detail, bool) {
 	if !usageNode.Exists() {
 		return usage.Detail{}, false
 	}
@@ -333,22 +350,28 @@ func parseOpenAIStreamUsage(line []byte) (usage.Detail, bool) {
 	return detail, true
 }
 
+// claudeUsageDetail converts Anthropic usage, whose input_tokens leave out the prompt tokens read
+// from or written to the cache, to the convention of the other providers: InputTokens is the
+// whole prompt, CachedTokens the part read from cache and CacheWriteTokens the part written.
+func claudeUsageDetail(usageNode gjson.Result) usage.Detail {
+	cacheRead := usageNode.Get("cache_read_input_tokens").Int()
+	cacheWrite := usageNode.Get("cache_creation_input_tokens").Int()
+	detail := usage.Detail{
+		InputTokens:      usageNode.Get("input_tokens").Int() + cacheRead + cacheWrite,
+		OutputTokens:     usageNode.Get("output_tokens").Int(),
+		CachedTokens:     cacheRead,
+		CacheWriteTokens: cacheWrite,
+	}
+	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	return detail
+}
+
 func parseClaudeUsage(data []byte) usage.Detail {
 	usageNode := gjson.ParseBytes(data).Get("usage")
 	if !usageNode.Exists() {
 		return usage.Detail{}
 	}
-	detail := usage.Detail{
-		InputTokens:  usageNode.Get("input_tokens").Int(),
-		OutputTokens: usageNode.Get("output_tokens").Int(),
-		CachedTokens: usageNode.Get("cache_read_input_tokens").Int(),
-	}
-	if detail.CachedTokens == 0 {
-		// fall back to creation tokens when read tokens are absent
-		detail.CachedTokens = usageNode.Get("cache_creation_input_tokens").Int()
-	}
-	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
-	return detail
+	return claudeUsageDetail(usageNode)
 }
 
 func parseClaudeStreamUsage(line []byte) (usage.Detail, bool) {
@@ -360,16 +383,7 @@ func parseClaudeStreamUsage(line []byte) (usage.Detail, bool) {
 	if !usageNode.Exists() {
 		return usage.Detail{}, false
 	}
-	detail := usage.Detail{
-		InputTokens:  usageNode.Get("input_tokens").Int(),
-		OutputTokens: usageNode.Get("output_tokens").Int(),
-		CachedTokens: usageNode.Get("cache_read_input_tokens").Int(),
-	}
-	if detail.CachedTokens == 0 {
-		detail.CachedTokens = usageNode.Get("cache_creation_input_tokens").Int()
-	}
-	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
-	return detail, true
+	return claudeUsageDetail(usageNode), true
 }
 
 func parseGeminiFamilyUsageDetail(node gjson.Result) usage.Detail {
@@ -386,16 +400,45 @@ func parseGeminiFamilyUsageDetail(node gjson.Result) usage.Detail {
 	return detail
 }
 
+// countGeminiImages counts the images in the candidates of a Gemini response, leaving out the
+// drafts that image models emit while thinking.
+func countGeminiImages(candidates gjson.Result) int64 {
+	var images int64
+	candidates.ForEach(func(_, candidate gjson.Result) bool {
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if part.Get("thought").Bool() {
+				return true
+			}
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			mimeType := inline.Get("mimeType").String()
+			if mimeType == "" {
+				mimeType = inline.Get("mime_type").String()
+			}
+			if strings.HasPrefix(mimeType, "image/") {
+				images++
+			}
+			return true
+		})
+		return true
+	})
+	return images
+}
+
 func parseGeminiCLIUsage(data []byte) usage.Detail {
 	usageNode := gjson.ParseBytes(data)
 	node := usageNode.Get("response.usageMetadata")
 	if !node.Exists() {
 		node = usageNode.Get("response.usage_metadata")
 	}
-	if !node.Exists() {
-		return usage.Detail{}
+	var detail usage.Detail
+	if node.Exists() {
+		detail = parseGeminiFamilyUsageDetail(node)
 	}
-	return parseGeminiFamilyUsageDetail(node)
+	detail.Images = countGeminiImages(usageNode.Get("response.candidates"))
+	return detail
 }
 
 func parseGeminiUsage(data []byte) usage.Detail {
@@ -404,10 +447,12 @@ func parseGeminiUsage(data []byte) usage.Detail {
 	if !node.Exists() {
 		node = usageNode.Get("usage_metadata")
 	}
-	if !node.Exists() {
-		return usage.Detail{}
+	var detail usage.Detail
+	if node.Exists() {
+		detail = parseGeminiFamilyUsageDetail(node)
 	}
-	return parseGeminiFamilyUsageDetail(node)
+	detail.Images = countGeminiImages(usageNode.Get("candidates"))
+	return detail
 }
 
 func parseGeminiStreamUsage(line []byte) (usage.Detail, bool) {
@@ -449,10 +494,16 @@ func parseAntigravityUsage(data []byte) usage.Detail {
 	if !node.Exists() {
 		node = usageNode.Get("usage_metadata")
 	}
-	if !node.Exists() {
-		return usage.Detail{}
+	var detail usage.Detail
+	if node.Exists() {
+		detail = parseGeminiFamilyUsageDetail(node)
 	}
-	return parseGeminiFamilyUsageDetail(node)
+	candidates := usageNode.Get("response.candidates")
+	if !candidates.Exists() {
+		candidates = usageNode.Get("candidates")
+	}
+	detail.Images = countGeminiImages(candidates)
+	return detail
 }
 
 func parseAntigravityStreamUsage(line []byte) (usage.Detail, bool) {
@@ -2,19 +2,28 @@
 package store
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	log "github.com/sirupsen/logrus"
 )
 
 // FileStore implements file-based persistent storage for usage statistics.
-// It stores usage statistics as a JSON file in a configured directory,
-// following the same pattern as OAuth credential storage.
+// It stores a bounded usage checkpoint as a JSON file in a configured directory,
+// following the same pattern as OAuth credential storage, and appends requests recorded
+// between checkpoints to a JSON-lines journal next to it.
 type FileStore struct {
-	mu       sync.Mutex
-	filePath string
+	mu          sync.Mutex
+	filePath    string
+	journalPath string
 }
 
 // NewFileStore creates a new file-based storage instance.
@@ -42,11 +51,13 @@ func NewFileStore(baseDir string) (*FileStore, error) {
 	filePath := filepath.Join(baseDir, "usage-statistics.json")
 
 	return &FileStore{
-		filePath: filePath,
+		filePath:    filePath,
+		journalPath: filepath.Join(baseDir, "usage-statistics.journal"),
 	}, nil
 }
 
-// SaveUsageStatistics persists the usage statistics snapshot to a JSON file.
+// SaveUsageStatistics persists the usage checkpoint to a JSON file and discards the journal,
+// whose events the checkpoint already contains.
 // The data parameter should be a JSON-encoded byte slice.
 // The file is written atomically using a temporary file and rename.
 func (s *FileStore) SaveUsageStatistics(ctx context.Context, data []byte) error {
@@ -69,28 +80,104 @@ func (s *FileStore) SaveUsageStatistics(ctx context.Context, data []byte) error
 		return fmt.Errorf("file store: failed to rename temporary file: %w", err)
 	}
 
+	if err := os.Remove(s.journalPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("file store: failed to remove usage journal: %w", err)
+	}
+
 	return nil
 }
 
-// LoadUsageStatistics retrieves the usage statistics snapshot from the JSON file.
-// If the file does not exist, it returns nil without error (indicating no previous statistics).
-// If the file exists but cannot be read, an error is returned.
-func (s *FileStore) LoadUsageStatistics(ctx context.Context) ([]byte, error) {
+// AppendUsageEvents appends request events to the usage journal, one JSON object per line.
+func (s *FileStore) AppendUsageEvents(ctx context.Context, events []usage.Event) error {
 	if s == nil {
-		return nil, fmt.Errorf("file store: not initialized")
+		return fmt.Errorf("file store: not initialized")
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("file store: failed to encode usage event: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.journalPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("file store: failed to open usage journal: %w", err)
+	}
+	if _, err = file.Write(buf.Bytes()); err == nil {
+		err = file.Sync()
+	}
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return fmt.Errorf("file store: failed to append usage journal: %w", err)
+	}
+	return nil
+}
+
+// LoadUsageJournal returns the stored checkpoint and the journaled events. A torn last line left
+// by a crash is skipped.
+func (s *FileStore) LoadUsageJournal(ctx context.Context) ([]byte, []usage.Event, error) {
+	if s == nil {
+		return nil, nil, fmt.Errorf("file store: not initialized")
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	data, err := os.ReadFile(s.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("file store: failed to read file: %w", err)
+	}
+	file, err := os.Open(s.journalPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// File doesn't exist yet - this is normal on first run
-			return nil, nil
+			return data, nil, nil
 		}
-		return nil, fmt.Errorf("file store: failed to read file: %w", err)
+		return nil, nil, fmt.Errorf("file store: failed to open usage journal: %w", err)
 	}
+	defer func() { _ = file.Close() }()
 
-	return data, nil
+	var events []usage.Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var event usage.Event
+		if errDecode := json.Unmarshal(scanner.Bytes(), &event); errDecode != nil {
+			log.WithError(errDecode).Warnf("file store: skipping malformed usage journal line %d", line)
+			continue
+		}
+		events = append(events, event)
+	}
+	if err = scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		log.Warnf("file store: usage journal has an oversized line, ignoring the rest (%d events loaded)", len(events))
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("file store: failed to read usage journal: %w", err)
+	}
+	return data, events, nil
+}
+
+// LoadUsageStatistics returns the stored checkpoint with the journaled events folded in.
+// If nothing was stored yet, it returns nil without error (indicating no previous statistics).
+// If the files exist but cannot be read, an error is returned.
+func (s *FileStore) LoadUsageStatistics(ctx context.Context) ([]byte, error) {
+	data, events, err := s.LoadUsageJournal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return data, nil
+	}
+	return usage.CompactUsage(data, events)
 }
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+)
+
+func TestFileStore_UsageJournal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	event := usage.Event{Seq: 1, APIKey: "key-1", Model: "gpt-test", RequestDetail: usage.RequestDetail{Timestamp: time.Now(), Tokens: usage.TokenStats{TotalTokens: 4}}}
+	if err = s.AppendUsageEvents(ctx, []usage.Event{event}); err != nil {
+		t.Fatalf("AppendUsageEvents: %v", err)
+	}
+	// A torn write left by a crash must not hide the events before it.
+	file, _ := os.OpenFile(filepath.Join(dir, "usage-statistics.journal"), os.O_WRONLY|os.O_APPEND, 0o600)
+	_, _ = file.WriteString(`{"seq":2,"api_key":"key-1","mod`)
+	_ = file.Close()
+
+	data, events, err := s.LoadUsageJournal(ctx)
+	if err != nil {
+		t.Fatalf("LoadUsageJournal: %v", err)
+	}
+	if data != nil || len(events) != 1 || events[0].Tokens.TotalTokens != 4 {
+		t.Fatalf("journal = %s, %+v", data, events)
+	}
+
+	compacted, err := s.LoadUsageStatistics(ctx)
+	if err != nil || compacted == nil {
+		t.Fatalf("LoadUsageStatistics = %s, %v", compacted, err)
+	}
+	if err = s.SaveUsageStatistics(ctx, compacted); err != nil {
+		t.Fatalf("SaveUsageStatistics: %v", err)
+	}
+	if _, errStat := os.Stat(filepath.Join(dir, "usage-statistics.journal")); !os.IsNotExist(errStat) {
+		t.Fatal("checkpoint did not discard the journal")
+	}
+
+	stats := usage.NewRequestStatistics()
+	data, events, _ = s.LoadUsageJournal(ctx)
+	if err = stats.Restore(data, events); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if snapshot := stats.Snapshot(); snapshot.TotalRequests != 1 || snapshot.TotalTokens != 4 {
+		t.Fatalf("restored totals = %d requests, %d tokens, want 1 and 4", snapshot.TotalRequests, snapshot.TotalTokens)
+	}
+}
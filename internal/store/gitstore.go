@@ -18,7 +18,11 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/object"
 	"github.com/go-git/go-git/v6/plumbing/transport"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
+	baseauth "github.com/giofahreza/AIProxyAPI/internal/auth"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
+	"github.com/giofahreza/AIProxyAPI/internal/misc"
 	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 // GitTokenStore persists token records and auth metadata using git as the backing storage.
@@ -241,8 +245,14 @@ func (s *GitTokenStore) Save(_ context.Context, auth *cliproxyauth.Auth) (string
 
 	switch {
 	case auth.Storage != nil:
-		if err = auth.Storage.SaveTokenToFile(path); err != nil {
-			return "", err
+		// Seal in memory and write once, so plaintext tokens never reach disk.
+		raw, errEncode := baseauth.EncodeTokenStorage(auth.Storage)
+		if errEncode != nil {
+			return "", fmt.Errorf("auth filestore: encode token storage: %w", errEncode)
+		}
+		misc.LogSavingCredentials(path)
+		if err = authcrypt.WriteSealedFile(path, raw); err != nil {
+			return "", fmt.Errorf("auth filestore: encrypt auth file: %w", err)
 		}
 	case auth.Metadata != nil:
 		raw, errMarshal := json.Marshal(auth.Metadata)
@@ -250,12 +260,15 @@ func (s *GitTokenStore) Save(_ context.Context, auth *cliproxyauth.Auth) (string
 			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", errMarshal)
 		}
 		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+			if plain, errOpen := authcrypt.Open(existing); errOpen == nil && authcrypt.IsCurrent(existing) && jsonEqual(plain, raw) {
 				return path, nil
 			}
 		} else if !os.IsNotExist(errRead) {
 			return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
 		}
+		if raw, errMarshal = authcrypt.Seal(raw); errMarshal != nil {
+			return "", fmt.Errorf("auth filestore: encrypt auth file: %w", errMarshal)
+		}
 		tmp := path + ".tmp"
 		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("auth filestore: write temp failed: %w", errWrite)
@@ -313,6 +326,9 @@ func (s *GitTokenStore) List(_ context.Context) ([]*cliproxyauth.Auth, error) {
 		}
 		auth, err := s.readAuthFile(path, dir)
 		if err != nil {
+			if errors.Is(err, authcrypt.ErrDecrypt) {
+				log.WithError(err).Warnf("auth filestore: skipping %s", path)
+			}
 			return nil
 		}
 		if auth != nil {
@@ -406,7 +422,7 @@ func (s *GitTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *GitTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
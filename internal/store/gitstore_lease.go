@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	log "github.com/sirupsen/logrus"
+)
+
+// gitLeaseRefPrefix holds one ref per auth lease. Lease commits chain onto each other and are
+// pushed without force, so the remote's fast-forward check decides which replica wins.
+const gitLeaseRefPrefix = "refs/aiproxy/leases/"
+
+// AcquireRefreshLease takes the refresh lease for id by pushing a lease commit on top of the
+// current one. A rejected push means another replica updated the lease first.
+func (s *GitTokenStore) AcquireRefreshLease(ctx context.Context, id, holder string, ttl time.Duration) (bool, error) {
+	if err := s.EnsureRepository(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return false, fmt.Errorf("git token store: open repo: %w", err)
+	}
+	refName := plumbing.ReferenceName(gitLeaseRefPrefix + leaseKey(id))
+	current, parent, err := s.fetchLeaseLocked(ctx, repo, refName)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && current.Holder != holder && time.Now().Before(current.ExpiresAt) {
+		return false, nil
+	}
+	return s.pushLeaseLocked(ctx, repo, refName, parent, refreshLease{ID: id, Holder: holder, ExpiresAt: time.Now().Add(ttl)})
+}
+
+// ReleaseRefreshLease pushes an expired lease commit when holder still owns the lease.
+func (s *GitTokenStore) ReleaseRefreshLease(ctx context.Context, id, holder string) error {
+	if err := s.EnsureRepository(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return fmt.Errorf("git token store: open repo: %w", err)
+	}
+	refName := plumbing.ReferenceName(gitLeaseRefPrefix + leaseKey(id))
+	current, parent, err := s.fetchLeaseLocked(ctx, repo, refName)
+	if err != nil || current == nil || current.Holder != holder {
+		return err
+	}
+	_, err = s.pushLeaseLocked(ctx, repo, refName, parent, refreshLease{ID: id})
+	return err
+}
+
+// fetchLeaseLocked mirrors the remote lease ref locally and decodes it. A missing ref yields a nil lease.
+func (s *GitTokenStore) fetchLeaseLocked(ctx context.Context, repo *git.Repository, refName plumbing.ReferenceName) (*refreshLease, plumbing.Hash, error) {
+	spec := config.RefSpec("+" + refName.String() + ":" + refName.String())
+	err := repo.FetchContext(ctx, &git.FetchOptions{RemoteName: "origin", Auth: s.gitAuth(), RefSpecs: []config.RefSpec{spec}})
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate):
+	case errors.Is(err, git.ErrRemoteRefNotFound), errors.Is(err, transport.ErrEmptyRemoteRepository):
+		_ = repo.Storer.RemoveReference(refName)
+		return nil, plumbing.ZeroHash, nil
+	default:
+		return nil, plumbing.ZeroHash, fmt.Errorf("git token store: fetch lease: %w", err)
+	}
+	ref, err := repo.Reference(refName, true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, plumbing.ZeroHash, nil
+		}
+		return nil, plumbing.ZeroHash, fmt.Errorf("git token store: resolve lease: %w", err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, plumbing.ZeroHash, fmt.Errorf("git token store: read lease commit: %w", err)
+	}
+	var lease refreshLease
+	if err = json.Unmarshal([]byte(commit.Message), &lease); err != nil {
+		// Unreadable leases are treated as free but still used as parent to keep pushes fast-forward.
+		return nil, ref.Hash(), nil
+	}
+	return &lease, ref.Hash(), nil
+}
+
+// pushLeaseLocked records lease as a child of parent and pushes it without force.
+func (s *GitTokenStore) pushLeaseLocked(ctx context.Context, repo *git.Repository, refName plumbing.ReferenceName, parent plumbing.Hash, lease refreshLease) (bool, error) {
+	message, err := json.Marshal(lease)
+	if err != nil {
+		return false, fmt.Errorf("git token store: encode lease: %w", err)
+	}
+	treeObj := repo.Storer.NewEncodedObject()
+	if err = (&object.Tree{}).Encode(treeObj); err != nil {
+		return false, fmt.Errorf("git token store: encode lease tree: %w", err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(treeObj)
+	if err != nil {
+		return false, fmt.Errorf("git token store: write lease tree: %w", err)
+	}
+	signature := object.Signature{Name: "AIProxyAPI", Email: "cliproxy@local", When: time.Now()}
+	commit := &object.Commit{Author: signature, Committer: signature, Message: string(message), TreeHash: treeHash}
+	if !parent.IsZero() {
+		commit.ParentHashes = []plumbing.Hash{parent}
+	}
+	commitObj := repo.Storer.NewEncodedObject()
+	if err = commit.Encode(commitObj); err != nil {
+		return false, fmt.Errorf("git token store: encode lease commit: %w", err)
+	}
+	commitHash, err := repo.Storer.SetEncodedObject(commitObj)
+	if err != nil {
+		return false, fmt.Errorf("git token store: write lease commit: %w", err)
+	}
+	if err = repo.Storer.SetReference(plumbing.NewHashReference(refName, commitHash)); err != nil {
+		return false, fmt.Errorf("git token store: update lease ref: %w", err)
+	}
+	spec := config.RefSpec(refName.String() + ":" + refName.String())
+	err = repo.PushContext(ctx, &git.PushOptions{RemoteName: "origin", Auth: s.gitAuth(), RefSpecs: []config.RefSpec{spec}})
+	if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return true, nil
+	}
+	if parent.IsZero() {
+		_ = repo.Storer.RemoveReference(refName)
+	} else {
+		_ = repo.Storer.SetReference(plumbing.NewHashReference(refName, parent))
+	}
+	if isLeaseRace(err) {
+		log.Debugf("git token store: lease push for %s lost: %v", lease.ID, err)
+		return false, nil
+	}
+	return false, fmt.Errorf("git token store: push lease: %w", err)
+}
+
+func isLeaseRace(err error) bool {
+	if errors.Is(err, git.ErrForceNeeded) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "non-fast-forward") || strings.Contains(msg, "rejected") || strings.Contains(msg, "failed to update ref")
+}
+
+// ReadAuth fetches the remote branch and returns the record for id as the remote has it. The local
+// copy is updated so the working tree follows replicas that refreshed the credential.
+func (s *GitTokenStore) ReadAuth(ctx context.Context, id string) (*cliproxyauth.Auth, error) {
+	if err := s.EnsureRepository(); err != nil {
+		return nil, err
+	}
+	path, err := s.resolveAuthPath(&cliproxyauth.Auth{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	rel, err := s.relativeToRepo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return nil, fmt.Errorf("git token store: open repo: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: get head: %w", err)
+	}
+	branch := head.Name().Short()
+	remoteRef := plumbing.NewRemoteReferenceName("origin", branch)
+	spec := config.RefSpec("+" + head.Name().String() + ":" + remoteRef.String())
+	if err = repo.FetchContext(ctx, &git.FetchOptions{RemoteName: "origin", Auth: s.gitAuth(), RefSpecs: []config.RefSpec{spec}, Force: true}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("git token store: fetch %s: %w", branch, err)
+	}
+	ref, err := repo.Reference(remoteRef, true)
+	if err != nil {
+		return nil, fmt.Errorf("git token store: resolve %s: %w", remoteRef, err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read remote commit: %w", err)
+	}
+	file, err := commit.File(filepath.ToSlash(rel))
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git token store: read %s: %w", rel, err)
+	}
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read %s: %w", rel, err)
+	}
+	if existing, errRead := os.ReadFile(path); errRead != nil || string(existing) != contents {
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("git token store: create auth dir: %w", err)
+		}
+		if err = os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			return nil, fmt.Errorf("git token store: write %s: %w", rel, err)
+		}
+	}
+	return s.readAuthFile(path, s.baseDirSnapshot())
+}
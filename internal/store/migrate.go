@@ -0,0 +1,907 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/minio/minio-go/v7"
+)
+
+// Backend kinds accepted by MigrationEndpoint.Kind.
+const (
+	BackendFile     = "file"
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendGit      = "git"
+	BackendObject   = "object"
+)
+
+// Actions recorded for each migrated item.
+const (
+	MigrationCreate    = "create"
+	MigrationUpdate    = "update"
+	MigrationUnchanged = "unchanged"
+	MigrationSkipped   = "skipped"
+)
+
+const usageStatisticsFile = "usage-statistics.json"
+
+// errUsageNotShared marks backends whose usage snapshot lives in each replica's local workspace
+// rather than in the shared backend.
+var errUsageNotShared = errors.New("usage statistics are kept in the local workspace for this backend")
+
+// MigrationEndpoint identifies one side of a store migration. Only the fields for Kind are used;
+// local workspaces for the remote backends are created in a temporary directory.
+type MigrationEndpoint struct {
+	Kind string
+
+	// ConfigPath and AuthDir locate the file backend.
+	ConfigPath string
+	AuthDir    string
+
+	Postgres PostgresStoreConfig
+
+	// SQLitePath is the database file of the SQLite backend.
+	SQLitePath string
+
+	GitRemote   string
+	GitUsername string
+	GitPassword string
+
+	Object ObjectStoreConfig
+}
+
+// String describes the endpoint for log output without exposing credentials.
+func (e MigrationEndpoint) String() string {
+	switch e.Kind {
+	case BackendFile:
+		return fmt.Sprintf("file (%s, %s)", e.ConfigPath, e.AuthDir)
+	case BackendSQLite:
+		return fmt.Sprintf("sqlite (%s)", e.SQLitePath)
+	case BackendGit:
+		return fmt.Sprintf("git (%s)", e.GitRemote)
+	case BackendObject:
+		return fmt.Sprintf("object (%s/%s)", e.Object.Endpoint, e.Object.Bucket)
+	case BackendPostgres:
+		if e.Postgres.Schema != "" {
+			return fmt.Sprintf("postgres (schema %s)", e.Postgres.Schema)
+		}
+		return "postgres"
+	default:
+		return e.Kind
+	}
+}
+
+// MigrationItem is the planned or applied action for one record.
+type MigrationItem struct {
+	// Category is "auth", "config" or "usage".
+	Category string
+	Name     string
+	Action   string
+	Checksum string
+	Note     string
+}
+
+// MigrationReport summarizes a store migration.
+type MigrationReport struct {
+	Items []MigrationItem
+	// DestinationOnly lists auth IDs that exist only in the destination; they are left untouched.
+	DestinationOnly []string
+	// Verified is true when every copied item was read back from a fresh destination
+	// workspace with a matching checksum.
+	Verified   bool
+	Mismatches []string
+}
+
+// Count returns how many items ended with action.
+func (r *MigrationReport) Count(action string) int {
+	n := 0
+	for _, item := range r.Items {
+		if item.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// migrationSnapshot is everything a migration moves, keyed by checksum-ready canonical data.
+type migrationSnapshot struct {
+	auths     map[string]map[string]any
+	config    []byte
+	hasConfig bool
+	usage     []byte
+	hasUsage  bool
+	usageErr  error
+}
+
+// migrationBackend reads and writes the records of one backend.
+type migrationBackend interface {
+	listAuths(ctx context.Context) (map[string]map[string]any, error)
+	saveAuth(ctx context.Context, id string, metadata map[string]any) error
+	readConfig(ctx context.Context) ([]byte, bool, error)
+	writeConfig(ctx context.Context, data []byte) error
+	readUsage(ctx context.Context) ([]byte, bool, error)
+	writeUsage(ctx context.Context, data []byte) error
+	// flush publishes buffered writes; backends that write through return nil.
+	flush(ctx context.Context) error
+	close()
+}
+
+// MigrateStores copies auth records, config.yaml and the usage statistics snapshot from one
+// backend to another. Records are compared by the SHA-256 of their canonical plaintext JSON, so
+// encryption and formatting differences between backends do not count as changes. With dryRun
+// nothing is written and the report lists the planned actions. Otherwise the destination is
+// read back through a fresh workspace afterwards and every checksum is verified.
+func MigrateStores(ctx context.Context, from, to MigrationEndpoint, dryRun bool) (*MigrationReport, error) {
+	if err := validateMigration(from, to); err != nil {
+		return nil, err
+	}
+	workDir, err := os.MkdirTemp("", "aiproxy-migrate-")
+	if err != nil {
+		return nil, fmt.Errorf("store migration: create work directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	source, err := openMigrationBackend(ctx, from, filepath.Join(workDir, "source"), true)
+	if err != nil {
+		return nil, fmt.Errorf("store migration: open source: %w", err)
+	}
+	defer source.close()
+	src, err := readMigrationSnapshot(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("store migration: read source: %w", err)
+	}
+
+	dest, err := openMigrationBackend(ctx, to, filepath.Join(workDir, "destination"), dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("store migration: open destination: %w", err)
+	}
+	defer dest.close()
+	existing, err := readMigrationSnapshot(ctx, dest)
+	if err != nil {
+		return nil, fmt.Errorf("store migration: read destination: %w", err)
+	}
+
+	report := planMigration(src, existing)
+	if dryRun {
+		return report, nil
+	}
+
+	for _, item := range report.Items {
+		if item.Action != MigrationCreate && item.Action != MigrationUpdate {
+			continue
+		}
+		switch item.Category {
+		case "auth":
+			err = dest.saveAuth(ctx, item.Name, src.auths[item.Name])
+		case "usage":
+			err = dest.writeUsage(ctx, src.usage)
+		case "config":
+			err = dest.writeConfig(ctx, src.config)
+		}
+		if err != nil {
+			return report, fmt.Errorf("store migration: write %s %s: %w", item.Category, item.Name, err)
+		}
+	}
+	if err = dest.flush(ctx); err != nil {
+		return report, fmt.Errorf("store migration: publish destination: %w", err)
+	}
+
+	verifier, err := openMigrationBackend(ctx, to, filepath.Join(workDir, "verify"), true)
+	if err != nil {
+		return report, fmt.Errorf("store migration: reopen destination: %w", err)
+	}
+	defer verifier.close()
+	written, err := readMigrationSnapshot(ctx, verifier)
+	if err != nil {
+		return report, fmt.Errorf("store migration: read back destination: %w", err)
+	}
+	report.Mismatches = verifyMigration(report, src, written)
+	report.Verified = len(report.Mismatches) == 0
+	if !report.Verified {
+		return report, fmt.Errorf("store migration: %d item(s) failed verification", len(report.Mismatches))
+	}
+	return report, nil
+}
+
+func validateMigration(from, to MigrationEndpoint) error {
+	for _, kind := range []string{from.Kind, to.Kind} {
+		switch kind {
+		case BackendFile, BackendPostgres, BackendSQLite, BackendGit, BackendObject:
+		default:
+			return fmt.Errorf("store migration: unknown backend %q (want file, postgres, sqlite, git or object)", kind)
+		}
+	}
+	if from.Kind != to.Kind {
+		return nil
+	}
+	if from.Kind == BackendSQLite && filepath.Clean(from.SQLitePath) != filepath.Clean(to.SQLitePath) {
+		return nil
+	}
+	if from.Kind == BackendFile && filepath.Clean(from.AuthDir) != filepath.Clean(to.AuthDir) && filepath.Clean(from.ConfigPath) != filepath.Clean(to.ConfigPath) {
+		return nil
+	}
+	return fmt.Errorf("store migration: source and destination are the same %s backend", from.Kind)
+}
+
+func readMigrationSnapshot(ctx context.Context, backend migrationBackend) (*migrationSnapshot, error) {
+	auths, err := backend.listAuths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snap := &migrationSnapshot{auths: auths}
+	if snap.config, snap.hasConfig, err = backend.readConfig(ctx); err != nil {
+		return nil, err
+	}
+	snap.config = normalizeLineEndingsBytes(snap.config)
+	snap.usage, snap.hasUsage, err = backend.readUsage(ctx)
+	if errors.Is(err, errUsageNotShared) {
+		snap.usageErr = err
+	} else if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+func planMigration(src, dest *migrationSnapshot) *MigrationReport {
+	report := &MigrationReport{}
+	ids := make([]string, 0, len(src.auths))
+	for id := range src.auths {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		sum := metadataChecksum(src.auths[id])
+		item := MigrationItem{Category: "auth", Name: id, Checksum: sum, Action: MigrationCreate}
+		if existing, ok := dest.auths[id]; ok {
+			item.Action = MigrationUpdate
+			if metadataChecksum(existing) == sum {
+				item.Action = MigrationUnchanged
+			}
+		}
+		report.Items = append(report.Items, item)
+	}
+	for id := range dest.auths {
+		if _, ok := src.auths[id]; !ok {
+			report.DestinationOnly = append(report.DestinationOnly, id)
+		}
+	}
+	sort.Strings(report.DestinationOnly)
+
+	usageItem := MigrationItem{Category: "usage", Name: usageStatisticsFile}
+	switch {
+	case src.usageErr != nil:
+		usageItem.Action, usageItem.Note = MigrationSkipped, "source: "+src.usageErr.Error()
+	case dest.usageErr != nil:
+		usageItem.Action, usageItem.Note = MigrationSkipped, "destination: "+dest.usageErr.Error()
+	default:
+		usageItem.Checksum = usageChecksum(src.usage)
+		usageItem.Action = blobAction(src.hasUsage, dest.hasUsage, usageItem.Checksum, usageChecksum(dest.usage))
+	}
+	report.Items = append(report.Items, usageItem)
+
+	config := MigrationItem{Category: "config", Name: "config.yaml", Checksum: bytesChecksum(src.config)}
+	config.Action = blobAction(src.hasConfig, dest.hasConfig, config.Checksum, bytesChecksum(dest.config))
+	report.Items = append(report.Items, config)
+	return report
+}
+
+func blobAction(inSource, inDest bool, sourceSum, destSum string) string {
+	switch {
+	case !inSource:
+		return MigrationSkipped
+	case !inDest:
+		return MigrationCreate
+	case sourceSum == destSum:
+		return MigrationUnchanged
+	default:
+		return MigrationUpdate
+	}
+}
+
+func verifyMigration(report *MigrationReport, src, written *migrationSnapshot) []string {
+	var mismatches []string
+	for _, item := range report.Items {
+		if item.Action == MigrationSkipped {
+			continue
+		}
+		var got string
+		switch item.Category {
+		case "auth":
+			if metadata, ok := written.auths[item.Name]; ok {
+				got = metadataChecksum(metadata)
+			}
+		case "usage":
+			if written.hasUsage {
+				got = usageChecksum(written.usage)
+			}
+		case "config":
+			if written.hasConfig {
+				got = bytesChecksum(written.config)
+			}
+		}
+		if got != item.Checksum {
+			mismatches = append(mismatches, fmt.Sprintf("%s %s: checksum %s, want %s", item.Category, item.Name, shortChecksum(got), shortChecksum(item.Checksum)))
+		}
+	}
+	return mismatches
+}
+
+func metadataChecksum(metadata map[string]any) string {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return ""
+	}
+	return bytesChecksum(data)
+}
+
+// usageChecksum hashes the content of stored usage statistics. Checkpoints and legacy snapshots
+// holding the same usage compare equal, whatever their save time or encoding.
+func usageChecksum(data []byte) string {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return bytesChecksum(nil)
+	}
+	sum, err := usage.UsageDigest(data)
+	if err != nil {
+		return jsonChecksum(data)
+	}
+	return sum
+}
+
+// jsonChecksum hashes data after re-encoding it, so key order and whitespace changes made by
+// jsonb columns do not matter.
+func jsonChecksum(data []byte) string {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return bytesChecksum(nil)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return bytesChecksum(data)
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return bytesChecksum(data)
+	}
+	return bytesChecksum(canonical)
+}
+
+func bytesChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func shortChecksum(sum string) string {
+	if sum == "" {
+		return "<missing>"
+	}
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
+
+// decodeAuthRecord returns the metadata of a stored auth record, decrypting it when sealed.
+func decodeAuthRecord(data []byte) (map[string]any, error) {
+	plain, err := authcrypt.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(plain)) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]any)
+	if err = json.Unmarshal(plain, &metadata); err != nil {
+		return nil, fmt.Errorf("unmarshal auth json: %w", err)
+	}
+	return metadata, nil
+}
+
+func encodeAuthRecord(metadata map[string]any) ([]byte, error) {
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("marshal metadata: %w", err)
+	}
+	return authcrypt.Seal(raw)
+}
+
+// readAuthTree loads every auth record below dir, keyed by slash-separated relative path.
+func readAuthTree(dir string) (map[string]map[string]any, error) {
+	out := make(map[string]map[string]any)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, fs.ErrNotExist) && path == dir {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") || d.Name() == usageStatisticsFile {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		metadata, err := decodeAuthRecord(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if metadata == nil {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		out[filepath.ToSlash(rel)] = metadata
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func writeAuthTree(dir, id string, metadata map[string]any) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(id))
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("auth id %q escapes the auth directory", id)
+	}
+	data, err := encodeAuthRecord(metadata)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	return path, authcrypt.WriteFile(path, data)
+}
+
+func readOptionalFile(path string) ([]byte, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return authcrypt.WriteFile(path, data)
+}
+
+func openMigrationBackend(ctx context.Context, endpoint MigrationEndpoint, workDir string, readOnly bool) (migrationBackend, error) {
+	switch endpoint.Kind {
+	case BackendFile:
+		if strings.TrimSpace(endpoint.AuthDir) == "" || strings.TrimSpace(endpoint.ConfigPath) == "" {
+			return nil, fmt.Errorf("file backend needs a config path and an auth directory")
+		}
+		return &fileMigrationBackend{configPath: endpoint.ConfigPath, authDir: endpoint.AuthDir}, nil
+	case BackendPostgres:
+		cfg := endpoint.Postgres
+		cfg.SpoolDir = filepath.Join(workDir, "pgstore")
+		pg, err := NewPostgresStore(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if !readOnly {
+			if err = pg.EnsureSchema(ctx); err != nil {
+				_ = pg.Close()
+				return nil, err
+			}
+		}
+		return &postgresMigrationBackend{store: pg}, nil
+	case BackendSQLite:
+		if _, err := os.Stat(endpoint.SQLitePath); readOnly && errors.Is(err, fs.ErrNotExist) {
+			return emptyMigrationBackend{}, nil
+		}
+		db, err := NewSQLiteStore(ctx, SQLiteStoreConfig{Path: endpoint.SQLitePath, SpoolDir: filepath.Join(workDir, "sqlitestore")})
+		if err != nil {
+			return nil, err
+		}
+		if !readOnly {
+			if err = db.EnsureSchema(ctx); err != nil {
+				_ = db.Close()
+				return nil, err
+			}
+		}
+		return &sqliteMigrationBackend{store: db}, nil
+	case BackendGit:
+		git := NewGitTokenStore(endpoint.GitRemote, endpoint.GitUsername, endpoint.GitPassword)
+		git.SetBaseDir(filepath.Join(workDir, "gitstore", "auths"))
+		if err := git.EnsureRepository(); err != nil {
+			return nil, err
+		}
+		return &gitMigrationBackend{store: git}, nil
+	case BackendObject:
+		cfg := endpoint.Object
+		cfg.LocalRoot = filepath.Join(workDir, "objectstore")
+		obj, err := NewObjectTokenStore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		exists, err := obj.client.BucketExists(ctx, cfg.Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("object store: check bucket: %w", err)
+		}
+		if !exists && !readOnly {
+			if err = obj.ensureBucket(ctx); err != nil {
+				return nil, err
+			}
+			exists = true
+		}
+		return &objectMigrationBackend{store: obj, bucketExists: exists}, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", endpoint.Kind)
+	}
+}
+
+// fileMigrationBackend is the plain auth directory and config file used without a remote store.
+type fileMigrationBackend struct {
+	configPath string
+	authDir    string
+}
+
+func (b *fileMigrationBackend) listAuths(context.Context) (map[string]map[string]any, error) {
+	return readAuthTree(b.authDir)
+}
+
+func (b *fileMigrationBackend) saveAuth(_ context.Context, id string, metadata map[string]any) error {
+	_, err := writeAuthTree(b.authDir, id, metadata)
+	return err
+}
+
+func (b *fileMigrationBackend) readConfig(context.Context) ([]byte, bool, error) {
+	return readOptionalFile(b.configPath)
+}
+
+func (b *fileMigrationBackend) writeConfig(_ context.Context, data []byte) error {
+	return writeFileAtomic(b.configPath, data)
+}
+
+// readUsage folds the usage journal into its checkpoint so the copy is a single blob.
+func (b *fileMigrationBackend) readUsage(ctx context.Context) ([]byte, bool, error) {
+	if _, err := os.Stat(b.authDir); os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	usageStore, err := NewFileStore(b.authDir)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := usageStore.LoadUsageStatistics(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, data != nil, nil
+}
+
+func (b *fileMigrationBackend) writeUsage(ctx context.Context, data []byte) error {
+	usageStore, err := NewFileStore(b.authDir)
+	if err != nil {
+		return err
+	}
+	return usageStore.SaveUsageStatistics(ctx, data)
+}
+
+func (b *fileMigrationBackend) flush(context.Context) error { return nil }
+
+func (b *fileMigrationBackend) close() {}
+
+// postgresMigrationBackend talks to the tables directly; the spool directory is not used.
+type postgresMigrationBackend struct {
+	store *PostgresStore
+}
+
+func (b *postgresMigrationBackend) listAuths(ctx context.Context) (map[string]map[string]any, error) {
+	query := fmt.Sprintf("SELECT id, content FROM %s", b.store.fullTableName(b.store.cfg.AuthTable))
+	rows, err := b.store.db.QueryContext(ctx, query)
+	if isUndefinedTable(err) {
+		return map[string]map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: list auth records: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]map[string]any)
+	for rows.Next() {
+		var id, payload string
+		if err = rows.Scan(&id, &payload); err != nil {
+			return nil, fmt.Errorf("postgres store: scan auth row: %w", err)
+		}
+		metadata, errDecode := decodeAuthRecord([]byte(payload))
+		if errDecode != nil {
+			return nil, fmt.Errorf("postgres store: auth %s: %w", id, errDecode)
+		}
+		if metadata != nil {
+			out[normalizeAuthID(id)] = metadata
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate auth rows: %w", err)
+	}
+	return out, nil
+}
+
+func (b *postgresMigrationBackend) saveAuth(ctx context.Context, id string, metadata map[string]any) error {
+	data, err := encodeAuthRecord(metadata)
+	if err != nil {
+		return err
+	}
+	return b.store.persistAuth(ctx, normalizeAuthID(id), data)
+}
+
+func (b *postgresMigrationBackend) readConfig(ctx context.Context) ([]byte, bool, error) {
+	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", b.store.fullTableName(b.store.cfg.ConfigTable))
+	var content string
+	err := b.store.db.QueryRowContext(ctx, query, defaultConfigKey).Scan(&content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), isUndefinedTable(err):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("postgres store: load config: %w", err)
+	}
+	return []byte(content), true, nil
+}
+
+func (b *postgresMigrationBackend) writeConfig(ctx context.Context, data []byte) error {
+	return b.store.persistConfig(ctx, data)
+}
+
+func (b *postgresMigrationBackend) readUsage(ctx context.Context) ([]byte, bool, error) {
+	data, err := b.store.LoadUsageStatistics(ctx)
+	if isUndefinedTable(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, data != nil, nil
+}
+
+func (b *postgresMigrationBackend) writeUsage(ctx context.Context, data []byte) error {
+	return b.store.SaveUsageStatistics(ctx, data)
+}
+
+func (b *postgresMigrationBackend) flush(context.Context) error { return nil }
+
+func (b *postgresMigrationBackend) close() { _ = b.store.Close() }
+
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
+
+// sqliteMigrationBackend talks to the database directly; the spool directory is not used.
+type sqliteMigrationBackend struct {
+	store *SQLiteStore
+}
+
+func (b *sqliteMigrationBackend) listAuths(ctx context.Context) (map[string]map[string]any, error) {
+	rows, err := b.store.db.QueryContext(ctx, "SELECT id, content FROM "+sqliteAuthTable)
+	if isMissingSQLiteTable(err) {
+		return map[string]map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: list auth records: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]map[string]any)
+	for rows.Next() {
+		var id, payload string
+		if err = rows.Scan(&id, &payload); err != nil {
+			return nil, fmt.Errorf("sqlite store: scan auth row: %w", err)
+		}
+		metadata, errDecode := decodeAuthRecord([]byte(payload))
+		if errDecode != nil {
+			return nil, fmt.Errorf("sqlite store: auth %s: %w", id, errDecode)
+		}
+		if metadata != nil {
+			out[normalizeAuthID(id)] = metadata
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite store: iterate auth rows: %w", err)
+	}
+	return out, nil
+}
+
+func (b *sqliteMigrationBackend) saveAuth(ctx context.Context, id string, metadata map[string]any) error {
+	data, err := encodeAuthRecord(metadata)
+	if err != nil {
+		return err
+	}
+	return b.store.persistAuth(ctx, normalizeAuthID(id), data)
+}
+
+func (b *sqliteMigrationBackend) readConfig(ctx context.Context) ([]byte, bool, error) {
+	var content string
+	err := b.store.db.QueryRowContext(ctx, "SELECT content FROM "+sqliteConfigTable+" WHERE id = ?", defaultConfigKey).Scan(&content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), isMissingSQLiteTable(err):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("sqlite store: load config: %w", err)
+	}
+	return []byte(content), true, nil
+}
+
+func (b *sqliteMigrationBackend) writeConfig(ctx context.Context, data []byte) error {
+	return b.store.persistConfig(ctx, data)
+}
+
+func (b *sqliteMigrationBackend) readUsage(ctx context.Context) ([]byte, bool, error) {
+	data, err := b.store.LoadUsageStatistics(ctx)
+	if isMissingSQLiteTable(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, data != nil, nil
+}
+
+func (b *sqliteMigrationBackend) writeUsage(ctx context.Context, data []byte) error {
+	return b.store.SaveUsageStatistics(ctx, data)
+}
+
+func (b *sqliteMigrationBackend) flush(context.Context) error { return nil }
+
+func (b *sqliteMigrationBackend) close() { _ = b.store.Close() }
+
+func isMissingSQLiteTable(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such table")
+}
+
+// emptyMigrationBackend stands in for a source or dry-run destination that does not exist yet.
+type emptyMigrationBackend struct{}
+
+func (emptyMigrationBackend) listAuths(context.Context) (map[string]map[string]any, error) {
+	return map[string]map[string]any{}, nil
+}
+
+func (emptyMigrationBackend) saveAuth(context.Context, string, map[string]any) error {
+	return fmt.Errorf("backend does not exist")
+}
+
+func (emptyMigrationBackend) readConfig(context.Context) ([]byte, bool, error) {
+	return nil, false, nil
+}
+
+func (emptyMigrationBackend) writeConfig(context.Context, []byte) error {
+	return fmt.Errorf("backend does not exist")
+}
+
+func (emptyMigrationBackend) readUsage(context.Context) ([]byte, bool, error) { return nil, false, nil }
+
+func (emptyMigrationBackend) writeUsage(context.Context, []byte) error {
+	return fmt.Errorf("backend does not exist")
+}
+
+func (emptyMigrationBackend) flush(context.Context) error { return nil }
+
+func (emptyMigrationBackend) close() {}
+
+// gitMigrationBackend writes into a fresh clone and pushes everything as one commit on flush.
+type gitMigrationBackend struct {
+	store   *GitTokenStore
+	pending []string
+	config  bool
+}
+
+func (b *gitMigrationBackend) listAuths(context.Context) (map[string]map[string]any, error) {
+	return readAuthTree(b.store.AuthDir())
+}
+
+func (b *gitMigrationBackend) saveAuth(_ context.Context, id string, metadata map[string]any) error {
+	path, err := writeAuthTree(b.store.AuthDir(), id, metadata)
+	if err != nil {
+		return err
+	}
+	b.pending = append(b.pending, path)
+	return nil
+}
+
+func (b *gitMigrationBackend) readConfig(context.Context) ([]byte, bool, error) {
+	return readOptionalFile(b.store.ConfigPath())
+}
+
+func (b *gitMigrationBackend) writeConfig(_ context.Context, data []byte) error {
+	if err := writeFileAtomic(b.store.ConfigPath(), data); err != nil {
+		return err
+	}
+	b.config = true
+	return nil
+}
+
+func (b *gitMigrationBackend) readUsage(context.Context) ([]byte, bool, error) {
+	return nil, false, errUsageNotShared
+}
+
+func (b *gitMigrationBackend) writeUsage(context.Context, []byte) error { return errUsageNotShared }
+
+func (b *gitMigrationBackend) flush(ctx context.Context) error {
+	if b.config {
+		b.pending = append(b.pending, b.store.ConfigPath())
+	}
+	return b.store.PersistAuthFiles(ctx, "Migrate auth records and config", b.pending...)
+}
+
+func (b *gitMigrationBackend) close() {}
+
+// objectMigrationBackend reads and writes bucket objects directly.
+type objectMigrationBackend struct {
+	store        *ObjectTokenStore
+	bucketExists bool
+}
+
+func (b *objectMigrationBackend) listAuths(ctx context.Context) (map[string]map[string]any, error) {
+	if !b.bucketExists {
+		return map[string]map[string]any{}, nil
+	}
+	if err := b.store.syncAuthFromBucket(ctx); err != nil {
+		return nil, err
+	}
+	return readAuthTree(b.store.AuthDir())
+}
+
+func (b *objectMigrationBackend) saveAuth(ctx context.Context, id string, metadata map[string]any) error {
+	path, err := writeAuthTree(b.store.AuthDir(), id, metadata)
+	if err != nil {
+		return err
+	}
+	return b.store.uploadAuth(ctx, path)
+}
+
+func (b *objectMigrationBackend) readConfig(ctx context.Context) ([]byte, bool, error) {
+	if !b.bucketExists {
+		return nil, false, nil
+	}
+	object, err := b.store.client.GetObject(ctx, b.store.cfg.Bucket, b.store.prefixedKey(objectStoreConfigKey), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("object store: fetch config: %w", err)
+	}
+	defer object.Close()
+	data, err := io.ReadAll(object)
+	if isObjectNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("object store: read config: %w", err)
+	}
+	return data, true, nil
+}
+
+func (b *objectMigrationBackend) writeConfig(ctx context.Context, data []byte) error {
+	return b.store.putObject(ctx, objectStoreConfigKey, data, "application/x-yaml")
+}
+
+func (b *objectMigrationBackend) readUsage(context.Context) ([]byte, bool, error) {
+	return nil, false, errUsageNotShared
+}
+
+func (b *objectMigrationBackend) writeUsage(context.Context, []byte) error { return errUsageNotShared }
+
+func (b *objectMigrationBackend) flush(context.Context) error { return nil }
+
+func (b *objectMigrationBackend) close() {}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func fileEndpoint(root string) MigrationEndpoint {
+	return MigrationEndpoint{Kind: BackendFile, ConfigPath: filepath.Join(root, "config.yaml"), AuthDir: filepath.Join(root, "auths")}
+}
+
+func actions(report *MigrationReport) map[string]string {
+	out := make(map[string]string)
+	for _, item := range report.Items {
+		out[item.Category+":"+item.Name] = item.Action
+	}
+	return out
+}
+
+func TestMigrateStores_FileToFile(t *testing.T) {
+	ctx := context.Background()
+	srcRoot, dstRoot := t.TempDir(), t.TempDir()
+	from, to := fileEndpoint(srcRoot), fileEndpoint(dstRoot)
+
+	writeTestFile(t, from.ConfigPath, "port: 8317\r\n")
+	writeTestFile(t, filepath.Join(from.AuthDir, "claude-a.json"), `{"type":"claude","email":"a@example.com"}`)
+	writeTestFile(t, filepath.Join(from.AuthDir, "team", "codex-b.json"), `{"type":"codex","refresh_token":"r"}`)
+	writeTestFile(t, filepath.Join(from.AuthDir, usageStatisticsFile), `{"version":1,"usage":{"total_requests":3}}`)
+	writeTestFile(t, filepath.Join(to.AuthDir, "claude-a.json"), `{"email":"a@example.com","type":"claude"}`)
+	writeTestFile(t, filepath.Join(to.AuthDir, "stale.json"), `{"type":"gemini"}`)
+
+	report, err := MigrateStores(ctx, from, to, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := map[string]string{
+		"auth:claude-a.json":           MigrationUnchanged,
+		"auth:team/codex-b.json":       MigrationCreate,
+		"usage:" + usageStatisticsFile: MigrationCreate,
+		"config:config.yaml":           MigrationCreate,
+	}
+	got := actions(report)
+	for key, action := range want {
+		if got[key] != action {
+			t.Fatalf("dry run %s = %q, want %q (all: %v)", key, got[key], action, got)
+		}
+	}
+	if len(report.DestinationOnly) != 1 || report.DestinationOnly[0] != "stale.json" {
+		t.Fatalf("DestinationOnly = %v, want [stale.json]", report.DestinationOnly)
+	}
+	if _, errStat := os.Stat(to.ConfigPath); !os.IsNotExist(errStat) {
+		t.Fatal("dry run wrote the destination config")
+	}
+
+	report, err = MigrateStores(ctx, from, to, false)
+	if err != nil {
+		t.Fatalf("migrate: %v (mismatches %v)", err, report.Mismatches)
+	}
+	if !report.Verified {
+		t.Fatal("migration was not verified")
+	}
+	if data, _ := os.ReadFile(to.ConfigPath); string(data) != "port: 8317\n" {
+		t.Fatalf("destination config = %q", data)
+	}
+
+	report, err = MigrateStores(ctx, from, to, true)
+	if err != nil {
+		t.Fatalf("second dry run: %v", err)
+	}
+	if n := report.Count(MigrationCreate) + report.Count(MigrationUpdate); n != 0 {
+		t.Fatalf("second dry run plans %d writes, want 0 (%v)", n, actions(report))
+	}
+}
+
+func TestMigrateStores_RejectsSameBackend(t *testing.T) {
+	root := t.TempDir()
+	if _, err := MigrateStores(context.Background(), fileEndpoint(root), fileEndpoint(root), true); err == nil {
+		t.Fatal("expected an error when source and destination are the same")
+	}
+	pg := MigrationEndpoint{Kind: BackendPostgres}
+	if _, err := MigrateStores(context.Background(), pg, pg, true); err == nil {
+		t.Fatal("expected an error for postgres to postgres")
+	}
+}
+
+func TestMigrateStores_FileToSQLiteKeepsUsageHistory(t *testing.T) {
+	ctx := context.Background()
+	from := fileEndpoint(t.TempDir())
+	to := MigrationEndpoint{Kind: BackendSQLite, SQLitePath: filepath.Join(t.TempDir(), "aiproxy.db")}
+
+	writeTestFile(t, from.ConfigPath, "port: 8317\n")
+	writeTestFile(t, filepath.Join(from.AuthDir, "claude-a.json"), `{"type":"claude"}`)
+	writeTestFile(t, filepath.Join(from.AuthDir, usageStatisticsFile), `{"total_requests":1,"apis":{"key-1":{"total_requests":1,"models":{"gpt-test":{"total_requests":1,"details":[{"timestamp":"2026-03-01T10:00:00Z","source":"a","auth_index":"1","tokens":{"total_tokens":7},"failed":false}]}}}}}`)
+
+	report, err := MigrateStores(ctx, from, to, false)
+	if err != nil {
+		t.Fatalf("migrate: %v (mismatches %v)", err, report.Mismatches)
+	}
+	if got := actions(report)["usage:"+usageStatisticsFile]; got != MigrationCreate {
+		t.Fatalf("usage action = %q, want create", got)
+	}
+
+	report, err = MigrateStores(ctx, from, to, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if n := report.Count(MigrationCreate) + report.Count(MigrationUpdate); n != 0 {
+		t.Fatalf("second run plans %d writes, want 0 (%v)", n, actions(report))
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -18,6 +19,8 @@ import (
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/giofahreza/AIProxyAPI/internal/misc"
+	baseauth "github.com/giofahreza/AIProxyAPI/internal/auth"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
 	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 )
@@ -40,6 +43,36 @@ type ObjectStoreConfig struct {
 	PathStyle bool
 }
 
+// ParseObjectEndpoint splits an endpoint such as "https://s3.example.com" into the host form
+// expected by ObjectStoreConfig.Endpoint and whether TLS should be used. Endpoints without a
+// scheme default to TLS.
+func ParseObjectEndpoint(raw string) (string, bool, error) {
+	resolved := strings.TrimSpace(raw)
+	useSSL := true
+	if strings.Contains(resolved, "://") {
+		parsed, err := url.Parse(resolved)
+		if err != nil {
+			return "", false, fmt.Errorf("parse object store endpoint %q: %w", raw, err)
+		}
+		switch strings.ToLower(parsed.Scheme) {
+		case "http":
+			useSSL = false
+		case "https":
+			useSSL = true
+		default:
+			return "", false, fmt.Errorf("unsupported object store scheme %q (only http and https are allowed)", parsed.Scheme)
+		}
+		if parsed.Host == "" {
+			return "", false, fmt.Errorf("object store endpoint %q is missing host information", raw)
+		}
+		resolved = parsed.Host
+		if parsed.Path != "" && parsed.Path != "/" {
+			resolved = strings.TrimSuffix(parsed.Host+parsed.Path, "/")
+		}
+	}
+	return strings.TrimRight(resolved, "/"), useSSL, nil
+}
+
 // ObjectTokenStore persists configuration and authentication metadata using an S3-compatible object storage backend.
 // Files are mirrored to a local workspace so existing file-based flows continue to operate.
 type ObjectTokenStore struct {
@@ -184,8 +217,14 @@ func (s *ObjectTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (s
 
 	switch {
 	case auth.Storage != nil:
-		if err = auth.Storage.SaveTokenToFile(path); err != nil {
-			return "", err
+		// Seal in memory and write once, so plaintext tokens never reach disk.
+		raw, errEncode := baseauth.EncodeTokenStorage(auth.Storage)
+		if errEncode != nil {
+			return "", fmt.Errorf("object store: encode token storage: %w", errEncode)
+		}
+		misc.LogSavingCredentials(path)
+		if err = authcrypt.WriteSealedFile(path, raw); err != nil {
+			return "", fmt.Errorf("object store: encrypt auth file: %w", err)
 		}
 	case auth.Metadata != nil:
 		raw, errMarshal := json.Marshal(auth.Metadata)
@@ -193,12 +232,15 @@ func (s *ObjectTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (s
 			return "", fmt.Errorf("object store: marshal metadata: %w", errMarshal)
 		}
 		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+			if plain, errOpen := authcrypt.Open(existing); errOpen == nil && authcrypt.IsCurrent(existing) && jsonEqual(plain, raw) {
 				return path, nil
 			}
 		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
 			return "", fmt.Errorf("object store: read existing metadata: %w", errRead)
 		}
+		if raw, errMarshal = authcrypt.Seal(raw); errMarshal != nil {
+			return "", fmt.Errorf("object store: encrypt auth file: %w", errMarshal)
+		}
 		tmp := path + ".tmp"
 		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("object store: write temp auth file: %w", errWrite)
@@ -553,7 +595,7 @@ func (s *ObjectTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *ObjectTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	"github.com/minio/minio-go/v7"
+)
+
+const objectStoreLeasePrefix = "leases"
+
+// refreshLease is the JSON body of a lease object.
+type refreshLease struct {
+	ID        string    `json:"id"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// leaseKey maps an auth ID to a flat object key so nested IDs do not create prefixes.
+func leaseKey(id string) string {
+	sum := sha256.Sum256([]byte(normalizeAuthID(id)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// AcquireRefreshLease takes the refresh lease for id using conditional writes, so two replicas
+// cannot both win. It relies on the backend honouring If-Match and If-None-Match on PUT, as MinIO does.
+func (s *ObjectTokenStore) AcquireRefreshLease(ctx context.Context, id, holder string, ttl time.Duration) (bool, error) {
+	key := s.prefixedKey(objectStoreLeasePrefix + "/" + leaseKey(id) + ".json")
+	current, etag, err := s.readLease(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && current.Holder != holder && time.Now().Before(current.ExpiresAt) {
+		return false, nil
+	}
+	opts := minio.PutObjectOptions{ContentType: "application/json"}
+	if etag == "" {
+		opts.SetMatchETagExcept("*")
+	} else {
+		opts.SetMatchETag(etag)
+	}
+	if err = s.writeLease(ctx, key, refreshLease{ID: id, Holder: holder, ExpiresAt: time.Now().Add(ttl)}, opts); err != nil {
+		if isPreconditionFailed(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseRefreshLease expires the lease for id when holder still owns it.
+func (s *ObjectTokenStore) ReleaseRefreshLease(ctx context.Context, id, holder string) error {
+	key := s.prefixedKey(objectStoreLeasePrefix + "/" + leaseKey(id) + ".json")
+	current, etag, err := s.readLease(ctx, key)
+	if err != nil || current == nil || current.Holder != holder {
+		return err
+	}
+	opts := minio.PutObjectOptions{ContentType: "application/json"}
+	opts.SetMatchETag(etag)
+	if err = s.writeLease(ctx, key, refreshLease{ID: id}, opts); err != nil && !isPreconditionFailed(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *ObjectTokenStore) readLease(ctx context.Context, key string) (*refreshLease, string, error) {
+	info, err := s.client.StatObject(ctx, s.cfg.Bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("object store: stat lease %s: %w", key, err)
+	}
+	getOpts := minio.GetObjectOptions{}
+	if err = getOpts.SetMatchETag(info.ETag); err != nil {
+		return nil, "", fmt.Errorf("object store: read lease %s: %w", key, err)
+	}
+	reader, err := s.client.GetObject(ctx, s.cfg.Bucket, key, getOpts)
+	if err != nil {
+		return nil, "", fmt.Errorf("object store: read lease %s: %w", key, err)
+	}
+	defer func() { _ = reader.Close() }()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		if isPreconditionFailed(err) {
+			// Rewritten between stat and get; report it as held so the caller backs off.
+			return &refreshLease{ExpiresAt: time.Now().Add(time.Minute)}, info.ETag, nil
+		}
+		return nil, "", fmt.Errorf("object store: read lease %s: %w", key, err)
+	}
+	var lease refreshLease
+	if err = json.Unmarshal(data, &lease); err != nil {
+		return nil, "", fmt.Errorf("object store: decode lease %s: %w", key, err)
+	}
+	return &lease, info.ETag, nil
+}
+
+func (s *ObjectTokenStore) writeLease(ctx context.Context, key string, lease refreshLease, opts minio.PutObjectOptions) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return fmt.Errorf("object store: encode lease: %w", err)
+	}
+	if _, err = s.client.PutObject(ctx, s.cfg.Bucket, key, bytes.NewReader(data), int64(len(data)), opts); err != nil {
+		return fmt.Errorf("object store: write lease %s: %w", key, err)
+	}
+	return nil
+}
+
+// ReadAuth downloads the latest version of one auth record, refreshes the local mirror and returns it.
+func (s *ObjectTokenStore) ReadAuth(ctx context.Context, id string) (*cliproxyauth.Auth, error) {
+	local, err := s.resolveDeletePath(id)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(s.authDir, local)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("object store: auth %s outside mirror", id)
+	}
+	key := s.prefixedKey(objectStoreAuthPrefix + "/" + filepath.ToSlash(rel))
+	reader, err := s.client.GetObject(ctx, s.cfg.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("object store: download auth %s: %w", key, err)
+	}
+	data, err := io.ReadAll(reader)
+	_ = reader.Close()
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: read auth %s: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(local), 0o700); err != nil {
+		return nil, fmt.Errorf("object store: prepare auth subdir: %w", err)
+	}
+	if existing, errRead := os.ReadFile(local); errRead != nil || !bytes.Equal(existing, data) {
+		if err = os.WriteFile(local, data, 0o600); err != nil {
+			return nil, fmt.Errorf("object store: write auth %s: %w", local, err)
+		}
+	}
+	return s.readAuthFile(local, s.authDir)
+}
+
+func isPreconditionFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+	resp := minio.ToErrorResponse(err)
+	return resp.StatusCode == http.StatusPreconditionFailed || resp.Code == "PreconditionFailed"
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Notify publishes payload on a Postgres NOTIFY channel.
+func (s *PostgresStore) Notify(ctx context.Context, channel, payload string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	if _, err := s.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("postgres store: notify: %w", err)
+	}
+	return nil
+}
+
+// Listen subscribes to channel on a dedicated connection and calls handle for every notification
+// until ctx is cancelled or the connection fails. The connection is discarded afterwards so the
+// subscription never leaks back into the pool.
+func (s *PostgresStore) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres store: acquire listen connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	var listenErr error
+	_ = conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = fmt.Errorf("postgres store: unexpected driver connection %T", driverConn)
+			return driver.ErrBadConn
+		}
+		pgConn := stdConn.Conn()
+		if _, errExec := pgConn.Exec(ctx, "LISTEN "+quoteIdentifier(channel)); errExec != nil {
+			listenErr = fmt.Errorf("postgres store: listen: %w", errExec)
+			return driver.ErrBadConn
+		}
+		for {
+			notification, errWait := pgConn.WaitForNotification(ctx)
+			if errWait != nil {
+				listenErr = errWait
+				return driver.ErrBadConn
+			}
+			handle(notification.Payload)
+		}
+	})
+	if errors.Is(listenErr, context.Canceled) || errors.Is(listenErr, context.DeadlineExceeded) {
+		return ctx.Err()
+	}
+	return listenErr
+}
+
+// UpsertClusterCounters stores this node's request counts per API key and model for month.
+func (s *PostgresStore) UpsertClusterCounters(ctx context.Context, nodeID, month string, counts map[string]map[string]int64) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres store: begin counter transaction: %w", err)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (node_id, month, api_key, model, count, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (node_id, month, api_key, model)
+		DO UPDATE SET count = EXCLUDED.count, updated_at = NOW()
+	`, s.fullTableName(s.cfg.CountTable))
+	for apiKey, models := range counts {
+		for model, count := range models {
+			if _, err = tx.ExecContext(ctx, query, nodeID, month, apiKey, model, count); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("postgres store: upsert cluster counter: %w", err)
+			}
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("postgres store: commit cluster counters: %w", err)
+	}
+	return nil
+}
+
+// LoadPeerCounters sums the request counts recorded by every node other than nodeID for month.
+func (s *PostgresStore) LoadPeerCounters(ctx context.Context, nodeID, month string) (map[string]map[string]int64, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf(`
+		SELECT api_key, model, SUM(count)
+		FROM %s
+		WHERE month = $1 AND node_id <> $2
+		GROUP BY api_key, model
+	`, s.fullTableName(s.cfg.CountTable))
+	rows, err := s.db.QueryContext(ctx, query, month, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: load cluster counters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]map[string]int64)
+	for rows.Next() {
+		var apiKey, model string
+		var count int64
+		if err = rows.Scan(&apiKey, &model, &count); err != nil {
+			return nil, fmt.Errorf("postgres store: scan cluster counter: %w", err)
+		}
+		if counts[apiKey] == nil {
+			counts[apiKey] = make(map[string]int64)
+		}
+		counts[apiKey][model] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate cluster counters: %w", err)
+	}
+	return counts, nil
+}
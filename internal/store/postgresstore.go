@@ -15,6 +15,9 @@ import (
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/giofahreza/AIProxyAPI/internal/misc"
+	baseauth "github.com/giofahreza/AIProxyAPI/internal/auth"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
 	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 )
@@ -23,6 +26,9 @@ const (
 	defaultConfigTable = "config_store"
 	defaultAuthTable   = "auth_store"
 	defaultUsageTable  = "usage_statistics"
+	defaultEventTable  = "usage_events"
+	defaultCountTable  = "cluster_counters"
+	defaultLeaseTable  = "auth_refresh_leases"
 	defaultConfigKey   = "config"
 	defaultUsageKey    = "statistics"
 )
@@ -34,6 +40,9 @@ type PostgresStoreConfig struct {
 	ConfigTable string
 	AuthTable   string
 	UsageTable  string
+	EventTable  string
+	CountTable  string
+	LeaseTable  string
 	SpoolDir    string
 }
 
@@ -64,6 +73,15 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.UsageTable == "" {
 		cfg.UsageTable = defaultUsageTable
 	}
+	if cfg.EventTable == "" {
+		cfg.EventTable = defaultEventTable
+	}
+	if cfg.CountTable == "" {
+		cfg.CountTable = defaultCountTable
+	}
+	if cfg.LeaseTable == "" {
+		cfg.LeaseTable = defaultLeaseTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -157,6 +175,45 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, usageTable)); err != nil {
 		return fmt.Errorf("postgres store: create usage table: %w", err)
 	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		ALTER TABLE %s ADD COLUMN IF NOT EXISTS through_id BIGINT NOT NULL DEFAULT 0
+	`, usageTable)); err != nil {
+		return fmt.Errorf("postgres store: add usage checkpoint position: %w", err)
+	}
+	eventTable := s.fullTableName(s.cfg.EventTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			content JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, eventTable)); err != nil {
+		return fmt.Errorf("postgres store: create usage event table: %w", err)
+	}
+	countTable := s.fullTableName(s.cfg.CountTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			node_id TEXT NOT NULL,
+			month TEXT NOT NULL,
+			api_key TEXT NOT NULL,
+			model TEXT NOT NULL,
+			count BIGINT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (node_id, month, api_key, model)
+		)
+	`, countTable)); err != nil {
+		return fmt.Errorf("postgres store: create cluster counter table: %w", err)
+	}
+	leaseTable := s.fullTableName(s.cfg.LeaseTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			holder TEXT NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		)
+	`, leaseTable)); err != nil {
+		return fmt.Errorf("postgres store: create refresh lease table: %w", err)
+	}
 	return nil
 }
 
@@ -231,8 +288,14 @@ func (s *PostgresStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (stri
 
 	switch {
 	case auth.Storage != nil:
-		if err = auth.Storage.SaveTokenToFile(path); err != nil {
-			return "", err
+		// Seal in memory and write once, so plaintext tokens never reach disk.
+		raw, errEncode := baseauth.EncodeTokenStorage(auth.Storage)
+		if errEncode != nil {
+			return "", fmt.Errorf("postgres store: encode token storage: %w", errEncode)
+		}
+		misc.LogSavingCredentials(path)
+		if err = authcrypt.WriteSealedFile(path, raw); err != nil {
+			return "", fmt.Errorf("postgres store: encrypt auth file: %w", err)
 		}
 	case auth.Metadata != nil:
 		raw, errMarshal := json.Marshal(auth.Metadata)
@@ -240,12 +303,15 @@ func (s *PostgresStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (stri
 			return "", fmt.Errorf("postgres store: marshal metadata: %w", errMarshal)
 		}
 		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+			if plain, errOpen := authcrypt.Open(existing); errOpen == nil && authcrypt.IsCurrent(existing) && jsonEqual(plain, raw) {
 				return path, nil
 			}
 		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
 			return "", fmt.Errorf("postgres store: read existing metadata: %w", errRead)
 		}
+		if raw, errMarshal = authcrypt.Seal(raw); errMarshal != nil {
+			return "", fmt.Errorf("postgres store: encrypt auth file: %w", errMarshal)
+		}
 		tmp := path + ".tmp"
 		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("postgres store: write temp auth file: %w", errWrite)
@@ -296,38 +362,9 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 		if err = rows.Scan(&id, &payload, &createdAt, &updatedAt); err != nil {
 			return nil, fmt.Errorf("postgres store: scan auth row: %w", err)
 		}
-		path, errPath := s.absoluteAuthPath(id)
-		if errPath != nil {
-			log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
-			continue
+		if auth := s.authFromRecord(id, payload, createdAt, updatedAt); auth != nil {
+			auths = append(auths, auth)
 		}
-		metadata := make(map[string]any)
-		if err = json.Unmarshal([]byte(payload), &metadata); err != nil {
-			log.WithError(err).Warnf("postgres store: skipping auth %s with invalid json", id)
-			continue
-		}
-		provider := strings.TrimSpace(valueAsString(metadata["type"]))
-		if provider == "" {
-			provider = "unknown"
-		}
-		attr := map[string]string{"path": path}
-		if email := strings.TrimSpace(valueAsString(metadata["email"])); email != "" {
-			attr["email"] = email
-		}
-		auth := &cliproxyauth.Auth{
-			ID:               normalizeAuthID(id),
-			Provider:         provider,
-			FileName:         normalizeAuthID(id),
-			Label:            labelFor(metadata),
-			Status:           cliproxyauth.StatusActive,
-			Attributes:       attr,
-			Metadata:         metadata,
-			CreatedAt:        createdAt,
-			UpdatedAt:        updatedAt,
-			LastRefreshedAt:  time.Time{},
-			NextRefreshAfter: time.Time{},
-		}
-		auths = append(auths, auth)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("postgres store: iterate auth rows: %w", err)
@@ -335,6 +372,105 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 	return auths, nil
 }
 
+// ReadAuth loads a single auth record straight from PostgreSQL.
+func (s *PostgresStore) ReadAuth(ctx context.Context, id string) (*cliproxyauth.Auth, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf("SELECT id, content, created_at, updated_at FROM %s WHERE id = $1", s.fullTableName(s.cfg.AuthTable))
+	var (
+		recordID  string
+		payload   string
+		createdAt time.Time
+		updatedAt time.Time
+	)
+	err := s.db.QueryRowContext(ctx, query, normalizeAuthID(id)).Scan(&recordID, &payload, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: read auth: %w", err)
+	}
+	return s.authFromRecord(recordID, payload, createdAt, updatedAt), nil
+}
+
+// authFromRecord converts a stored row into an auth entry, or returns nil when it cannot be used.
+func (s *PostgresStore) authFromRecord(id, payload string, createdAt, updatedAt time.Time) *cliproxyauth.Auth {
+	path, errPath := s.absoluteAuthPath(id)
+	if errPath != nil {
+		log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
+		return nil
+	}
+	plain, errOpen := authcrypt.Open([]byte(payload))
+	if errOpen != nil {
+		log.WithError(errOpen).Warnf("postgres store: skipping auth %s", id)
+		return nil
+	}
+	metadata := make(map[string]any)
+	if err := json.Unmarshal(plain, &metadata); err != nil {
+		log.WithError(err).Warnf("postgres store: skipping auth %s with invalid json", id)
+		return nil
+	}
+	provider := strings.TrimSpace(valueAsString(metadata["type"]))
+	if provider == "" {
+		provider = "unknown"
+	}
+	attr := map[string]string{"path": path}
+	if email := strings.TrimSpace(valueAsString(metadata["email"])); email != "" {
+		attr["email"] = email
+	}
+	return &cliproxyauth.Auth{
+		ID:               normalizeAuthID(id),
+		Provider:         provider,
+		FileName:         normalizeAuthID(id),
+		Label:            labelFor(metadata),
+		Status:           cliproxyauth.StatusActive,
+		Attributes:       attr,
+		Metadata:         metadata,
+		CreatedAt:        createdAt,
+		UpdatedAt:        updatedAt,
+		LastRefreshedAt:  time.Time{},
+		NextRefreshAfter: time.Time{},
+	}
+}
+
+// AcquireRefreshLease takes the refresh lease for id when it is free, expired or already held by holder.
+func (s *PostgresStore) AcquireRefreshLease(ctx context.Context, id, holder string, ttl time.Duration) (bool, error) {
+	if s == nil || s.db == nil {
+		return false, fmt.Errorf("postgres store: not initialized")
+	}
+	table := s.fullTableName(s.cfg.LeaseTable)
+	query := fmt.Sprintf(`
+		INSERT INTO %s AS lease (id, holder, expires_at)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3))
+		ON CONFLICT (id)
+		DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE lease.expires_at < NOW() OR lease.holder = EXCLUDED.holder
+		RETURNING holder
+	`, table)
+	var owner string
+	err := s.db.QueryRowContext(ctx, query, normalizeAuthID(id), holder, ttl.Seconds()).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("postgres store: acquire refresh lease: %w", err)
+	}
+	return owner == holder, nil
+}
+
+// ReleaseRefreshLease drops the refresh lease for id when holder owns it.
+func (s *PostgresStore) ReleaseRefreshLease(ctx context.Context, id, holder string) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres store: not initialized")
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND holder = $2", s.fullTableName(s.cfg.LeaseTable))
+	if _, err := s.db.ExecContext(ctx, query, normalizeAuthID(id), holder); err != nil {
+		return fmt.Errorf("postgres store: release refresh lease: %w", err)
+	}
+	return nil
+}
+
 // Delete removes an auth file and the corresponding database record.
 func (s *PostgresStore) Delete(ctx context.Context, id string) error {
 	id = strings.TrimSpace(id)
@@ -561,7 +697,8 @@ func (s *PostgresStore) deleteConfigRecord(ctx context.Context) error {
 	return nil
 }
 
-// SaveUsageStatistics persists usage statistics snapshot to PostgreSQL.
+// SaveUsageStatistics stores a usage checkpoint and compacts the event journal: events appended
+// before the checkpoint are part of it and are deleted in the same transaction.
 func (s *PostgresStore) SaveUsageStatistics(ctx context.Context, data []byte) error {
 	if s == nil || s.db == nil {
 		return fmt.Errorf("postgres store: not initialized")
@@ -569,34 +706,129 @@ func (s *PostgresStore) SaveUsageStatistics(ctx context.Context, data []byte) er
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres store: begin usage transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	eventTable := s.fullTableName(s.cfg.EventTable)
+	var throughID int64
+	if err = tx.QueryRowContext(ctx, fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", eventTable)).Scan(&throughID); err != nil {
+		return fmt.Errorf("postgres store: read usage journal position: %w", err)
+	}
 	jsonPayload := json.RawMessage(data)
 	query := fmt.Sprintf(`
-		INSERT INTO %s (id, content, created_at, updated_at)
-		VALUES ($1, $2, NOW(), NOW())
+		INSERT INTO %s (id, content, through_id, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
 		ON CONFLICT (id)
-		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+		DO UPDATE SET content = EXCLUDED.content, through_id = EXCLUDED.through_id, updated_at = NOW()
 	`, s.fullTableName(s.cfg.UsageTable))
-	if _, err := s.db.ExecContext(ctx, query, defaultUsageKey, jsonPayload); err != nil {
+	if _, err = tx.ExecContext(ctx, query, defaultUsageKey, jsonPayload, throughID); err != nil {
 		return fmt.Errorf("postgres store: upsert usage statistics: %w", err)
 	}
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id <= $1", eventTable), throughID); err != nil {
+		return fmt.Errorf("postgres store: compact usage journal: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("postgres store: commit usage checkpoint: %w", err)
+	}
 	return nil
 }
 
-// LoadUsageStatistics retrieves usage statistics snapshot from PostgreSQL.
-func (s *PostgresStore) LoadUsageStatistics(ctx context.Context) ([]byte, error) {
+// AppendUsageEvents stores request events recorded since the previous save, one row each.
+func (s *PostgresStore) AppendUsageEvents(ctx context.Context, events []usage.Event) error {
 	if s == nil || s.db == nil {
-		return nil, fmt.Errorf("postgres store: not initialized")
+		return fmt.Errorf("postgres store: not initialized")
 	}
-	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.UsageTable))
-	var content string
-	err := s.db.QueryRowContext(ctx, query, defaultUsageKey).Scan(&content)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil // No statistics found, return empty
+	if len(events) == 0 {
+		return nil
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("postgres store: load usage statistics: %w", err)
+		return fmt.Errorf("postgres store: begin usage transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (content) VALUES ($1)", s.fullTableName(s.cfg.EventTable)))
+	if err != nil {
+		return fmt.Errorf("postgres store: prepare usage event insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, event := range events {
+		payload, errMarshal := json.Marshal(event)
+		if errMarshal != nil {
+			return fmt.Errorf("postgres store: encode usage event: %w", errMarshal)
+		}
+		if _, err = stmt.ExecContext(ctx, json.RawMessage(payload)); err != nil {
+			return fmt.Errorf("postgres store: insert usage event: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("postgres store: commit usage events: %w", err)
+	}
+	return nil
+}
+
+// LoadUsageJournal returns the stored checkpoint and the events appended after it.
+func (s *PostgresStore) LoadUsageJournal(ctx context.Context) ([]byte, []usage.Event, error) {
+	if s == nil || s.db == nil {
+		return nil, nil, fmt.Errorf("postgres store: not initialized")
+	}
+	var (
+		content   string
+		throughID int64
+	)
+	query := fmt.Sprintf("SELECT content, through_id FROM %s WHERE id = $1", s.fullTableName(s.cfg.UsageTable))
+	err := s.db.QueryRowContext(ctx, query, defaultUsageKey).Scan(&content, &throughID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		content, throughID = "", 0
+	case err != nil:
+		return nil, nil, fmt.Errorf("postgres store: load usage statistics: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT id, content FROM %s WHERE id > $1 ORDER BY id", s.fullTableName(s.cfg.EventTable)), throughID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("postgres store: load usage events: %w", err)
+	}
+	defer rows.Close()
+	var events []usage.Event
+	for rows.Next() {
+		var (
+			id      int64
+			payload string
+			event   usage.Event
+		)
+		if err = rows.Scan(&id, &payload); err != nil {
+			return nil, nil, fmt.Errorf("postgres store: scan usage event: %w", err)
+		}
+		if errDecode := json.Unmarshal([]byte(payload), &event); errDecode != nil {
+			log.WithError(errDecode).Warnf("postgres store: skipping malformed usage event %d", id)
+			continue
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("postgres store: iterate usage events: %w", err)
+	}
+	if content == "" {
+		return nil, events, nil
+	}
+	return []byte(content), events, nil
+}
+
+// LoadUsageStatistics returns the stored checkpoint with the events appended after it folded in.
+func (s *PostgresStore) LoadUsageStatistics(ctx context.Context) ([]byte, error) {
+	data, events, err := s.LoadUsageJournal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return data, nil
 	}
-	return []byte(content), nil
+	return usage.CompactUsage(data, events)
 }
 
 func (s *PostgresStore) resolveAuthPath(auth *cliproxyauth.Auth) (string, error) {
@@ -0,0 +1,806 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	baseauth "github.com/giofahreza/AIProxyAPI/internal/auth"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
+	"github.com/giofahreza/AIProxyAPI/internal/misc"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	sqliteConfigTable = "config_store"
+	sqliteAuthTable   = "auth_store"
+	sqliteUsageTable  = "usage_requests"
+
+	sqliteUsageCheckpointTable = "usage_checkpoint"
+
+	// sqliteTimeLayout is fixed width so stored timestamps sort and compare as text.
+	sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// SQLiteStoreConfig captures configuration required to initialize a SQLite-backed store.
+type SQLiteStoreConfig struct {
+	// Path is the database file. It is created when missing.
+	Path string
+	// SpoolDir is the local workspace mirroring config and auth files. Defaults to a
+	// "sqlitestore" directory next to the database file.
+	SpoolDir string
+	// ConfigPath, when set, keeps config.yaml at that path instead of restoring it from the
+	// database. The database then only holds a copy written by PersistConfig.
+	ConfigPath string
+}
+
+// SQLiteStore persists configuration, authentication metadata and per-request usage history in a
+// single SQLite file. Like the Postgres store it mirrors records to a local workspace so existing
+// file-based workflows continue to operate.
+type SQLiteStore struct {
+	db             *sql.DB
+	cfg            SQLiteStoreConfig
+	spoolRoot      string
+	configPath     string
+	authDir        string
+	externalConfig bool
+	mu             sync.Mutex
+}
+
+// NewSQLiteStore opens (or creates) the database file and prepares the local workspace.
+func NewSQLiteStore(ctx context.Context, cfg SQLiteStoreConfig) (*SQLiteStore, error) {
+	dbPath := strings.TrimSpace(cfg.Path)
+	if dbPath == "" {
+		return nil, fmt.Errorf("sqlite store: database path is required")
+	}
+	absDB, err := filepath.Abs(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: resolve database path: %w", err)
+	}
+	cfg.Path = absDB
+	if err = os.MkdirAll(filepath.Dir(absDB), 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite store: create database directory: %w", err)
+	}
+
+	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
+	if spoolRoot == "" {
+		spoolRoot = filepath.Join(filepath.Dir(absDB), "sqlitestore")
+	}
+	absSpool, err := filepath.Abs(spoolRoot)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: resolve spool directory: %w", err)
+	}
+	configPath := filepath.Join(absSpool, "config", "config.yaml")
+	externalConfig := strings.TrimSpace(cfg.ConfigPath) != ""
+	if externalConfig {
+		configPath = cfg.ConfigPath
+	}
+	authDir := filepath.Join(absSpool, "auths")
+	if err = os.MkdirAll(filepath.Dir(configPath), 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite store: create config directory: %w", err)
+	}
+	if err = os.MkdirAll(authDir, 0o700); err != nil {
+		return nil, fmt.Errorf("sqlite store: create auth directory: %w", err)
+	}
+
+	dsn := "file:" + filepath.ToSlash(absDB) + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: open database: %w", err)
+	}
+	// A single connection serializes writers and avoids SQLITE_BUSY between pooled connections.
+	db.SetMaxOpenConns(1)
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite store: open database: %w", err)
+	}
+
+	return &SQLiteStore{
+		db:             db,
+		cfg:            cfg,
+		spoolRoot:      absSpool,
+		configPath:     configPath,
+		authDir:        authDir,
+		externalConfig: externalConfig,
+	}, nil
+}
+
+// Close releases the database handle.
+func (s *SQLiteStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// EnsureSchema creates the required tables.
+func (s *SQLiteStore) EnsureSchema(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("sqlite store: not initialized")
+	}
+	statements := []struct {
+		what  string
+		query string
+	}{
+		{"config table", `CREATE TABLE IF NOT EXISTS ` + sqliteConfigTable + ` (
+			id TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)`},
+		{"auth table", `CREATE TABLE IF NOT EXISTS ` + sqliteAuthTable + ` (
+			id TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)`},
+		{"usage table", `CREATE TABLE IF NOT EXISTS ` + sqliteUsageTable + ` (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			request_key TEXT NOT NULL UNIQUE,
+			requested_at TEXT NOT NULL,
+			api_key TEXT NOT NULL,
+			model TEXT NOT NULL,
+			source TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			failed INTEGER NOT NULL DEFAULT 0,
+			hedged INTEGER NOT NULL DEFAULT 0,
+			input_tokens INTEGER NOT NULL DEFAULT 0,
+			output_tokens INTEGER NOT NULL DEFAULT 0,
+			reasoning_tokens INTEGER NOT NULL DEFAULT 0,
+			cached_tokens INTEGER NOT NULL DEFAULT 0,
+			total_tokens INTEGER NOT NULL DEFAULT 0,
+			user_prompt TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			end_user TEXT NOT NULL DEFAULT '',
+			images INTEGER NOT NULL DEFAULT 0,
+			cache_write_tokens INTEGER NOT NULL DEFAULT 0
+		)`},
+		{"usage checkpoint table", `CREATE TABLE IF NOT EXISTS ` + sqliteUsageCheckpointTable + ` (
+			id TEXT PRIMARY KEY,
+			content TEXT NOT NULL,
+			through_id INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)`},
+		{"usage time index", `CREATE INDEX IF NOT EXISTS ` + sqliteUsageTable + `_requested_at ON ` + sqliteUsageTable + ` (requested_at)`},
+		{"usage key index", `CREATE INDEX IF NOT EXISTS ` + sqliteUsageTable + `_api_key_model ON ` + sqliteUsageTable + ` (api_key, model, requested_at)`},
+	}
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt.query); err != nil {
+			return fmt.Errorf("sqlite store: create %s: %w", stmt.what, err)
+		}
+	}
+	// Columns added after the table was first released.
+	for _, column := range sqliteUsageLateColumns {
+		if err := s.ensureColumn(ctx, sqliteUsageTable, column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqliteUsageLateColumns are the usage table columns added after the table was first released,
+// with the value read in their place from databases that lack them.
+var sqliteUsageLateColumns = []struct{ name, definition, missing string }{
+	{"provider", "TEXT NOT NULL DEFAULT ''", "''"},
+	{"end_user", "TEXT NOT NULL DEFAULT ''", "''"},
+	{"images", "INTEGER NOT NULL DEFAULT 0", "0"},
+	{"cache_write_tokens", "INTEGER NOT NULL DEFAULT 0", "0"},
+}
+
+func (s *SQLiteStore) ensureColumn(ctx context.Context, table, column, definition string) error {
+	if exists, err := s.hasColumn(ctx, table, column); err != nil || exists {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column+" "+definition); err != nil {
+		return fmt.Errorf("sqlite store: add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) hasColumn(ctx context.Context, table, column string) (bool, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count); err != nil {
+		return false, fmt.Errorf("sqlite store: inspect %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// Bootstrap creates the schema and synchronizes configuration and auth records between the
+// database and the local workspace.
+func (s *SQLiteStore) Bootstrap(ctx context.Context, exampleConfigPath string) error {
+	if err := s.EnsureSchema(ctx); err != nil {
+		return err
+	}
+	if s.externalConfig {
+		if err := s.PersistConfig(ctx); err != nil {
+			return err
+		}
+	} else if err := s.syncConfigFromDatabase(ctx, exampleConfigPath); err != nil {
+		return err
+	}
+	return s.syncAuthFromDatabase(ctx)
+}
+
+// ConfigPath returns the managed configuration file path.
+func (s *SQLiteStore) ConfigPath() string {
+	if s == nil {
+		return ""
+	}
+	return s.configPath
+}
+
+// AuthDir returns the local directory containing mirrored auth files.
+func (s *SQLiteStore) AuthDir() string {
+	if s == nil {
+		return ""
+	}
+	return s.authDir
+}
+
+// WorkDir exposes the root spool directory used for mirroring.
+func (s *SQLiteStore) WorkDir() string {
+	if s == nil {
+		return ""
+	}
+	return s.spoolRoot
+}
+
+// SetBaseDir implements the optional interface used by authenticators; it is a no-op because
+// the SQLite-backed store controls its own workspace.
+func (s *SQLiteStore) SetBaseDir(string) {}
+
+// Save persists authentication metadata to disk and the database.
+func (s *SQLiteStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
+	if auth == nil {
+		return "", fmt.Errorf("sqlite store: auth is nil")
+	}
+
+	path, err := s.resolveAuthPath(auth)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", fmt.Errorf("sqlite store: missing file path attribute for %s", auth.ID)
+	}
+
+	if auth.Disabled {
+		if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrNotExist) {
+			return "", nil
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("sqlite store: create auth directory: %w", err)
+	}
+
+	switch {
+	case auth.Storage != nil:
+		// Seal in memory and write once, so plaintext tokens never reach disk.
+		raw, errEncode := baseauth.EncodeTokenStorage(auth.Storage)
+		if errEncode != nil {
+			return "", fmt.Errorf("sqlite store: encode token storage: %w", errEncode)
+		}
+		misc.LogSavingCredentials(path)
+		if err = authcrypt.WriteSealedFile(path, raw); err != nil {
+			return "", fmt.Errorf("sqlite store: encrypt auth file: %w", err)
+		}
+	case auth.Metadata != nil:
+		raw, errMarshal := json.Marshal(auth.Metadata)
+		if errMarshal != nil {
+			return "", fmt.Errorf("sqlite store: marshal metadata: %w", errMarshal)
+		}
+		if existing, errRead := os.ReadFile(path); errRead == nil {
+			if plain, errOpen := authcrypt.Open(existing); errOpen == nil && authcrypt.IsCurrent(existing) && jsonEqual(plain, raw) {
+				return path, nil
+			}
+		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
+			return "", fmt.Errorf("sqlite store: read existing metadata: %w", errRead)
+		}
+		if raw, errMarshal = authcrypt.Seal(raw); errMarshal != nil {
+			return "", fmt.Errorf("sqlite store: encrypt auth file: %w", errMarshal)
+		}
+		if errWrite := authcrypt.WriteFile(path, raw); errWrite != nil {
+			return "", fmt.Errorf("sqlite store: %w", errWrite)
+		}
+	default:
+		return "", fmt.Errorf("sqlite store: nothing to persist for %s", auth.ID)
+	}
+
+	if auth.Attributes == nil {
+		auth.Attributes = make(map[string]string)
+	}
+	auth.Attributes["path"] = path
+
+	if strings.TrimSpace(auth.FileName) == "" {
+		auth.FileName = auth.ID
+	}
+
+	relID, err := s.relativeAuthID(path)
+	if err != nil {
+		return "", err
+	}
+	if err = s.syncAuthFile(ctx, relID, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List enumerates all auth records stored in the database.
+func (s *SQLiteStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error) {
+	query := "SELECT id, content, created_at, updated_at FROM " + sqliteAuthTable + " ORDER BY id"
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite store: list auth: %w", err)
+	}
+	defer rows.Close()
+
+	auths := make([]*cliproxyauth.Auth, 0, 32)
+	for rows.Next() {
+		var (
+			id        string
+			payload   string
+			createdAt int64
+			updatedAt int64
+		)
+		if err = rows.Scan(&id, &payload, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("sqlite store: scan auth row: %w", err)
+		}
+		if auth := s.authFromRecord(id, payload, time.Unix(createdAt, 0), time.Unix(updatedAt, 0)); auth != nil {
+			auths = append(auths, auth)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite store: iterate auth rows: %w", err)
+	}
+	return auths, nil
+}
+
+// authFromRecord converts a stored row into an auth entry, or returns nil when it cannot be used.
+func (s *SQLiteStore) authFromRecord(id, payload string, createdAt, updatedAt time.Time) *cliproxyauth.Auth {
+	path, errPath := s.absoluteAuthPath(id)
+	if errPath != nil {
+		log.WithError(errPath).Warnf("sqlite store: skipping auth %s outside spool", id)
+		return nil
+	}
+	metadata, errDecode := decodeAuthRecord([]byte(payload))
+	if errDecode != nil || metadata == nil {
+		log.WithError(errDecode).Warnf("sqlite store: skipping auth %s", id)
+		return nil
+	}
+	provider := strings.TrimSpace(valueAsString(metadata["type"]))
+	if provider == "" {
+		provider = "unknown"
+	}
+	attr := map[string]string{"path": path}
+	if email := strings.TrimSpace(valueAsString(metadata["email"])); email != "" {
+		attr["email"] = email
+	}
+	return &cliproxyauth.Auth{
+		ID:               normalizeAuthID(id),
+		Provider:         provider,
+		FileName:         normalizeAuthID(id),
+		Label:            labelFor(metadata),
+		Status:           cliproxyauth.StatusActive,
+		Attributes:       attr,
+		Metadata:         metadata,
+		CreatedAt:        createdAt,
+		UpdatedAt:        updatedAt,
+		LastRefreshedAt:  time.Time{},
+		NextRefreshAfter: time.Time{},
+	}
+}
+
+// Delete removes an auth record from the workspace and the database.
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return fmt.Errorf("sqlite store: id is empty")
+	}
+	path := filepath.Join(s.authDir, filepath.FromSlash(id))
+	if strings.ContainsRune(id, os.PathSeparator) || filepath.IsAbs(id) {
+		path = id
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("sqlite store: delete auth file: %w", err)
+	}
+	relID, err := s.relativeAuthID(path)
+	if err != nil {
+		return err
+	}
+	return s.deleteAuthRecord(ctx, relID)
+}
+
+// PersistAuthFiles stores the provided auth file changes in the database.
+func (s *SQLiteStore) PersistAuthFiles(ctx context.Context, _ string, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range paths {
+		trimmed := strings.TrimSpace(p)
+		if trimmed == "" {
+			continue
+		}
+		if !filepath.IsAbs(trimmed) {
+			trimmed = filepath.Join(s.authDir, trimmed)
+		}
+		relID, err := s.relativeAuthID(trimmed)
+		if err != nil {
+			log.WithError(err).Warnf("sqlite store: ignoring auth path %s", p)
+			continue
+		}
+		if err = s.syncAuthFile(ctx, relID, trimmed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PersistConfig mirrors the local configuration file to the database.
+func (s *SQLiteStore) PersistConfig(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.configPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			_, errDelete := s.db.ExecContext(ctx, "DELETE FROM "+sqliteConfigTable+" WHERE id = ?", defaultConfigKey)
+			if errDelete != nil {
+				return fmt.Errorf("sqlite store: delete config: %w", errDelete)
+			}
+			return nil
+		}
+		return fmt.Errorf("sqlite store: read config file: %w", err)
+	}
+	return s.persistConfig(ctx, data)
+}
+
+func (s *SQLiteStore) syncConfigFromDatabase(ctx context.Context, exampleConfigPath string) error {
+	var content string
+	err := s.db.QueryRowContext(ctx, "SELECT content FROM "+sqliteConfigTable+" WHERE id = ?", defaultConfigKey).Scan(&content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, errStat := os.Stat(s.configPath); errors.Is(errStat, fs.ErrNotExist) {
+			if exampleConfigPath != "" {
+				if errCopy := misc.CopyConfigTemplate(exampleConfigPath, s.configPath); errCopy != nil {
+					return fmt.Errorf("sqlite store: copy example config: %w", errCopy)
+				}
+			} else if errWrite := os.WriteFile(s.configPath, []byte{}, 0o600); errWrite != nil {
+				return fmt.Errorf("sqlite store: create empty config: %w", errWrite)
+			}
+		}
+		data, errRead := os.ReadFile(s.configPath)
+		if errRead != nil {
+			return fmt.Errorf("sqlite store: read local config: %w", errRead)
+		}
+		return s.persistConfig(ctx, data)
+	case err != nil:
+		return fmt.Errorf("sqlite store: load config from database: %w", err)
+	}
+	if err = os.WriteFile(s.configPath, []byte(normalizeLineEndings(content)), 0o600); err != nil {
+		return fmt.Errorf("sqlite store: write config to spool: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) syncAuthFromDatabase(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, content FROM "+sqliteAuthTable)
+	if err != nil {
+		return fmt.Errorf("sqlite store: load auth from database: %w", err)
+	}
+	defer rows.Close()
+
+	if err = os.RemoveAll(s.authDir); err != nil {
+		return fmt.Errorf("sqlite store: reset auth directory: %w", err)
+	}
+	if err = os.MkdirAll(s.authDir, 0o700); err != nil {
+		return fmt.Errorf("sqlite store: recreate auth directory: %w", err)
+	}
+
+	for rows.Next() {
+		var id, payload string
+		if err = rows.Scan(&id, &payload); err != nil {
+			return fmt.Errorf("sqlite store: scan auth row: %w", err)
+		}
+		path, errPath := s.absoluteAuthPath(id)
+		if errPath != nil {
+			log.WithError(errPath).Warnf("sqlite store: skipping auth %s outside spool", id)
+			continue
+		}
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("sqlite store: create auth subdir: %w", err)
+		}
+		if err = os.WriteFile(path, []byte(payload), 0o600); err != nil {
+			return fmt.Errorf("sqlite store: write auth file: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("sqlite store: iterate auth rows: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) syncAuthFile(ctx context.Context, relID, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s.deleteAuthRecord(ctx, relID)
+		}
+		return fmt.Errorf("sqlite store: read auth file: %w", err)
+	}
+	if len(data) == 0 {
+		return s.deleteAuthRecord(ctx, relID)
+	}
+	return s.persistAuth(ctx, relID, data)
+}
+
+func (s *SQLiteStore) persistAuth(ctx context.Context, relID string, data []byte) error {
+	now := time.Now().Unix()
+	query := "INSERT INTO " + sqliteAuthTable + ` (id, content, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at`
+	if _, err := s.db.ExecContext(ctx, query, relID, string(data), now, now); err != nil {
+		return fmt.Errorf("sqlite store: upsert auth record: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) deleteAuthRecord(ctx context.Context, relID string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM "+sqliteAuthTable+" WHERE id = ?", relID); err != nil {
+		return fmt.Errorf("sqlite store: delete auth record: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) persistConfig(ctx context.Context, data []byte) error {
+	now := time.Now().Unix()
+	query := "INSERT INTO " + sqliteConfigTable + ` (id, content, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at`
+	if _, err := s.db.ExecContext(ctx, query, defaultConfigKey, normalizeLineEndings(string(data)), now, now); err != nil {
+		return fmt.Errorf("sqlite store: upsert config: %w", err)
+	}
+	return nil
+}
+
+// SaveUsageStatistics stores a usage checkpoint. Request rows stay in the usage_requests table as
+// queryable history until they age out of the event retention, but only rows appended after the
+// checkpoint are replayed on load.
+func (s *SQLiteStore) SaveUsageStatistics(ctx context.Context, data []byte) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("sqlite store: not initialized")
+	}
+	data, err := usage.CompactUsage(data, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite store: decode usage statistics: %w", err)
+	}
+	if data == nil {
+		data = []byte("{}")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite store: begin usage transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	var throughID int64
+	if err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM "+sqliteUsageTable).Scan(&throughID); err != nil {
+		return fmt.Errorf("sqlite store: read usage position: %w", err)
+	}
+	query := `INSERT INTO ` + sqliteUsageCheckpointTable + ` (id, content, through_id, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET content = excluded.content, through_id = excluded.through_id, updated_at = excluded.updated_at`
+	if _, err = tx.ExecContext(ctx, query, defaultConfigKey, string(data), throughID, time.Now().Unix()); err != nil {
+		return fmt.Errorf("sqlite store: upsert usage checkpoint: %w", err)
+	}
+	days := usage.GetRequestStatistics().Retention().EventRetentionDays
+	cutoff := time.Now().AddDate(0, 0, -days).UTC().Format(sqliteTimeLayout)
+	if _, err = tx.ExecContext(ctx, "DELETE FROM "+sqliteUsageTable+" WHERE requested_at < ? AND id <= ?", cutoff, throughID); err != nil {
+		return fmt.Errorf("sqlite store: prune usage rows: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite store: commit usage checkpoint: %w", err)
+	}
+	return nil
+}
+
+// AppendUsageEvents stores each request event as a row in the usage_requests table. Rows are
+// keyed by their content, so appending the same request again is a no-op.
+func (s *SQLiteStore) AppendUsageEvents(ctx context.Context, events []usage.Event) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("sqlite store: not initialized")
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite store: begin usage transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO "+sqliteUsageTable+` (
+		request_key, requested_at, api_key, model, source, auth_index, failed, hedged,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, user_prompt, provider, end_user, images, cache_write_tokens
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("sqlite store: prepare usage insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, event := range events {
+		requestedAt := event.Timestamp.UTC().Format(sqliteTimeLayout)
+		tokens := event.Tokens
+		if _, err = stmt.ExecContext(ctx,
+			usageRequestKey(event.APIKey, event.Model, requestedAt, event.RequestDetail), requestedAt, event.APIKey, event.Model,
+			event.Source, event.AuthIndex, event.Failed, event.Hedged,
+			tokens.InputTokens, tokens.OutputTokens, tokens.ReasoningTokens, tokens.CachedTokens, tokens.TotalTokens,
+			event.UserPrompt, event.Provider, event.EndUser, tokens.Images, tokens.CacheWriteTokens,
+		); err != nil {
+			return fmt.Errorf("sqlite store: insert usage row: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite store: commit usage rows: %w", err)
+	}
+	return nil
+}
+
+// LoadUsageJournal returns the stored checkpoint and the request rows appended after it. Without
+// a checkpoint every row is returned, which is how history written before checkpoints existed is
+// picked up.
+func (s *SQLiteStore) LoadUsageJournal(ctx context.Context) ([]byte, []usage.Event, error) {
+	if s == nil || s.db == nil {
+		return nil, nil, fmt.Errorf("sqlite store: not initialized")
+	}
+	var (
+		content   string
+		throughID int64
+	)
+	err := s.db.QueryRowContext(ctx, "SELECT content, through_id FROM "+sqliteUsageCheckpointTable+" WHERE id = ?", defaultConfigKey).Scan(&content, &throughID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), isMissingSQLiteTable(err):
+		content, throughID = "", 0
+	case err != nil:
+		return nil, nil, fmt.Errorf("sqlite store: load usage checkpoint: %w", err)
+	}
+
+	// Databases that were not opened by this version yet lack the newer columns.
+	lateColumns := make([]string, 0, len(sqliteUsageLateColumns))
+	for _, column := range sqliteUsageLateColumns {
+		exists, errColumn := s.hasColumn(ctx, sqliteUsageTable, column.name)
+		if errColumn != nil {
+			return nil, nil, errColumn
+		}
+		if exists {
+			lateColumns = append(lateColumns, column.name)
+		} else {
+			lateColumns = append(lateColumns, column.missing)
+		}
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT requested_at, api_key, model, source, auth_index, failed, hedged,
+		input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, user_prompt, `+strings.Join(lateColumns, ", ")+`
+		FROM `+sqliteUsageTable+` WHERE id > ? ORDER BY id`, throughID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sqlite store: load usage rows: %w", err)
+	}
+	defer rows.Close()
+
+	var events []usage.Event
+	for rows.Next() {
+		var (
+			requestedAt string
+			event       usage.Event
+		)
+		if err = rows.Scan(&requestedAt, &event.APIKey, &event.Model, &event.Source, &event.AuthIndex, &event.Failed, &event.Hedged,
+			&event.Tokens.InputTokens, &event.Tokens.OutputTokens, &event.Tokens.ReasoningTokens,
+			&event.Tokens.CachedTokens, &event.Tokens.TotalTokens, &event.UserPrompt, &event.Provider, &event.EndUser, &event.Tokens.Images, &event.Tokens.CacheWriteTokens); err != nil {
+			return nil, nil, fmt.Errorf("sqlite store: scan usage row: %w", err)
+		}
+		timestamp, errParse := time.Parse(sqliteTimeLayout, requestedAt)
+		if errParse != nil {
+			log.WithError(errParse).Warnf("sqlite store: skipping usage row with invalid timestamp %q", requestedAt)
+			continue
+		}
+		event.Timestamp = timestamp.Local()
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("sqlite store: iterate usage rows: %w", err)
+	}
+	if content == "" {
+		return nil, events, nil
+	}
+	return []byte(content), events, nil
+}
+
+// LoadUsageStatistics returns the stored checkpoint with the rows appended after it folded in.
+func (s *SQLiteStore) LoadUsageStatistics(ctx context.Context) ([]byte, error) {
+	data, events, err := s.LoadUsageJournal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return data, nil
+	}
+	return usage.CompactUsage(data, events)
+}
+
+// usageRequestKey identifies a request detail by its content, matching how usage snapshots
+// deduplicate details when they are merged.
+func usageRequestKey(apiKey, model, requestedAt string, detail usage.RequestDetail) string {
+	tokens := detail.Tokens
+	raw := fmt.Sprintf("%s|%s|%s|%s|%s|%t|%d|%d|%d|%d|%d",
+		apiKey, model, requestedAt, detail.Source, detail.AuthIndex, detail.Failed,
+		tokens.InputTokens, tokens.OutputTokens, tokens.ReasoningTokens, tokens.CachedTokens, tokens.TotalTokens)
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *SQLiteStore) resolveAuthPath(auth *cliproxyauth.Auth) (string, error) {
+	if auth.Attributes != nil {
+		if p := strings.TrimSpace(auth.Attributes["path"]); p != "" {
+			return p, nil
+		}
+	}
+	if fileName := strings.TrimSpace(auth.FileName); fileName != "" {
+		if filepath.IsAbs(fileName) {
+			return fileName, nil
+		}
+		return filepath.Join(s.authDir, fileName), nil
+	}
+	if auth.ID == "" {
+		return "", fmt.Errorf("sqlite store: missing id")
+	}
+	if filepath.IsAbs(auth.ID) {
+		return auth.ID, nil
+	}
+	return filepath.Join(s.authDir, filepath.FromSlash(auth.ID)), nil
+}
+
+func (s *SQLiteStore) relativeAuthID(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.authDir, path)
+	}
+	rel, err := filepath.Rel(s.authDir, filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("sqlite store: compute relative path: %w", err)
+	}
+	if strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("sqlite store: path %s outside managed directory", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (s *SQLiteStore) absoluteAuthPath(id string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(id))
+	if strings.HasPrefix(clean, "..") || filepath.IsAbs(clean) {
+		return "", fmt.Errorf("sqlite store: invalid auth identifier %s", id)
+	}
+	return filepath.Join(s.authDir, clean), nil
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/auth/claude"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
+	"github.com/giofahreza/AIProxyAPI/internal/usage"
+	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+)
+
+func newTestSQLiteStore(t *testing.T, dbPath string) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(context.Background(), SQLiteStoreConfig{Path: dbPath, SpoolDir: filepath.Join(t.TempDir(), "spool")})
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err = s.Bootstrap(context.Background(), ""); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	return s
+}
+
+func TestSQLiteStore_AuthAndConfigRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "aiproxy.db")
+	s := newTestSQLiteStore(t, dbPath)
+
+	auth := &cliproxyauth.Auth{ID: "claude-a.json", Metadata: map[string]any{"type": "claude", "email": "a@example.com"}}
+	if _, err := s.Save(ctx, auth); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := os.WriteFile(s.ConfigPath(), []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := s.PersistConfig(ctx); err != nil {
+		t.Fatalf("PersistConfig: %v", err)
+	}
+	_ = s.Close()
+
+	// A second process with an empty workspace restores everything from the database.
+	reopened := newTestSQLiteStore(t, dbPath)
+	auths, err := reopened.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(auths) != 1 || auths[0].ID != "claude-a.json" || auths[0].Provider != "claude" || auths[0].Attributes["email"] != "a@example.com" {
+		t.Fatalf("List = %+v", auths)
+	}
+	if _, err = os.Stat(filepath.Join(reopened.AuthDir(), "claude-a.json")); err != nil {
+		t.Fatalf("auth not mirrored to workspace: %v", err)
+	}
+	if data, _ := os.ReadFile(reopened.ConfigPath()); string(data) != "port: 8317\n" {
+		t.Fatalf("config = %q", data)
+	}
+
+	if err = reopened.Delete(ctx, "claude-a.json"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if auths, _ = reopened.List(ctx); len(auths) != 0 {
+		t.Fatalf("List after delete = %d records", len(auths))
+	}
+}
+
+func TestSQLiteStore_SealsTokenStorageBeforeWriting(t *testing.T) {
+	ring, err := authcrypt.NewKeyring([]string{"k1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	authcrypt.SetKeyring(ring)
+	t.Cleanup(func() { authcrypt.SetKeyring(nil) })
+
+	s := newTestSQLiteStore(t, filepath.Join(t.TempDir(), "aiproxy.db"))
+	auth := &cliproxyauth.Auth{ID: "claude-b.json", Storage: &claude.ClaudeTokenStorage{RefreshToken: "refresh-secret", Email: "b@example.com"}}
+	path, err := s.Save(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read auth file: %v", err)
+	}
+	if !authcrypt.IsSealed(data) || strings.Contains(string(data), "refresh-secret") {
+		t.Fatalf("auth file is not sealed: %s", data)
+	}
+	if _, errStat := os.Stat(path + ".tmp"); !os.IsNotExist(errStat) {
+		t.Fatalf("temp file left behind: %v", errStat)
+	}
+	plain, err := authcrypt.Open(data)
+	if err != nil || !strings.Contains(string(plain), `"type":"claude"`) {
+		t.Fatalf("Open = %s, %v", plain, err)
+	}
+}
+
+func TestSQLiteStore_UsageJournalReplaysOnlyRowsAfterCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSQLiteStore(t, filepath.Join(t.TempDir(), "aiproxy.db"))
+
+	first := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	event := func(at time.Time, failed bool) usage.Event {
+		return usage.Event{APIKey: "key-1", Model: "gpt-test", RequestDetail: usage.RequestDetail{
+			Timestamp: at, Source: "a@example.com", Failed: failed,
+			Tokens: usage.TokenStats{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		}}
+	}
+	a, b := event(first, false), event(first.Add(time.Minute), true)
+	if err := s.AppendUsageEvents(ctx, []usage.Event{a}); err != nil {
+		t.Fatalf("AppendUsageEvents: %v", err)
+	}
+	if err := s.AppendUsageEvents(ctx, []usage.Event{a, b}); err != nil {
+		t.Fatalf("AppendUsageEvents: %v", err)
+	}
+
+	var rows, failed int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*), SUM(failed) FROM "+sqliteUsageTable+" WHERE api_key = ? AND model = ?", "key-1", "gpt-test").Scan(&rows, &failed); err != nil {
+		t.Fatalf("query usage rows: %v", err)
+	}
+	if rows != 2 || failed != 1 {
+		t.Fatalf("rows = %d, failed = %d, want 2 and 1", rows, failed)
+	}
+
+	data, events, err := s.LoadUsageJournal(ctx)
+	if err != nil {
+		t.Fatalf("LoadUsageJournal: %v", err)
+	}
+	if data != nil || len(events) != 2 || !events[0].Timestamp.Equal(first) || events[0].Tokens.TotalTokens != 15 {
+		t.Fatalf("journal before checkpoint = %s, %+v", data, events)
+	}
+	stats := usage.NewRequestStatistics()
+	if err = stats.Restore(data, events); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	checkpoint, err := stats.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if err = s.SaveUsageStatistics(ctx, checkpoint); err != nil {
+		t.Fatalf("SaveUsageStatistics: %v", err)
+	}
+	if err = s.AppendUsageEvents(ctx, []usage.Event{event(first.Add(2*time.Minute), false)}); err != nil {
+		t.Fatalf("AppendUsageEvents: %v", err)
+	}
+
+	data, events, err = s.LoadUsageJournal(ctx)
+	if err != nil {
+		t.Fatalf("LoadUsageJournal: %v", err)
+	}
+	if data == nil || len(events) != 1 {
+		t.Fatalf("journal after checkpoint has checkpoint %t and %d events, want true and 1", data != nil, len(events))
+	}
+	reloaded := usage.NewRequestStatistics()
+	if err = reloaded.Restore(data, events); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if snapshot := reloaded.Snapshot(); snapshot.TotalRequests != 3 || snapshot.FailureCount != 1 {
+		t.Fatalf("restored totals = %d requests, %d failures, want 3 and 1", snapshot.TotalRequests, snapshot.FailureCount)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	_ "github.com/giofahreza/AIProxyAPI/internal/translator/openai/claude"
 	_ "github.com/giofahreza/AIProxyAPI/internal/translator/openai/gemini"
 	_ "github.com/giofahreza/AIProxyAPI/internal/translator/openai/gemini-cli"
+	_ "github.com/giofahreza/AIProxyAPI/internal/translator/openai/ollama"
 	_ "github.com/giofahreza/AIProxyAPI/internal/translator/openai/openai/chat-completions"
 	_ "github.com/giofahreza/AIProxyAPI/internal/translator/openai/openai/responses"
 
@@ -0,0 +1,19 @@
+package ollama
+
+import (
+	. "github.com/giofahreza/AIProxyAPI/internal/constant"
+	"github.com/giofahreza/AIProxyAPI/internal/interfaces"
+	"github.com/giofahreza/AIProxyAPI/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Ollama,
+		OpenAI,
+		ConvertOllamaRequestToOpenAI,
+		interfaces.TranslateResponse{
+			Stream:    ConvertOpenAIResponseToOllama,
+			NonStream: ConvertOpenAIResponseToOllamaNonStream,
+		},
+	)
+}
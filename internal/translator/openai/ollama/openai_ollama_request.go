@@ -0,0 +1,177 @@
+// Package ollama provides translation between the Ollama chat API and OpenAI Chat Completions.
+// Requests from Ollama clients are converted into OpenAI chat requests, and OpenAI responses are
+// converted back into Ollama's NDJSON chat messages, so any model reachable through the OpenAI
+// format can serve Ollama-only tools.
+package ollama
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOllamaRequestToOpenAI transforms an Ollama /api/chat request into an OpenAI Chat
+// Completions request. Sampling options, tools, structured output formats, images and the
+// think flag are mapped to their OpenAI equivalents; Ollama-only options such as top_k or
+// keep_alive are dropped.
+func ConvertOllamaRequestToOpenAI(modelName string, inputRawJSON []byte, stream bool) []byte {
+	root := gjson.ParseBytes(bytes.Clone(inputRawJSON))
+	out := `{"model":"","messages":[]}`
+	out, _ = sjson.Set(out, "model", modelName)
+	out, _ = sjson.Set(out, "stream", stream)
+	if stream {
+		out, _ = sjson.Set(out, "stream_options.include_usage", true)
+	}
+
+	options := root.Get("options")
+	if v := options.Get("temperature"); v.Exists() {
+		out, _ = sjson.Set(out, "temperature", v.Float())
+	}
+	if v := options.Get("top_p"); v.Exists() {
+		out, _ = sjson.Set(out, "top_p", v.Float())
+	}
+	if v := options.Get("num_predict"); v.Exists() && v.Int() > 0 {
+		out, _ = sjson.Set(out, "max_tokens", v.Int())
+	}
+	if v := options.Get("seed"); v.Exists() {
+		out, _ = sjson.Set(out, "seed", v.Int())
+	}
+	if v := options.Get("presence_penalty"); v.Exists() {
+		out, _ = sjson.Set(out, "presence_penalty", v.Float())
+	}
+	if v := options.Get("frequency_penalty"); v.Exists() {
+		out, _ = sjson.Set(out, "frequency_penalty", v.Float())
+	}
+	if v := options.Get("stop"); v.Exists() {
+		if v.IsArray() {
+			out, _ = sjson.SetRaw(out, "stop", v.Raw)
+		} else if v.String() != "" {
+			out, _ = sjson.Set(out, "stop", v.String())
+		}
+	}
+
+	// format is either "json" or a JSON schema object.
+	if format := root.Get("format"); format.Exists() {
+		switch {
+		case format.IsObject():
+			out, _ = sjson.Set(out, "response_format.type", "json_schema")
+			out, _ = sjson.Set(out, "response_format.json_schema.name", "response")
+			out, _ = sjson.SetRaw(out, "response_format.json_schema.schema", format.Raw)
+		case format.String() == "json":
+			out, _ = sjson.Set(out, "response_format.type", "json_object")
+		}
+	}
+
+	// think is a boolean for most models and an effort level for some (e.g. gpt-oss).
+	if think := root.Get("think"); think.Exists() {
+		switch think.Type {
+		case gjson.True:
+			if effort, ok := util.ThinkingBudgetToEffort(modelName, -1); ok && effort != "" {
+				out, _ = sjson.Set(out, "reasoning_effort", effort)
+			}
+		case gjson.False:
+			if effort, ok := util.ThinkingBudgetToEffort(modelName, 0); ok && effort != "" {
+				out, _ = sjson.Set(out, "reasoning_effort", effort)
+			}
+		case gjson.String:
+			if level := strings.ToLower(strings.TrimSpace(think.String())); level != "" {
+				out, _ = sjson.Set(out, "reasoning_effort", level)
+			}
+		}
+	}
+
+	if tools := root.Get("tools"); tools.IsArray() && len(tools.Array()) > 0 {
+		out, _ = sjson.SetRaw(out, "tools", tools.Raw)
+	}
+
+	// Ollama tool calls carry no IDs, so IDs are generated here and handed to the tool results
+	// that follow, matched by function name.
+	pendingCalls := make(map[string][]string)
+	callCount := 0
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		role := message.Get("role").String()
+		msg := `{"role":""}`
+		msg, _ = sjson.Set(msg, "role", role)
+
+		switch role {
+		case "tool":
+			name := message.Get("tool_name").String()
+			if name == "" {
+				name = message.Get("name").String()
+			}
+			callID := "call_" + name
+			if queue := pendingCalls[name]; len(queue) > 0 {
+				callID = queue[0]
+				pendingCalls[name] = queue[1:]
+			}
+			msg, _ = sjson.Set(msg, "tool_call_id", callID)
+			msg, _ = sjson.Set(msg, "content", message.Get("content").String())
+		case "assistant":
+			msg, _ = sjson.Set(msg, "content", message.Get("content").String())
+			message.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+				name := call.Get("function.name").String()
+				callID := call.Get("id").String()
+				if callID == "" {
+					callID = fmt.Sprintf("call_%d", callCount)
+				}
+				callCount++
+				pendingCalls[name] = append(pendingCalls[name], callID)
+				arguments := call.Get("function.arguments")
+				argsText := "{}"
+				if arguments.IsObject() {
+					argsText = arguments.Raw
+				} else if arguments.String() != "" {
+					argsText = arguments.String()
+				}
+				toolCall := `{"type":"function","function":{}}`
+				toolCall, _ = sjson.Set(toolCall, "id", callID)
+				toolCall, _ = sjson.Set(toolCall, "function.name", name)
+				toolCall, _ = sjson.Set(toolCall, "function.arguments", argsText)
+				msg, _ = sjson.SetRaw(msg, "tool_calls.-1", toolCall)
+				return true
+			})
+		default:
+			images := message.Get("images").Array()
+			if len(images) == 0 {
+				msg, _ = sjson.Set(msg, "content", message.Get("content").String())
+				break
+			}
+			msg, _ = sjson.SetRaw(msg, "content", "[]")
+			if text := message.Get("content").String(); text != "" {
+				part, _ := sjson.Set(`{"type":"text"}`, "text", text)
+				msg, _ = sjson.SetRaw(msg, "content.-1", part)
+			}
+			for _, image := range images {
+				part, _ := sjson.Set(`{"type":"image_url"}`, "image_url.url", imageDataURL(image.String()))
+				msg, _ = sjson.SetRaw(msg, "content.-1", part)
+			}
+		}
+		out, _ = sjson.SetRaw(out, "messages.-1", msg)
+		return true
+	})
+
+	return []byte(out)
+}
+
+// imageDataURL wraps a base64 image from an Ollama request in a data URL, detecting the
+// media type from the encoded magic bytes.
+func imageDataURL(encoded string) string {
+	encoded = strings.TrimSpace(encoded)
+	if strings.HasPrefix(encoded, "data:") {
+		return encoded
+	}
+	mediaType := "image/png"
+	switch {
+	case strings.HasPrefix(encoded, "/9j/"):
+		mediaType = "image/jpeg"
+	case strings.HasPrefix(encoded, "R0lGOD"):
+		mediaType = "image/gif"
+	case strings.HasPrefix(encoded, "UklGR"):
+		mediaType = "image/webp"
+	}
+	return "data:" + mediaType + ";base64," + encoded
+}
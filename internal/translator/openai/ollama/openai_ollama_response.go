@@ -0,0 +1,179 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIResponseToOllamaParams tracks state across the chunks of one streamed response.
+type ConvertOpenAIResponseToOllamaParams struct {
+	Start        time.Time
+	ToolCalls    map[int]*toolCallAccumulator
+	FinishReason string
+	Usage        gjson.Result
+	Done         bool
+}
+
+type toolCallAccumulator struct {
+	Name      string
+	Arguments strings.Builder
+}
+
+// ConvertOpenAIResponseToOllama converts an OpenAI Chat Completions stream chunk into Ollama
+// chat messages, one NDJSON line per returned string. Text and reasoning deltas are forwarded
+// as they arrive, tool calls are buffered until the choice finishes, and the closing done
+// message is written once usage or the [DONE] marker has been seen.
+func ConvertOpenAIResponseToOllama(_ context.Context, modelName string, _, _, rawJSON []byte, param *any) []string {
+	if *param == nil {
+		*param = &ConvertOpenAIResponseToOllamaParams{Start: time.Now()}
+	}
+	p, ok := (*param).(*ConvertOpenAIResponseToOllamaParams)
+	if !ok || p.Done {
+		return nil
+	}
+
+	rawJSON = bytes.TrimSpace(rawJSON)
+	if bytes.HasPrefix(rawJSON, []byte("data:")) {
+		rawJSON = bytes.TrimSpace(rawJSON[5:])
+	}
+	if len(rawJSON) == 0 {
+		return nil
+	}
+	if string(rawJSON) == "[DONE]" {
+		return p.finish(modelName)
+	}
+
+	root := gjson.ParseBytes(rawJSON)
+	if usage := root.Get("usage"); usage.Exists() && usage.Type != gjson.Null {
+		p.Usage = usage
+	}
+
+	var out []string
+	choice := root.Get("choices.0")
+	delta := choice.Get("delta")
+	if reasoning := delta.Get("reasoning_content").String(); reasoning != "" {
+		line := messageLine(modelName, "")
+		line, _ = sjson.Set(line, "message.thinking", reasoning)
+		out = append(out, line)
+	}
+	if content := delta.Get("content").String(); content != "" {
+		out = append(out, messageLine(modelName, content))
+	}
+	delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		if p.ToolCalls == nil {
+			p.ToolCalls = make(map[int]*toolCallAccumulator)
+		}
+		index := int(call.Get("index").Int())
+		acc, exists := p.ToolCalls[index]
+		if !exists {
+			acc = &toolCallAccumulator{}
+			p.ToolCalls[index] = acc
+		}
+		if name := call.Get("function.name").String(); name != "" {
+			acc.Name = name
+		}
+		acc.Arguments.WriteString(call.Get("function.arguments").String())
+		return true
+	})
+
+	if reason := choice.Get("finish_reason").String(); reason != "" && p.FinishReason == "" {
+		p.FinishReason = reason
+		if len(p.ToolCalls) > 0 {
+			line := messageLine(modelName, "")
+			line, _ = sjson.SetRaw(line, "message.tool_calls", p.toolCallsJSON())
+			out = append(out, line)
+		}
+	}
+	if p.FinishReason != "" && p.Usage.Exists() {
+		out = append(out, p.finish(modelName)...)
+	}
+	return out
+}
+
+// finish returns the closing done message once.
+func (p *ConvertOpenAIResponseToOllamaParams) finish(modelName string) []string {
+	if p.Done {
+		return nil
+	}
+	p.Done = true
+	line := messageLine(modelName, "")
+	line = applyDone(line, p.FinishReason, p.Usage, time.Since(p.Start))
+	return []string{line}
+}
+
+func (p *ConvertOpenAIResponseToOllamaParams) toolCallsJSON() string {
+	indexes := make([]int, 0, len(p.ToolCalls))
+	for index := range p.ToolCalls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	calls := "[]"
+	for _, index := range indexes {
+		acc := p.ToolCalls[index]
+		calls, _ = sjson.SetRaw(calls, "-1", ollamaToolCall(acc.Name, acc.Arguments.String()))
+	}
+	return calls
+}
+
+// ConvertOpenAIResponseToOllamaNonStream converts an OpenAI Chat Completions response into a
+// single Ollama chat response.
+func ConvertOpenAIResponseToOllamaNonStream(_ context.Context, modelName string, _, _, rawJSON []byte, _ *any) string {
+	root := gjson.ParseBytes(rawJSON)
+	message := root.Get("choices.0.message")
+	line := messageLine(modelName, message.Get("content").String())
+	if reasoning := message.Get("reasoning_content").String(); reasoning != "" {
+		line, _ = sjson.Set(line, "message.thinking", reasoning)
+	}
+	if calls := message.Get("tool_calls"); calls.IsArray() && len(calls.Array()) > 0 {
+		converted := "[]"
+		calls.ForEach(func(_, call gjson.Result) bool {
+			converted, _ = sjson.SetRaw(converted, "-1", ollamaToolCall(call.Get("function.name").String(), call.Get("function.arguments").String()))
+			return true
+		})
+		line, _ = sjson.SetRaw(line, "message.tool_calls", converted)
+	}
+	return applyDone(line, root.Get("choices.0.finish_reason").String(), root.Get("usage"), 0)
+}
+
+func messageLine(modelName, content string) string {
+	line := `{"model":"","created_at":"","message":{"role":"assistant","content":""},"done":false}`
+	line, _ = sjson.Set(line, "model", modelName)
+	line, _ = sjson.Set(line, "created_at", time.Now().UTC().Format(time.RFC3339Nano))
+	line, _ = sjson.Set(line, "message.content", content)
+	return line
+}
+
+// applyDone marks a message as the final one and adds the done reason and token counts.
+// Ollama timing fields other than total_duration have no upstream equivalent and are zero.
+func applyDone(line, finishReason string, usage gjson.Result, elapsed time.Duration) string {
+	doneReason := "stop"
+	if finishReason == "length" {
+		doneReason = "length"
+	}
+	line, _ = sjson.Set(line, "done", true)
+	line, _ = sjson.Set(line, "done_reason", doneReason)
+	line, _ = sjson.Set(line, "total_duration", elapsed.Nanoseconds())
+	line, _ = sjson.Set(line, "load_duration", 0)
+	line, _ = sjson.Set(line, "prompt_eval_count", usage.Get("prompt_tokens").Int())
+	line, _ = sjson.Set(line, "prompt_eval_duration", 0)
+	line, _ = sjson.Set(line, "eval_count", usage.Get("completion_tokens").Int())
+	line, _ = sjson.Set(line, "eval_duration", 0)
+	return line
+}
+
+// ollamaToolCall builds an Ollama tool call, whose arguments are a JSON object rather than
+// the encoded string OpenAI uses.
+func ollamaToolCall(name, arguments string) string {
+	call := `{"function":{"name":"","arguments":{}}}`
+	call, _ = sjson.Set(call, "function.name", name)
+	if args := strings.TrimSpace(arguments); args != "" && gjson.Valid(args) && gjson.Parse(args).IsObject() {
+		call, _ = sjson.SetRaw(call, "function.arguments", args)
+	}
+	return call
+}
@@ -0,0 +1,108 @@
+package ollama
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOllamaRequestToOpenAI(t *testing.T) {
+	input := []byte(`{
+		"model": "llama3",
+		"messages": [
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": "what is this?", "images": ["/9j/4AAQ"]},
+			{"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "get_weather", "arguments": {"city": "Tokyo"}}}]},
+			{"role": "tool", "tool_name": "get_weather", "content": "sunny"}
+		],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+		"format": "json",
+		"options": {"temperature": 0.2, "num_predict": 128, "stop": ["END"], "top_k": 40}
+	}`)
+
+	out := gjson.ParseBytes(ConvertOllamaRequestToOpenAI("gpt-4o", input, true))
+
+	if got := out.Get("model").String(); got != "gpt-4o" {
+		t.Errorf("model = %q", got)
+	}
+	if !out.Get("stream").Bool() || !out.Get("stream_options.include_usage").Bool() {
+		t.Errorf("expected streaming with usage, got %s", out.Raw)
+	}
+	if out.Get("temperature").Float() != 0.2 || out.Get("max_tokens").Int() != 128 || out.Get("stop.0").String() != "END" {
+		t.Errorf("options not mapped: %s", out.Raw)
+	}
+	if out.Get("top_k").Exists() {
+		t.Errorf("top_k should be dropped")
+	}
+	if got := out.Get("response_format.type").String(); got != "json_object" {
+		t.Errorf("response_format.type = %q", got)
+	}
+	if got := out.Get("messages.1.content.1.image_url.url").String(); got != "data:image/jpeg;base64,/9j/4AAQ" {
+		t.Errorf("image url = %q", got)
+	}
+	callID := out.Get("messages.2.tool_calls.0.id").String()
+	if callID == "" || out.Get("messages.2.tool_calls.0.function.arguments").String() != `{"city": "Tokyo"}` {
+		t.Errorf("tool call not mapped: %s", out.Get("messages.2").Raw)
+	}
+	if got := out.Get("messages.3.tool_call_id").String(); got != callID {
+		t.Errorf("tool result id = %q, want %q", got, callID)
+	}
+	if !out.Get("tools.0.function.name").Exists() {
+		t.Errorf("tools not copied")
+	}
+}
+
+func TestConvertOpenAIResponseToOllamaStream(t *testing.T) {
+	var param any
+	ctx := context.Background()
+	chunks := []string{
+		`data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"lookup","arguments":"{\"q\":"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"x\"}"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":3}}`,
+		`[DONE]`,
+	}
+	var lines []gjson.Result
+	for _, chunk := range chunks {
+		for _, line := range ConvertOpenAIResponseToOllama(ctx, "gpt-4o", nil, nil, []byte(chunk), &param) {
+			lines = append(lines, gjson.Parse(line))
+		}
+	}
+
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d: %v", len(lines), lines)
+	}
+	if lines[0].Get("message.content").String() != "Hel" || lines[1].Get("message.content").String() != "lo" {
+		t.Errorf("unexpected text lines: %s %s", lines[0].Raw, lines[1].Raw)
+	}
+	if got := lines[2].Get("message.tool_calls.0.function.arguments.q").String(); got != "x" {
+		t.Errorf("tool call arguments = %s", lines[2].Raw)
+	}
+	final := lines[3]
+	if !final.Get("done").Bool() || final.Get("done_reason").String() != "stop" {
+		t.Errorf("unexpected final line: %s", final.Raw)
+	}
+	if final.Get("prompt_eval_count").Int() != 7 || final.Get("eval_count").Int() != 3 {
+		t.Errorf("usage not mapped: %s", final.Raw)
+	}
+	for _, line := range lines {
+		if line.Get("model").String() != "gpt-4o" {
+			t.Errorf("model = %q", line.Get("model").String())
+		}
+	}
+}
+
+func TestConvertOpenAIResponseToOllamaNonStream(t *testing.T) {
+	raw := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"hi","reasoning_content":"hmm"},"finish_reason":"length"}],"usage":{"prompt_tokens":2,"completion_tokens":5}}`)
+	out := gjson.Parse(ConvertOpenAIResponseToOllamaNonStream(context.Background(), "claude-sonnet-4", nil, nil, raw, nil))
+
+	if out.Get("message.content").String() != "hi" || out.Get("message.thinking").String() != "hmm" {
+		t.Errorf("unexpected message: %s", out.Raw)
+	}
+	if !out.Get("done").Bool() || out.Get("done_reason").String() != "length" || out.Get("eval_count").Int() != 5 {
+		t.Errorf("unexpected completion fields: %s", out.Raw)
+	}
+}
@@ -0,0 +1,377 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// checkpointVersion marks the bounded checkpoint format. Older stores hold a full
+	// StatisticsSnapshot without a version, which is still accepted on load.
+	checkpointVersion = 2
+
+	// checkpointEveryEvents and checkpointInterval decide when a journaling store gets a fresh
+	// checkpoint, which lets it discard the events appended so far.
+	checkpointEveryEvents = 10000
+	checkpointInterval    = time.Hour
+
+	// maxPendingEvents bounds the events waiting to be appended to a journal. When a store stays
+	// unreachable the oldest are dropped and the next save writes a checkpoint instead.
+	maxPendingEvents = 100000
+)
+
+// Event is a single recorded request as kept in the recent-request buffer and in usage journals.
+type Event struct {
+	// Seq orders events recorded by this process; journaled events at or below the sequence of
+	// the checkpoint they were loaded with are already part of it.
+	Seq      uint64 `json:"seq,omitempty"`
+	APIKey   string `json:"api_key"`
+	Model    string `json:"model"`
+	Provider string `json:"provider,omitempty"`
+	RequestDetail
+}
+
+// UsageJournal is implemented by usage stores that persist incrementally. New request events are
+// appended as they come in, and from time to time SaveUsageStatistics receives a bounded
+// checkpoint that replaces everything stored before it.
+type UsageJournal interface {
+	UsageStore
+	// AppendUsageEvents adds request events recorded since the previous save.
+	AppendUsageEvents(ctx context.Context, events []Event) error
+	// LoadUsageJournal returns the latest checkpoint and the events appended after it.
+	LoadUsageJournal(ctx context.Context) ([]byte, []Event, error)
+}
+
+// checkpoint is the persisted form of RequestStatistics. Its size depends on the retention
+// settings, not on the number of requests served.
+type checkpoint struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	Seq     uint64    `json:"seq"`
+
+	TotalRequests int64 `json:"total_requests"`
+	SuccessCount  int64 `json:"success_count"`
+	FailureCount  int64 `json:"failure_count"`
+	TotalTokens   int64 `json:"total_tokens"`
+
+	APIs map[string]apiCheckpoint `json:"apis"`
+
+	RequestsByHour [24]int64 `json:"requests_by_hour"`
+	TokensByHour   [24]int64 `json:"tokens_by_hour"`
+
+	Month        string                                 `json:"month"`
+	Monthly      map[string]map[string]int64            `json:"monthly,omitempty"`
+	MonthlyUsers map[string]map[string]map[string]int64 `json:"monthly_users,omitempty"`
+
+	Rollups map[Granularity][]Rollup `json:"rollups"`
+	Recent  []Event                  `json:"recent"`
+}
+
+type apiCheckpoint struct {
+	TotalRequests int64                      `json:"total_requests"`
+	TotalTokens   int64                      `json:"total_tokens"`
+	Models        map[string]modelCheckpoint `json:"models"`
+}
+
+type modelCheckpoint struct {
+	TotalRequests int64 `json:"total_requests"`
+	TotalTokens   int64 `json:"total_tokens"`
+}
+
+// checkpointLocked captures the current state. Callers hold s.mu.
+func (s *RequestStatistics) checkpointLocked(now time.Time) checkpoint {
+	cp := checkpoint{
+		Version:        checkpointVersion,
+		SavedAt:        now,
+		Seq:            s.seq,
+		TotalRequests:  s.totalRequests,
+		SuccessCount:   s.successCount,
+		FailureCount:   s.failureCount,
+		TotalTokens:    s.totalTokens,
+		APIs:           make(map[string]apiCheckpoint, len(s.apis)),
+		RequestsByHour: s.requestsByHour,
+		TokensByHour:   s.tokensByHour,
+		Month:          s.month,
+		Rollups:        make(map[Granularity][]Rollup, len(granularities)),
+		Recent:         s.recent.events(),
+	}
+	for apiKey, stats := range s.apis {
+		api := apiCheckpoint{TotalRequests: stats.TotalRequests, TotalTokens: stats.TotalTokens, Models: make(map[string]modelCheckpoint, len(stats.Models))}
+		for model, modelStatsValue := range stats.Models {
+			api.Models[model] = modelCheckpoint{TotalRequests: modelStatsValue.TotalRequests, TotalTokens: modelStatsValue.TotalTokens}
+		}
+		cp.APIs[apiKey] = api
+	}
+	if len(s.monthly) > 0 {
+		cp.Monthly = make(map[string]map[string]int64, len(s.monthly))
+		for apiKey, models := range s.monthly {
+			cp.Monthly[apiKey] = make(map[string]int64, len(models))
+			for model, count := range models {
+				cp.Monthly[apiKey][model] = count
+			}
+		}
+	}
+	if len(s.monthlyUsers) > 0 {
+		cp.MonthlyUsers = make(map[string]map[string]map[string]int64, len(s.monthlyUsers))
+		for apiKey, users := range s.monthlyUsers {
+			cp.MonthlyUsers[apiKey] = make(map[string]map[string]int64, len(users))
+			for endUser, models := range users {
+				cp.MonthlyUsers[apiKey][endUser] = make(map[string]int64, len(models))
+				for model, count := range models {
+					cp.MonthlyUsers[apiKey][endUser][model] = count
+				}
+			}
+		}
+	}
+	for _, g := range granularities {
+		cp.Rollups[g] = s.sortedRollupsLocked(g, time.Time{}, time.Time{})
+	}
+	return cp
+}
+
+// restoreCheckpointLocked adds a persisted checkpoint to the current state. Callers hold s.mu.
+func (s *RequestStatistics) restoreCheckpointLocked(cp checkpoint, now time.Time) {
+	s.totalRequests += cp.TotalRequests
+	s.successCount += cp.SuccessCount
+	s.failureCount += cp.FailureCount
+	s.totalTokens += cp.TotalTokens
+	for apiKey, api := range cp.APIs {
+		stats := s.apiStatsLocked(apiKey)
+		stats.TotalRequests += api.TotalRequests
+		stats.TotalTokens += api.TotalTokens
+		for model, modelCp := range api.Models {
+			modelStatsValue := stats.modelStats(model)
+			modelStatsValue.TotalRequests += modelCp.TotalRequests
+			modelStatsValue.TotalTokens += modelCp.TotalTokens
+		}
+	}
+	for hour := range cp.RequestsByHour {
+		s.requestsByHour[hour] += cp.RequestsByHour[hour]
+		s.tokensByHour[hour] += cp.TokensByHour[hour]
+	}
+	if month := now.Format("2006-01"); cp.Month == month {
+		s.rollMonthLocked(month)
+		for apiKey, models := range cp.Monthly {
+			for model, count := range models {
+				s.monthlyLocked(apiKey)[model] += count
+			}
+		}
+		for apiKey, users := range cp.MonthlyUsers {
+			for endUser, models := range users {
+				for model, count := range models {
+					s.monthlyUserLocked(apiKey, endUser)[model] += count
+				}
+			}
+		}
+	}
+	for g, rollups := range cp.Rollups {
+		if _, ok := s.rollups[g]; !ok {
+			continue
+		}
+		for _, rollup := range rollups {
+			s.mergeRollupLocked(g, rollup)
+		}
+	}
+	for _, event := range cp.Recent {
+		s.recent.push(event)
+	}
+	if cp.Seq > s.seq {
+		s.seq = cp.Seq
+	}
+}
+
+// Checkpoint encodes the current statistics in the bounded checkpoint format.
+func (s *RequestStatistics) Checkpoint() ([]byte, error) {
+	if s == nil {
+		return nil, nil
+	}
+	now := time.Now()
+	s.mu.Lock()
+	s.pruneLocked(now)
+	cp := s.checkpointLocked(now)
+	s.mu.Unlock()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return nil, fmt.Errorf("marshal usage checkpoint: %w", err)
+	}
+	return data, nil
+}
+
+// Restore adds persisted usage to the statistics: a checkpoint, or a full snapshot written by
+// older versions, followed by journaled events. Events already covered by the checkpoint are
+// skipped.
+func (s *RequestStatistics) Restore(data []byte, events []Event) error {
+	if s == nil {
+		return nil
+	}
+	var (
+		cp      checkpoint
+		legacy  StatisticsSnapshot
+		isCheck bool
+	)
+	if len(bytes.TrimSpace(data)) > 0 {
+		var probe struct {
+			Version int `json:"version"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return fmt.Errorf("unmarshal usage statistics: %w", err)
+		}
+		isCheck = probe.Version >= checkpointVersion
+		target := any(&legacy)
+		if isCheck {
+			target = &cp
+		}
+		if err := json.Unmarshal(data, target); err != nil {
+			return fmt.Errorf("unmarshal usage statistics: %w", err)
+		}
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if isCheck {
+		s.restoreCheckpointLocked(cp, now)
+	} else if len(legacy.APIs) > 0 {
+		s.mergeSnapshotLocked(legacy, false)
+		s.needCheckpoint = true
+	}
+	for _, event := range events {
+		if event.Seq != 0 && event.Seq <= cp.Seq {
+			continue
+		}
+		event.APIKey = strings.TrimSpace(event.APIKey)
+		if event.APIKey == "" {
+			continue
+		}
+		if event.Model = strings.TrimSpace(event.Model); event.Model == "" {
+			event.Model = "unknown"
+		}
+		event.Tokens = normaliseTokenStats(event.Tokens)
+		if event.Timestamp.IsZero() {
+			event.Timestamp = now
+		}
+		s.addLocked(event, false, now)
+		s.needCheckpoint = true
+	}
+	s.pruneLocked(now)
+	s.lastCheckpoint = now
+	return nil
+}
+
+// CompactUsage folds a stored checkpoint (or legacy snapshot) and its journaled events into a
+// single checkpoint, using the retention of the shared statistics.
+func CompactUsage(data []byte, events []Event) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 && len(events) == 0 {
+		return nil, nil
+	}
+	stats := NewRequestStatistics()
+	stats.SetRetention(GetRequestStatistics().Retention())
+	if err := stats.Restore(data, events); err != nil {
+		return nil, err
+	}
+	return stats.Checkpoint()
+}
+
+// UsageDigest returns a SHA-256 over the content of stored usage data, ignoring save times,
+// sequence numbers, time zones and encoding, so equal usage in different stores compares equal.
+func UsageDigest(data []byte) (string, error) {
+	stats := NewRequestStatistics()
+	stats.SetRetention(GetRequestStatistics().Retention())
+	if err := stats.Restore(data, nil); err != nil {
+		return "", err
+	}
+	stats.mu.Lock()
+	cp := stats.checkpointLocked(time.Time{})
+	stats.mu.Unlock()
+	cp.Seq = 0
+	for i := range cp.Recent {
+		cp.Recent[i].Seq = 0
+		cp.Recent[i].Timestamp = cp.Recent[i].Timestamp.UTC()
+	}
+	for g := range cp.Rollups {
+		for i := range cp.Rollups[g] {
+			cp.Rollups[g][i].Start = cp.Rollups[g][i].Start.UTC()
+		}
+	}
+	canonical, err := json.Marshal(cp)
+	if err != nil {
+		return "", fmt.Errorf("marshal usage checkpoint: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setJournaling switches collection of events for AppendUsageEvents on or off.
+func (s *RequestStatistics) setJournaling(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journaling = enabled
+	if !enabled {
+		s.pending = nil
+	}
+}
+
+// takePending removes and returns the events not yet appended to the journal.
+func (s *RequestStatistics) takePending() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.pending
+	s.pending = nil
+	s.journaled += len(events)
+	return events
+}
+
+// returnPending puts events back in front of the queue after a failed append.
+func (s *RequestStatistics) returnPending(events []Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journaled -= len(events)
+	s.pending = append(events, s.pending...)
+	s.trimPendingLocked()
+}
+
+func (s *RequestStatistics) trimPendingLocked() {
+	if over := len(s.pending) - maxPendingEvents; over > 0 {
+		s.pending = append([]Event(nil), s.pending[over:]...)
+		s.needCheckpoint = true
+	}
+}
+
+// checkpointDue reports whether a journaling store should receive a fresh checkpoint.
+func (s *RequestStatistics) checkpointDue(now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.needCheckpoint || s.journaled >= checkpointEveryEvents || now.Sub(s.lastCheckpoint) >= checkpointInterval
+}
+
+// journalCheckpoint encodes a checkpoint for a journaling store. Pending events are part of it,
+// so they are dropped from the queue; markCheckpointFailed asks for a retry if the write fails.
+func (s *RequestStatistics) journalCheckpoint() ([]byte, error) {
+	now := time.Now()
+	s.mu.Lock()
+	s.pruneLocked(now)
+	cp := s.checkpointLocked(now)
+	s.pending = nil
+	s.journaled = 0
+	s.needCheckpoint = false
+	s.lastCheckpoint = now
+	s.mu.Unlock()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		s.markCheckpointFailed()
+		return nil, fmt.Errorf("marshal usage checkpoint: %w", err)
+	}
+	return data, nil
+}
+
+func (s *RequestStatistics) markCheckpointFailed() {
+	s.mu.Lock()
+	s.needCheckpoint = true
+	s.mu.Unlock()
+}
@@ -5,7 +5,6 @@ package usage
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -13,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/config"
 	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 )
@@ -58,10 +58,25 @@ func SetStatisticsEnabled(enabled bool) { statisticsEnabled.Store(enabled) }
 // StatisticsEnabled reports the current recording state.
 func StatisticsEnabled() bool { return statisticsEnabled.Load() }
 
-// RequestStatistics maintains aggregated request metrics in memory.
+// ApplyRetention applies the configured retention to the shared statistics store.
+func ApplyRetention(cfg config.UsageRetention) {
+	defaultRequestStatistics.SetRetention(Retention{
+		RecentRequests:     cfg.RecentRequests,
+		MinuteRollupHours:  cfg.MinuteRollupHours,
+		HourRollupDays:     cfg.HourRollupDays,
+		DayRollupDays:      cfg.DayRollupDays,
+		EventRetentionDays: cfg.EventRetentionDays,
+	})
+}
+
+// RequestStatistics maintains aggregated request metrics in memory. Lifetime totals are kept
+// as counters, request history as per-minute, per-hour and per-day rollups, and only the most
+// recent raw requests are retained, so memory stays bounded by the retention settings.
 type RequestStatistics struct {
 	mu sync.RWMutex
 
+	retention Retention
+
 	totalRequests int64
 	successCount  int64
 	failureCount  int64
@@ -69,10 +84,30 @@ type RequestStatistics struct {
 
 	apis map[string]*apiStats
 
-	requestsByDay  map[string]int64
-	requestsByHour map[int]int64
-	tokensByDay    map[string]int64
-	tokensByHour   map[int]int64
+	requestsByHour [24]int64
+	tokensByHour   [24]int64
+
+	rollups   map[Granularity]map[rollupKey]*Rollup
+	recent    eventRing
+	lastPrune time.Time
+
+	// month and monthly count this month's requests per API key and model for quota checks;
+	// monthlyUsers splits them further by end user (API key, then end user, then model).
+	month        string
+	monthly      map[string]map[string]int64
+	monthlyUsers map[string]map[string]map[string]int64
+
+	// seq numbers recorded events; pending holds events not yet appended to a journaling store.
+	seq            uint64
+	journaling     bool
+	pending        []Event
+	journaled      int
+	needCheckpoint bool
+	lastCheckpoint time.Time
+
+	// peerMonth and peerUsage hold monthly request counts reported by cluster peers.
+	peerMonth string
+	peerUsage map[string]map[string]int64
 }
 
 // apiStats holds aggregated metrics for a single API key.
@@ -86,7 +121,15 @@ type apiStats struct {
 type modelStats struct {
 	TotalRequests int64
 	TotalTokens   int64
-	Details       []RequestDetail
+}
+
+func (a *apiStats) modelStats(model string) *modelStats {
+	stats, ok := a.Models[model]
+	if !ok {
+		stats = &modelStats{}
+		a.Models[model] = stats
+	}
+	return stats
 }
 
 // RequestDetail stores the timestamp and token usage for a single request.
@@ -94,18 +137,22 @@ type RequestDetail struct {
 	Timestamp  time.Time  `json:"timestamp"`
 	Source     string     `json:"source"`
 	AuthIndex  string     `json:"auth_index"`
+	EndUser    string     `json:"end_user,omitempty"`
 	Tokens     TokenStats `json:"tokens"`
 	Failed     bool       `json:"failed"`
+	Hedged     bool       `json:"hedged,omitempty"`
 	UserPrompt string     `json:"user_prompt,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
 type TokenStats struct {
-	InputTokens     int64 `json:"input_tokens"`
-	OutputTokens    int64 `json:"output_tokens"`
-	ReasoningTokens int64 `json:"reasoning_tokens"`
-	CachedTokens    int64 `json:"cached_tokens"`
-	TotalTokens     int64 `json:"total_tokens"`
+	InputTokens      int64 `json:"input_tokens"`
+	OutputTokens     int64 `json:"output_tokens"`
+	ReasoningTokens  int64 `json:"reasoning_tokens"`
+	CachedTokens     int64 `json:"cached_tokens"`
+	CacheWriteTokens int64 `json:"cache_write_tokens,omitempty"`
+	TotalTokens      int64 `json:"total_tokens"`
+	Images           int64 `json:"images,omitempty"`
 }
 
 // StatisticsSnapshot represents an immutable view of the aggregated metrics.
@@ -130,7 +177,8 @@ type APISnapshot struct {
 	Models        map[string]ModelSnapshot `json:"models"`
 }
 
-// ModelSnapshot summarises metrics for a specific model.
+// ModelSnapshot summarises metrics for a specific model. Details only lists the requests that
+// are still in the recent-request buffer.
 type ModelSnapshot struct {
 	TotalRequests int64           `json:"total_requests"`
 	TotalTokens   int64           `json:"total_tokens"`
@@ -142,15 +190,47 @@ var defaultRequestStatistics = NewRequestStatistics()
 // GetRequestStatistics returns the shared statistics store.
 func GetRequestStatistics() *RequestStatistics { return defaultRequestStatistics }
 
-// NewRequestStatistics constructs an empty statistics store.
+// NewRequestStatistics constructs an empty statistics store with the default retention.
 func NewRequestStatistics() *RequestStatistics {
+	retention := DefaultRetention()
+	rollups := make(map[Granularity]map[rollupKey]*Rollup, len(granularities))
+	for _, g := range granularities {
+		rollups[g] = make(map[rollupKey]*Rollup)
+	}
 	return &RequestStatistics{
+		retention:      retention,
 		apis:           make(map[string]*apiStats),
-		requestsByDay:  make(map[string]int64),
-		requestsByHour: make(map[int]int64),
-		tokensByDay:    make(map[string]int64),
-		tokensByHour:   make(map[int]int64),
+		rollups:        rollups,
+		recent:         newEventRing(retention.RecentRequests),
+		monthly:        make(map[string]map[string]int64),
+		monthlyUsers:   make(map[string]map[string]map[string]int64),
+		lastCheckpoint: time.Now(),
+	}
+}
+
+// SetRetention changes how much history is kept and drops what falls outside the new limits.
+func (s *RequestStatistics) SetRetention(retention Retention) {
+	if s == nil {
+		return
+	}
+	retention = retention.normalize()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if retention.RecentRequests != len(s.recent.buf) {
+		s.recent = s.recent.resize(retention.RecentRequests)
+	}
+	s.retention = retention
+	s.pruneLocked(time.Now())
+}
+
+// Retention returns the retention currently applied.
+func (s *RequestStatistics) Retention() Retention {
+	if s == nil {
+		return DefaultRetention()
 	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.retention
 }
 
 // Record ingests a new usage record and updates the aggregates.
@@ -165,8 +245,6 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	if timestamp.IsZero() {
 		timestamp = time.Now()
 	}
-	detail := normaliseDetail(record.Detail)
-	totalTokens := detail.TotalTokens
 	statsKey := record.APIKey
 	if statsKey == "" {
 		statsKey = resolveAPIIdentifier(ctx, record)
@@ -175,59 +253,130 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	if !failed {
 		failed = !resolveSuccess(ctx)
 	}
-	success := !failed
 	modelName := record.Model
 	if modelName == "" {
 		modelName = "unknown"
 	}
-	dayKey := timestamp.Format("2006-01-02")
-	hourKey := timestamp.Hour()
+	event := Event{
+		APIKey:   statsKey,
+		Model:    modelName,
+		Provider: record.Provider,
+		RequestDetail: RequestDetail{
+			Timestamp:  timestamp,
+			Source:     record.Source,
+			AuthIndex:  record.AuthIndex,
+			EndUser:    record.EndUser,
+			Tokens:     normaliseDetail(record.Detail),
+			Failed:     failed,
+			Hedged:     record.Hedged,
+			UserPrompt: record.UserPrompt,
+		},
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.addLocked(event, true, time.Now())
+}
+
+// addLocked folds one request into every aggregate. Events without a sequence number get the
+// next one. When queue is set and a journaling store is registered, the event is also queued
+// for AppendUsageEvents. Callers hold s.mu.
+func (s *RequestStatistics) addLocked(event Event, queue bool, now time.Time) {
+	if event.Seq == 0 {
+		s.seq++
+		event.Seq = s.seq
+	} else if event.Seq > s.seq {
+		s.seq = event.Seq
+	}
+	totalTokens := event.Tokens.TotalTokens
+	if totalTokens < 0 {
+		totalTokens = 0
+	}
 
 	s.totalRequests++
-	if success {
-		s.successCount++
-	} else {
+	if event.Failed {
 		s.failureCount++
+	} else {
+		s.successCount++
 	}
 	s.totalTokens += totalTokens
 
-	stats, ok := s.apis[statsKey]
+	stats := s.apiStatsLocked(event.APIKey)
+	stats.TotalRequests++
+	stats.TotalTokens += totalTokens
+	modelStatsValue := stats.modelStats(event.Model)
+	modelStatsValue.TotalRequests++
+	modelStatsValue.TotalTokens += totalTokens
+
+	hour := event.Timestamp.Local().Hour()
+	s.requestsByHour[hour]++
+	s.tokensByHour[hour] += totalTokens
+
+	for _, g := range granularities {
+		s.addRollupLocked(g, event)
+	}
+	month := now.Format("2006-01")
+	s.rollMonthLocked(month)
+	if event.Timestamp.Local().Format("2006-01") == month {
+		s.monthlyLocked(event.APIKey)[event.Model]++
+		if event.EndUser != "" {
+			s.monthlyUserLocked(event.APIKey, event.EndUser)[event.Model]++
+		}
+	}
+	s.recent.push(event)
+
+	if queue && s.journaling {
+		s.pending = append(s.pending, event)
+		s.trimPendingLocked()
+	}
+	if now.Sub(s.lastPrune) >= time.Minute {
+		s.pruneLocked(now)
+	}
+}
+
+func (s *RequestStatistics) apiStatsLocked(apiKey string) *apiStats {
+	stats, ok := s.apis[apiKey]
 	if !ok {
 		stats = &apiStats{Models: make(map[string]*modelStats)}
-		s.apis[statsKey] = stats
-	}
-	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp:  timestamp,
-		Source:     record.Source,
-		AuthIndex:  record.AuthIndex,
-		Tokens:     detail,
-		Failed:     failed,
-		UserPrompt: record.UserPrompt,
-	})
+		s.apis[apiKey] = stats
+	}
+	return stats
+}
 
-	s.requestsByDay[dayKey]++
-	s.requestsByHour[hourKey]++
-	s.tokensByDay[dayKey] += totalTokens
-	s.tokensByHour[hourKey] += totalTokens
+// rollMonthLocked resets the monthly counters when month (YYYY-MM) differs from the tracked one.
+func (s *RequestStatistics) rollMonthLocked(month string) {
+	if s.month != month {
+		s.month = month
+		s.monthly = make(map[string]map[string]int64)
+		s.monthlyUsers = make(map[string]map[string]map[string]int64)
+	}
 }
 
-func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
-	stats.TotalRequests++
-	stats.TotalTokens += detail.Tokens.TotalTokens
-	modelStatsValue, ok := stats.Models[model]
+func (s *RequestStatistics) monthlyLocked(apiKey string) map[string]int64 {
+	models, ok := s.monthly[apiKey]
 	if !ok {
-		modelStatsValue = &modelStats{}
-		stats.Models[model] = modelStatsValue
+		models = make(map[string]int64)
+		s.monthly[apiKey] = models
 	}
-	modelStatsValue.TotalRequests++
-	modelStatsValue.TotalTokens += detail.Tokens.TotalTokens
-	modelStatsValue.Details = append(modelStatsValue.Details, detail)
+	return models
 }
 
-// Snapshot returns a copy of the aggregated metrics for external consumption.
+func (s *RequestStatistics) monthlyUserLocked(apiKey, endUser string) map[string]int64 {
+	users, ok := s.monthlyUsers[apiKey]
+	if !ok {
+		users = make(map[string]map[string]int64)
+		s.monthlyUsers[apiKey] = users
+	}
+	models, ok := users[endUser]
+	if !ok {
+		models = make(map[string]int64)
+		users[endUser] = models
+	}
+	return models
+}
+
+// Snapshot returns a copy of the aggregated metrics for external consumption. Per-day counts
+// come from the retained day rollups and request details from the recent-request buffer.
 func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result := StatisticsSnapshot{}
 	if s == nil {
@@ -242,6 +391,16 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
 
+	details := make(map[string]map[string][]RequestDetail)
+	s.recent.each(func(event Event) {
+		models, ok := details[event.APIKey]
+		if !ok {
+			models = make(map[string][]RequestDetail)
+			details[event.APIKey] = models
+		}
+		models[event.Model] = append(models[event.Model], event.RequestDetail)
+	})
+
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
 		apiSnapshot := APISnapshot{
@@ -250,8 +409,10 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 			Models:        make(map[string]ModelSnapshot, len(stats.Models)),
 		}
 		for modelName, modelStatsValue := range stats.Models {
-			requestDetails := make([]RequestDetail, len(modelStatsValue.Details))
-			copy(requestDetails, modelStatsValue.Details)
+			requestDetails := details[apiName][modelName]
+			if requestDetails == nil {
+				requestDetails = []RequestDetail{}
+			}
 			apiSnapshot.Models[modelName] = ModelSnapshot{
 				TotalRequests: modelStatsValue.TotalRequests,
 				TotalTokens:   modelStatsValue.TotalTokens,
@@ -261,26 +422,23 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.APIs[apiName] = apiSnapshot
 	}
 
-	result.RequestsByDay = make(map[string]int64, len(s.requestsByDay))
-	for k, v := range s.requestsByDay {
-		result.RequestsByDay[k] = v
+	result.RequestsByDay = make(map[string]int64)
+	result.TokensByDay = make(map[string]int64)
+	for _, rollup := range s.rollups[GranularityDay] {
+		key := rollup.Start.Format("2006-01-02")
+		result.RequestsByDay[key] += rollup.Requests
+		result.TokensByDay[key] += rollup.Tokens.TotalTokens
 	}
 
-	result.RequestsByHour = make(map[string]int64, len(s.requestsByHour))
-	for hour, v := range s.requestsByHour {
-		key := formatHour(hour)
-		result.RequestsByHour[key] = v
-	}
-
-	result.TokensByDay = make(map[string]int64, len(s.tokensByDay))
-	for k, v := range s.tokensByDay {
-		result.TokensByDay[k] = v
-	}
-
-	result.TokensByHour = make(map[string]int64, len(s.tokensByHour))
-	for hour, v := range s.tokensByHour {
+	result.RequestsByHour = make(map[string]int64)
+	result.TokensByHour = make(map[string]int64)
+	for hour := range s.requestsByHour {
+		if s.requestsByHour[hour] == 0 && s.tokensByHour[hour] == 0 {
+			continue
+		}
 		key := formatHour(hour)
-		result.TokensByHour[key] = v
+		result.RequestsByHour[key] = s.requestsByHour[hour]
+		result.TokensByHour[key] = s.tokensByHour[hour]
 	}
 
 	return result
@@ -292,43 +450,31 @@ type MergeResult struct {
 }
 
 // MergeSnapshot merges an exported statistics snapshot into the current store.
-// Existing data is preserved and duplicate request details are skipped.
+// Existing data is preserved and details matching a request in the recent-request buffer are skipped.
 func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResult {
-	result := MergeResult{}
 	if s == nil {
-		return result
+		return MergeResult{}
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.mergeSnapshotLocked(snapshot, true)
+}
 
-	seen := make(map[string]struct{})
-	for apiName, stats := range s.apis {
-		if stats == nil {
-			continue
-		}
-		for modelName, modelStatsValue := range stats.Models {
-			if modelStatsValue == nil {
-				continue
-			}
-			for _, detail := range modelStatsValue.Details {
-				seen[dedupKey(apiName, modelName, detail)] = struct{}{}
-			}
-		}
-	}
+// mergeSnapshotLocked records the details of snapshot as requests. Callers hold s.mu.
+func (s *RequestStatistics) mergeSnapshotLocked(snapshot StatisticsSnapshot, queue bool) MergeResult {
+	result := MergeResult{}
+	seen := make(map[string]struct{}, s.recent.size)
+	s.recent.each(func(event Event) {
+		seen[dedupKey(event.APIKey, event.Model, event.RequestDetail)] = struct{}{}
+	})
 
+	now := time.Now()
 	for apiName, apiSnapshot := range snapshot.APIs {
 		apiName = strings.TrimSpace(apiName)
 		if apiName == "" {
 			continue
 		}
-		stats, ok := s.apis[apiName]
-		if !ok || stats == nil {
-			stats = &apiStats{Models: make(map[string]*modelStats)}
-			s.apis[apiName] = stats
-		} else if stats.Models == nil {
-			stats.Models = make(map[string]*modelStats)
-		}
 		for modelName, modelSnapshot := range apiSnapshot.Models {
 			modelName = strings.TrimSpace(modelName)
 			if modelName == "" {
@@ -337,7 +483,7 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 			for _, detail := range modelSnapshot.Details {
 				detail.Tokens = normaliseTokenStats(detail.Tokens)
 				if detail.Timestamp.IsZero() {
-					detail.Timestamp = time.Now()
+					detail.Timestamp = now
 				}
 				key := dedupKey(apiName, modelName, detail)
 				if _, exists := seen[key]; exists {
@@ -345,7 +491,7 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 					continue
 				}
 				seen[key] = struct{}{}
-				s.recordImported(apiName, modelName, stats, detail)
+				s.addLocked(Event{APIKey: apiName, Model: modelName, RequestDetail: detail}, queue, now)
 				result.Added++
 			}
 		}
@@ -354,31 +500,6 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 	return result
 }
 
-func (s *RequestStatistics) recordImported(apiName, modelName string, stats *apiStats, detail RequestDetail) {
-	totalTokens := detail.Tokens.TotalTokens
-	if totalTokens < 0 {
-		totalTokens = 0
-	}
-
-	s.totalRequests++
-	if detail.Failed {
-		s.failureCount++
-	} else {
-		s.successCount++
-	}
-	s.totalTokens += totalTokens
-
-	s.updateAPIStats(stats, modelName, detail)
-
-	dayKey := detail.Timestamp.Format("2006-01-02")
-	hourKey := detail.Timestamp.Hour()
-
-	s.requestsByDay[dayKey]++
-	s.requestsByHour[hourKey]++
-	s.tokensByDay[dayKey] += totalTokens
-	s.tokensByHour[hourKey] += totalTokens
-}
-
 func dedupKey(apiName, modelName string, detail RequestDetail) string {
 	timestamp := detail.Timestamp.UTC().Format(time.RFC3339Nano)
 	tokens := normaliseTokenStats(detail.Tokens)
@@ -442,11 +563,13 @@ const httpStatusBadRequest = 400
 
 func normaliseDetail(detail coreusage.Detail) TokenStats {
 	tokens := TokenStats{
-		InputTokens:     detail.InputTokens,
-		OutputTokens:    detail.OutputTokens,
-		ReasoningTokens: detail.ReasoningTokens,
-		CachedTokens:    detail.CachedTokens,
-		TotalTokens:     detail.TotalTokens,
+		InputTokens:      detail.InputTokens,
+		OutputTokens:     detail.OutputTokens,
+		ReasoningTokens:  detail.ReasoningTokens,
+		CachedTokens:     detail.CachedTokens,
+		CacheWriteTokens: detail.CacheWriteTokens,
+		TotalTokens:      detail.TotalTokens,
+		Images:           detail.Images,
 	}
 	if tokens.TotalTokens == 0 {
 		tokens.TotalTokens = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
@@ -475,17 +598,22 @@ func formatHour(hour int) string {
 	return fmt.Sprintf("%02d", hour)
 }
 
-// UsageStore defines the interface for persisting usage statistics.
+// UsageStore defines the interface for persisting usage statistics. SaveUsageStatistics receives
+// a bounded checkpoint that replaces the stored state; LoadUsageStatistics returns the stored
+// checkpoint, or a full snapshot written by older versions. Stores that also implement
+// UsageJournal (file, SQLite and Postgres) are saved incrementally; any other store falls back
+// to receiving the full checkpoint on every save.
 type UsageStore interface {
 	SaveUsageStatistics(ctx context.Context, data []byte) error
 	LoadUsageStatistics(ctx context.Context) ([]byte, error)
 }
 
 var (
-	globalUsageStore    UsageStore
-	globalUsageStoreMu  sync.RWMutex
-	periodicSaveTicker  *time.Ticker
-	periodicSaveStopCh  chan struct{}
+	globalUsageStore   UsageStore
+	globalUsageStoreMu sync.RWMutex
+	periodicSaveTicker *time.Ticker
+	periodicSaveStopCh chan struct{}
+	saveMu             sync.Mutex
 )
 
 // RegisterUsageStore sets the global usage statistics store.
@@ -493,6 +621,11 @@ func RegisterUsageStore(store UsageStore) {
 	globalUsageStoreMu.Lock()
 	defer globalUsageStoreMu.Unlock()
 	globalUsageStore = store
+	_, journaling := store.(UsageJournal)
+	if store != nil && !journaling {
+		log.Warnf("usage: %T does not journal usage events; every save rewrites the full checkpoint", store)
+	}
+	defaultRequestStatistics.setJournaling(journaling)
 }
 
 // GetUsageStore returns the global usage statistics store.
@@ -502,7 +635,9 @@ func GetUsageStore() UsageStore {
 	return globalUsageStore
 }
 
-// SaveStatistics persists current usage statistics to the store.
+// SaveStatistics persists current usage statistics to the store. Journaling stores receive the
+// events recorded since the last save and, when one is due, a fresh checkpoint; other stores
+// receive a checkpoint every time.
 func SaveStatistics(ctx context.Context) error {
 	store := GetUsageStore()
 	if store == nil {
@@ -514,13 +649,36 @@ func SaveStatistics(ctx context.Context) error {
 		return nil
 	}
 
-	snapshot := stats.Snapshot()
-	data, err := json.Marshal(snapshot)
-	if err != nil {
-		return fmt.Errorf("marshal usage statistics: %w", err)
+	saveMu.Lock()
+	defer saveMu.Unlock()
+
+	journal, ok := store.(UsageJournal)
+	if !ok {
+		data, err := stats.Checkpoint()
+		if err != nil {
+			return err
+		}
+		return store.SaveUsageStatistics(ctx, data)
 	}
 
-	return store.SaveUsageStatistics(ctx, data)
+	if events := stats.takePending(); len(events) > 0 {
+		if err := journal.AppendUsageEvents(ctx, events); err != nil {
+			stats.returnPending(events)
+			return fmt.Errorf("append usage events: %w", err)
+		}
+	}
+	if !stats.checkpointDue(time.Now()) {
+		return nil
+	}
+	data, err := stats.journalCheckpoint()
+	if err != nil {
+		return err
+	}
+	if err = journal.SaveUsageStatistics(ctx, data); err != nil {
+		stats.markCheckpointFailed()
+		return fmt.Errorf("save usage checkpoint: %w", err)
+	}
+	return nil
 }
 
 // LoadStatistics retrieves and merges persisted usage statistics from the store.
@@ -530,26 +688,29 @@ func LoadStatistics(ctx context.Context) error {
 		return nil // No store configured, skip
 	}
 
-	data, err := store.LoadUsageStatistics(ctx)
+	var (
+		data   []byte
+		events []Event
+		err    error
+	)
+	if journal, ok := store.(UsageJournal); ok {
+		data, events, err = journal.LoadUsageJournal(ctx)
+	} else {
+		data, err = store.LoadUsageStatistics(ctx)
+	}
 	if err != nil {
 		return fmt.Errorf("load usage statistics: %w", err)
 	}
-	if len(data) == 0 {
+	if len(data) == 0 && len(events) == 0 {
 		return nil // No statistics found
 	}
 
-	var snapshot StatisticsSnapshot
-	if err = json.Unmarshal(data, &snapshot); err != nil {
-		return fmt.Errorf("unmarshal usage statistics: %w", err)
-	}
-
 	stats := GetRequestStatistics()
 	if stats == nil {
 		return nil
 	}
 
-	stats.MergeSnapshot(snapshot)
-	return nil
+	return stats.Restore(data, events)
 }
 
 // StartPeriodicSave begins automatic periodic saving of usage statistics.
@@ -604,30 +765,12 @@ func (s *RequestStatistics) GetMonthlyUsage(apiKey, modelName string) int64 {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	stats, ok := s.apis[apiKey]
-	if !ok || stats == nil {
-		return 0
-	}
-
-	modelStatsValue, ok := stats.Models[modelName]
-	if !ok || modelStatsValue == nil {
-		return 0
-	}
-
-	// Get current year and month
 	now := time.Now()
-	currentYear := now.Year()
-	currentMonth := now.Month()
-
-	// Count requests in the current month
 	var count int64
-	for _, detail := range modelStatsValue.Details {
-		if detail.Timestamp.Year() == currentYear && detail.Timestamp.Month() == currentMonth {
-			count++
-		}
+	if s.month == now.Format("2006-01") {
+		count = s.monthly[apiKey][modelName]
 	}
-
-	return count
+	return count + s.peerUsageLocked(apiKey, now)[modelName]
 }
 
 // GetMonthlyUsageAllModels returns a map of model names to request counts for the current month
@@ -640,30 +783,88 @@ func (s *RequestStatistics) GetMonthlyUsageAllModels(apiKey string) map[string]i
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	stats, ok := s.apis[apiKey]
-	if !ok || stats == nil {
-		return make(map[string]int64)
+	now := time.Now()
+	result := make(map[string]int64)
+	for modelName, count := range s.peerUsageLocked(apiKey, now) {
+		result[modelName] += count
+	}
+	if s.month != now.Format("2006-01") {
+		return result
+	}
+	for modelName, count := range s.monthly[apiKey] {
+		if count > 0 {
+			result[modelName] += count
+		}
 	}
 
-	now := time.Now()
-	currentYear := now.Year()
-	currentMonth := now.Month()
+	return result
+}
 
+// GetMonthlyEndUserUsage returns a map of model names to request counts for the current month
+// made through apiKey on behalf of endUser. Only requests recorded by this process are counted.
+func (s *RequestStatistics) GetMonthlyEndUserUsage(apiKey, endUser string) map[string]int64 {
 	result := make(map[string]int64)
-	for modelName, modelStatsValue := range stats.Models {
-		if modelStatsValue == nil {
-			continue
-		}
-		var count int64
-		for _, detail := range modelStatsValue.Details {
-			if detail.Timestamp.Year() == currentYear && detail.Timestamp.Month() == currentMonth {
-				count++
+	if s == nil {
+		return result
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.month != time.Now().Format("2006-01") {
+		return result
+	}
+	for modelName, count := range s.monthlyUsers[apiKey][endUser] {
+		result[modelName] = count
+	}
+	return result
+}
+
+// MonthlyUsageSnapshot returns the locally recorded request counts per API key and model for the
+// current month. Counts reported by cluster peers are not included.
+func (s *RequestStatistics) MonthlyUsageSnapshot() (string, map[string]map[string]int64) {
+	month := time.Now().Format("2006-01")
+	result := make(map[string]map[string]int64)
+	if s == nil {
+		return month, result
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.month != month {
+		return month, result
+	}
+	for apiKey, models := range s.monthly {
+		for modelName, count := range models {
+			if count == 0 {
+				continue
 			}
-		}
-		if count > 0 {
-			result[modelName] = count
+			if result[apiKey] == nil {
+				result[apiKey] = make(map[string]int64)
+			}
+			result[apiKey][modelName] = count
 		}
 	}
+	return month, result
+}
 
-	return result
+// SetPeerMonthlyUsage replaces the request counts reported by cluster peers for month (YYYY-MM).
+// Monthly usage lookups add these counts to the local ones while month is current.
+func (s *RequestStatistics) SetPeerMonthlyUsage(month string, counts map[string]map[string]int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.peerMonth = month
+	s.peerUsage = counts
+	s.mu.Unlock()
+}
+
+// peerUsageLocked returns peer counts for apiKey in the month containing now. Callers hold s.mu.
+func (s *RequestStatistics) peerUsageLocked(apiKey string, now time.Time) map[string]int64 {
+	if s.peerMonth != now.Format("2006-01") {
+		return nil
+	}
+	return s.peerUsage[apiKey]
 }
@@ -0,0 +1,276 @@
+package usage
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+)
+
+// Dimensions a usage query can group by.
+const (
+	GroupDay      = "day"
+	GroupHour     = "hour"
+	GroupKey      = "key"
+	GroupUser     = "user"
+	GroupModel    = "model"
+	GroupProvider = "provider"
+	GroupAuth     = "auth"
+)
+
+// Query selects and groups usage history. Empty filters match everything.
+type Query struct {
+	// From and To bound the bucket start times as [From, To). A zero value leaves that side open.
+	From time.Time
+	To   time.Time
+
+	APIKeys []string
+	// EndUsers selects requests attributed to these end users.
+	EndUsers []string
+	// Models holds model names or wildcard patterns (e.g., "claude-*").
+	Models      []string
+	Providers   []string
+	AuthIndexes []string
+	// Failed, when set, keeps only failed (true) or only successful (false) requests.
+	Failed *bool
+
+	// GroupBy lists the dimensions to group by, in output order. Without any, all matching
+	// requests are summed into a single row.
+	GroupBy []string
+
+	// Pricing computes the Cost of each row. Models without a price cost nothing.
+	Pricing []config.ModelPrice
+}
+
+// QueryRow is one group of a usage query. Only the dimensions grouped by are set.
+type QueryRow struct {
+	Period    string     `json:"period,omitempty"`
+	APIKey    string     `json:"api_key,omitempty"`
+	EndUser   string     `json:"end_user,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	Provider  string     `json:"provider,omitempty"`
+	AuthIndex string     `json:"auth_index,omitempty"`
+	Requests  int64      `json:"requests"`
+	Failures  int64      `json:"failures"`
+	ErrorRate float64    `json:"error_rate"`
+	Tokens    TokenStats `json:"tokens"`
+	Cost      float64    `json:"cost"`
+}
+
+// QueryResult holds the rows of a usage query and their total.
+type QueryResult struct {
+	// Granularity is the rollup the query was answered from; From and To apply at its precision.
+	Granularity Granularity `json:"granularity"`
+	// Since is the start of the oldest history still retained at that granularity.
+	Since   time.Time  `json:"since"`
+	GroupBy []string   `json:"group_by"`
+	Rows    []QueryRow `json:"rows"`
+	Total   QueryRow   `json:"total"`
+}
+
+// ParseGroupBy validates a comma-separated list of group dimensions.
+func ParseGroupBy(raw string) ([]string, error) {
+	var groups []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		group := strings.ToLower(strings.TrimSpace(part))
+		if group == "" || seen[group] {
+			continue
+		}
+		switch group {
+		case GroupDay, GroupHour, GroupKey, GroupUser, GroupModel, GroupProvider, GroupAuth:
+		default:
+			return nil, fmt.Errorf("unknown group %q (want day, hour, key, user, model, provider or auth)", group)
+		}
+		seen[group] = true
+		groups = append(groups, group)
+	}
+	if seen[GroupDay] && seen[GroupHour] {
+		return nil, fmt.Errorf("group by day or by hour, not both")
+	}
+	return groups, nil
+}
+
+// Query answers q from the retained rollups. Hour rollups are used when grouping by hour or
+// when the range starts within their retention; otherwise day rollups are used.
+func (s *RequestStatistics) Query(q Query) QueryResult {
+	result := QueryResult{GroupBy: q.GroupBy, Rows: []QueryRow{}}
+	if s == nil {
+		return result
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	result.Granularity = GranularityDay
+	hourSince := s.retention.cutoff(GranularityHour, now)
+	if containsString(q.GroupBy, GroupHour) || (!q.From.IsZero() && !q.From.Before(hourSince)) {
+		result.Granularity = GranularityHour
+	}
+	result.Since = bucketStart(result.Granularity, s.retention.cutoff(result.Granularity, now))
+
+	apiKeys, endUsers := stringSet(q.APIKeys), stringSet(q.EndUsers)
+	providers, authIndexes := stringSet(q.Providers), stringSet(q.AuthIndexes)
+	rows := make(map[string]*QueryRow)
+	var order []string
+	for _, rollup := range s.rollups[result.Granularity] {
+		if !q.From.IsZero() && rollup.Start.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && !rollup.Start.Before(q.To) {
+			continue
+		}
+		if !matchesSet(apiKeys, rollup.APIKey) || !matchesSet(endUsers, rollup.EndUser) {
+			continue
+		}
+		if !matchesSet(providers, rollup.Provider) || !matchesSet(authIndexes, rollup.AuthIndex) {
+			continue
+		}
+		if len(q.Models) > 0 && !matchesAnyModel(q.Models, rollup.Model) {
+			continue
+		}
+		if q.Failed != nil && *q.Failed != rollup.Failed {
+			continue
+		}
+
+		group := QueryRow{}
+		for _, dimension := range q.GroupBy {
+			switch dimension {
+			case GroupDay:
+				group.Period = rollup.Start.Format("2006-01-02")
+			case GroupHour:
+				group.Period = rollup.Start.Format("2006-01-02T15:00")
+			case GroupKey:
+				group.APIKey = rollup.APIKey
+			case GroupUser:
+				group.EndUser = rollup.EndUser
+			case GroupModel:
+				group.Model = rollup.Model
+			case GroupProvider:
+				group.Provider = rollup.Provider
+			case GroupAuth:
+				group.AuthIndex = rollup.AuthIndex
+			}
+		}
+		id := strings.Join([]string{group.Period, group.APIKey, group.EndUser, group.Model, group.Provider, group.AuthIndex}, "\x00")
+		row, ok := rows[id]
+		if !ok {
+			row = &group
+			rows[id] = row
+			order = append(order, id)
+		}
+		cost := Cost(q.Pricing, rollup.Model, rollup.Tokens)
+		for _, target := range []*QueryRow{row, &result.Total} {
+			target.Requests += rollup.Requests
+			if rollup.Failed {
+				target.Failures += rollup.Requests
+			}
+			target.Tokens.add(rollup.Tokens)
+			target.Cost += cost
+		}
+	}
+
+	sort.Strings(order)
+	for _, id := range order {
+		row := rows[id]
+		row.ErrorRate = errorRate(row.Failures, row.Requests)
+		result.Rows = append(result.Rows, *row)
+	}
+	result.Total.ErrorRate = errorRate(result.Total.Failures, result.Total.Requests)
+	return result
+}
+
+// AuthTotalsOn sums the requests and tokens each credential recorded on the day of t, keyed by
+// auth index. Requests without a credential are left out.
+func (s *RequestStatistics) AuthTotalsOn(t time.Time) map[string]QueryRow {
+	day := bucketStart(GranularityDay, t)
+	result := s.Query(Query{From: day, To: day.AddDate(0, 0, 1), GroupBy: []string{GroupAuth}})
+	totals := make(map[string]QueryRow, len(result.Rows))
+	for _, row := range result.Rows {
+		if row.AuthIndex != "" {
+			totals[row.AuthIndex] = row
+		}
+	}
+	return totals
+}
+
+// Cost prices tokens and generated images with the first entry of pricing that matches model.
+// Tokens read from and written to the prompt cache are part of the input tokens and are billed
+// at the cached and cache-write prices instead.
+func Cost(pricing []config.ModelPrice, model string, tokens TokenStats) float64 {
+	for _, price := range pricing {
+		if !matchesAnyModel(price.Models, model) {
+			continue
+		}
+		input := tokens.InputTokens - tokens.CachedTokens - tokens.CacheWriteTokens
+		if input < 0 {
+			input = 0
+		}
+		reasoning := price.ReasoningPerMillion
+		if reasoning == 0 {
+			reasoning = price.OutputPerMillion
+		}
+		cacheWrite := price.CacheWritePerMillion
+		if cacheWrite == 0 {
+			cacheWrite = price.InputPerMillion
+		}
+		tokenCost := (float64(input)*price.InputPerMillion +
+			float64(tokens.CachedTokens)*price.CachedPerMillion +
+			float64(tokens.CacheWriteTokens)*cacheWrite +
+			float64(tokens.OutputTokens)*price.OutputPerMillion +
+			float64(tokens.ReasoningTokens)*reasoning) / 1_000_000
+		return tokenCost + float64(tokens.Images)*price.PerImage
+	}
+	return 0
+}
+
+func errorRate(failures, requests int64) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(failures) / float64(requests)
+}
+
+func stringSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
+
+func matchesSet(set map[string]struct{}, value string) bool {
+	if set == nil {
+		return true
+	}
+	_, ok := set[value]
+	return ok
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyModel reports whether model equals or matches one of the wildcard patterns.
+func matchesAnyModel(patterns []string, model string) bool {
+	for _, pattern := range patterns {
+		if pattern == model {
+			return true
+		}
+		if matched, err := filepath.Match(pattern, model); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
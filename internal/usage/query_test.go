@@ -0,0 +1,176 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+func TestRequestStatistics_AuthTotalsOnSumsTodayPerCredential(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	record := func(authIndex string, at time.Time, total int64) {
+		stats.Record(context.Background(), coreusage.Record{
+			Model: "gpt-5", AuthIndex: authIndex, RequestedAt: at,
+			Detail: coreusage.Detail{TotalTokens: total},
+		})
+	}
+	record("a", now, 100)
+	record("a", now, 50)
+	record("b", now, 10)
+	record("a", now.AddDate(0, 0, -1), 1_000)
+	record("", now, 5)
+
+	totals := stats.AuthTotalsOn(now)
+	if len(totals) != 2 {
+		t.Fatalf("totals = %+v, want credentials a and b", totals)
+	}
+	if a := totals["a"]; a.Requests != 2 || a.Tokens.TotalTokens != 150 {
+		t.Fatalf("totals[a] = %+v, want 2 requests and 150 tokens from today", a)
+	}
+	if b := totals["b"]; b.Requests != 1 || b.Tokens.TotalTokens != 10 {
+		t.Fatalf("totals[b] = %+v", b)
+	}
+}
+
+func TestRequestStatistics_QueryGroupsFiltersAndPrices(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	record := func(key, model, provider string, failed bool, input, output int64) {
+		stats.Record(context.Background(), coreusage.Record{
+			APIKey: key, Model: model, Provider: provider, AuthIndex: "1", RequestedAt: now, Failed: failed,
+			Detail: coreusage.Detail{InputTokens: input, OutputTokens: output},
+		})
+	}
+	record("team-a", "claude-sonnet-4", "claude", false, 1_000_000, 100_000)
+	record("team-a", "claude-sonnet-4", "claude", true, 0, 0)
+	record("team-a", "gpt-5", "codex", false, 500_000, 0)
+	record("team-b", "claude-sonnet-4", "claude", false, 2_000_000, 0)
+
+	pricing := []config.ModelPrice{{Models: []string{"claude-*"}, InputPerMillion: 3, OutputPerMillion: 15}}
+	result := stats.Query(Query{
+		From:    now.Add(-time.Hour),
+		APIKeys: []string{"team-a"},
+		GroupBy: []string{GroupKey, GroupModel},
+		Pricing: pricing,
+	})
+	if result.Granularity != GranularityHour {
+		t.Fatalf("granularity = %s, want hour for a recent range", result.Granularity)
+	}
+	if len(result.Rows) != 2 {
+		t.Fatalf("rows = %+v, want claude and gpt rows for team-a", result.Rows)
+	}
+	claude := result.Rows[0]
+	if claude.Model != "claude-sonnet-4" || claude.Requests != 2 || claude.Failures != 1 || claude.ErrorRate != 0.5 {
+		t.Fatalf("claude row = %+v", claude)
+	}
+	if math.Abs(claude.Cost-4.5) > 1e-9 {
+		t.Fatalf("claude cost = %v, want 4.5", claude.Cost)
+	}
+	if result.Rows[1].Cost != 0 || result.Total.Requests != 3 || result.Total.Tokens.InputTokens != 1_500_000 {
+		t.Fatalf("gpt row = %+v, total = %+v", result.Rows[1], result.Total)
+	}
+
+	failed := false
+	byProvider := stats.Query(Query{Models: []string{"claude-*"}, Failed: &failed, GroupBy: []string{GroupDay, GroupProvider}})
+	if byProvider.Granularity != GranularityDay || len(byProvider.Rows) != 1 {
+		t.Fatalf("provider rows = %+v", byProvider)
+	}
+	if row := byProvider.Rows[0]; row.Provider != "claude" || row.Period != now.Format("2006-01-02") || row.Requests != 2 || row.Failures != 0 {
+		t.Fatalf("provider row = %+v", row)
+	}
+}
+
+func TestRequestStatistics_PricesClaudeCacheReadsAndWrites(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	// Anthropic reports input_tokens=100, cache_read_input_tokens=10000 and
+	// cache_creation_input_tokens=2000; executors record the whole prompt as input.
+	stats.Record(context.Background(), coreusage.Record{
+		APIKey: "team-a", Model: "claude-sonnet-4", Provider: "claude", RequestedAt: now,
+		Detail: coreusage.Detail{InputTokens: 12_100, OutputTokens: 500, CachedTokens: 10_000, CacheWriteTokens: 2_000},
+	})
+
+	pricing := []config.ModelPrice{{Models: []string{"claude-*"}, InputPerMillion: 3, OutputPerMillion: 15, CachedPerMillion: 0.3, CacheWritePerMillion: 3.75}}
+	result := stats.Query(Query{From: now.Add(-time.Hour), GroupBy: []string{GroupModel}, Pricing: pricing})
+	if len(result.Rows) != 1 || result.Rows[0].Tokens.CacheWriteTokens != 2_000 {
+		t.Fatalf("rows = %+v", result.Rows)
+	}
+	want := (100*3 + 10_000*0.3 + 2_000*3.75 + 500*15) / 1_000_000.0
+	if math.Abs(result.Rows[0].Cost-want) > 1e-12 {
+		t.Fatalf("cost = %v, want %v", result.Rows[0].Cost, want)
+	}
+
+	pricing[0].CacheWritePerMillion = 0
+	if got, want := Cost(pricing, "claude-sonnet-4", result.Rows[0].Tokens), (2_100*3+10_000*0.3+500*15)/1_000_000.0; math.Abs(got-want) > 1e-12 {
+		t.Fatalf("cost without cache-write price = %v, want %v", got, want)
+	}
+}
+
+func TestRequestStatistics_PricesGeneratedImages(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		stats.Record(context.Background(), coreusage.Record{
+			APIKey: "team-a", Model: "gemini-2.5-flash-image", RequestedAt: now,
+			Detail: coreusage.Detail{InputTokens: 100_000, OutputTokens: 1290, Images: 1},
+		})
+	}
+
+	pricing := []config.ModelPrice{{Models: []string{"gemini-*-image"}, InputPerMillion: 0.3, PerImage: 0.039}}
+	result := stats.Query(Query{From: now.Add(-time.Hour), GroupBy: []string{GroupModel}, Pricing: pricing})
+	if len(result.Rows) != 1 || result.Rows[0].Tokens.Images != 3 {
+		t.Fatalf("rows = %+v", result.Rows)
+	}
+	if want := 3 * (0.03 + 0.039); math.Abs(result.Rows[0].Cost-want) > 1e-9 {
+		t.Fatalf("cost = %v, want %v", result.Rows[0].Cost, want)
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	groups, err := ParseGroupBy(" model, day ,model")
+	if err != nil || len(groups) != 2 || groups[0] != GroupModel || groups[1] != GroupDay {
+		t.Fatalf("ParseGroupBy = %v, %v", groups, err)
+	}
+	if _, err = ParseGroupBy("day,hour"); err == nil {
+		t.Fatal("expected an error when grouping by day and hour")
+	}
+	if _, err = ParseGroupBy("team"); err == nil {
+		t.Fatal("expected an error for an unknown group")
+	}
+}
+
+func TestRequestStatistics_AttributesSharedKeyUsageToEndUsers(t *testing.T) {
+	stats := NewRequestStatistics()
+	now := time.Now()
+	for _, user := range []string{"alice", "alice", "bob", ""} {
+		stats.Record(context.Background(), coreusage.Record{APIKey: "shared", EndUser: user, Model: "gpt-5", RequestedAt: now})
+	}
+
+	result := stats.Query(Query{APIKeys: []string{"shared"}, GroupBy: []string{GroupUser}})
+	if len(result.Rows) != 3 || result.Rows[1].EndUser != "alice" || result.Rows[1].Requests != 2 {
+		t.Fatalf("rows = %+v, want anonymous, alice and bob", result.Rows)
+	}
+	if bob := stats.Query(Query{EndUsers: []string{"bob"}}); bob.Total.Requests != 1 {
+		t.Fatalf("bob total = %+v", bob.Total)
+	}
+
+	if got := stats.GetMonthlyEndUserUsage("shared", "alice")["gpt-5"]; got != 2 {
+		t.Fatalf("alice monthly usage = %d, want 2", got)
+	}
+	checkpoint, err := stats.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	restored := NewRequestStatistics()
+	if err = restored.Restore(checkpoint, nil); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := restored.GetMonthlyEndUserUsage("shared", "alice")["gpt-5"]; got != 2 {
+		t.Fatalf("restored alice monthly usage = %d, want 2", got)
+	}
+}
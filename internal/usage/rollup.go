@@ -0,0 +1,269 @@
+package usage
+
+import (
+	"sort"
+	"time"
+)
+
+// Granularity names the bucket width of a usage rollup.
+type Granularity string
+
+const (
+	// GranularityMinute buckets requests by minute.
+	GranularityMinute Granularity = "minute"
+	// GranularityHour buckets requests by hour.
+	GranularityHour Granularity = "hour"
+	// GranularityDay buckets requests by calendar day in local time.
+	GranularityDay Granularity = "day"
+)
+
+var granularities = []Granularity{GranularityMinute, GranularityHour, GranularityDay}
+
+// Retention bounds how much usage history is kept in memory and in the persisted state.
+// Zero or negative values fall back to the defaults.
+type Retention struct {
+	// RecentRequests is the number of raw request events kept for request-level views.
+	RecentRequests int
+	// MinuteRollupHours is how long per-minute rollups are kept.
+	MinuteRollupHours int
+	// HourRollupDays is how long per-hour rollups are kept.
+	HourRollupDays int
+	// DayRollupDays is how long per-day rollups are kept.
+	DayRollupDays int
+	// EventRetentionDays is how long stores that keep a request log (SQLite) retain raw rows.
+	EventRetentionDays int
+}
+
+// DefaultRetention returns the retention used when nothing is configured.
+func DefaultRetention() Retention {
+	return Retention{
+		RecentRequests:     10000,
+		MinuteRollupHours:  48,
+		HourRollupDays:     31,
+		DayRollupDays:      400,
+		EventRetentionDays: 90,
+	}
+}
+
+func (r Retention) normalize() Retention {
+	defaults := DefaultRetention()
+	if r.RecentRequests <= 0 {
+		r.RecentRequests = defaults.RecentRequests
+	}
+	if r.MinuteRollupHours <= 0 {
+		r.MinuteRollupHours = defaults.MinuteRollupHours
+	}
+	if r.HourRollupDays <= 0 {
+		r.HourRollupDays = defaults.HourRollupDays
+	}
+	if r.DayRollupDays <= 0 {
+		r.DayRollupDays = defaults.DayRollupDays
+	}
+	if r.EventRetentionDays <= 0 {
+		r.EventRetentionDays = defaults.EventRetentionDays
+	}
+	return r
+}
+
+// cutoff returns the start of the oldest bucket of g that is still retained at now.
+func (r Retention) cutoff(g Granularity, now time.Time) time.Time {
+	switch g {
+	case GranularityMinute:
+		return now.Add(-time.Duration(r.MinuteRollupHours) * time.Hour)
+	case GranularityHour:
+		return now.AddDate(0, 0, -r.HourRollupDays)
+	default:
+		return bucketStart(GranularityDay, now.AddDate(0, 0, -r.DayRollupDays))
+	}
+}
+
+// Rollup aggregates the requests of one API key, end user, model, provider and credential
+// within one bucket. Failed and successful requests are counted in separate rollups.
+type Rollup struct {
+	Start     time.Time  `json:"start"`
+	APIKey    string     `json:"api_key"`
+	EndUser   string     `json:"end_user,omitempty"`
+	Model     string     `json:"model"`
+	Provider  string     `json:"provider,omitempty"`
+	AuthIndex string     `json:"auth_index,omitempty"`
+	Failed    bool       `json:"failed,omitempty"`
+	Requests  int64      `json:"requests"`
+	Tokens    TokenStats `json:"tokens"`
+}
+
+type rollupKey struct {
+	start     int64
+	apiKey    string
+	endUser   string
+	model     string
+	provider  string
+	authIndex string
+	failed    bool
+}
+
+func (r *Rollup) key() rollupKey {
+	return rollupKey{start: r.Start.Unix(), apiKey: r.APIKey, endUser: r.EndUser, model: r.Model, provider: r.Provider, authIndex: r.AuthIndex, failed: r.Failed}
+}
+
+// bucketStart returns the start of the bucket of g that contains t, in local time.
+func bucketStart(g Granularity, t time.Time) time.Time {
+	t = t.Local()
+	switch g {
+	case GranularityMinute:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	case GranularityHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+func (t *TokenStats) add(other TokenStats) {
+	t.InputTokens += other.InputTokens
+	t.OutputTokens += other.OutputTokens
+	t.ReasoningTokens += other.ReasoningTokens
+	t.CachedTokens += other.CachedTokens
+	t.CacheWriteTokens += other.CacheWriteTokens
+	t.TotalTokens += other.TotalTokens
+	t.Images += other.Images
+}
+
+// addRollupLocked folds one request into the bucket of g. Callers hold s.mu.
+func (s *RequestStatistics) addRollupLocked(g Granularity, event Event) {
+	probe := Rollup{Start: bucketStart(g, event.Timestamp), APIKey: event.APIKey, EndUser: event.EndUser, Model: event.Model, Provider: event.Provider, AuthIndex: event.AuthIndex, Failed: event.Failed}
+	key := probe.key()
+	buckets := s.rollups[g]
+	rollup, ok := buckets[key]
+	if !ok {
+		rollup = &probe
+		buckets[key] = rollup
+	}
+	rollup.Requests++
+	rollup.Tokens.add(event.Tokens)
+}
+
+// mergeRollupLocked adds a persisted rollup to the in-memory buckets. Callers hold s.mu.
+func (s *RequestStatistics) mergeRollupLocked(g Granularity, r Rollup) {
+	r.Start = bucketStart(g, r.Start)
+	key := r.key()
+	buckets := s.rollups[g]
+	existing, ok := buckets[key]
+	if !ok {
+		buckets[key] = &r
+		return
+	}
+	existing.Requests += r.Requests
+	existing.Tokens.add(r.Tokens)
+}
+
+// pruneLocked drops rollups that fell out of the retention window. Callers hold s.mu.
+func (s *RequestStatistics) pruneLocked(now time.Time) {
+	s.lastPrune = now
+	for _, g := range granularities {
+		cutoff := s.retention.cutoff(g, now).Unix()
+		for key := range s.rollups[g] {
+			if key.start < cutoff {
+				delete(s.rollups[g], key)
+			}
+		}
+	}
+}
+
+// sortedRollupsLocked returns copies of the rollups of g within [from, to), ordered by start
+// time, API key, end user, model, provider, credential and failure. A zero from or to leaves that side
+// open. Callers hold s.mu.
+func (s *RequestStatistics) sortedRollupsLocked(g Granularity, from, to time.Time) []Rollup {
+	result := make([]Rollup, 0, len(s.rollups[g]))
+	for _, rollup := range s.rollups[g] {
+		if !from.IsZero() && rollup.Start.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !rollup.Start.Before(to) {
+			continue
+		}
+		result = append(result, *rollup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.APIKey != b.APIKey {
+			return a.APIKey < b.APIKey
+		}
+		if a.EndUser != b.EndUser {
+			return a.EndUser < b.EndUser
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.AuthIndex != b.AuthIndex {
+			return a.AuthIndex < b.AuthIndex
+		}
+		return !a.Failed && b.Failed
+	})
+	return result
+}
+
+// Rollups returns the retained rollups of granularity g whose bucket starts within [from, to).
+// A zero from or to leaves that side of the range open.
+func (s *RequestStatistics) Rollups(g Granularity, from, to time.Time) []Rollup {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.rollups[g]; !ok {
+		return nil
+	}
+	return s.sortedRollupsLocked(g, from, to)
+}
+
+// eventRing keeps the most recent request events in insertion order.
+type eventRing struct {
+	buf   []Event
+	start int
+	size  int
+}
+
+func newEventRing(capacity int) eventRing {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return eventRing{buf: make([]Event, capacity)}
+}
+
+// push appends event, overwriting the oldest one when the ring is full.
+func (r *eventRing) push(event Event) {
+	if r.size < len(r.buf) {
+		r.buf[(r.start+r.size)%len(r.buf)] = event
+		r.size++
+		return
+	}
+	r.buf[r.start] = event
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// each calls fn for every event from oldest to newest.
+func (r *eventRing) each(fn func(Event)) {
+	for i := 0; i < r.size; i++ {
+		fn(r.buf[(r.start+i)%len(r.buf)])
+	}
+}
+
+// events returns a copy of the buffered events from oldest to newest.
+func (r *eventRing) events() []Event {
+	out := make([]Event, 0, r.size)
+	r.each(func(event Event) { out = append(out, event) })
+	return out
+}
+
+// resize returns a ring of the given capacity holding the newest events of r.
+func (r *eventRing) resize(capacity int) eventRing {
+	next := newEventRing(capacity)
+	r.each(next.push)
+	return next
+}
@@ -0,0 +1,144 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+func TestRequestStatistics_KeepsBoundedRecentRequestsAndRollups(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.SetRetention(Retention{RecentRequests: 3, MinuteRollupHours: 1})
+
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	stats.Record(context.Background(), coreusage.Record{APIKey: "key-1", Model: "gpt-test", AuthIndex: "1", RequestedAt: old, Detail: coreusage.Detail{TotalTokens: 10}})
+	for i := 0; i < 4; i++ {
+		stats.Record(context.Background(), coreusage.Record{APIKey: "key-1", Model: "gpt-test", AuthIndex: "2", RequestedAt: now, Detail: coreusage.Detail{TotalTokens: 10}})
+	}
+	stats.mu.Lock()
+	stats.pruneLocked(now)
+	stats.mu.Unlock()
+
+	snapshot := stats.Snapshot()
+	model := snapshot.APIs["key-1"].Models["gpt-test"]
+	if snapshot.TotalRequests != 5 || model.TotalRequests != 5 || model.TotalTokens != 50 {
+		t.Fatalf("totals = %d requests, model %d requests / %d tokens, want 5, 5 and 50", snapshot.TotalRequests, model.TotalRequests, model.TotalTokens)
+	}
+	if len(model.Details) != 3 {
+		t.Fatalf("details = %d, want the 3 most recent", len(model.Details))
+	}
+	var byDay int64
+	for _, count := range snapshot.RequestsByDay {
+		byDay += count
+	}
+	if byDay != 5 {
+		t.Fatalf("requests by day = %v, want 5 in total", snapshot.RequestsByDay)
+	}
+
+	if minutes := stats.Rollups(GranularityMinute, time.Time{}, time.Time{}); len(minutes) != 1 || minutes[0].AuthIndex != "2" || minutes[0].Requests != 4 {
+		t.Fatalf("minute rollups = %+v, want only the recent bucket", minutes)
+	}
+	hours := stats.Rollups(GranularityHour, old.Truncate(time.Hour), time.Time{})
+	if len(hours) != 2 || hours[0].AuthIndex != "1" || hours[0].Tokens.TotalTokens != 10 {
+		t.Fatalf("hour rollups = %+v", hours)
+	}
+
+	if got := stats.GetMonthlyUsage("key-1", "gpt-test"); old.Month() == now.Month() && got != 5 {
+		t.Fatalf("monthly usage = %d, want 5", got)
+	}
+}
+
+func TestRequestStatistics_CheckpointRestoresStateAndSkipsCoveredEvents(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.setJournaling(true)
+	for i := 0; i < 3; i++ {
+		stats.Record(context.Background(), coreusage.Record{APIKey: "key-1", Model: "gpt-test", RequestedAt: time.Now(), Detail: coreusage.Detail{InputTokens: 2, OutputTokens: 3}})
+	}
+	journal := stats.takePending()
+	if len(journal) != 3 {
+		t.Fatalf("pending = %d, want 3", len(journal))
+	}
+	checkpoint, err := stats.journalCheckpoint()
+	if err != nil {
+		t.Fatalf("journalCheckpoint: %v", err)
+	}
+	stats.Record(context.Background(), coreusage.Record{APIKey: "key-1", Model: "gpt-test", RequestedAt: time.Now(), Failed: true})
+	journal = append(journal, stats.takePending()...)
+
+	restored := NewRequestStatistics()
+	if err = restored.Restore(checkpoint, journal); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	snapshot := restored.Snapshot()
+	if snapshot.TotalRequests != 4 || snapshot.FailureCount != 1 || snapshot.TotalTokens != 15 {
+		t.Fatalf("restored totals = %+v, want 4 requests, 1 failure, 15 tokens", snapshot)
+	}
+	if got := restored.GetMonthlyUsage("key-1", "gpt-test"); got != 4 {
+		t.Fatalf("monthly usage = %d, want 4", got)
+	}
+	if len(snapshot.APIs["key-1"].Models["gpt-test"].Details) != 4 {
+		t.Fatalf("details = %+v", snapshot.APIs["key-1"].Models["gpt-test"].Details)
+	}
+}
+
+type memoryJournal struct {
+	checkpoint []byte
+	events     []Event
+	saves      int
+}
+
+func (m *memoryJournal) SaveUsageStatistics(_ context.Context, data []byte) error {
+	m.checkpoint, m.events = data, nil
+	m.saves++
+	return nil
+}
+
+func (m *memoryJournal) LoadUsageStatistics(context.Context) ([]byte, error) {
+	return m.checkpoint, nil
+}
+
+func (m *memoryJournal) AppendUsageEvents(_ context.Context, events []Event) error {
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *memoryJournal) LoadUsageJournal(context.Context) ([]byte, []Event, error) {
+	return m.checkpoint, m.events, nil
+}
+
+func TestSaveStatistics_AppendsEventsUntilCheckpointIsDue(t *testing.T) {
+	journal := &memoryJournal{}
+	RegisterUsageStore(journal)
+	t.Cleanup(func() { RegisterUsageStore(nil) })
+
+	stats := GetRequestStatistics()
+	stats.Record(context.Background(), coreusage.Record{APIKey: "journal-key", Model: "gpt-test", RequestedAt: time.Now()})
+	if err := SaveStatistics(context.Background()); err != nil {
+		t.Fatalf("SaveStatistics: %v", err)
+	}
+	if len(journal.events) != 1 || journal.saves != 0 {
+		t.Fatalf("after first save: %d events, %d checkpoints, want 1 and 0", len(journal.events), journal.saves)
+	}
+
+	stats.mu.Lock()
+	stats.lastCheckpoint = time.Now().Add(-2 * checkpointInterval)
+	stats.mu.Unlock()
+	stats.Record(context.Background(), coreusage.Record{APIKey: "journal-key", Model: "gpt-test", RequestedAt: time.Now()})
+	if err := SaveStatistics(context.Background()); err != nil {
+		t.Fatalf("SaveStatistics: %v", err)
+	}
+	if journal.saves != 1 || len(journal.events) != 0 {
+		t.Fatalf("after due save: %d events, %d checkpoints, want 0 and 1", len(journal.events), journal.saves)
+	}
+
+	restored := NewRequestStatistics()
+	if err := restored.Restore(journal.checkpoint, journal.events); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := restored.Snapshot().APIs["journal-key"].TotalRequests; got != 2 {
+		t.Fatalf("restored journal-key requests = %d, want 2", got)
+	}
+}
@@ -0,0 +1,60 @@
+package util
+
+import "strings"
+
+// MatchWildcard reports whether value matches pattern, where '*' matches zero or more characters.
+// Both are trimmed; matching is case-sensitive, so callers comparing model names normalize case
+// first. An empty pattern matches nothing.
+// Examples:
+//
+//	"*-5" matches "gpt-5"
+//	"gpt-*" matches "gpt-5" and "gpt-4"
+//	"gemini-*-pro" matches "gemini-2.5-pro" and "gemini-3-pro".
+func MatchWildcard(pattern, value string) bool {
+	pattern = strings.TrimSpace(pattern)
+	value = strings.TrimSpace(value)
+	if pattern == "" {
+		return false
+	}
+	if pattern == "*" {
+		return true
+	}
+	// Iterative glob-style matcher supporting only '*' wildcard.
+	pi, si := 0, 0
+	starIdx := -1
+	matchIdx := 0
+	for si < len(value) {
+		if pi < len(pattern) && pattern[pi] == value[si] {
+			pi++
+			si++
+			continue
+		}
+		if pi < len(pattern) && pattern[pi] == '*' {
+			starIdx = pi
+			matchIdx = si
+			pi++
+			continue
+		}
+		if starIdx != -1 {
+			pi = starIdx + 1
+			matchIdx++
+			si = matchIdx
+			continue
+		}
+		return false
+	}
+	for pi < len(pattern) && pattern[pi] == '*' {
+		pi++
+	}
+	return pi == len(pattern)
+}
+
+// MatchAnyWildcard reports whether value matches any of patterns using MatchWildcard.
+func MatchAnyWildcard(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if MatchWildcard(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package util
+
+import "testing"
+
+func TestMatchWildcard(t *testing.T) {
+	cases := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"gpt-5", "gpt-5", true},
+		{"gpt-5", "gpt-5-mini", false},
+		{"*", "anything", true},
+		{"*-5", "gpt-5", true},
+		{"gpt-*", "gpt-4", true},
+		{"gemini-*-pro", "gemini-2.5-pro", true},
+		{"gemini-*-pro", "gemini-2.5-flash", false},
+		{"*sonnet*", "claude-sonnet-4", true},
+		{"a*a", "a", false},
+		{"a*b*a", "abba", true},
+		{" gpt-* ", "gpt-5", true},
+		{"GPT-*", "gpt-5", false},
+		{"", "", false},
+		{"", "gpt-5", false},
+	}
+	for _, tc := range cases {
+		if got := MatchWildcard(tc.pattern, tc.value); got != tc.want {
+			t.Errorf("MatchWildcard(%q, %q) = %v, want %v", tc.pattern, tc.value, got, tc.want)
+		}
+	}
+	if !MatchAnyWildcard([]string{"claude-*", "gpt-*"}, "gpt-5") || MatchAnyWildcard(nil, "gpt-5") {
+		t.Errorf("MatchAnyWildcard() did not match any of its patterns")
+	}
+}
@@ -33,6 +33,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.UsageStatisticsEnabled != newCfg.UsageStatisticsEnabled {
 		changes = append(changes, fmt.Sprintf("usage-statistics-enabled: %t -> %t", oldCfg.UsageStatisticsEnabled, newCfg.UsageStatisticsEnabled))
 	}
+	if oldCfg.UsageRetention != newCfg.UsageRetention {
+		changes = append(changes, fmt.Sprintf("usage-retention: %+v -> %+v", oldCfg.UsageRetention, newCfg.UsageRetention))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelPricing, newCfg.ModelPricing) {
+		changes = append(changes, fmt.Sprintf("model-pricing: updated (%d -> %d entries)", len(oldCfg.ModelPricing), len(newCfg.ModelPricing)))
+	}
+	if oldCfg.EndUserAttribution != newCfg.EndUserAttribution {
+		changes = append(changes, fmt.Sprintf("end-user-attribution: %+v -> %+v", oldCfg.EndUserAttribution, newCfg.EndUserAttribution))
+	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
@@ -51,10 +60,20 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.WebsocketAuth != newCfg.WebsocketAuth {
 		changes = append(changes, fmt.Sprintf("ws-auth: %t -> %t", oldCfg.WebsocketAuth, newCfg.WebsocketAuth))
 	}
+	if oldCfg.PublicBaseURL != newCfg.PublicBaseURL {
+		changes = append(changes, fmt.Sprintf("public-base-url: %s -> %s", oldCfg.PublicBaseURL, newCfg.PublicBaseURL))
+	}
 	if oldCfg.ForceModelPrefix != newCfg.ForceModelPrefix {
 		changes = append(changes, fmt.Sprintf("force-model-prefix: %t -> %t", oldCfg.ForceModelPrefix, newCfg.ForceModelPrefix))
 	}
 
+	if !reflect.DeepEqual(oldCfg.Routing.Hedging, newCfg.Routing.Hedging) {
+		changes = append(changes, fmt.Sprintf("routing.hedging: updated (%d -> %d rules)", len(oldCfg.Routing.Hedging), len(newCfg.Routing.Hedging)))
+	}
+	if !reflect.DeepEqual(oldCfg.HealthProbes, newCfg.HealthProbes) {
+		changes = append(changes, fmt.Sprintf("health-probes: enabled %t -> %t, interval %ds -> %ds", oldCfg.HealthProbes.Enabled, newCfg.HealthProbes.Enabled, oldCfg.HealthProbes.IntervalSeconds, newCfg.HealthProbes.IntervalSeconds))
+	}
+
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-project: %t -> %t", oldCfg.QuotaExceeded.SwitchProject, newCfg.QuotaExceeded.SwitchProject))
@@ -90,6 +109,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if !equalStringMap(o.Headers, n.Headers) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].headers: updated", i))
 			}
+			if o.CredentialCaps != n.CredentialCaps {
+				changes = append(changes, fmt.Sprintf("gemini[%d].caps: %s -> %s", i, formatCredentialCaps(o.CredentialCaps), formatCredentialCaps(n.CredentialCaps)))
+			}
 			oldModels := SummarizeGeminiModels(o.Models)
 			newModels := SummarizeGeminiModels(n.Models)
 			if oldModels.hash != newModels.hash {
@@ -125,6 +147,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if !equalStringMap(o.Headers, n.Headers) {
 				changes = append(changes, fmt.Sprintf("claude[%d].headers: updated", i))
 			}
+			if o.CredentialCaps != n.CredentialCaps {
+				changes = append(changes, fmt.Sprintf("claude[%d].caps: %s -> %s", i, formatCredentialCaps(o.CredentialCaps), formatCredentialCaps(n.CredentialCaps)))
+			}
 			oldModels := SummarizeClaudeModels(o.Models)
 			newModels := SummarizeClaudeModels(n.Models)
 			if oldModels.hash != newModels.hash {
@@ -160,6 +185,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if !equalStringMap(o.Headers, n.Headers) {
 				changes = append(changes, fmt.Sprintf("codex[%d].headers: updated", i))
 			}
+			if o.CredentialCaps != n.CredentialCaps {
+				changes = append(changes, fmt.Sprintf("codex[%d].caps: %s -> %s", i, formatCredentialCaps(o.CredentialCaps), formatCredentialCaps(n.CredentialCaps)))
+			}
 			oldModels := SummarizeCodexModels(o.Models)
 			newModels := SummarizeCodexModels(n.Models)
 			if oldModels.hash != newModels.hash {
@@ -271,6 +299,117 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if !equalStringMap(o.Headers, n.Headers) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].headers: updated", i))
 			}
+			if o.CredentialCaps != n.CredentialCaps {
+				changes = append(changes, fmt.Sprintf("vertex[%d].caps: %s -> %s", i, formatCredentialCaps(o.CredentialCaps), formatCredentialCaps(n.CredentialCaps)))
+			}
+		}
+	}
+
+	// Bedrock credentials
+	if len(oldCfg.BedrockKey) != len(newCfg.BedrockKey) {
+		changes = append(changes, fmt.Sprintf("bedrock-api-key count: %d -> %d", len(oldCfg.BedrockKey), len(newCfg.BedrockKey)))
+	} else {
+		for i := range oldCfg.BedrockKey {
+			o := oldCfg.BedrockKey[i]
+			n := newCfg.BedrockKey[i]
+			if strings.TrimSpace(o.Region) != strings.TrimSpace(n.Region) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].region: %s -> %s", i, strings.TrimSpace(o.Region), strings.TrimSpace(n.Region)))
+			}
+			if strings.TrimSpace(o.Endpoint) != strings.TrimSpace(n.Endpoint) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].endpoint: %s -> %s", i, strings.TrimSpace(o.Endpoint), strings.TrimSpace(n.Endpoint)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.AccessKeyID != n.AccessKeyID || o.SecretAccessKey != n.SecretAccessKey || o.SessionToken != n.SessionToken {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].credentials: updated", i))
+			}
+			if ComputeBedrockModelsHash(o.Models) != ComputeBedrockModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].headers: updated", i))
+			}
+			if o.CredentialCaps != n.CredentialCaps {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].caps: %s -> %s", i, formatCredentialCaps(o.CredentialCaps), formatCredentialCaps(n.CredentialCaps)))
+			}
+		}
+	}
+
+	// Azure OpenAI resources
+	if len(oldCfg.AzureOpenAI) != len(newCfg.AzureOpenAI) {
+		changes = append(changes, fmt.Sprintf("azure-openai count: %d -> %d", len(oldCfg.AzureOpenAI), len(newCfg.AzureOpenAI)))
+	} else {
+		for i := range oldCfg.AzureOpenAI {
+			o := oldCfg.AzureOpenAI[i]
+			n := newCfg.AzureOpenAI[i]
+			if strings.TrimSpace(o.Endpoint) != strings.TrimSpace(n.Endpoint) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].endpoint: %s -> %s", i, strings.TrimSpace(o.Endpoint), strings.TrimSpace(n.Endpoint)))
+			}
+			if strings.TrimSpace(o.APIVersion) != strings.TrimSpace(n.APIVersion) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].api-version: %s -> %s", i, strings.TrimSpace(o.APIVersion), strings.TrimSpace(n.APIVersion)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.APIKey != n.APIKey || o.TenantID != n.TenantID || o.ClientID != n.ClientID || o.ClientSecret != n.ClientSecret {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].credentials: updated", i))
+			}
+			if ComputeAzureOpenAIModelsHash(o.Models) != ComputeAzureOpenAIModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].headers: updated", i))
+			}
+			if o.CredentialCaps != n.CredentialCaps {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].caps: %s -> %s", i, formatCredentialCaps(o.CredentialCaps), formatCredentialCaps(n.CredentialCaps)))
+			}
+		}
+	}
+
+	// Ollama servers
+	if len(oldCfg.Ollama) != len(newCfg.Ollama) {
+		changes = append(changes, fmt.Sprintf("ollama count: %d -> %d", len(oldCfg.Ollama), len(newCfg.Ollama)))
+	} else {
+		for i := range oldCfg.Ollama {
+			o := oldCfg.Ollama[i]
+			n := newCfg.Ollama[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.APIKey != n.APIKey {
+				changes = append(changes, fmt.Sprintf("ollama[%d].api-key: updated", i))
+			}
+			if o.DiscoveryIntervalSeconds != n.DiscoveryIntervalSeconds {
+				changes = append(changes, fmt.Sprintf("ollama[%d].discovery-interval-seconds: %d -> %d", i, o.DiscoveryIntervalSeconds, n.DiscoveryIntervalSeconds))
+			}
+			if o.LoadTimeoutSeconds != n.LoadTimeoutSeconds {
+				changes = append(changes, fmt.Sprintf("ollama[%d].load-timeout-seconds: %d -> %d", i, o.LoadTimeoutSeconds, n.LoadTimeoutSeconds))
+			}
+			if o.ContextLength != n.ContextLength {
+				changes = append(changes, fmt.Sprintf("ollama[%d].context-length: %d -> %d", i, o.ContextLength, n.ContextLength))
+			}
+			if ComputeOllamaModelsHash(o.Models) != ComputeOllamaModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].headers: updated", i))
+			}
+			if o.CredentialCaps != n.CredentialCaps {
+				changes = append(changes, fmt.Sprintf("ollama[%d].caps: %s -> %s", i, formatCredentialCaps(o.CredentialCaps), formatCredentialCaps(n.CredentialCaps)))
+			}
 		}
 	}
 
@@ -297,6 +436,10 @@ func equalStringMap(a, b map[string]string) bool {
 	return true
 }
 
+func formatCredentialCaps(caps config.CredentialCaps) string {
+	return fmt.Sprintf("concurrent=%d requests/day=%d tokens/day=%d", caps.MaxConcurrent, caps.MaxRequestsPerDay, caps.MaxTokensPerDay)
+}
+
 func formatProxyURL(raw string) string {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/giofahreza/AIProxyAPI/internal/config"
@@ -41,6 +42,55 @@ func ComputeVertexCompatModelsHash(models []config.VertexCompatModel) string {
 	return hashJoined(keys)
 }
 
+// ComputeBedrockModelsHash returns a stable hash for Bedrock model aliases.
+func ComputeBedrockModelsHash(models []config.BedrockModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeAzureOpenAIModelsHash returns a stable hash for Azure OpenAI deployment mappings.
+func ComputeAzureOpenAIModelsHash(models []config.AzureOpenAIModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			deployment := strings.TrimSpace(model.Deployment)
+			if name == "" && deployment == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(deployment))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeOllamaModelsHash returns a stable hash for Ollama model aliases and context lengths.
+func ComputeOllamaModelsHash(models []config.OllamaModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			key := strings.ToLower(name) + "|" + strings.ToLower(alias)
+			if model.ContextLength > 0 {
+				key += "|" + strconv.Itoa(model.ContextLength)
+			}
+			out(key)
+		}
+	})
+	return hashJoined(keys)
+}
+
 // ComputeClaudeModelsHash returns a stable hash for Claude model aliases.
 func ComputeClaudeModelsHash(models []config.ClaudeModel) string {
 	keys := normalizeModelPairs(func(out func(key string)) {
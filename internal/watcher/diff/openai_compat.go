@@ -75,6 +75,21 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if !equalCompatCaps(oldEntry.APIKeyEntries, newEntry.APIKeyEntries) {
+		details = append(details, "caps updated")
+	}
+	if oldEntry.DiscoverModels != newEntry.DiscoverModels {
+		details = append(details, fmt.Sprintf("discover-models %t -> %t", oldEntry.DiscoverModels, newEntry.DiscoverModels))
+	}
+	if oldEntry.DiscoveryIntervalSeconds != newEntry.DiscoveryIntervalSeconds {
+		details = append(details, fmt.Sprintf("discovery-interval-seconds %d -> %d", oldEntry.DiscoveryIntervalSeconds, newEntry.DiscoveryIntervalSeconds))
+	}
+	if !equalStringSet(oldEntry.IncludeModels, newEntry.IncludeModels) {
+		details = append(details, fmt.Sprintf("include-models %d -> %d", len(oldEntry.IncludeModels), len(newEntry.IncludeModels)))
+	}
+	if !equalStringSet(oldEntry.ExcludedModels, newEntry.ExcludedModels) {
+		details = append(details, fmt.Sprintf("excluded-models %d -> %d", len(oldEntry.ExcludedModels), len(newEntry.ExcludedModels)))
+	}
 	if len(details) == 0 {
 		return ""
 	}
@@ -181,3 +196,66 @@ func openAICompatSignature(entry config.OpenAICompatibility) string {
 	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
 	return hex.EncodeToString(sum[:])
 }
+
+// equalCompatCaps compares the caps of the capped keys by API key. Keys without caps are
+// ignored, so adding or removing an uncapped key is not reported as a caps change.
+func equalCompatCaps(oldEntries, newEntries []config.OpenAICompatibilityAPIKey) bool {
+	oldCaps, newCaps := cappedCompatKeys(oldEntries), cappedCompatKeys(newEntries)
+	if len(oldCaps) != len(newCaps) {
+		return false
+	}
+	for key, caps := range oldCaps {
+		if other, ok := newCaps[key]; !ok || other != caps {
+			return false
+		}
+	}
+	return true
+}
+
+func cappedCompatKeys(entries []config.OpenAICompatibilityAPIKey) map[string]config.CredentialCaps {
+	out := make(map[string]config.CredentialCaps, len(entries))
+	for _, entry := range entries {
+		if entry.CredentialCaps != (config.CredentialCaps{}) {
+			out[strings.TrimSpace(entry.APIKey)] = entry.CredentialCaps
+		}
+	}
+	return out
+}
+
+// DiffDiscoveredModels describes how a provider's discovered model list changed between two
+// discovery runs, in the same style as BuildConfigChangeDetails.
+func DiffDiscoveredModels(provider string, oldIDs, newIDs []string) []string {
+	oldSet := make(map[string]struct{}, len(oldIDs))
+	for _, id := range oldIDs {
+		oldSet[id] = struct{}{}
+	}
+	newSet := make(map[string]struct{}, len(newIDs))
+	for _, id := range newIDs {
+		newSet[id] = struct{}{}
+	}
+	added := make([]string, 0)
+	for id := range newSet {
+		if _, ok := oldSet[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	removed := make([]string, 0)
+	for id := range oldSet {
+		if _, ok := newSet[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	changes := []string{fmt.Sprintf("provider models updated: %s (models %d -> %d)", provider, len(oldSet), len(newSet))}
+	if len(added) > 0 {
+		changes = append(changes, "  added: "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		changes = append(changes, "  removed: "+strings.Join(removed, ", "))
+	}
+	return changes
+}
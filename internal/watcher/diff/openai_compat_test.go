@@ -185,3 +185,54 @@ func TestOpenAICompatKeyUsesModelNameWhenAliasEmpty(t *testing.T) {
 		t.Fatalf("expected model-name fallback, got %s/%s", key, label)
 	}
 }
+
+func TestDiffOpenAICompatibility_DiscoverySettings(t *testing.T) {
+	oldList := []config.OpenAICompatibility{{Name: "provider-a"}}
+	newList := []config.OpenAICompatibility{{
+		Name:                     "provider-a",
+		DiscoverModels:           true,
+		DiscoveryIntervalSeconds: 600,
+		ExcludedModels:           []string{"*-preview"},
+	}}
+
+	changes := DiffOpenAICompatibility(oldList, newList)
+	expectContains(t, changes, "provider updated: provider-a (discover-models false -> true, discovery-interval-seconds 0 -> 600, excluded-models 0 -> 1)")
+}
+
+func TestDiffDiscoveredModels(t *testing.T) {
+	if changes := DiffDiscoveredModels("acme", []string{"a", "b"}, []string{"b", "a"}); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+	changes := DiffDiscoveredModels("acme", []string{"a", "b"}, []string{"b", "d", "c"})
+	expectContains(t, changes, "provider models updated: acme (models 2 -> 3)")
+	expectContains(t, changes, "  added: c, d")
+	expectContains(t, changes, "  removed: a")
+}
+
+func TestEqualCompatCaps(t *testing.T) {
+	capped := config.OpenAICompatibilityAPIKey{APIKey: "k1", CredentialCaps: config.CredentialCaps{MaxConcurrent: 2}}
+	plain := config.OpenAICompatibilityAPIKey{APIKey: "k2"}
+
+	tests := []struct {
+		name     string
+		oldList  []config.OpenAICompatibilityAPIKey
+		newList  []config.OpenAICompatibilityAPIKey
+		expected bool
+	}{
+		{name: "both empty", expected: true},
+		{name: "unchanged", oldList: []config.OpenAICompatibilityAPIKey{capped}, newList: []config.OpenAICompatibilityAPIKey{capped}, expected: true},
+		{name: "uncapped key added", oldList: []config.OpenAICompatibilityAPIKey{capped}, newList: []config.OpenAICompatibilityAPIKey{capped, plain}, expected: true},
+		{name: "uncapped key removed", oldList: []config.OpenAICompatibilityAPIKey{capped, plain}, newList: []config.OpenAICompatibilityAPIKey{capped}, expected: true},
+		{name: "capped key added", oldList: []config.OpenAICompatibilityAPIKey{plain}, newList: []config.OpenAICompatibilityAPIKey{plain, capped}, expected: false},
+		{name: "capped key removed", oldList: []config.OpenAICompatibilityAPIKey{capped, plain}, newList: []config.OpenAICompatibilityAPIKey{plain}, expected: false},
+		{name: "keys reordered", oldList: []config.OpenAICompatibilityAPIKey{capped, plain}, newList: []config.OpenAICompatibilityAPIKey{plain, capped}, expected: true},
+		{name: "cap changed", oldList: []config.OpenAICompatibilityAPIKey{capped}, newList: []config.OpenAICompatibilityAPIKey{{APIKey: "k1"}}, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := equalCompatCaps(tt.oldList, tt.newList); got != tt.expected {
+				t.Fatalf("equalCompatCaps() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
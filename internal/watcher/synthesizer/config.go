@@ -2,6 +2,7 @@ package synthesizer
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/giofahreza/AIProxyAPI/internal/watcher/diff"
@@ -9,7 +10,7 @@ import (
 )
 
 // ConfigSynthesizer generates Auth entries from configuration API keys.
-// It handles Gemini, Claude, Codex, OpenAI-compat, and Vertex-compat providers.
+// It handles Gemini, Claude, Codex, OpenAI-compat, Vertex-compat, Bedrock, Azure OpenAI, and Ollama providers.
 type ConfigSynthesizer struct{}
 
 // NewConfigSynthesizer creates a new ConfigSynthesizer instance.
@@ -34,6 +35,12 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
+	// Bedrock
+	out = append(out, s.synthesizeBedrockKeys(ctx)...)
+	// Azure OpenAI
+	out = append(out, s.synthesizeAzureOpenAIKeys(ctx)...)
+	// Ollama
+	out = append(out, s.synthesizeOllamaKeys(ctx)...)
 
 	return out, nil
 }
@@ -66,6 +73,7 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addCredentialCapsToAttrs(entry.CredentialCaps, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   "gemini",
@@ -110,6 +118,7 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addCredentialCapsToAttrs(ck.CredentialCaps, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -154,6 +163,7 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addCredentialCapsToAttrs(ck.CredentialCaps, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -208,7 +218,9 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			addModelDiscoveryToAttrs(compat, attrs)
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addCredentialCapsToAttrs(entry.CredentialCaps, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
@@ -236,6 +248,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			addModelDiscoveryToAttrs(compat, attrs)
 			addConfigHeadersToAttrs(compat.Headers, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
@@ -282,6 +295,7 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(compat.Headers, attrs)
+		addCredentialCapsToAttrs(compat.CredentialCaps, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   providerName,
@@ -298,3 +312,152 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 	}
 	return out
 }
+
+// synthesizeBedrockKeys creates Auth entries for AWS Bedrock credentials.
+func (s *ConfigSynthesizer) synthesizeBedrockKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		accessKey := strings.TrimSpace(entry.AccessKeyID)
+		secret := strings.TrimSpace(entry.SecretAccessKey)
+		region := strings.TrimSpace(entry.Region)
+		if accessKey == "" || secret == "" || region == "" {
+			continue
+		}
+		endpoint := strings.TrimSpace(entry.Endpoint)
+		id, token := idGen.Next("bedrock:apikey", accessKey, region, endpoint)
+		attrs := map[string]string{
+			"source":            fmt.Sprintf("config:bedrock[%s]", token),
+			"region":            region,
+			"access_key_id":     accessKey,
+			"secret_access_key": secret,
+		}
+		if sessionToken := strings.TrimSpace(entry.SessionToken); sessionToken != "" {
+			attrs["session_token"] = sessionToken
+		}
+		if endpoint != "" {
+			attrs["base_url"] = endpoint
+		}
+		if hash := diff.ComputeBedrockModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addCredentialCapsToAttrs(entry.CredentialCaps, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "bedrock",
+			Label:      "bedrock-apikey",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeAzureOpenAIKeys creates Auth entries for Azure OpenAI resources.
+func (s *ConfigSynthesizer) synthesizeAzureOpenAIKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.AzureOpenAI))
+	for i := range cfg.AzureOpenAI {
+		entry := cfg.AzureOpenAI[i]
+		endpoint := strings.TrimSpace(entry.Endpoint)
+		key := strings.TrimSpace(entry.APIKey)
+		if endpoint == "" || (key == "" && !entry.UsesEntraID()) {
+			continue
+		}
+		id, token := idGen.Next("azure-openai:apikey", endpoint, key, entry.ClientID)
+		attrs := map[string]string{
+			"source":      fmt.Sprintf("config:azure-openai[%s]", token),
+			"base_url":    endpoint,
+			"api_version": strings.TrimSpace(entry.APIVersion),
+		}
+		if key != "" {
+			attrs["api_key"] = key
+		} else {
+			attrs["tenant_id"] = entry.TenantID
+			attrs["client_id"] = entry.ClientID
+			attrs["client_secret"] = entry.ClientSecret
+		}
+		if hash := diff.ComputeAzureOpenAIModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addCredentialCapsToAttrs(entry.CredentialCaps, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "azure-openai",
+			Label:      "azure-openai",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeOllamaKeys creates Auth entries for Ollama and llama.cpp servers.
+func (s *ConfigSynthesizer) synthesizeOllamaKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.Ollama))
+	for i := range cfg.Ollama {
+		entry := cfg.Ollama[i]
+		base := strings.TrimSpace(entry.BaseURL)
+		if base == "" {
+			continue
+		}
+		key := strings.TrimSpace(entry.APIKey)
+		id, token := idGen.Next("ollama:server", base, key)
+		attrs := map[string]string{
+			"source":                     fmt.Sprintf("config:ollama[%s]", token),
+			"base_url":                   base,
+			"discovery_interval_seconds": strconv.Itoa(entry.DiscoveryIntervalSeconds),
+			"load_timeout_seconds":       strconv.Itoa(entry.LoadTimeoutSeconds),
+		}
+		if entry.ContextLength > 0 {
+			attrs["context_length"] = strconv.Itoa(entry.ContextLength)
+		}
+		if key != "" {
+			attrs["api_key"] = key
+		}
+		if hash := diff.ComputeOllamaModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addCredentialCapsToAttrs(entry.CredentialCaps, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "ollama",
+			Label:      "ollama",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
@@ -435,6 +435,163 @@ func TestConfigSynthesizer_VertexCompat_SkipsEmptyAndHeaders(t *testing.T) {
 	}
 }
 
+func TestConfigSynthesizer_BedrockKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			BedrockKey: []config.BedrockKey{
+				{Region: "us-east-1", AccessKeyID: "", SecretAccessKey: "secret"}, // skipped: no access key
+				{
+					Region:          "us-west-2",
+					AccessKeyID:     "AKIDEXAMPLE",
+					SecretAccessKey: "secret",
+					SessionToken:    "token",
+					Endpoint:        "http://127.0.0.1:9000",
+					Models:          []config.BedrockModel{{Name: "anthropic.claude-3-5-haiku-20241022-v1:0", Alias: "claude-3-5-haiku"}},
+				},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 1 {
+		t.Fatalf("expected 1 auth, got %d", len(auths))
+	}
+	a := auths[0]
+	if a.Provider != "bedrock" || a.Label != "bedrock-apikey" {
+		t.Errorf("expected bedrock/bedrock-apikey, got %s/%s", a.Provider, a.Label)
+	}
+	for key, want := range map[string]string{
+		"region":            "us-west-2",
+		"access_key_id":     "AKIDEXAMPLE",
+		"secret_access_key": "secret",
+		"session_token":     "token",
+		"base_url":          "http://127.0.0.1:9000",
+	} {
+		if got := a.Attributes[key]; got != want {
+			t.Errorf("expected %s=%q, got %q", key, want, got)
+		}
+	}
+	if a.Attributes["models_hash"] == "" {
+		t.Error("expected models_hash attribute")
+	}
+}
+
+func TestConfigSynthesizer_AzureOpenAIKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			AzureOpenAI: []config.AzureOpenAIKey{
+				{Endpoint: "https://skip.openai.azure.com", TenantID: "tenant"}, // skipped: no key or client secret
+				{
+					Endpoint:   "https://keyed.openai.azure.com",
+					APIVersion: "2024-10-21",
+					APIKey:     "azure-key",
+					Models:     []config.AzureOpenAIModel{{Name: "gpt-4o", Deployment: "prod-gpt4o"}},
+				},
+				{
+					Endpoint:     "https://entra.openai.azure.com",
+					APIVersion:   "2024-10-21",
+					TenantID:     "tenant",
+					ClientID:     "client",
+					ClientSecret: "secret",
+				},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+	keyed, entra := auths[0], auths[1]
+	for _, a := range auths {
+		if a.Provider != "azure-openai" || a.Label != "azure-openai" {
+			t.Errorf("expected azure-openai/azure-openai, got %s/%s", a.Provider, a.Label)
+		}
+		if a.Attributes["api_version"] != "2024-10-21" {
+			t.Errorf("expected api_version attribute, got %q", a.Attributes["api_version"])
+		}
+	}
+	if keyed.Attributes["api_key"] != "azure-key" || keyed.Attributes["base_url"] != "https://keyed.openai.azure.com" {
+		t.Errorf("unexpected keyed attributes: %v", keyed.Attributes)
+	}
+	if keyed.Attributes["models_hash"] == "" {
+		t.Error("expected models_hash attribute")
+	}
+	if entra.Attributes["api_key"] != "" {
+		t.Errorf("expected no api_key for client credentials, got %q", entra.Attributes["api_key"])
+	}
+	for key, want := range map[string]string{"tenant_id": "tenant", "client_id": "client", "client_secret": "secret"} {
+		if got := entra.Attributes[key]; got != want {
+			t.Errorf("expected %s=%q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestConfigSynthesizer_OllamaKeys(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			Ollama: []config.OllamaKey{
+				{APIKey: "orphan"}, // skipped: no base URL
+				{
+					BaseURL:                  "http://gpu-1:11434",
+					DiscoveryIntervalSeconds: 60,
+					LoadTimeoutSeconds:       120,
+				},
+				{
+					BaseURL: "https://ollama.internal",
+					APIKey:  "gateway-key",
+					Prefix:  "gpu",
+					Models:  []config.OllamaModel{{Name: "qwen3:32b", Alias: "qwen"}},
+				},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+	local, gateway := auths[0], auths[1]
+	for _, a := range auths {
+		if a.Provider != "ollama" || a.Label != "ollama" {
+			t.Errorf("expected ollama/ollama, got %s/%s", a.Provider, a.Label)
+		}
+	}
+	if local.Attributes["base_url"] != "http://gpu-1:11434" || local.Attributes["api_key"] != "" {
+		t.Errorf("unexpected local attributes: %v", local.Attributes)
+	}
+	if local.Attributes["discovery_interval_seconds"] != "60" || local.Attributes["load_timeout_seconds"] != "120" {
+		t.Errorf("expected interval attributes, got %v", local.Attributes)
+	}
+	if gateway.Attributes["api_key"] != "gateway-key" || gateway.Prefix != "gpu" {
+		t.Errorf("unexpected gateway auth: prefix=%q attrs=%v", gateway.Prefix, gateway.Attributes)
+	}
+	if gateway.Attributes["models_hash"] == "" {
+		t.Error("expected models_hash attribute")
+	}
+	if local.ID == gateway.ID {
+		t.Error("expected distinct auth IDs")
+	}
+}
+
 func TestConfigSynthesizer_OpenAICompat_WithModelsHash(t *testing.T) {
 	synth := NewConfigSynthesizer()
 	ctx := &SynthesisContext{
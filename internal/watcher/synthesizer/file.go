@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
 	"github.com/giofahreza/AIProxyAPI/internal/runtime/geminicli"
 	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
 )
@@ -46,7 +47,7 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 			continue
 		}
 		full := filepath.Join(ctx.AuthDir, name)
-		data, errRead := os.ReadFile(full)
+		data, errRead := authcrypt.ReadFile(full)
 		if errRead != nil || len(data) == 0 {
 			continue
 		}
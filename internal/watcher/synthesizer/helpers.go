@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/giofahreza/AIProxyAPI/internal/config"
@@ -108,3 +109,34 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addCredentialCapsToAttrs copies configured usage caps into auth attributes.
+func addCredentialCapsToAttrs(caps config.CredentialCaps, attrs map[string]string) {
+	if attrs == nil {
+		return
+	}
+	if caps.MaxConcurrent > 0 {
+		attrs[coreauth.AttrMaxConcurrent] = strconv.Itoa(caps.MaxConcurrent)
+	}
+	if caps.MaxRequestsPerDay > 0 {
+		attrs[coreauth.AttrMaxRequestsPerDay] = strconv.FormatInt(caps.MaxRequestsPerDay, 10)
+	}
+	if caps.MaxTokensPerDay > 0 {
+		attrs[coreauth.AttrMaxTokensPerDay] = strconv.FormatInt(caps.MaxTokensPerDay, 10)
+	}
+}
+
+// addModelDiscoveryToAttrs records model discovery settings so changing them re-registers the
+// provider's models on reload.
+func addModelDiscoveryToAttrs(compat *config.OpenAICompatibility, attrs map[string]string) {
+	if compat == nil || !compat.DiscoverModels || attrs == nil {
+		return
+	}
+	attrs["discovery_interval_seconds"] = strconv.Itoa(compat.DiscoveryIntervalSeconds)
+	if hash := diff.ComputeExcludedModelsHash(compat.IncludeModels); hash != "" {
+		attrs["included_models_hash"] = hash
+	}
+	if hash := diff.ComputeExcludedModelsHash(compat.ExcludedModels); hash != "" {
+		attrs["excluded_models_hash"] = hash
+	}
+}
@@ -20,6 +20,7 @@ import (
 	coreexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
 	"github.com/giofahreza/AIProxyAPI/sdk/config"
 	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
@@ -46,8 +47,9 @@ type ErrorDetail struct {
 const idempotencyKeyMetadataKey = "idempotency_key"
 
 const (
-	defaultStreamingKeepAliveSeconds = 0
-	defaultStreamingBootstrapRetries = 0
+	defaultStreamingKeepAliveSeconds    = 0
+	defaultStreamingBootstrapRetries    = 0
+	defaultStreamingContinuationRetries = 0
 )
 
 // BuildErrorResponseBody builds an OpenAI-compatible JSON error response body.
@@ -125,6 +127,18 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamingContinuationRetries returns how many times a stream that already sent bytes may be resumed.
+func StreamingContinuationRetries(cfg *config.SDKConfig) int {
+	retries := defaultStreamingContinuationRetries
+	if cfg != nil {
+		retries = cfg.Streaming.ContinuationRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	return retries
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
@@ -422,6 +436,17 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
+	maxContinuationRetries := StreamingContinuationRetries(h.Cfg)
+	var continuation *streamContinuation
+	if maxContinuationRetries > 0 {
+		// Every auth that serves an attempt of this request, bootstrap retries included, is
+		// excluded from the later attempts once continuation is active.
+		if continuation = newStreamContinuation(handlerType); continuation != nil {
+			ctx = coreauth.WithStreamContinuation(ctx)
+		} else {
+			log.Debugf("stream continuation is not supported for %s clients", handlerType)
+		}
+	}
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -448,6 +473,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		defer close(errChan)
 		sentPayload := false
 		bootstrapRetries := 0
+		continuationRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
 
 		bootstrapEligible := func(err error) bool {
@@ -479,6 +505,11 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					if continuation != nil {
+						for _, out := range continuation.flush() {
+							dataChan <- out
+						}
+					}
 					return
 				}
 				if chunk.Err != nil {
@@ -496,6 +527,35 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							streamErr = retryErr
 						}
 					}
+					// Continuation: the client already received part of the answer, so resume on another
+					// auth with the delivered text as a prefill and splice the new stream in.
+					if sentPayload && continuation.resumable() && continuationRetries < maxContinuationRetries && bootstrapEligible(streamErr) {
+						continuationRetries++
+						payload := continuation.continuationPayload(rawJSON)
+						contReq := req
+						contReq.Payload = cloneBytes(payload)
+						contOpts := opts
+						contOpts.OriginalRequest = cloneBytes(payload)
+						retryChunks, retryErr := h.AuthManager.ExecuteStream(ctx, providers, contReq, contOpts)
+						if retryErr == nil {
+							log.Debugf("resuming interrupted stream for model %s (attempt %d): %v", normalizedModel, continuationRetries, streamErr)
+							for _, out := range continuation.resume() {
+								dataChan <- out
+							}
+							chunks = retryChunks
+							continue outer
+						}
+						streamErr = retryErr
+					}
+					// A stream that already delivered complete tool calls cannot be extended, since the
+					// provider expects their results next; end the turn so the client runs the calls.
+					if sentPayload && continuation.endsWithToolCalls() && bootstrapEligible(streamErr) {
+						log.Debugf("ending interrupted stream for model %s after its tool calls: %v", normalizedModel, streamErr)
+						for _, out := range continuation.finishToolCalls() {
+							dataChan <- out
+						}
+						return
+					}
 
 					status := http.StatusInternalServerError
 					if se, ok := streamErr.(interface{ StatusCode() int }); ok && se != nil {
@@ -514,7 +574,13 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				}
 				if len(chunk.Payload) > 0 {
 					sentPayload = true
-					dataChan <- cloneBytes(chunk.Payload)
+					if continuation == nil {
+						dataChan <- cloneBytes(chunk.Payload)
+						continue
+					}
+					for _, out := range continuation.process(cloneBytes(chunk.Payload)) {
+						dataChan <- out
+					}
 				}
 			}
 		}
@@ -535,6 +601,12 @@ func statusFromError(err error) int {
 }
 
 func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
+	return ResolveModelRoute(modelName)
+}
+
+// ResolveModelRoute resolves a requested model name to its providers, normalized model and
+// thinking metadata exactly as the execution paths do.
+func ResolveModelRoute(modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
 	// Resolve "auto" model to an actual available model first
 	resolvedModelName := util.ResolveAutoModel(modelName)
 
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/giofahreza/AIProxyAPI/internal/constant"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	coreexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdkconfig "github.com/giofahreza/AIProxyAPI/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type breakMidStreamExecutor struct {
+	mu       sync.Mutex
+	authIDs  []string
+	payloads [][]byte
+}
+
+func (e *breakMidStreamExecutor) Identifier() string { return "claude" }
+
+func (e *breakMidStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *breakMidStreamExecutor) ExecuteStream(_ context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.authIDs = append(e.authIDs, auth.ID)
+	e.payloads = append(e.payloads, req.Payload)
+	call := len(e.authIDs)
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 8)
+	ch <- coreexecutor.StreamChunk{Payload: []byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_" + auth.ID + "\"}}\n\n")}
+	ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n")}
+	if call == 1 {
+		ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello wor\"}}\n\n")}
+		ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream", Message: "connection reset", HTTPStatus: http.StatusBadGateway}}
+		close(ch)
+		return ch, nil
+	}
+	ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"ld!\"}}\n\n")}
+	ch <- coreexecutor.StreamChunk{Payload: []byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n")}
+	ch <- coreexecutor.StreamChunk{Payload: []byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")}
+	close(ch)
+	return ch, nil
+}
+
+func (e *breakMidStreamExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *breakMidStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func TestExecuteStreamWithAuthManager_ContinuesBrokenStream(t *testing.T) {
+	executor := &breakMidStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	for _, id := range []string{"cont-auth1", "cont-auth2"} {
+		auth := &coreauth.Auth{ID: id, Provider: "claude", Status: coreauth.StatusActive, Metadata: map[string]any{"email": id + "@example.com"}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "cont-model"}})
+		clientID := id
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(clientID) })
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{ContinuationRetries: 1},
+	}, manager)
+	raw := []byte(`{"model":"cont-model","messages":[{"role":"user","content":"hi"}]}`)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "claude", "cont-model", raw, "")
+
+	var got strings.Builder
+	for chunk := range dataChan {
+		got.Write(chunk)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	if len(executor.authIDs) != 2 || executor.authIDs[0] == executor.authIDs[1] {
+		t.Fatalf("expected continuation on a different auth, got %v", executor.authIDs)
+	}
+	prefill := gjson.GetBytes(executor.payloads[1], "messages.1")
+	if prefill.Get("role").String() != "assistant" || prefill.Get("content").String() != "Hello wor" {
+		t.Fatalf("expected assistant prefill, got %s", executor.payloads[1])
+	}
+
+	out := got.String()
+	if n := strings.Count(out, "event: message_start"); n != 1 {
+		t.Fatalf("expected a single message_start, got %d in %q", n, out)
+	}
+	if n := strings.Count(out, "event: content_block_start"); n != 1 {
+		t.Fatalf("expected the continuation to reuse the open text block, got %d starts in %q", n, out)
+	}
+	var text strings.Builder
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "data: ") {
+			text.WriteString(gjson.Get(strings.TrimPrefix(line, "data: "), "delta.text").String())
+		}
+	}
+	if text.String() != "Hello world!" {
+		t.Fatalf("expected spliced text %q, got %q", "Hello world!", text.String())
+	}
+}
+
+// streamBreak in a scripted stream makes the stream fail at that point.
+const streamBreak = "\x00break"
+
+// scriptedStreamExecutor serves streams[i] to the i-th call, or the last stream once they run out.
+type scriptedStreamExecutor struct {
+	breakMidStreamExecutor
+	streams [][]string
+}
+
+func (e *scriptedStreamExecutor) ExecuteStream(_ context.Context, auth *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.authIDs = append(e.authIDs, auth.ID)
+	e.payloads = append(e.payloads, req.Payload)
+	stream := e.streams[min(len(e.authIDs), len(e.streams))-1]
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, len(stream))
+	for _, chunk := range stream {
+		if chunk == streamBreak {
+			ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream", Message: "connection reset", HTTPStatus: http.StatusBadGateway}}
+			break
+		}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+	}
+	close(ch)
+	return ch, nil
+}
+
+// runContinuation streams a request through a handler with continuation enabled and two auths
+// served by executor, and returns what the client received.
+func runContinuation(t *testing.T, executor coreauth.ProviderExecutor, handlerType, model string, raw []byte) string {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{model + "-auth1", model + "-auth2"} {
+		auth := &coreauth.Auth{ID: id, Provider: "claude", Status: coreauth.StatusActive, Metadata: map[string]any{"email": id + "@example.com"}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "claude", []*registry.ModelInfo{{ID: model}})
+		clientID := id
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(clientID) })
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{ContinuationRetries: 1},
+	}, manager)
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), handlerType, model, raw, "")
+	var got strings.Builder
+	for chunk := range dataChan {
+		got.Write(chunk)
+		got.WriteString("\n")
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	return got.String()
+}
+
+func sseData(out string) []gjson.Result {
+	var events []gjson.Result
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "data: ") {
+			events = append(events, gjson.Parse(strings.TrimPrefix(line, "data: ")))
+		}
+	}
+	return events
+}
+
+func TestExecuteStreamWithAuthManager_ContinuesResponsesStream(t *testing.T) {
+	executor := &scriptedStreamExecutor{streams: [][]string{{
+		// Translated streams carry each event in one chunk.
+		"event: response.created\ndata: {\"type\":\"response.created\",\"sequence_number\":0,\"response\":{\"id\":\"resp_1\",\"created_at\":1,\"status\":\"in_progress\",\"output\":[]}}",
+		"event: response.output_item.added\ndata: {\"type\":\"response.output_item.added\",\"sequence_number\":1,\"output_index\":0,\"item\":{\"id\":\"msg_1\",\"type\":\"message\",\"status\":\"in_progress\",\"content\":[],\"role\":\"assistant\"}}",
+		"event: response.content_part.added\ndata: {\"type\":\"response.content_part.added\",\"sequence_number\":2,\"item_id\":\"msg_1\",\"output_index\":0,\"content_index\":0,\"part\":{\"type\":\"output_text\",\"text\":\"\"}}",
+		"event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"sequence_number\":3,\"item_id\":\"msg_1\",\"output_index\":0,\"content_index\":0,\"delta\":\"Hello wor\"}",
+		streamBreak,
+	}, {
+		// Passthrough streams send the event and data lines as separate chunks.
+		"event: response.created", `data: {"type":"response.created","sequence_number":0,"response":{"id":"resp_2","created_at":2,"status":"in_progress","output":[]}}`, "",
+		"event: response.output_item.added", `data: {"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"id":"msg_2","type":"message","status":"in_progress","content":[],"role":"assistant"}}`, "",
+		"event: response.content_part.added", `data: {"type":"response.content_part.added","sequence_number":2,"item_id":"msg_2","output_index":0,"content_index":0,"part":{"type":"output_text","text":""}}`, "",
+		"event: response.output_text.delta", `data: {"type":"response.output_text.delta","sequence_number":3,"item_id":"msg_2","output_index":0,"content_index":0,"delta":"ld!"}`, "",
+		"event: response.output_text.done", `data: {"type":"response.output_text.done","sequence_number":4,"item_id":"msg_2","output_index":0,"content_index":0,"text":"ld!"}`, "",
+		"event: response.content_part.done", `data: {"type":"response.content_part.done","sequence_number":5,"item_id":"msg_2","output_index":0,"content_index":0,"part":{"type":"output_text","text":"ld!"}}`, "",
+		"event: response.output_item.done", `data: {"type":"response.output_item.done","sequence_number":6,"output_index":0,"item":{"id":"msg_2","type":"message","status":"completed","content":[{"type":"output_text","text":"ld!"}],"role":"assistant"}}`, "",
+		"event: response.completed", `data: {"type":"response.completed","sequence_number":7,"response":{"id":"resp_2","created_at":2,"status":"completed","output":[{"id":"msg_2","type":"message","content":[{"type":"output_text","text":"ld!"}]}]}}`, "",
+	}}}
+
+	out := runContinuation(t, executor, constant.OpenaiResponse, "resp-cont-model", []byte(`{"model":"resp-cont-model","input":"hi"}`))
+
+	if len(executor.authIDs) != 2 || executor.authIDs[0] == executor.authIDs[1] {
+		t.Fatalf("expected continuation on a different auth, got %v", executor.authIDs)
+	}
+	input := gjson.GetBytes(executor.payloads[1], "input")
+	if input.Get("0.content.0.text").String() != "hi" || input.Get("1.role").String() != "assistant" || input.Get("1.content.0.text").String() != "Hello wor" {
+		t.Fatalf("expected the user input followed by an assistant prefill, got %s", executor.payloads[1])
+	}
+
+	events := sseData(out)
+	var text strings.Builder
+	counts := make(map[string]int)
+	for i, event := range events {
+		if got := event.Get("sequence_number").Int(); got != int64(i) {
+			t.Fatalf("event %d sequence_number = %d, want contiguous numbering in %q", i, got, out)
+		}
+		if id := event.Get("item_id"); id.Exists() && id.String() != "msg_1" {
+			t.Fatalf("event %d item_id = %q, want the interrupted item msg_1", i, id.String())
+		}
+		counts[event.Get("type").String()]++
+		text.WriteString(event.Get("delta").String())
+	}
+	if counts["response.created"] != 1 || counts["response.output_item.added"] != 1 || counts["response.content_part.added"] != 1 {
+		t.Fatalf("expected the continuation to reuse the open response and item, got %v", counts)
+	}
+	if text.String() != "Hello world!" {
+		t.Fatalf("expected spliced text %q, got %q", "Hello world!", text.String())
+	}
+	last := events[len(events)-1]
+	if last.Get("type").String() != "response.completed" || last.Get("response.id").String() != "resp_1" {
+		t.Fatalf("expected the original response to complete, got %s", last.Raw)
+	}
+	if output := last.Get("response.output"); len(output.Array()) != 1 || output.Get("0.id").String() != "msg_1" || output.Get("0.content.0.text").String() != "Hello world!" {
+		t.Fatalf("expected the completed output to hold the whole message, got %s", output.Raw)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_EndsBrokenStreamAfterToolCalls(t *testing.T) {
+	executor := &scriptedStreamExecutor{streams: [][]string{{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"f\",\"input\":{}}}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{}\"}}\n\n",
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Let me\"}}\n\n",
+		streamBreak,
+	}}}
+
+	out := runContinuation(t, executor, "claude", "tool-cont-model", []byte(`{"model":"tool-cont-model","messages":[{"role":"user","content":"hi"}]}`))
+
+	if len(executor.authIDs) != 1 {
+		t.Fatalf("expected no continuation after a complete tool call, got attempts on %v", executor.authIDs)
+	}
+	events := sseData(out)
+	if len(events) < 3 {
+		t.Fatalf("expected closing events, got %q", out)
+	}
+	tail := events[len(events)-3:]
+	if tail[0].Get("type").String() != "content_block_stop" || tail[0].Get("index").Int() != 1 {
+		t.Fatalf("expected the open text block to be closed, got %s", tail[0].Raw)
+	}
+	if tail[1].Get("type").String() != "message_delta" || tail[1].Get("delta.stop_reason").String() != "tool_use" {
+		t.Fatalf("expected a tool_use stop reason, got %s", tail[1].Raw)
+	}
+	if tail[2].Get("type").String() != "message_stop" {
+		t.Fatalf("expected message_stop, got %s", tail[2].Raw)
+	}
+}
+
+func TestStreamContinuation_FinishToolCallsPerFormat(t *testing.T) {
+	cont := newStreamContinuation("openai")
+	cont.process([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"f","arguments":"{}"}}]}}]}`))
+	cont.process([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"Let"}}]}`))
+	out := cont.finishToolCalls()
+	if !cont.endsWithToolCalls() || len(out) != 1 || gjson.GetBytes(out[0], "choices.0.finish_reason").String() != "tool_calls" || gjson.GetBytes(out[0], "id").String() != "chatcmpl-1" {
+		t.Fatalf("expected a tool_calls finish chunk, got %q", out)
+	}
+
+	cont = newStreamContinuation(constant.OpenaiResponse)
+	cont.process([]byte(`event: response.created` + "\n" + `data: {"type":"response.created","sequence_number":0,"response":{"id":"resp_1","status":"in_progress","output":[]}}`))
+	cont.process([]byte(`event: response.output_item.added` + "\n" + `data: {"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"f","arguments":""}}`))
+	if out := cont.process([]byte(`event: response.output_item.done` + "\n" + `data: {"type":"response.output_item.done","sequence_number":2,"output_index":0,"item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"f","arguments":"{}"}}`)); len(out) != 2 {
+		t.Fatalf("expected the complete call to be released, got %q", out)
+	}
+	cont.process([]byte(`event: response.output_item.added` + "\n" + `data: {"type":"response.output_item.added","sequence_number":3,"output_index":1,"item":{"id":"fc_2","type":"function_call","call_id":"call_2","name":"g","arguments":""}}`))
+	events := sseData(string(bytes.Join(cont.finishToolCalls(), []byte("\n"))))
+	if len(events) != 1 || events[0].Get("type").String() != "response.completed" || events[0].Get("sequence_number").Int() != 3 {
+		t.Fatalf("expected only response.completed after the partial call, got %v", events)
+	}
+	if output := events[0].Get("response.output"); events[0].Get("response.id").String() != "resp_1" || len(output.Array()) != 1 || output.Get("0.call_id").String() != "call_1" {
+		t.Fatalf("expected the completed response to list the delivered call, got %s", events[0].Raw)
+	}
+}
+
+func TestStreamContinuation_OpenAIDropsRepeatedText(t *testing.T) {
+	cont := newStreamContinuation("openai")
+	cont.process([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"role":"assistant","content":"The quick"}}]}`))
+	cont.process([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"f"}}]}}]}`))
+
+	payload := cont.continuationPayload([]byte(`{"messages":[{"role":"user","content":"go"}]}`))
+	if got := gjson.GetBytes(payload, "messages.1.content").String(); got != "The quick" {
+		t.Fatalf("expected prefill %q, got %q", "The quick", got)
+	}
+	cont.resume()
+
+	if out := cont.process([]byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"role":"assistant","content":"The "}}]}`)); len(out) != 0 {
+		t.Fatalf("expected repeated text to be dropped, got %q", out)
+	}
+	out := cont.process([]byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"content":"quick brown fox"}}]}`))
+	if len(out) != 1 {
+		t.Fatalf("expected one chunk, got %d", len(out))
+	}
+	if got := gjson.GetBytes(out[0], "choices.0.delta.content").String(); got != " brown fox" {
+		t.Fatalf("expected de-duplicated content, got %q", got)
+	}
+	if got := gjson.GetBytes(out[0], "id").String(); got != "chatcmpl-1" {
+		t.Fatalf("expected original chunk id, got %q", got)
+	}
+}
+
+func claudeDeltaText(chunks [][]byte) string {
+	var text strings.Builder
+	for _, chunk := range chunks {
+		for _, line := range strings.Split(string(chunk), "\n") {
+			if strings.HasPrefix(line, "data: ") {
+				text.WriteString(gjson.Get(strings.TrimPrefix(line, "data: "), "delta.text").String())
+			}
+		}
+	}
+	return text.String()
+}
+
+func TestStreamContinuation_ClaudeKeepsNewTextStartingLikeDelivered(t *testing.T) {
+	cont := newStreamContinuation("claude")
+	cont.process([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"))
+	cont.process([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"The cat. \"}}\n\n"))
+	cont.resume()
+
+	// Claude honours the prefill, so the continuation carries on after "The cat." and its first
+	// word merely coincides with the start of the delivered text.
+	var out [][]byte
+	out = append(out, cont.process([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"))...)
+	out = append(out, cont.process([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" The\"}}\n\n"))...)
+	out = append(out, cont.process([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" dog sat.\"}}\n\n"))...)
+	if got := claudeDeltaText(out); got != "The dog sat." {
+		t.Fatalf("continuation text = %q, want %q", got, "The dog sat.")
+	}
+
+	// Text that matches the delivered start until the block ends is released before the stop.
+	cont = newStreamContinuation("claude")
+	cont.process([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n"))
+	cont.process([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"The cat. \"}}\n\n"))
+	cont.resume()
+	out = cont.process([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"The\"}}\n\n"))
+	if len(out) != 0 {
+		t.Fatalf("expected ambiguous text to be held, got %q", out)
+	}
+	out = cont.process([]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"))
+	if len(out) != 2 || claudeDeltaText(out[:1]) != "The" || !strings.Contains(string(out[1]), "content_block_stop") {
+		t.Fatalf("expected held text before the block stop, got %q", out)
+	}
+}
+
+func TestStreamContinuation_OpenAIReleasesHeldTextAtFinish(t *testing.T) {
+	cont := newStreamContinuation("openai")
+	cont.process([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"The cat. "}}]}`))
+	cont.resume()
+
+	if out := cont.process([]byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"content":"The"}}]}`)); len(out) != 0 {
+		t.Fatalf("expected ambiguous text to be held, got %q", out)
+	}
+	out := cont.process([]byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`))
+	if len(out) != 2 || gjson.GetBytes(out[0], "choices.0.delta.content").String() != "The" || gjson.GetBytes(out[1], "choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("expected held text before the finish chunk, got %q", out)
+	}
+	if got := gjson.GetBytes(out[0], "id").String(); got != "chatcmpl-1" {
+		t.Fatalf("expected original chunk id, got %q", got)
+	}
+}
@@ -0,0 +1,474 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/giofahreza/AIProxyAPI/internal/constant"
+	"github.com/giofahreza/AIProxyAPI/internal/interfaces"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	"github.com/giofahreza/AIProxyAPI/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxImagesPerRequest is the largest n the Images API accepts.
+	maxImagesPerRequest = 10
+
+	// maxImageUploadSize bounds each image and mask uploaded to /v1/images/edits.
+	maxImageUploadSize = 20 << 20
+
+	imageResponseFormatB64 = "b64_json"
+	imageResponseFormatURL = "url"
+
+	// GeneratedImagePath is the prefix of the URLs returned for response_format=url.
+	GeneratedImagePath = "/v1/images/files/"
+)
+
+// geminiAspectRatios are the aspect ratios Gemini image models accept.
+var geminiAspectRatios = []struct {
+	name  string
+	ratio float64
+}{
+	{"1:1", 1}, {"2:3", 2.0 / 3}, {"3:2", 3.0 / 2}, {"3:4", 3.0 / 4}, {"4:3", 4.0 / 3},
+	{"4:5", 4.0 / 5}, {"5:4", 5.0 / 4}, {"9:16", 9.0 / 16}, {"16:9", 16.0 / 9}, {"21:9", 21.0 / 9},
+}
+
+// OpenAIImagesAPIHandler serves the OpenAI Images API with Gemini image models. Every requested
+// image is one generateContent call, so usage is accounted per image by the executors.
+type OpenAIImagesAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOpenAIImagesAPIHandler creates a new OpenAI Images API handlers instance.
+func NewOpenAIImagesAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIImagesAPIHandler {
+	return &OpenAIImagesAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation. Requests are built in the
+// Gemini format, so Gemini is the format translators start from.
+func (h *OpenAIImagesAPIHandler) HandlerType() string {
+	return Gemini
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIImagesAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+// imageRequest is a generation or edit request in the form shared by both endpoints.
+type imageRequest struct {
+	Model          string
+	Prompt         string
+	N              int
+	Size           string
+	ResponseFormat string
+	// Images are the pictures to edit; Mask marks the area to change in them.
+	Images []imageBlob
+	Mask   *imageBlob
+}
+
+type imageBlob struct {
+	mimeType string
+	data     []byte
+}
+
+// generatedImage is an image returned by the model, still base64 encoded.
+type generatedImage struct {
+	mimeType string
+	b64      string
+}
+
+type imageData struct {
+	B64JSON       string `json:"b64_json,omitempty"`
+	URL           string `json:"url,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+type imageUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+	TotalTokens  int64 `json:"total_tokens"`
+}
+
+type imagesResponse struct {
+	Created int64       `json:"created"`
+	Data    []imageData `json:"data"`
+	Usage   imageUsage  `json:"usage"`
+}
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+func (h *OpenAIImagesAPIHandler) ImageGenerations(c *gin.Context) {
+	var body struct {
+		Model          string `json:"model"`
+		Prompt         string `json:"prompt"`
+		N              int    `json:"n"`
+		Size           string `json:"size"`
+		ResponseFormat string `json:"response_format"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeImageRequestError(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	h.generateImages(c, imageRequest{
+		Model:          body.Model,
+		Prompt:         body.Prompt,
+		N:              body.N,
+		Size:           body.Size,
+		ResponseFormat: body.ResponseFormat,
+	})
+}
+
+// ImageEdits handles the multipart /v1/images/edits endpoint. It accepts one or more "image"
+// (or "image[]") files and an optional "mask".
+func (h *OpenAIImagesAPIHandler) ImageEdits(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		writeImageRequestError(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	req := imageRequest{
+		Model:          formValue(form, "model"),
+		Prompt:         formValue(form, "prompt"),
+		Size:           formValue(form, "size"),
+		ResponseFormat: formValue(form, "response_format"),
+	}
+	if raw := formValue(form, "n"); raw != "" {
+		if req.N, err = strconv.Atoi(raw); err != nil {
+			writeImageRequestError(c, fmt.Sprintf("Invalid n: %q", raw))
+			return
+		}
+	}
+	files := make([]*multipart.FileHeader, 0, len(form.File["image"])+len(form.File["image[]"]))
+	files = append(append(files, form.File["image"]...), form.File["image[]"]...)
+	if len(files) == 0 {
+		writeImageRequestError(c, "image is required")
+		return
+	}
+	for _, file := range files {
+		image, errRead := readImageUpload(file)
+		if errRead != nil {
+			writeImageRequestError(c, errRead.Error())
+			return
+		}
+		req.Images = append(req.Images, image)
+	}
+	if masks := form.File["mask"]; len(masks) > 0 {
+		mask, errRead := readImageUpload(masks[0])
+		if errRead != nil {
+			writeImageRequestError(c, errRead.Error())
+			return
+		}
+		req.Mask = &mask
+	}
+	h.generateImages(c, req)
+}
+
+// GeneratedImage serves an image kept for a response_format=url request. The URLs carry no API
+// key, like those of the OpenAI API, and stop working after imageStoreTTL.
+func (h *OpenAIImagesAPIHandler) GeneratedImage(c *gin.Context) {
+	image, ok := generatedImages.get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Image not found or expired",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(int(imageStoreTTL.Seconds())))
+	c.Data(http.StatusOK, image.mimeType, image.data)
+}
+
+func (h *OpenAIImagesAPIHandler) generateImages(c *gin.Context, req imageRequest) {
+	req.Model = strings.TrimSpace(req.Model)
+	if req.Model == "" {
+		writeImageRequestError(c, "model is required")
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeImageRequestError(c, "prompt is required")
+		return
+	}
+	if req.N == 0 {
+		req.N = 1
+	}
+	if req.N < 1 || req.N > maxImagesPerRequest {
+		writeImageRequestError(c, fmt.Sprintf("n must be between 1 and %d", maxImagesPerRequest))
+		return
+	}
+	switch req.ResponseFormat {
+	case "":
+		req.ResponseFormat = imageResponseFormatB64
+	case imageResponseFormatB64, imageResponseFormatURL:
+	default:
+		writeImageRequestError(c, fmt.Sprintf("response_format must be %q or %q", imageResponseFormatB64, imageResponseFormatURL))
+		return
+	}
+	aspectRatio, imageSize, err := geminiImageConfig(req.Size)
+	if err != nil {
+		writeImageRequestError(c, err.Error())
+		return
+	}
+	payload := buildGeminiImageRequest(req, aspectRatio, imageSize)
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	var (
+		images        []generatedImage
+		revisedPrompt string
+		usage         imageUsage
+	)
+	for len(images) < req.N {
+		resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), req.Model, payload, "")
+		if errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		generated, text, respUsage, errParse := parseGeminiImages(resp)
+		if errParse != nil {
+			errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errParse}
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		images = append(images, generated...)
+		if revisedPrompt == "" {
+			revisedPrompt = text
+		}
+		usage.InputTokens += respUsage.InputTokens
+		usage.OutputTokens += respUsage.OutputTokens
+		usage.TotalTokens += respUsage.TotalTokens
+	}
+
+	out := imagesResponse{Created: time.Now().Unix(), Data: make([]imageData, 0, req.N), Usage: usage}
+	for _, image := range images[:req.N] {
+		item := imageData{RevisedPrompt: revisedPrompt}
+		if req.ResponseFormat == imageResponseFormatB64 {
+			item.B64JSON = image.b64
+			out.Data = append(out.Data, item)
+			continue
+		}
+		data, errDecode := base64.StdEncoding.DecodeString(image.b64)
+		if errDecode != nil {
+			errMsg := &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("decode generated image: %w", errDecode)}
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		id, errStore := generatedImages.put(image.mimeType, data)
+		if errStore != nil {
+			errMsg := &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errStore}
+			h.WriteErrorResponse(c, errMsg)
+			cliCancel(errMsg.Error)
+			return
+		}
+		item.URL = generatedImageURL(c, h.publicBaseURL(), id)
+		out.Data = append(out.Data, item)
+	}
+	c.JSON(http.StatusOK, out)
+	cliCancel()
+}
+
+// geminiImageConfig maps an OpenAI size such as "1792x1024" to the closest Gemini aspect ratio.
+// Sizes of 2048 pixels or more also ask for a larger image, which only Gemini 3 models support.
+func geminiImageConfig(size string) (aspectRatio, imageSize string, err error) {
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size == "" || size == "auto" {
+		return "", "", nil
+	}
+	rawWidth, rawHeight, ok := strings.Cut(size, "x")
+	width, errWidth := strconv.Atoi(rawWidth)
+	height, errHeight := strconv.Atoi(rawHeight)
+	if !ok || errWidth != nil || errHeight != nil || width <= 0 || height <= 0 {
+		return "", "", fmt.Errorf("invalid size %q, expected WIDTHxHEIGHT or auto", size)
+	}
+	ratio := float64(width) / float64(height)
+	best := math.Inf(1)
+	for _, candidate := range geminiAspectRatios {
+		if distance := math.Abs(math.Log(ratio / candidate.ratio)); distance < best {
+			best, aspectRatio = distance, candidate.name
+		}
+	}
+	switch longest := max(width, height); {
+	case longest >= 4096:
+		imageSize = "4K"
+	case longest >= 2048:
+		imageSize = "2K"
+	}
+	return aspectRatio, imageSize, nil
+}
+
+// buildGeminiImageRequest builds the generateContent request for one image of req.
+func buildGeminiImageRequest(req imageRequest, aspectRatio, imageSize string) []byte {
+	payload := []byte(`{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseModalities":["TEXT","IMAGE"]}}`)
+	payload = appendTextPart(payload, req.Prompt)
+	for _, image := range req.Images {
+		payload = appendInlinePart(payload, image)
+	}
+	if req.Mask != nil {
+		payload = appendTextPart(payload, "The next image is a mask. Change only the areas where it is transparent and keep the rest unchanged.")
+		payload = appendInlinePart(payload, *req.Mask)
+	}
+	if aspectRatio != "" {
+		payload, _ = sjson.SetBytes(payload, "generationConfig.imageConfig.aspectRatio", aspectRatio)
+	}
+	if imageSize != "" {
+		payload, _ = sjson.SetBytes(payload, "generationConfig.imageConfig.imageSize", imageSize)
+	}
+	return payload
+}
+
+func appendTextPart(payload []byte, text string) []byte {
+	part, _ := sjson.Set(`{}`, "text", text)
+	payload, _ = sjson.SetRawBytes(payload, "contents.0.parts.-1", []byte(part))
+	return payload
+}
+
+func appendInlinePart(payload []byte, image imageBlob) []byte {
+	part, _ := sjson.Set(`{}`, "inlineData.mimeType", image.mimeType)
+	part, _ = sjson.Set(part, "inlineData.data", base64.StdEncoding.EncodeToString(image.data))
+	payload, _ = sjson.SetRawBytes(payload, "contents.0.parts.-1", []byte(part))
+	return payload
+}
+
+// parseGeminiImages returns the images, the accompanying text and the token usage of a Gemini
+// response. Drafts emitted while thinking are skipped. A response without images is an error
+// that carries the finish reason and text, which usually explain the refusal.
+func parseGeminiImages(resp []byte) ([]generatedImage, string, imageUsage, error) {
+	root := gjson.ParseBytes(resp)
+	if !root.Get("candidates").Exists() && root.Get("response").Exists() {
+		root = root.Get("response")
+	}
+	var (
+		images []generatedImage
+		texts  []string
+	)
+	root.Get("candidates").ForEach(func(_, candidate gjson.Result) bool {
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			if part.Get("thought").Bool() {
+				return true
+			}
+			if text := strings.TrimSpace(part.Get("text").String()); text != "" {
+				texts = append(texts, text)
+				return true
+			}
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			mimeType := inline.Get("mimeType").String()
+			if mimeType == "" {
+				mimeType = inline.Get("mime_type").String()
+			}
+			if data := inline.Get("data").String(); strings.HasPrefix(mimeType, "image/") && data != "" {
+				images = append(images, generatedImage{mimeType: mimeType, b64: data})
+			}
+			return true
+		})
+		return true
+	})
+
+	usageNode := root.Get("usageMetadata")
+	usage := imageUsage{
+		InputTokens:  usageNode.Get("promptTokenCount").Int(),
+		OutputTokens: usageNode.Get("candidatesTokenCount").Int() + usageNode.Get("thoughtsTokenCount").Int(),
+		TotalTokens:  usageNode.Get("totalTokenCount").Int(),
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+
+	text := strings.Join(texts, "\n")
+	if len(images) == 0 {
+		reason := root.Get("candidates.0.finishReason").String()
+		if reason == "" {
+			reason = root.Get("promptFeedback.blockReason").String()
+		}
+		msg := "model returned no image"
+		if reason != "" {
+			msg += " (" + reason + ")"
+		}
+		if text != "" {
+			msg += ": " + text
+		}
+		return nil, text, usage, fmt.Errorf("%s", msg)
+	}
+	return images, text, usage, nil
+}
+
+// readImageUpload reads an uploaded image, sniffing its type when the part does not name one.
+func readImageUpload(file *multipart.FileHeader) (imageBlob, error) {
+	if file.Size > maxImageUploadSize {
+		return imageBlob{}, fmt.Errorf("%s is larger than %d MB", file.Filename, maxImageUploadSize>>20)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return imageBlob{}, fmt.Errorf("read %s: %w", file.Filename, err)
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(io.LimitReader(f, maxImageUploadSize+1))
+	if err != nil {
+		return imageBlob{}, fmt.Errorf("read %s: %w", file.Filename, err)
+	}
+	if len(data) > maxImageUploadSize {
+		return imageBlob{}, fmt.Errorf("%s is larger than %d MB", file.Filename, maxImageUploadSize>>20)
+	}
+	mimeType := file.Header.Get("Content-Type")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return imageBlob{}, fmt.Errorf("%s is not an image", file.Filename)
+	}
+	return imageBlob{mimeType: mimeType, data: data}, nil
+}
+
+func (h *OpenAIImagesAPIHandler) publicBaseURL() string {
+	if h.Cfg == nil {
+		return ""
+	}
+	return h.Cfg.PublicBaseURL
+}
+
+// generatedImageURL returns the absolute URL of a stored image. Behind a reverse proxy the
+// configured public base URL is used, since forwarded headers can be set by any client.
+func generatedImageURL(c *gin.Context, publicBaseURL, id string) string {
+	if base := strings.TrimRight(strings.TrimSpace(publicBaseURL), "/"); base != "" {
+		return base + GeneratedImagePath + id
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + GeneratedImagePath + id
+}
+
+func formValue(form *multipart.Form, key string) string {
+	if values := form.Value[key]; len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+func writeImageRequestError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,208 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	"github.com/giofahreza/AIProxyAPI/sdk/api/handlers"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	coreexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	sdkconfig "github.com/giofahreza/AIProxyAPI/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type imageStubExecutor struct {
+	mu       sync.Mutex
+	payloads [][]byte
+}
+
+func (e *imageStubExecutor) Identifier() string { return "gemini" }
+
+func (e *imageStubExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, req.Payload)
+	e.mu.Unlock()
+	image := base64.StdEncoding.EncodeToString([]byte("png-bytes"))
+	return coreexecutor.Response{Payload: []byte(`{"candidates":[{"content":{"parts":[` +
+		`{"inlineData":{"mimeType":"image/png","data":"draft"},"thought":true},` +
+		`{"text":"A red fox"},` +
+		`{"inlineData":{"mimeType":"image/png","data":"` + image + `"}}]}}],` +
+		`"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":1290,"totalTokenCount":1300}}`)}, nil
+}
+
+func (e *imageStubExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *imageStubExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *imageStubExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func newImagesTestRouter(t *testing.T) (*gin.Engine, *imageStubExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &imageStubExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "images-auth", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "gemini-2.5-flash-image"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIImagesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/images/generations", h.ImageGenerations)
+	router.POST("/v1/images/edits", h.ImageEdits)
+	router.GET(GeneratedImagePath+":id", h.GeneratedImage)
+	return router, executor
+}
+
+func TestImageGenerations_URLResponse(t *testing.T) {
+	router, executor := newImagesTestRouter(t)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/generations",
+		strings.NewReader(`{"model":"gemini-2.5-flash-image","prompt":"a fox","n":2,"size":"1792x1024","response_format":"url"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	if len(executor.payloads) != 2 {
+		t.Fatalf("upstream calls = %d, want one per image", len(executor.payloads))
+	}
+	payload := executor.payloads[0]
+	if got := gjson.GetBytes(payload, "generationConfig.imageConfig.aspectRatio").String(); got != "16:9" {
+		t.Fatalf("aspect ratio = %q", got)
+	}
+	if gjson.GetBytes(payload, "generationConfig.imageConfig.imageSize").Exists() {
+		t.Fatalf("image size set for a 1K request: %s", payload)
+	}
+	if got := gjson.GetBytes(payload, "contents.0.parts.0.text").String(); got != "a fox" {
+		t.Fatalf("prompt = %q", got)
+	}
+
+	var resp imagesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Data) != 2 || resp.Usage.TotalTokens != 2600 || resp.Data[0].RevisedPrompt != "A red fox" {
+		t.Fatalf("response = %+v", resp)
+	}
+	url := resp.Data[0].URL
+	if !strings.HasPrefix(url, "http://example.com"+GeneratedImagePath) || resp.Data[0].B64JSON != "" {
+		t.Fatalf("url = %q", url)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(url, "http://example.com"), nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "png-bytes" || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("stored image: status = %d, type = %q, body = %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestGeneratedImageURL_IgnoresForwardedHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/generations", nil)
+	c.Request.Header.Set("X-Forwarded-Host", "attacker.example")
+	c.Request.Header.Set("X-Forwarded-Proto", "https")
+
+	if got := generatedImageURL(c, "", "abc"); got != "http://example.com"+GeneratedImagePath+"abc" {
+		t.Fatalf("generatedImageURL() = %q, want the request host", got)
+	}
+	if got := generatedImageURL(c, "https://proxy.example.com/", "abc"); got != "https://proxy.example.com"+GeneratedImagePath+"abc" {
+		t.Fatalf("generatedImageURL() = %q, want the public base URL", got)
+	}
+}
+
+func TestImageEdits_MultipartWithMask(t *testing.T) {
+	router, executor := newImagesTestRouter(t)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("model", "gemini-2.5-flash-image")
+	_ = form.WriteField("prompt", "add a hat")
+	image, _ := form.CreateFormFile("image[]", "photo.png")
+	_, _ = image.Write([]byte("\x89PNG\r\n\x1a\nphoto"))
+	mask, _ := form.CreateFormFile("mask", "mask.png")
+	_, _ = mask.Write([]byte("\x89PNG\r\n\x1a\nmask"))
+	_ = form.Close()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/images/edits", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if got := gjson.GetBytes(rec.Body.Bytes(), "data.0.b64_json").String(); got != base64.StdEncoding.EncodeToString([]byte("png-bytes")) {
+		t.Fatalf("b64_json = %q", got)
+	}
+
+	parts := gjson.GetBytes(executor.payloads[0], "contents.0.parts").Array()
+	if len(parts) != 4 || parts[1].Get("inlineData.mimeType").String() != "image/png" || parts[3].Get("inlineData.data").String() != base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\nmask")) {
+		t.Fatalf("parts = %s", gjson.GetBytes(executor.payloads[0], "contents.0.parts").Raw)
+	}
+}
+
+func TestImageRequests_Validation(t *testing.T) {
+	router, executor := newImagesTestRouter(t)
+	for _, body := range []string{
+		`{"prompt":"a fox"}`,
+		`{"model":"gemini-2.5-flash-image","prompt":"a fox","n":11}`,
+		`{"model":"gemini-2.5-flash-image","prompt":"a fox","size":"big"}`,
+		`{"model":"gemini-2.5-flash-image","prompt":"a fox","response_format":"png"}`,
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/images/generations", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", body, rec.Code)
+		}
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("invalid requests reached the executor %d times", len(executor.payloads))
+	}
+}
+
+func TestGeminiImageConfig(t *testing.T) {
+	cases := map[string][2]string{
+		"":          {"", ""},
+		"auto":      {"", ""},
+		"1024x1024": {"1:1", ""},
+		"1024x1792": {"9:16", ""},
+		"1536x1024": {"3:2", ""},
+		"2048x2048": {"1:1", "2K"},
+		"4096x2304": {"16:9", "4K"},
+	}
+	for size, want := range cases {
+		aspectRatio, imageSize, err := geminiImageConfig(size)
+		if err != nil || aspectRatio != want[0] || imageSize != want[1] {
+			t.Fatalf("geminiImageConfig(%q) = %q, %q, %v; want %q, %q", size, aspectRatio, imageSize, err, want[0], want[1])
+		}
+	}
+}
+
+func TestParseGeminiImages_NoImage(t *testing.T) {
+	_, _, _, err := parseGeminiImages([]byte(`{"candidates":[{"finishReason":"IMAGE_SAFETY","content":{"parts":[{"text":"I can't draw that."}]}}]}`))
+	if err == nil || !strings.Contains(err.Error(), "IMAGE_SAFETY") || !strings.Contains(err.Error(), "I can't draw that.") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package openai
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// imageStoreTTL is how long the URL of a generated image stays valid.
+	imageStoreTTL = time.Hour
+
+	// imageStoreMaxBytes bounds the memory held by stored images; the oldest are dropped first.
+	imageStoreMaxBytes = 256 << 20
+)
+
+type storedImage struct {
+	id       string
+	mimeType string
+	data     []byte
+	expires  time.Time
+}
+
+// imageStore keeps the images of response_format=url requests in memory under random IDs until
+// they expire. Images are kept in insertion order, so the expired ones are always the oldest.
+type imageStore struct {
+	mu     sync.Mutex
+	images map[string]*storedImage
+	order  []*storedImage
+	size   int
+}
+
+var generatedImages = newImageStore()
+
+func newImageStore() *imageStore {
+	return &imageStore{images: make(map[string]*storedImage)}
+}
+
+// put stores data and returns the ID it can be fetched with.
+func (s *imageStore) put(mimeType string, data []byte) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate image id: %w", err)
+	}
+	now := time.Now()
+	image := &storedImage{id: hex.EncodeToString(raw), mimeType: mimeType, data: data, expires: now.Add(imageStoreTTL)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.order) > 0 && (now.After(s.order[0].expires) || s.size+len(data) > imageStoreMaxBytes) {
+		s.dropOldestLocked()
+	}
+	s.images[image.id] = image
+	s.order = append(s.order, image)
+	s.size += len(data)
+	return image.id, nil
+}
+
+// get returns the image stored under id unless it has expired.
+func (s *imageStore) get(id string) (storedImage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	image, ok := s.images[id]
+	if !ok || time.Now().After(image.expires) {
+		return storedImage{}, false
+	}
+	return *image, true
+}
+
+func (s *imageStore) dropOldestLocked() {
+	oldest := s.order[0]
+	s.order[0] = nil
+	s.order = s.order[1:]
+	delete(s.images, oldest.id)
+	s.size -= len(oldest.data)
+}
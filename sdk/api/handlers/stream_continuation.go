@@ -0,0 +1,969 @@
+package handlers
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/giofahreza/AIProxyAPI/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// minEchoOverlap is how much of the delivered text a continuation must reproduce before it is
+// treated as a restarted answer whose repetition is dropped.
+const minEchoOverlap = 32
+
+// streamContinuation records what a streaming response has already delivered to the client so a
+// stream that breaks mid-way can be resumed on another auth. Text deltas are forwarded as they
+// arrive and accumulated for the assistant prefill; tool-call deltas are held back until the call
+// is complete so a partial call can be dropped and regenerated by the continuation.
+//
+// Chunks are in the client (handler) format. Supported formats are OpenAI chat completions, OpenAI
+// Responses, Claude/Anthropic messages and Gemini/Gemini CLI; other formats return a nil tracker.
+// Responses events are renumbered and their output indexes and item IDs remapped so the spliced
+// stream reads as one response.
+//
+// A stream that already delivered a complete tool call is not resumed: providers expect the
+// call's result next, so a continuation cannot extend the turn. Such a stream is ended as a
+// tool-call turn instead, letting the client run the delivered calls; output that followed the
+// last complete call is cut.
+type streamContinuation struct {
+	format string
+
+	// text is every assistant text delta forwarded so far.
+	text strings.Builder
+	// toolCalls is set once a complete tool call has been forwarded; such streams cannot be resumed.
+	toolCalls bool
+	// finished is set once the stream delivered its finish reason; stopped once it ended the message.
+	finished bool
+	stopped  bool
+	// held buffers tool-call chunks until the call is complete.
+	held [][]byte
+	// resuming is set while a continuation stream is being spliced in.
+	resuming bool
+	// echo is the delivered text a continuation may repeat before producing new content.
+	echo string
+	// echoHeld is continuation text that matches the start of echo but is too short to tell a
+	// restarted answer from new output that happens to begin the same way.
+	echoHeld string
+	// echoConfirmed is set once the continuation has reproduced enough of echo to be a restart.
+	echoConfirmed bool
+	// trimLeading drops leading whitespace of the continuation when the prefill was right-trimmed.
+	trimLeading bool
+
+	// OpenAI state.
+	chunkID string
+
+	// Claude and Responses state. Indexes are Claude content block or Responses output indexes.
+	pending    []byte
+	holding    bool
+	openIndex  int
+	openType   string
+	nextIndex  int
+	indexMap   map[int]int
+	mergedText bool
+
+	// Responses state.
+	seqInit    bool
+	nextSeq    int64
+	response   string
+	idMap      map[string]string
+	openItem   string
+	openItemID string
+	openText   strings.Builder
+	partOpen   bool
+	doneItems  []string
+}
+
+func newStreamContinuation(handlerType string) *streamContinuation {
+	switch handlerType {
+	case constant.OpenAI, constant.OpenaiResponse, constant.Claude, constant.Anthropic, constant.Gemini, constant.GeminiCLI:
+		return &streamContinuation{format: handlerType, openIndex: -1}
+	default:
+		return nil
+	}
+}
+
+func (s *streamContinuation) isClaude() bool {
+	return s.format == constant.Claude || s.format == constant.Anthropic
+}
+
+// trimsPrefill reports whether the prefill loses its trailing whitespace. Anthropic rejects
+// prefills ending in whitespace, and Responses requests are often served by Claude.
+func (s *streamContinuation) trimsPrefill() bool {
+	return s.isClaude() || s.format == constant.OpenaiResponse
+}
+
+// resumable reports whether the delivered output can be extended by a continuation request.
+func (s *streamContinuation) resumable() bool {
+	return s != nil && !s.toolCalls
+}
+
+// endsWithToolCalls reports whether the delivered output contains a complete tool call, so a
+// broken stream is ended with finishToolCalls instead of being resumed.
+func (s *streamContinuation) endsWithToolCalls() bool {
+	return s != nil && s.toolCalls
+}
+
+// process consumes an upstream chunk and returns the chunks to forward to the client.
+func (s *streamContinuation) process(chunk []byte) [][]byte {
+	switch {
+	case s.isClaude():
+		return s.processClaude(chunk)
+	case s.format == constant.OpenAI:
+		return s.processOpenAI(chunk)
+	case s.format == constant.OpenaiResponse:
+		return s.processResponses(chunk)
+	default:
+		return s.processGemini(chunk)
+	}
+}
+
+// flush returns any chunks still buffered when the upstream stream ends cleanly.
+func (s *streamContinuation) flush() [][]byte {
+	var out [][]byte
+	if s.isClaude() && len(bytes.TrimSpace(s.pending)) > 0 {
+		out = append(out, s.claudeEvent(s.pending)...)
+	}
+	if s.format == constant.OpenaiResponse && len(s.pending) > 0 {
+		out = append(out, s.pending)
+	}
+	s.pending = nil
+	if held := s.releaseEcho(); held != "" {
+		out = append(out, s.heldTextChunks(held)...)
+	}
+	if s.format == constant.OpenaiResponse {
+		out = append(out, s.emitResponses(s.held...)...)
+	} else {
+		out = append(out, s.held...)
+	}
+	s.held = nil
+	return out
+}
+
+// finishToolCalls ends a broken stream that already delivered complete tool calls. A partial call
+// still held back is dropped, and the events the client needs to see a finished tool-call turn
+// are returned.
+func (s *streamContinuation) finishToolCalls() [][]byte {
+	s.held = nil
+	s.holding = false
+	s.pending = nil
+	var out [][]byte
+	if held := s.releaseEcho(); held != "" {
+		out = append(out, s.heldTextChunks(held)...)
+	}
+	switch {
+	case s.isClaude():
+		if s.openIndex >= 0 {
+			out = append(out, claudeBlockStop(s.openIndex))
+			s.openIndex = -1
+			s.openType = ""
+		}
+		if !s.finished {
+			out = append(out, claudeSSE("message_delta", []byte(`{"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":0}}`)))
+		}
+		if !s.stopped {
+			out = append(out, claudeSSE("message_stop", []byte(`{"type":"message_stop"}`)))
+		}
+	case s.format == constant.OpenAI:
+		if !s.finished {
+			chunk := []byte(`{"object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`)
+			if s.chunkID != "" {
+				chunk, _ = sjson.SetBytes(chunk, "id", s.chunkID)
+			}
+			out = append(out, chunk)
+		}
+	case s.format == constant.OpenaiResponse:
+		out = append(out, s.closeResponsesItem()...)
+		if !s.finished {
+			out = append(out, s.emitResponses(s.completedResponse())...)
+		}
+	default:
+		if !s.finished {
+			chunk := []byte(`{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":""}]},"finishReason":"STOP"}]}`)
+			if s.format == constant.GeminiCLI {
+				chunk, _ = sjson.SetRawBytes([]byte(`{}`), "response", chunk)
+			}
+			out = append(out, chunk)
+		}
+	}
+	s.finished = true
+	s.stopped = true
+	return out
+}
+
+// continuationPayload returns the original client request extended with the delivered text as an
+// assistant prefill in the client's format.
+func (s *streamContinuation) continuationPayload(rawJSON []byte) []byte {
+	prefill := s.text.String()
+	if s.trimsPrefill() {
+		prefill = strings.TrimRight(prefill, " \t\r\n")
+	}
+	if prefill == "" {
+		return rawJSON
+	}
+	switch s.format {
+	case constant.Gemini:
+		return appendGeminiPrefill(rawJSON, "contents", prefill)
+	case constant.GeminiCLI:
+		return appendGeminiPrefill(rawJSON, "request.contents", prefill)
+	case constant.OpenaiResponse:
+		return appendResponsesPrefill(rawJSON, prefill)
+	default:
+		return appendMessagesPrefill(rawJSON, prefill)
+	}
+}
+
+// resume switches the tracker to splice a continuation stream and returns any events needed to
+// keep the client's event sequence valid (e.g. closing an interrupted thinking block).
+func (s *streamContinuation) resume() [][]byte {
+	if s.format == constant.OpenaiResponse && s.holding && len(s.held) > 0 {
+		// The partial tool call is dropped, so its output index goes to the next item.
+		s.nextIndex = int(gjson.GetBytes(s.held[0], "output_index").Int())
+	}
+	full := s.text.String()
+	s.echo = full
+	s.echoHeld = ""
+	s.echoConfirmed = false
+	s.trimLeading = s.trimsPrefill() && strings.TrimRight(full, " \t\r\n") != full
+	s.held = nil
+	s.resuming = true
+	if !s.isClaude() && s.format != constant.OpenaiResponse {
+		return nil
+	}
+	s.pending = nil
+	s.holding = false
+	s.indexMap = make(map[int]int)
+	s.mergedText = false
+	if s.format == constant.OpenaiResponse {
+		s.idMap = make(map[string]string)
+		if s.openIndex >= 0 && s.openType != "message" {
+			return s.closeResponsesItem()
+		}
+		return nil
+	}
+	if s.openIndex >= 0 && s.openType != "text" {
+		stop := claudeBlockStop(s.openIndex)
+		s.openIndex = -1
+		s.openType = ""
+		return [][]byte{stop}
+	}
+	return nil
+}
+
+// dedupe removes text the continuation repeats from the already delivered output. Providers that
+// honour the prefill continue right after it, so repetition is only dropped once the continuation
+// has reproduced at least minEchoOverlap bytes (or all) of the delivered text; until then matching
+// text is held and handed back as soon as the continuation diverges.
+func (s *streamContinuation) dedupe(text string) string {
+	if s.trimLeading {
+		text = strings.TrimLeft(text, " \t\r\n")
+		if text == "" {
+			return ""
+		}
+		s.trimLeading = false
+	}
+	if s.echo == "" || text == "" {
+		return text
+	}
+	candidate := s.echoHeld + text
+	s.echoHeld = ""
+	switch {
+	case strings.HasPrefix(s.echo, candidate):
+		if !s.echoConfirmed && len(candidate) < min(len(s.echo), minEchoOverlap) {
+			s.echoHeld = candidate
+			return ""
+		}
+		s.echoConfirmed = true
+		s.echo = s.echo[len(candidate):]
+		return ""
+	case strings.HasPrefix(candidate, s.echo):
+		// Reproducing all of the remaining delivered text is a restart whatever its length.
+		candidate = candidate[len(s.echo):]
+	}
+	s.echo = ""
+	return candidate
+}
+
+// releaseEcho stops looking for repeated text and returns the text held while deciding, which
+// belongs to the response and is recorded as delivered.
+func (s *streamContinuation) releaseEcho() string {
+	held := s.echoHeld
+	s.echoHeld = ""
+	s.echo = ""
+	s.text.WriteString(held)
+	return held
+}
+
+// heldTextChunks returns chunks in the client format that deliver text released by releaseEcho.
+func (s *streamContinuation) heldTextChunks(text string) [][]byte {
+	switch {
+	case s.isClaude():
+		delta, _ := sjson.SetBytes([]byte(`{"type":"content_block_delta","delta":{"type":"text_delta"}}`), "delta.text", text)
+		if s.openIndex >= 0 && s.openType == "text" {
+			delta, _ = sjson.SetBytes(delta, "index", s.openIndex)
+			return [][]byte{claudeSSE("content_block_delta", delta)}
+		}
+		index := s.nextIndex
+		s.nextIndex++
+		start, _ := sjson.SetBytes([]byte(`{"type":"content_block_start","content_block":{"type":"text","text":""}}`), "index", index)
+		delta, _ = sjson.SetBytes(delta, "index", index)
+		return [][]byte{claudeSSE("content_block_start", start), claudeSSE("content_block_delta", delta), claudeBlockStop(index)}
+	case s.format == constant.OpenaiResponse:
+		if s.openIndex < 0 || s.openType != "message" {
+			return nil
+		}
+		s.openText.WriteString(text)
+		delta := []byte(`{"type":"response.output_text.delta","content_index":0}`)
+		delta, _ = sjson.SetBytes(delta, "item_id", s.openItemID)
+		delta, _ = sjson.SetBytes(delta, "output_index", s.openIndex)
+		delta, _ = sjson.SetBytes(delta, "delta", text)
+		return s.emitResponses(delta)
+	case s.format == constant.OpenAI:
+		chunk, _ := sjson.SetBytes([]byte(`{"object":"chat.completion.chunk","choices":[{"index":0,"delta":{}}]}`), "choices.0.delta.content", text)
+		if s.chunkID != "" {
+			chunk, _ = sjson.SetBytes(chunk, "id", s.chunkID)
+		}
+		return [][]byte{chunk}
+	default:
+		chunk, _ := sjson.SetBytes([]byte(`{"candidates":[{"index":0,"content":{"role":"model","parts":[{}]}}]}`), "candidates.0.content.parts.0.text", text)
+		if s.format == constant.GeminiCLI {
+			chunk, _ = sjson.SetRawBytes([]byte(`{}`), "response", chunk)
+		}
+		return [][]byte{chunk}
+	}
+}
+
+func (s *streamContinuation) processOpenAI(chunk []byte) [][]byte {
+	if !gjson.ValidBytes(chunk) {
+		return [][]byte{chunk}
+	}
+	if s.chunkID == "" {
+		s.chunkID = gjson.GetBytes(chunk, "id").String()
+	}
+	if reason := gjson.GetBytes(chunk, "choices.0.finish_reason"); reason.Exists() && reason.Type != gjson.Null {
+		s.finished = true
+	}
+	var out [][]byte
+	if s.echoHeld != "" && !gjson.GetBytes(chunk, "choices.0.delta.content").Exists() {
+		out = s.heldTextChunks(s.releaseEcho())
+	}
+	if gjson.GetBytes(chunk, "choices.0.delta.tool_calls").Exists() {
+		s.held = append(s.held, s.rewriteOpenAI(chunk))
+		return out
+	}
+	out = append(out, s.releaseHeld()...)
+	if s.resuming {
+		chunk = s.rewriteOpenAI(chunk)
+		if content := gjson.GetBytes(chunk, "choices.0.delta.content"); content.Exists() {
+			deduped := s.dedupe(content.String())
+			if deduped != content.String() {
+				chunk, _ = sjson.SetBytes(chunk, "choices.0.delta.content", deduped)
+			}
+		}
+		chunk, _ = sjson.DeleteBytes(chunk, "choices.0.delta.role")
+		if isEmptyOpenAIChunk(chunk) {
+			return out
+		}
+	}
+	s.text.WriteString(gjson.GetBytes(chunk, "choices.0.delta.content").String())
+	return append(out, chunk)
+}
+
+func (s *streamContinuation) rewriteOpenAI(chunk []byte) []byte {
+	if !s.resuming || s.chunkID == "" {
+		return chunk
+	}
+	if id := gjson.GetBytes(chunk, "id"); id.Exists() && id.String() != s.chunkID {
+		chunk, _ = sjson.SetBytes(chunk, "id", s.chunkID)
+	}
+	return chunk
+}
+
+func isEmptyOpenAIChunk(chunk []byte) bool {
+	if gjson.GetBytes(chunk, "usage").IsObject() {
+		return false
+	}
+	choice := gjson.GetBytes(chunk, "choices.0")
+	if !choice.Exists() {
+		return false
+	}
+	if reason := choice.Get("finish_reason"); reason.Exists() && reason.Type != gjson.Null {
+		return false
+	}
+	empty := true
+	choice.Get("delta").ForEach(func(_, value gjson.Result) bool {
+		if value.Type != gjson.Null && value.String() != "" {
+			empty = false
+		}
+		return empty
+	})
+	return empty
+}
+
+func (s *streamContinuation) releaseHeld() [][]byte {
+	if len(s.held) == 0 {
+		return nil
+	}
+	out := s.held
+	s.held = nil
+	s.toolCalls = true
+	return out
+}
+
+func (s *streamContinuation) processGemini(chunk []byte) [][]byte {
+	if !gjson.ValidBytes(chunk) {
+		return [][]byte{chunk}
+	}
+	candidate := "candidates.0"
+	if s.format == constant.GeminiCLI && gjson.GetBytes(chunk, "response").Exists() {
+		candidate = "response." + candidate
+	}
+	root := candidate + ".content.parts"
+	parts := gjson.GetBytes(chunk, root)
+	if !parts.IsArray() {
+		return [][]byte{chunk}
+	}
+	ended := gjson.GetBytes(chunk, candidate+".finishReason").Exists()
+	if ended {
+		s.finished = true
+	}
+	for i, part := range parts.Array() {
+		if part.Get("functionCall").Exists() {
+			// Gemini delivers function calls whole, so they are never partial.
+			s.toolCalls = true
+			ended = true
+			continue
+		}
+		text := part.Get("text")
+		if !text.Exists() || part.Get("thought").Bool() {
+			continue
+		}
+		value := text.String()
+		if s.resuming {
+			if deduped := s.dedupe(value); deduped != value {
+				value = deduped
+				chunk, _ = sjson.SetBytes(chunk, root+"."+strconv.Itoa(i)+".text", value)
+			}
+		}
+		s.text.WriteString(value)
+	}
+	if ended && s.echoHeld != "" {
+		return append(s.heldTextChunks(s.releaseEcho()), chunk)
+	}
+	return [][]byte{chunk}
+}
+
+// processClaude reassembles SSE events, since passthrough executors emit Claude streams line by line.
+func (s *streamContinuation) processClaude(chunk []byte) [][]byte {
+	s.pending = append(s.pending, chunk...)
+	var out [][]byte
+	for {
+		idx := bytes.Index(s.pending, []byte("\n\n"))
+		if idx < 0 {
+			break
+		}
+		event := s.pending[:idx]
+		s.pending = s.pending[idx+2:]
+		if len(bytes.TrimSpace(event)) == 0 {
+			continue
+		}
+		out = append(out, s.claudeEvent(event)...)
+	}
+	if len(s.pending) == 0 {
+		s.pending = nil
+	}
+	return out
+}
+
+// claudeEvent rewrites one SSE event, first releasing held echo text when the event ends the
+// text the continuation was producing.
+func (s *streamContinuation) claudeEvent(event []byte) [][]byte {
+	var released [][]byte
+	if s.echoHeld != "" && endsClaudeText(claudeEventData(event)) {
+		released = s.heldTextChunks(s.releaseEcho())
+	}
+	return append(released, s.rewriteClaudeEvent(event)...)
+}
+
+func claudeEventData(event []byte) []byte {
+	var data []byte
+	for _, line := range bytes.Split(event, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if bytes.HasPrefix(line, []byte("data:")) {
+			data = bytes.TrimSpace(line[len("data:"):])
+		}
+	}
+	return data
+}
+
+func endsClaudeText(data []byte) bool {
+	switch gjson.GetBytes(data, "type").String() {
+	case "", "ping":
+		return false
+	case "content_block_delta":
+		return gjson.GetBytes(data, "delta.type").String() != "text_delta"
+	default:
+		return true
+	}
+}
+
+func (s *streamContinuation) rewriteClaudeEvent(event []byte) [][]byte {
+	data := claudeEventData(event)
+	raw := append(bytes.Clone(event), '\n', '\n')
+	if len(data) == 0 || !gjson.ValidBytes(data) {
+		return [][]byte{raw}
+	}
+
+	switch gjson.GetBytes(data, "type").String() {
+	case "message_start":
+		if s.resuming {
+			return nil
+		}
+	case "message_delta":
+		if reason := gjson.GetBytes(data, "delta.stop_reason"); reason.Exists() && reason.Type != gjson.Null {
+			s.finished = true
+		}
+	case "message_stop":
+		s.stopped = true
+	case "content_block_start":
+		index := int(gjson.GetBytes(data, "index").Int())
+		blockType := gjson.GetBytes(data, "content_block.type").String()
+		var out [][]byte
+		if s.resuming {
+			if blockType == "text" && s.openType == "text" && !s.mergedText {
+				// The interrupted text block is still open on the client; continue inside it.
+				s.indexMap[index] = s.openIndex
+				s.mergedText = true
+				return nil
+			}
+			if s.openIndex >= 0 {
+				out = append(out, claudeBlockStop(s.openIndex))
+				s.openIndex = -1
+				s.openType = ""
+			}
+			s.indexMap[index] = s.nextIndex
+			index = s.nextIndex
+			data, _ = sjson.SetBytes(data, "index", index)
+		}
+		if index+1 > s.nextIndex {
+			s.nextIndex = index + 1
+		}
+		encoded := raw
+		if s.resuming {
+			encoded = claudeSSE("content_block_start", data)
+		}
+		if blockType == "tool_use" || blockType == "server_tool_use" {
+			s.holding = true
+			s.held = append(s.held, encoded)
+			return out
+		}
+		s.openIndex = index
+		s.openType = blockType
+		return append(out, encoded)
+	case "content_block_delta":
+		data = s.remapClaudeIndex(data)
+		if s.holding {
+			s.held = append(s.held, claudeSSE("content_block_delta", data))
+			return nil
+		}
+		if gjson.GetBytes(data, "delta.type").String() == "text_delta" {
+			text := gjson.GetBytes(data, "delta.text").String()
+			if s.resuming {
+				if deduped := s.dedupe(text); deduped != text {
+					if deduped == "" {
+						return nil
+					}
+					text = deduped
+					data, _ = sjson.SetBytes(data, "delta.text", text)
+				}
+			}
+			s.text.WriteString(text)
+		}
+		if s.resuming {
+			return [][]byte{claudeSSE("content_block_delta", data)}
+		}
+	case "content_block_stop":
+		data = s.remapClaudeIndex(data)
+		if s.holding {
+			s.held = append(s.held, claudeSSE("content_block_stop", data))
+			s.holding = false
+			return s.releaseHeld()
+		}
+		if int(gjson.GetBytes(data, "index").Int()) == s.openIndex {
+			s.openIndex = -1
+			s.openType = ""
+		}
+		if s.resuming {
+			return [][]byte{claudeSSE("content_block_stop", data)}
+		}
+	}
+	return [][]byte{raw}
+}
+
+func (s *streamContinuation) remapClaudeIndex(data []byte) []byte {
+	if !s.resuming {
+		return data
+	}
+	index := int(gjson.GetBytes(data, "index").Int())
+	if mapped, ok := s.indexMap[index]; ok && mapped != index {
+		data, _ = sjson.SetBytes(data, "index", mapped)
+	}
+	return data
+}
+
+func claudeSSE(eventType string, data []byte) []byte {
+	out := make([]byte, 0, len(eventType)+len(data)+16)
+	out = append(out, "event: "...)
+	out = append(out, eventType...)
+	out = append(out, "\ndata: "...)
+	out = append(out, data...)
+	return append(out, '\n', '\n')
+}
+
+func claudeBlockStop(index int) []byte {
+	data, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", index)
+	return claudeSSE("content_block_stop", data)
+}
+
+// processResponses rewrites one Responses event. Passthrough executors emit the event line and
+// its data line as separate chunks, so an event line is held until its data arrives.
+func (s *streamContinuation) processResponses(chunk []byte) [][]byte {
+	data := claudeEventData(chunk)
+	if len(data) == 0 {
+		if bytes.HasPrefix(bytes.TrimSpace(chunk), []byte("event:")) {
+			s.pending = chunk
+			return nil
+		}
+		return [][]byte{chunk}
+	}
+	original := chunk
+	if len(s.pending) > 0 && !bytes.Contains(chunk, []byte("event:")) {
+		original = append(append(s.pending, '\n'), chunk...)
+	}
+	s.pending = nil
+	if !gjson.ValidBytes(data) {
+		return [][]byte{original}
+	}
+	if seq := gjson.GetBytes(data, "sequence_number"); seq.Exists() && !s.seqInit {
+		s.seqInit = true
+		s.nextSeq = seq.Int()
+	}
+
+	eventType := gjson.GetBytes(data, "type").String()
+	var out [][]byte
+	if s.echoHeld != "" && eventType != "response.output_text.delta" {
+		out = s.heldTextChunks(s.releaseEcho())
+	}
+	events := s.responsesEvent(eventType, data)
+	if len(out) == 0 && len(events) == 1 {
+		// Forward the upstream bytes untouched when the event needed no rewriting.
+		if numbered := s.numberResponsesEvent(events[0]); bytes.Equal(numbered, data) {
+			s.nextSeq++
+			return [][]byte{original}
+		}
+	}
+	return append(out, s.emitResponses(events...)...)
+}
+
+// responsesEvent tracks one Responses event and returns the events to forward in its place,
+// before they are numbered.
+func (s *streamContinuation) responsesEvent(eventType string, data []byte) [][]byte {
+	switch eventType {
+	case "response.created", "response.in_progress":
+		if s.resuming {
+			return nil
+		}
+		if s.response == "" {
+			s.response = gjson.GetBytes(data, "response").Raw
+		}
+		return [][]byte{data}
+	case "response.output_item.added":
+		index := int(gjson.GetBytes(data, "output_index").Int())
+		item := gjson.GetBytes(data, "item")
+		itemType := item.Get("type").String()
+		var out [][]byte
+		if s.resuming {
+			if itemType == "message" && s.openType == "message" && !s.mergedText {
+				// The interrupted message is still open on the client; continue inside it.
+				s.indexMap[index] = s.openIndex
+				s.idMap[item.Get("id").String()] = s.openItemID
+				s.mergedText = true
+				return nil
+			}
+			out = s.closeResponsesItem()
+			s.indexMap[index] = s.nextIndex
+			index = s.nextIndex
+			data, _ = sjson.SetBytes(data, "output_index", index)
+		}
+		if index+1 > s.nextIndex {
+			s.nextIndex = index + 1
+		}
+		if itemType == "function_call" || itemType == "custom_tool_call" {
+			s.holding = true
+			s.held = append(s.held, data)
+			return out
+		}
+		s.openIndex = index
+		s.openType = itemType
+		s.openItem = item.Raw
+		s.openItemID = item.Get("id").String()
+		s.openText.Reset()
+		s.partOpen = false
+		return append(out, data)
+	case "response.completed", "response.incomplete", "response.failed":
+		s.finished = true
+		if s.resuming {
+			data = s.rewriteResponse(data)
+		}
+		return [][]byte{data}
+	}
+
+	data = s.remapResponses(data)
+	if s.holding {
+		s.held = append(s.held, data)
+		if eventType == "response.output_item.done" {
+			s.holding = false
+			s.doneItems = append(s.doneItems, gjson.GetBytes(data, "item").Raw)
+			return s.releaseHeld()
+		}
+		return nil
+	}
+	open := s.openIndex >= 0 && gjson.GetBytes(data, "item_id").String() == s.openItemID
+	switch eventType {
+	case "response.content_part.added":
+		if open {
+			if s.resuming && s.partOpen {
+				return nil
+			}
+			s.partOpen = true
+		}
+	case "response.output_text.delta":
+		text := gjson.GetBytes(data, "delta").String()
+		if s.resuming {
+			if deduped := s.dedupe(text); deduped != text {
+				if deduped == "" {
+					return nil
+				}
+				text = deduped
+				data, _ = sjson.SetBytes(data, "delta", text)
+			}
+		}
+		s.text.WriteString(text)
+		if open {
+			s.openText.WriteString(text)
+		}
+	case "response.output_text.done":
+		if open && s.resuming {
+			data, _ = sjson.SetBytes(data, "text", s.openText.String())
+		}
+	case "response.content_part.done":
+		if open {
+			if s.resuming {
+				data, _ = sjson.SetBytes(data, "part.text", s.openText.String())
+			}
+			s.partOpen = false
+		}
+	case "response.output_item.done":
+		index := int(gjson.GetBytes(data, "output_index").Int())
+		if s.openIndex >= 0 && index == s.openIndex {
+			if s.resuming && s.openType == "message" && gjson.GetBytes(data, "item.content.0.type").String() == "output_text" {
+				data, _ = sjson.SetBytes(data, "item.content.0.text", s.openText.String())
+			}
+			s.openIndex = -1
+			s.openType = ""
+			s.openItemID = ""
+		}
+		s.doneItems = append(s.doneItems, gjson.GetBytes(data, "item").Raw)
+	}
+	return [][]byte{data}
+}
+
+// remapResponses moves a continuation event onto the client's output indexes and item IDs.
+func (s *streamContinuation) remapResponses(data []byte) []byte {
+	if !s.resuming {
+		return data
+	}
+	if index := gjson.GetBytes(data, "output_index"); index.Exists() {
+		if mapped, ok := s.indexMap[int(index.Int())]; ok && mapped != int(index.Int()) {
+			data, _ = sjson.SetBytes(data, "output_index", mapped)
+		}
+	}
+	if id := gjson.GetBytes(data, "item_id"); id.Exists() {
+		if mapped, ok := s.idMap[id.String()]; ok {
+			data, _ = sjson.SetBytes(data, "item_id", mapped)
+		}
+	}
+	if id := gjson.GetBytes(data, "item.id"); id.Exists() {
+		if mapped, ok := s.idMap[id.String()]; ok {
+			data, _ = sjson.SetBytes(data, "item.id", mapped)
+		}
+	}
+	return data
+}
+
+// rewriteResponse gives a continuation's final response the interrupted response's identity and
+// every output item the client received.
+func (s *streamContinuation) rewriteResponse(data []byte) []byte {
+	if s.response != "" {
+		original := gjson.Parse(s.response)
+		for _, key := range []string{"id", "created_at"} {
+			if value := original.Get(key); value.Exists() {
+				data, _ = sjson.SetRawBytes(data, "response."+key, []byte(value.Raw))
+			}
+		}
+	}
+	data, _ = sjson.SetRawBytes(data, "response.output", []byte("["+strings.Join(s.doneItems, ",")+"]"))
+	return data
+}
+
+// completedResponse builds the response.completed event that ends a tool-call turn.
+func (s *streamContinuation) completedResponse() []byte {
+	response := []byte(s.response)
+	if !gjson.ValidBytes(response) || len(response) == 0 {
+		response = []byte(`{"object":"response"}`)
+	}
+	response, _ = sjson.SetBytes(response, "status", "completed")
+	response, _ = sjson.SetRawBytes(response, "output", []byte("["+strings.Join(s.doneItems, ",")+"]"))
+	event, _ := sjson.SetRawBytes([]byte(`{"type":"response.completed"}`), "response", response)
+	return event
+}
+
+// closeResponsesItem ends the output item left open by the interrupted stream. A message keeps the
+// text delivered so far; any other item is marked incomplete.
+func (s *streamContinuation) closeResponsesItem() [][]byte {
+	if s.openIndex < 0 {
+		return nil
+	}
+	var events [][]byte
+	item := []byte(s.openItem)
+	if s.openType == "message" {
+		text := s.openText.String()
+		if s.partOpen {
+			done := []byte(`{"type":"response.output_text.done","content_index":0}`)
+			done, _ = sjson.SetBytes(done, "item_id", s.openItemID)
+			done, _ = sjson.SetBytes(done, "output_index", s.openIndex)
+			done, _ = sjson.SetBytes(done, "text", text)
+			part := []byte(`{"type":"response.content_part.done","content_index":0,"part":{"type":"output_text","annotations":[]}}`)
+			part, _ = sjson.SetBytes(part, "item_id", s.openItemID)
+			part, _ = sjson.SetBytes(part, "output_index", s.openIndex)
+			part, _ = sjson.SetBytes(part, "part.text", text)
+			events = append(events, done, part)
+		}
+		item, _ = sjson.SetRawBytes(item, "content", []byte(`[{"type":"output_text","annotations":[]}]`))
+		item, _ = sjson.SetBytes(item, "content.0.text", text)
+		item, _ = sjson.SetBytes(item, "status", "completed")
+	} else {
+		item, _ = sjson.SetBytes(item, "status", "incomplete")
+	}
+	itemDone, _ := sjson.SetBytes([]byte(`{"type":"response.output_item.done"}`), "output_index", s.openIndex)
+	itemDone, _ = sjson.SetRawBytes(itemDone, "item", item)
+	events = append(events, itemDone)
+	s.doneItems = append(s.doneItems, string(item))
+	s.openIndex = -1
+	s.openType = ""
+	s.openItemID = ""
+	s.partOpen = false
+	return s.emitResponses(events...)
+}
+
+// numberResponsesEvent gives data the next sequence number when the upstream numbers its events.
+func (s *streamContinuation) numberResponsesEvent(data []byte) []byte {
+	if !s.seqInit {
+		return data
+	}
+	data, _ = sjson.SetBytes(data, "sequence_number", s.nextSeq)
+	return data
+}
+
+// emitResponses numbers events and encodes them as SSE event/data pairs.
+func (s *streamContinuation) emitResponses(events ...[]byte) [][]byte {
+	out := make([][]byte, 0, len(events))
+	for _, data := range events {
+		data = s.numberResponsesEvent(data)
+		if s.seqInit {
+			s.nextSeq++
+		}
+		chunk := make([]byte, 0, len(data)+64)
+		chunk = append(chunk, "event: "...)
+		chunk = append(chunk, gjson.GetBytes(data, "type").String()...)
+		chunk = append(chunk, "\ndata: "...)
+		out = append(out, append(chunk, data...))
+	}
+	return out
+}
+
+// appendMessagesPrefill adds the prefill to an OpenAI or Claude style messages array, extending
+// a trailing assistant message when the client already supplied one.
+func appendMessagesPrefill(rawJSON []byte, prefill string) []byte {
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return rawJSON
+	}
+	items := messages.Array()
+	if n := len(items); n > 0 && items[n-1].Get("role").String() == "assistant" {
+		last := "messages." + strconv.Itoa(n-1) + ".content"
+		content := items[n-1].Get("content")
+		switch {
+		case content.Type == gjson.String:
+			out, err := sjson.SetBytes(rawJSON, last, content.String()+prefill)
+			if err == nil {
+				return out
+			}
+		case content.IsArray():
+			out, err := sjson.SetBytes(rawJSON, last+".-1", map[string]any{"type": "text", "text": prefill})
+			if err == nil {
+				return out
+			}
+		}
+	}
+	out, err := sjson.SetBytes(rawJSON, "messages.-1", map[string]any{"role": "assistant", "content": prefill})
+	if err != nil {
+		return rawJSON
+	}
+	return out
+}
+
+func appendGeminiPrefill(rawJSON []byte, path, prefill string) []byte {
+	if !gjson.GetBytes(rawJSON, path).IsArray() {
+		return rawJSON
+	}
+	out, err := sjson.SetBytes(rawJSON, path+".-1", map[string]any{
+		"role":  "model",
+		"parts": []map[string]any{{"text": prefill}},
+	})
+	if err != nil {
+		return rawJSON
+	}
+	return out
+}
+
+// appendResponsesPrefill adds the prefill to a Responses request as an assistant message. A string
+// input becomes the user message before it.
+func appendResponsesPrefill(rawJSON []byte, prefill string) []byte {
+	message := map[string]any{
+		"type":    "message",
+		"role":    "assistant",
+		"content": []map[string]any{{"type": "output_text", "text": prefill}},
+	}
+	input := gjson.GetBytes(rawJSON, "input")
+	var out []byte
+	var err error
+	switch {
+	case input.IsArray():
+		out, err = sjson.SetBytes(rawJSON, "input.-1", message)
+	case input.Type == gjson.String:
+		out, err = sjson.SetBytes(rawJSON, "input", []map[string]any{{
+			"type":    "message",
+			"role":    "user",
+			"content": []map[string]any{{"type": "input_text", "text": input.String()}},
+		}, message})
+	default:
+		return rawJSON
+	}
+	if err != nil {
+		return rawJSON
+	}
+	return out
+}
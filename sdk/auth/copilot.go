@@ -42,10 +42,17 @@ func (a *CopilotAuthenticator) Login(ctx context.Context, cfg *config.Config, op
 		opts = &LoginOptions{}
 	}
 
-	authSvc := copilot.NewCopilotAuth(cfg)
+	endpoints := copilot.Endpoints{}
+	if opts.Metadata != nil {
+		endpoints.GitHubHost = opts.Metadata["github_host"]
+		endpoints.APIHost = opts.Metadata["github_api_host"]
+		endpoints.CopilotAPIBase = opts.Metadata["copilot_base_url"]
+		endpoints.ClientID = opts.Metadata["github_client_id"]
+	}
+	authSvc := copilot.NewCopilotAuthWithEndpoints(cfg, endpoints)
 
 	// Step 1: Initiate GitHub device flow
-	fmt.Println("Initiating GitHub Copilot authentication...")
+	fmt.Printf("Initiating GitHub Copilot authentication with %s...\n", authSvc.Endpoints().GitHubHost)
 	deviceResp, err := authSvc.InitiateDeviceFlow(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("GitHub device flow initiation failed: %w", err)
@@ -112,6 +119,7 @@ func (a *CopilotAuthenticator) Login(ctx context.Context, cfg *config.Config, op
 		"email": tokenStorage.Email,
 		"sku":   tokenStorage.SKU,
 	}
+	authSvc.Endpoints().ApplyToMetadata(metadata)
 
 	fmt.Printf("GitHub Copilot authentication successful! (SKU: %s)\n", tokenStorage.SKU)
 
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -11,7 +12,11 @@ import (
 	"sync"
 	"time"
 
+	baseauth "github.com/giofahreza/AIProxyAPI/internal/auth"
+	"github.com/giofahreza/AIProxyAPI/internal/authcrypt"
+	"github.com/giofahreza/AIProxyAPI/internal/misc"
 	cliproxyauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 // FileTokenStore persists token records and auth metadata using the filesystem as backing storage.
@@ -63,8 +68,14 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 
 	switch {
 	case auth.Storage != nil:
-		if err = auth.Storage.SaveTokenToFile(path); err != nil {
-			return "", err
+		// Seal in memory and write once, so plaintext tokens never reach disk.
+		raw, errEncode := baseauth.EncodeTokenStorage(auth.Storage)
+		if errEncode != nil {
+			return "", fmt.Errorf("auth filestore: encode token storage: %w", errEncode)
+		}
+		misc.LogSavingCredentials(path)
+		if err = authcrypt.WriteSealedFile(path, raw); err != nil {
+			return "", fmt.Errorf("auth filestore: encrypt failed: %w", err)
 		}
 	case auth.Metadata != nil:
 		raw, errMarshal := json.Marshal(auth.Metadata)
@@ -74,12 +85,16 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 		if existing, errRead := os.ReadFile(path); errRead == nil {
 			// Use metadataEqualIgnoringTimestamps to skip writes when only timestamp fields change.
 			// This prevents the token refresh loop caused by timestamp/expired/expires_in changes.
-			if metadataEqualIgnoringTimestamps(existing, raw) {
+			// Files that are not yet sealed with the active key are always rewritten.
+			if plain, errOpen := authcrypt.Open(existing); errOpen == nil && authcrypt.IsCurrent(existing) && metadataEqualIgnoringTimestamps(plain, raw) {
 				return path, nil
 			}
 		} else if errRead != nil && !os.IsNotExist(errRead) {
 			return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
 		}
+		if raw, errMarshal = authcrypt.Seal(raw); errMarshal != nil {
+			return "", fmt.Errorf("auth filestore: encrypt failed: %w", errMarshal)
+		}
 		tmp := path + ".tmp"
 		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("auth filestore: write temp failed: %w", errWrite)
@@ -122,6 +137,9 @@ func (s *FileTokenStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 		}
 		auth, err := s.readAuthFile(path, dir)
 		if err != nil {
+			if errors.Is(err, authcrypt.ErrDecrypt) {
+				log.WithError(err).Warnf("auth filestore: skipping %s", path)
+			}
 			return nil
 		}
 		if auth != nil {
@@ -163,7 +181,7 @@ func (s *FileTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
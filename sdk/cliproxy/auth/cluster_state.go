@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+)
+
+// StateUpdate carries the availability state MarkResult, Register or Update produced for one
+// auth so replicas sharing the same credentials can apply it. Model is empty for auth-level
+// state, which includes whether the auth is disabled.
+type StateUpdate struct {
+	AuthID string `json:"auth_id"`
+	Model  string `json:"model,omitempty"`
+	// ModelState is set for model-level updates.
+	ModelState *ModelState `json:"model_state,omitempty"`
+	// SuspendReason mirrors the registry suspension applied by the originating replica.
+	SuspendReason string `json:"suspend_reason,omitempty"`
+
+	Disabled       bool       `json:"disabled,omitempty"`
+	Status         Status     `json:"status,omitempty"`
+	StatusMessage  string     `json:"status_message,omitempty"`
+	Unavailable    bool       `json:"unavailable,omitempty"`
+	NextRetryAfter time.Time  `json:"next_retry_after,omitempty"`
+	Quota          QuotaState `json:"quota"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// StatePublisher receives state changes recorded by MarkResult and availability changes made
+// through Register and Update (disables, re-enables). Implementations must not block.
+type StatePublisher interface {
+	PublishState(ctx context.Context, update StateUpdate)
+}
+
+// SetStatePublisher installs the publisher notified about cooldowns, quota hits, disables and
+// recoveries.
+func (m *Manager) SetStatePublisher(publisher StatePublisher) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.statePublisher = publisher
+	m.mu.Unlock()
+}
+
+// modelStateNeedsReset reports whether a successful result clears state that peers may still honour.
+func modelStateNeedsReset(state *ModelState) bool {
+	if state == nil {
+		return false
+	}
+	return state.Unavailable || state.Quota.Exceeded || state.Status != StatusActive || !state.NextRetryAfter.IsZero()
+}
+
+// authStateNeedsReset is the auth-level counterpart of modelStateNeedsReset.
+func authStateNeedsReset(auth *Auth) bool {
+	if auth == nil {
+		return false
+	}
+	return auth.Unavailable || auth.Quota.Exceeded || !auth.NextRetryAfter.IsZero()
+}
+
+func modelStateUpdate(authID, model string, state *ModelState, suspendReason string) StateUpdate {
+	update := StateUpdate{AuthID: authID, Model: model, SuspendReason: suspendReason}
+	if state != nil {
+		copied := *state
+		copied.LastError = cloneError(state.LastError)
+		update.ModelState = &copied
+		update.UpdatedAt = state.UpdatedAt
+	}
+	return update
+}
+
+func authStateUpdate(auth *Auth) StateUpdate {
+	return StateUpdate{
+		AuthID:         auth.ID,
+		Disabled:       auth.Disabled,
+		Status:         auth.Status,
+		StatusMessage:  auth.StatusMessage,
+		Unavailable:    auth.Unavailable,
+		NextRetryAfter: auth.NextRetryAfter,
+		Quota:          auth.Quota,
+		UpdatedAt:      auth.UpdatedAt,
+	}
+}
+
+// availabilityUpdate returns the auth-level update to publish when Register or Update changes
+// whether an auth is disabled or its status. It returns nil for new auths and unchanged ones.
+// The update is stamped no earlier than now so peers holding a newer timestamp still apply it.
+func availabilityUpdate(previous, next *Auth, now time.Time) *StateUpdate {
+	if previous == nil || next == nil {
+		return nil
+	}
+	if previous.Disabled == next.Disabled && previous.Status == next.Status {
+		return nil
+	}
+	update := authStateUpdate(next)
+	if update.UpdatedAt.Before(now) {
+		update.UpdatedAt = now
+	}
+	return &update
+}
+
+// ApplyStateUpdate merges state published by another replica. Updates older than the local state
+// are ignored. The update is neither persisted nor republished.
+func (m *Manager) ApplyStateUpdate(update StateUpdate) bool {
+	if m == nil || update.AuthID == "" {
+		return false
+	}
+	resumed, suspended, quotaSet := false, false, false
+	m.mu.Lock()
+	auth, ok := m.auths[update.AuthID]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return false
+	}
+	now := time.Now()
+	if update.Model != "" {
+		if update.ModelState == nil {
+			m.mu.Unlock()
+			return false
+		}
+		if existing := auth.ModelStates[update.Model]; existing != nil && !update.ModelState.UpdatedAt.After(existing.UpdatedAt) {
+			m.mu.Unlock()
+			return false
+		}
+		state := *update.ModelState
+		state.LastError = cloneError(update.ModelState.LastError)
+		if auth.ModelStates == nil {
+			auth.ModelStates = make(map[string]*ModelState)
+		}
+		auth.ModelStates[update.Model] = &state
+		if state.Unavailable {
+			auth.Status = StatusError
+			auth.StatusMessage = state.StatusMessage
+			suspended = update.SuspendReason != ""
+			quotaSet = state.Quota.Exceeded
+		} else {
+			if !hasModelError(auth, now) {
+				auth.Status = StatusActive
+				auth.StatusMessage = ""
+				auth.LastError = nil
+			}
+			resumed = true
+		}
+		updateAggregatedAvailability(auth, now)
+	} else {
+		if !update.UpdatedAt.After(auth.UpdatedAt) {
+			m.mu.Unlock()
+			return false
+		}
+		auth.Disabled = update.Disabled
+		auth.Status = update.Status
+		auth.StatusMessage = update.StatusMessage
+		auth.Unavailable = update.Unavailable
+		auth.NextRetryAfter = update.NextRetryAfter
+		auth.Quota = update.Quota
+		if !update.Unavailable {
+			auth.LastError = nil
+		}
+	}
+	if update.UpdatedAt.After(auth.UpdatedAt) {
+		auth.UpdatedAt = update.UpdatedAt
+	}
+	m.mu.Unlock()
+
+	if update.Model != "" {
+		reg := registry.GetGlobalRegistry()
+		switch {
+		case resumed:
+			reg.ClearModelQuotaExceeded(update.AuthID, update.Model)
+			reg.ResumeClientModel(update.AuthID, update.Model)
+		case suspended:
+			if quotaSet {
+				reg.SetModelQuotaExceeded(update.AuthID, update.Model)
+			}
+			reg.SuspendClientModel(update.AuthID, update.Model, update.SuspendReason)
+		}
+	}
+	return true
+}
@@ -114,9 +114,19 @@ type Manager struct {
 	// modelNameMappings stores global model name alias mappings (alias -> upstream name) keyed by channel.
 	modelNameMappings atomic.Value
 
+	// hedgingRules stores the compiled hedging table used by Execute.
+	hedgingRules atomic.Value
+
+	// healthProbeConfig stores the background health probe settings; probes holds their state.
+	healthProbeConfig atomic.Value
+	probes            healthProbeState
+
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// statePublisher shares MarkResult state changes with peer replicas.
+	statePublisher StatePublisher
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -211,9 +221,18 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	}
 	auth.EnsureIndex()
 	m.mu.Lock()
+	published := availabilityUpdate(m.auths[auth.ID], auth, time.Now())
+	publisher := m.statePublisher
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
 	_ = m.persist(ctx, auth)
+	if publisher != nil && published != nil {
+		publisher.PublishState(ctx, *published)
+	}
+	if auth.Disabled {
+		providerRateLimits.forget(auth.ID)
+		regionCooldowns.forget(auth.ID)
+	}
 	m.hook.OnAuthRegistered(ctx, auth.Clone())
 	return auth.Clone(), nil
 }
@@ -229,9 +248,18 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.indexAssigned = existing.indexAssigned
 	}
 	auth.EnsureIndex()
+	published := availabilityUpdate(m.auths[auth.ID], auth, time.Now())
+	publisher := m.statePublisher
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
 	_ = m.persist(ctx, auth)
+	if publisher != nil && published != nil {
+		publisher.PublishState(ctx, *published)
+	}
+	if auth.Disabled {
+		providerRateLimits.forget(auth.ID)
+		regionCooldowns.forget(auth.ID)
+	}
 	m.hook.OnAuthUpdated(ctx, auth.Clone())
 	return auth.Clone(), nil
 }
@@ -274,11 +302,24 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		attempts = 1
 	}
 
+	runOnce := func(execCtx context.Context) (cliproxyexecutor.Response, error) {
+		return m.executeProvidersOnce(execCtx, rotated, func(providerCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+			return m.executeWithProvider(providerCtx, provider, req, opts)
+		})
+	}
+	hedgeDelay := m.hedgeDelayFor(req.Model)
+
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
-		resp, errExec := m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
-			return m.executeWithProvider(execCtx, provider, req, opts)
-		})
+		var (
+			resp    cliproxyexecutor.Response
+			errExec error
+		)
+		if hedgeDelay > 0 {
+			resp, errExec = m.executeHedged(ctx, req.Model, hedgeDelay, runOnce)
+		} else {
+			resp, errExec = runOnce(ctx)
+		}
 		if errExec == nil {
 			return resp, nil
 		}
@@ -409,7 +450,14 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		}
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		if !hedgeStateFromContext(ctx).claim(auth.ID) {
+			continue
+		}
+		if !usageCapTracker.acquire(auth, time.Now()) {
+			entry.Debugf("Skip saturated auth %s for model %s", auth.ID, req.Model)
+			continue
+		}
+		execCtx := withRateLimitTarget(ctx, auth.ID, routeModel)
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
@@ -418,6 +466,11 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		usageCapTracker.release(auth.ID)
+		if errExec != nil && hedgeAborted(ctx) {
+			// A competing hedge attempt won; do not penalise this auth.
+			return cliproxyexecutor.Response{}, errExec
+		}
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
@@ -471,7 +524,12 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		}
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		// Token-count calls count against the usage caps like any other request.
+		if !usageCapTracker.acquire(auth, time.Now()) {
+			entry.Debugf("Skip saturated auth %s for model %s", auth.ID, req.Model)
+			continue
+		}
+		execCtx := withRateLimitTarget(ctx, auth.ID, routeModel)
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
@@ -480,6 +538,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		usageCapTracker.release(auth.ID)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
@@ -533,7 +592,12 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		}
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		if !usageCapTracker.acquire(auth, time.Now()) {
+			entry.Debugf("Skip saturated auth %s for model %s", auth.ID, req.Model)
+			continue
+		}
+		continuationStateFromContext(ctx).claim(auth.ID)
+		execCtx := withRateLimitTarget(ctx, auth.ID, routeModel)
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
@@ -543,6 +607,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		execReq.Model, execReq.Metadata = m.applyOAuthModelMapping(auth, execReq.Model, execReq.Metadata)
 		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
 		if errStream != nil {
+			usageCapTracker.release(auth.ID)
 			rerr := &Error{Message: errStream.Error()}
 			var se cliproxyexecutor.StatusError
 			if errors.As(errStream, &se) && se != nil {
@@ -557,6 +622,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
+			defer usageCapTracker.release(streamAuth.ID)
 			var failed bool
 			for chunk := range streamChunks {
 				if chunk.Err != nil && !failed {
@@ -639,8 +705,13 @@ func filterAllowedProviders(ctx context.Context, providers []string) []string {
 	if !exists {
 		return providers
 	}
-	allowed, ok := allowedRaw.([]string)
-	if !ok || len(allowed) == 0 {
+	allowed, _ := allowedRaw.([]string)
+	return restrictProviders(providers, allowed)
+}
+
+// restrictProviders keeps only the providers present in allowed; an empty allow-list keeps all.
+func restrictProviders(providers []string, allowed []string) []string {
+	if len(allowed) == 0 {
 		return providers
 	}
 	allowedSet := make(map[string]struct{}, len(allowed))
@@ -833,15 +904,22 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	suspendReason := ""
 	clearModelQuota := false
 	setModelQuota := false
+	var published *StateUpdate
 
 	m.mu.Lock()
+	publisher := m.statePublisher
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
 
 		if result.Success {
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
+				wasBlocked := modelStateNeedsReset(state)
 				resetModelState(state, now)
+				if wasBlocked {
+					update := modelStateUpdate(auth.ID, result.Model, state, "")
+					published = &update
+				}
 				updateAggregatedAvailability(auth, now)
 				if !hasModelError(auth, now) {
 					auth.LastError = nil
@@ -852,7 +930,12 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				shouldResumeModel = true
 				clearModelQuota = true
 			} else {
+				wasBlocked := authStateNeedsReset(auth)
 				clearAuthStateOnSuccess(auth, now)
+				if wasBlocked {
+					update := authStateUpdate(auth)
+					published = &update
+				}
 			}
 		} else {
 			if result.Model != "" {
@@ -916,8 +999,12 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				auth.Status = StatusError
 				auth.UpdatedAt = now
 				updateAggregatedAvailability(auth, now)
+				update := modelStateUpdate(auth.ID, result.Model, state, suspendReason)
+				published = &update
 			} else {
 				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
+				update := authStateUpdate(auth)
+				published = &update
 			}
 		}
 
@@ -936,6 +1023,9 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	} else if shouldSuspendModel {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
+	if publisher != nil && published != nil {
+		publisher.PublishState(ctx, *published)
+	}
 
 	m.hook.OnResult(ctx, result)
 }
@@ -1219,6 +1309,8 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		return nil, nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	candidates := make([]*Auth, 0, len(m.auths))
+	hedge := hedgeStateFromContext(ctx)
+	resumed := continuationStateFromContext(ctx)
 	modelKey := strings.TrimSpace(model)
 	registryRef := registry.GetGlobalRegistry()
 	for _, candidate := range m.auths {
@@ -1228,6 +1320,12 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
+		if hedge.isClaimed(candidate.ID) {
+			continue
+		}
+		if resumed.isClaimed(candidate.ID) {
+			continue
+		}
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
@@ -1324,6 +1422,16 @@ func (m *Manager) StartAutoRefresh(parent context.Context, interval time.Duratio
 	}()
 }
 
+// RefreshAuth refreshes the auth's credentials now through its executor and persists the
+// result. It returns nil without refreshing when the auth or its executor is unknown, or when
+// another replica holds the refresh lease.
+func (m *Manager) RefreshAuth(ctx context.Context, id string) error {
+	if m == nil {
+		return nil
+	}
+	return m.refreshAuth(ctx, id)
+}
+
 // StopAutoRefresh cancels the background refresh loop, if running.
 func (m *Manager) StopAutoRefresh() {
 	if m.refreshCancel != nil {
@@ -1585,7 +1693,9 @@ func (m *Manager) markRefreshPending(id string, now time.Time) bool {
 	return true
 }
 
-func (m *Manager) refreshAuth(ctx context.Context, id string) {
+// refreshAuth refreshes the auth's credentials through its executor. It returns the refresh
+// error; a refresh left to another process holding the lease is not an error.
+func (m *Manager) refreshAuth(ctx context.Context, id string) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -1597,13 +1707,18 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	}
 	m.mu.RUnlock()
 	if auth == nil || exec == nil {
-		return
+		return nil
+	}
+	release, proceed := m.acquireRefreshLease(ctx, auth)
+	if !proceed {
+		return nil
 	}
+	defer release()
 	cloned := auth.Clone()
 	updated, err := exec.Refresh(ctx, cloned)
 	if err != nil && errors.Is(err, context.Canceled) {
 		log.Debugf("refresh canceled for %s, %s", auth.Provider, auth.ID)
-		return
+		return err
 	}
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
@@ -1615,7 +1730,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 			m.auths[id] = current
 		}
 		m.mu.Unlock()
-		return
+		return err
 	}
 	if updated == nil {
 		updated = cloned
@@ -1630,6 +1745,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	updated.LastError = nil
 	updated.UpdatedAt = now
 	_, _ = m.Update(ctx, updated)
+	return nil
 }
 
 func (m *Manager) executorFor(provider string) ProviderExecutor {
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+)
+
+// RouteCandidate describes how the manager would treat one auth for a request.
+type RouteCandidate struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider"`
+	Label    string `json:"label,omitempty"`
+	// Eligible reports whether the selector would consider the auth right now.
+	Eligible bool `json:"eligible"`
+	// BlockReason explains why an ineligible auth is skipped.
+	BlockReason string     `json:"block_reason,omitempty"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+	// UpstreamModel is the model after prefix stripping and OAuth model mappings.
+	UpstreamModel string `json:"upstream_model"`
+	// Metadata is the execution metadata after the same rewrites.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// Auth is a snapshot of the auth for callers that need to preview the upstream request.
+	Auth *Auth `json:"-"`
+}
+
+// RouteFilter carries the per-API-key restrictions normally set by the limits middleware.
+type RouteFilter struct {
+	AllowedProviders   []string
+	AllowedCredentials []string
+}
+
+// ExplainRoute reports, without executing anything or advancing selector state, which providers
+// a request for model would try and how every auth of those providers would be treated.
+func (m *Manager) ExplainRoute(providers []string, model string, metadata map[string]any, filter RouteFilter) ([]string, []RouteCandidate) {
+	if m == nil {
+		return nil, nil
+	}
+	normalized := restrictProviders(m.normalizeProviders(providers), filter.AllowedProviders)
+	allowedCreds := make(map[string]struct{}, len(filter.AllowedCredentials))
+	for _, id := range filter.AllowedCredentials {
+		allowedCreds[id] = struct{}{}
+	}
+	providerSet := make(map[string]struct{}, len(normalized))
+	for _, provider := range normalized {
+		providerSet[provider] = struct{}{}
+	}
+
+	now := time.Now()
+	modelKey := strings.TrimSpace(model)
+	registryRef := registry.GetGlobalRegistry()
+	m.mu.RLock()
+	candidates := make([]RouteCandidate, 0)
+	for _, auth := range m.auths {
+		if _, ok := providerSet[auth.Provider]; !ok {
+			continue
+		}
+		candidate := RouteCandidate{
+			AuthID:   auth.ID,
+			Provider: auth.Provider,
+			Label:    auth.Label,
+			Auth:     auth.Clone(),
+		}
+		candidate.UpstreamModel, candidate.Metadata = rewriteModelForAuth(model, metadata, auth)
+		candidate.UpstreamModel, candidate.Metadata = m.applyOAuthModelMapping(auth, candidate.UpstreamModel, candidate.Metadata)
+		switch {
+		case m.executors[auth.Provider] == nil:
+			candidate.BlockReason = "executor_not_registered"
+		case auth.Disabled:
+			candidate.BlockReason = blockReasonDisabled.String()
+		case modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(auth.ID, modelKey):
+			candidate.BlockReason = "model_not_supported"
+		case len(allowedCreds) > 0 && !credentialAllowed(allowedCreds, auth.ID):
+			candidate.BlockReason = "credential_not_allowed"
+		default:
+			if blocked, reason, next := isAuthBlockedForModel(auth, model, now); blocked {
+				candidate.BlockReason = reason.String()
+				if !next.IsZero() {
+					retry := next
+					candidate.NextRetryAt = &retry
+				}
+			} else {
+				candidate.Eligible = true
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	m.mu.RUnlock()
+
+	order := make(map[string]int, len(normalized))
+	for i, provider := range normalized {
+		order[provider] = i
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Provider != candidates[j].Provider {
+			return order[candidates[i].Provider] < order[candidates[j].Provider]
+		}
+		if candidates[i].Eligible != candidates[j].Eligible {
+			return candidates[i].Eligible
+		}
+		return candidates[i].AuthID < candidates[j].AuthID
+	})
+	return normalized, candidates
+}
+
+func credentialAllowed(allowed map[string]struct{}, id string) bool {
+	_, ok := allowed[id]
+	return ok
+}
+
+// String returns the identifier used when reporting the reason to operators.
+func (r blockReason) String() string {
+	switch r {
+	case blockReasonNone:
+		return ""
+	case blockReasonCooldown:
+		return "cooldown"
+	case blockReasonDisabled:
+		return "disabled"
+	case blockReasonSaturated:
+		return "saturated"
+	default:
+		return "unavailable"
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+)
+
+type explainStubExecutor struct{}
+
+func (explainStubExecutor) Identifier() string { return "explain-test" }
+
+func (explainStubExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (explainStubExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (explainStubExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (explainStubExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestManagerExplainRoute_ReportsBlockReasons(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(explainStubExecutor{})
+
+	cooldownUntil := time.Now().Add(time.Hour)
+	auths := []*Auth{
+		{ID: "explain-ready", Provider: "explain-test", Prefix: "team", Status: StatusActive},
+		{ID: "explain-cooling", Provider: "explain-test", Status: StatusActive, ModelStates: map[string]*ModelState{
+			"team/explain-model": {Status: StatusError, Unavailable: true, NextRetryAfter: cooldownUntil, Quota: QuotaState{Exceeded: true, NextRecoverAt: cooldownUntil}},
+		}},
+		{ID: "explain-other", Provider: "explain-test", Status: StatusActive},
+	}
+	for _, auth := range auths {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register(%s): %v", auth.ID, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "team/explain-model"}})
+		id := auth.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	providers, candidates := m.ExplainRoute([]string{"explain-test"}, "team/explain-model", nil, RouteFilter{
+		AllowedCredentials: []string{"explain-ready", "explain-cooling"},
+	})
+	if len(providers) != 1 || providers[0] != "explain-test" {
+		t.Fatalf("providers = %v, want [explain-test]", providers)
+	}
+	got := make(map[string]RouteCandidate, len(candidates))
+	for _, candidate := range candidates {
+		got[candidate.AuthID] = candidate
+	}
+	if c := got["explain-ready"]; !c.Eligible || c.UpstreamModel != "explain-model" {
+		t.Fatalf("explain-ready = %+v, want eligible with prefix stripped", c)
+	}
+	if c := got["explain-cooling"]; c.Eligible || c.BlockReason != "cooldown" || c.NextRetryAt == nil {
+		t.Fatalf("explain-cooling = %+v, want cooldown with retry time", c)
+	}
+	if c := got["explain-other"]; c.Eligible || c.BlockReason != "credential_not_allowed" {
+		t.Fatalf("explain-other = %+v, want credential_not_allowed", c)
+	}
+	if candidates[0].AuthID != "explain-ready" {
+		t.Fatalf("eligible candidates should sort first, got %s", candidates[0].AuthID)
+	}
+}
@@ -0,0 +1,427 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+	sdktranslator "github.com/giofahreza/AIProxyAPI/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// AttrDisableHealthProbe opts a single auth out of background health probes when set to true,
+// either as an attribute (config-backed auths) or in metadata (auth JSON files).
+const AttrDisableHealthProbe = "disable_health_probe"
+
+const (
+	defaultProbeInterval = 10 * time.Minute
+	defaultProbeTimeout  = 30 * time.Second
+	probeCheckInterval   = 30 * time.Second
+	probeConcurrency     = 4
+	probeHistorySize     = 20
+	// probeDisableAfter is how many probes in a row must be rejected as unauthorized before the
+	// auth is disabled. One rejection may just be an expired access token, so the first one
+	// triggers a refresh and only a failed refresh disables the auth straight away.
+	probeDisableAfter = 2
+)
+
+// HealthProber is implemented by executors that can check a credential without generating a
+// completion, for example by listing models or reading quota.
+type HealthProber interface {
+	Probe(ctx context.Context, auth *Auth) error
+}
+
+// ProbeResult records one background health probe of an auth.
+type ProbeResult struct {
+	At time.Time `json:"at"`
+	// Method is "endpoint" when the executor's own check was used and "completion" otherwise.
+	Method     string `json:"method"`
+	Model      string `json:"model,omitempty"`
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+	// Disabled is set when this probe disabled the auth.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+type healthProbeSettings struct {
+	enabled   bool
+	interval  time.Duration
+	timeout   time.Duration
+	providers map[string]internalconfig.HealthProbeProvider
+}
+
+// healthProbeState keeps probe history per auth.
+type healthProbeState struct {
+	mu      sync.Mutex
+	history map[string][]ProbeResult
+	running map[string]bool
+	cancel  context.CancelFunc
+}
+
+// SetHealthProbeConfig updates the background health probe settings. It takes effect on the
+// next probe round.
+func (m *Manager) SetHealthProbeConfig(cfg internalconfig.HealthProbeConfig) {
+	if m == nil {
+		return
+	}
+	settings := &healthProbeSettings{
+		enabled:   cfg.Enabled,
+		interval:  time.Duration(cfg.IntervalSeconds) * time.Second,
+		timeout:   time.Duration(cfg.TimeoutSeconds) * time.Second,
+		providers: make(map[string]internalconfig.HealthProbeProvider, len(cfg.Providers)),
+	}
+	if settings.interval <= 0 {
+		settings.interval = defaultProbeInterval
+	}
+	if settings.timeout <= 0 {
+		settings.timeout = defaultProbeTimeout
+	}
+	for provider, providerCfg := range cfg.Providers {
+		settings.providers[strings.ToLower(strings.TrimSpace(provider))] = providerCfg
+	}
+	m.healthProbeConfig.Store(settings)
+}
+
+func (m *Manager) healthProbeSettings() *healthProbeSettings {
+	settings, _ := m.healthProbeConfig.Load().(*healthProbeSettings)
+	if settings == nil {
+		return &healthProbeSettings{interval: defaultProbeInterval, timeout: defaultProbeTimeout}
+	}
+	return settings
+}
+
+// StartHealthProbes launches the background loop that probes enabled auths. The loop idles
+// while probes are disabled in the configuration. Starting it again replaces the previous loop.
+func (m *Manager) StartHealthProbes(parent context.Context) {
+	if m == nil {
+		return
+	}
+	m.StopHealthProbes()
+	ctx, cancel := context.WithCancel(parent)
+	m.probes.mu.Lock()
+	m.probes.cancel = cancel
+	m.probes.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(probeCheckInterval)
+		defer ticker.Stop()
+		for {
+			m.probeDueAuths(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopHealthProbes cancels the background probe loop, if running.
+func (m *Manager) StopHealthProbes() {
+	if m == nil {
+		return
+	}
+	m.probes.mu.Lock()
+	cancel := m.probes.cancel
+	m.probes.cancel = nil
+	m.probes.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// probeDueAuths probes every eligible auth whose last probe is older than the interval.
+func (m *Manager) probeDueAuths(ctx context.Context) {
+	settings := m.healthProbeSettings()
+	if !settings.enabled {
+		return
+	}
+	now := time.Now()
+	var due []*Auth
+	for _, auth := range m.snapshotAuths() {
+		if !m.probeEligible(auth, settings) {
+			continue
+		}
+		m.probes.mu.Lock()
+		history := m.probes.history[auth.ID]
+		running := m.probes.running[auth.ID]
+		m.probes.mu.Unlock()
+		if running {
+			continue
+		}
+		if len(history) > 0 && now.Sub(history[len(history)-1].At) < settings.interval {
+			continue
+		}
+		due = append(due, auth)
+	}
+
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+	for _, auth := range due {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := m.ProbeAuth(ctx, id); err != nil {
+				log.Debugf("health probe skipped for %s: %v", id, err)
+			}
+		}(auth.ID)
+	}
+	wg.Wait()
+}
+
+func (m *Manager) probeEligible(auth *Auth, settings *healthProbeSettings) bool {
+	if auth == nil || auth.Disabled || auth.Status == StatusDisabled {
+		return false
+	}
+	if settings.providers[strings.ToLower(auth.Provider)].Disabled || authFlag(auth, AttrDisableHealthProbe) {
+		return false
+	}
+	return m.executorFor(auth.Provider) != nil
+}
+
+// ProbeAuth runs one health probe against the auth now, records it in the probe history and
+// reflects it in the auth's Status and StatusMessage. Probe outcomes stay out of the auth's
+// cooldown and quota state, which belongs to real traffic; a confirmed 401 disables the auth.
+func (m *Manager) ProbeAuth(ctx context.Context, id string) (ProbeResult, error) {
+	if m == nil {
+		return ProbeResult{}, errors.New("auth manager unavailable")
+	}
+	auth, ok := m.GetByID(id)
+	if !ok {
+		return ProbeResult{}, fmt.Errorf("auth %s not found", id)
+	}
+	executor := m.executorFor(auth.Provider)
+	if executor == nil {
+		return ProbeResult{}, fmt.Errorf("no executor for provider %s", auth.Provider)
+	}
+	m.probes.mu.Lock()
+	if m.probes.running == nil {
+		m.probes.running = make(map[string]bool)
+	}
+	if m.probes.running[id] {
+		m.probes.mu.Unlock()
+		return ProbeResult{}, fmt.Errorf("auth %s is already being probed", id)
+	}
+	m.probes.running[id] = true
+	m.probes.mu.Unlock()
+	defer func() {
+		m.probes.mu.Lock()
+		delete(m.probes.running, id)
+		m.probes.mu.Unlock()
+	}()
+
+	settings := m.healthProbeSettings()
+	providerCfg := settings.providers[strings.ToLower(auth.Provider)]
+
+	probeCtx, cancel := context.WithTimeout(ctx, settings.timeout)
+	defer cancel()
+	// Probe traffic is not billed to anyone.
+	probeCtx, deferred := coreusage.WithDeferredPublish(probeCtx)
+	deferred.Discard()
+	if rt := m.roundTripperFor(auth); rt != nil {
+		probeCtx = context.WithValue(probeCtx, roundTripperContextKey{}, rt)
+		probeCtx = context.WithValue(probeCtx, "cliproxy.roundtripper", rt)
+	}
+
+	result := ProbeResult{At: time.Now()}
+	var errProbe error
+	if prober, okProber := executor.(HealthProber); okProber {
+		result.Method = "endpoint"
+		errProbe = prober.Probe(probeCtx, auth)
+	} else {
+		result.Method = "completion"
+		result.Model = probeModel(auth, providerCfg)
+		if result.Model == "" {
+			return ProbeResult{}, fmt.Errorf("no model to probe auth %s with", id)
+		}
+		if blocked, _, _ := isAuthBlockedForModel(auth, result.Model, result.At); blocked {
+			return ProbeResult{}, fmt.Errorf("auth %s is cooling down for %s", id, result.Model)
+		}
+		probeCtx = withRateLimitTarget(probeCtx, auth.ID, result.Model)
+		req := cliproxyexecutor.Request{
+			Model:   result.Model,
+			Payload: []byte(fmt.Sprintf(`{"model":%q,"messages":[{"role":"user","content":"ping"}],"max_tokens":1}`, result.Model)),
+		}
+		opts := cliproxyexecutor.Options{OriginalRequest: req.Payload, SourceFormat: sdktranslator.FormatOpenAI}
+		_, errProbe = executor.Execute(probeCtx, auth, req, opts)
+	}
+	result.LatencyMS = time.Since(result.At).Milliseconds()
+	if errors.Is(errProbe, context.Canceled) && ctx.Err() != nil {
+		return ProbeResult{}, ctx.Err()
+	}
+
+	if errProbe != nil {
+		result.Error = errProbe.Error()
+		result.StatusCode = statusCodeFromError(errProbe)
+	} else {
+		result.Success = true
+	}
+	if result.StatusCode == http.StatusUnauthorized {
+		result.Disabled = m.handleUnauthorizedProbe(ctx, auth.ID, result.Error)
+	} else {
+		m.applyProbeStatus(ctx, auth.ID, result)
+	}
+	m.recordProbe(auth.ID, result)
+	return result, nil
+}
+
+// applyProbeStatus marks the auth as failing after a failed probe and as active again after a
+// successful one. A success leaves the status alone while real traffic has the auth cooling down
+// or over quota, since that state explains the status better than the probe does.
+func (m *Manager) applyProbeStatus(ctx context.Context, id string, result ProbeResult) {
+	auth, ok := m.GetByID(id)
+	if !ok || auth.Disabled {
+		return
+	}
+	status, message := StatusActive, ""
+	if !result.Success {
+		status, message = StatusError, "health probe failed: "+result.Error
+	} else if auth.Unavailable || auth.Quota.Exceeded {
+		return
+	}
+	if auth.Status == status && auth.StatusMessage == message {
+		return
+	}
+	auth.Status = status
+	auth.StatusMessage = message
+	auth.UpdatedAt = time.Now()
+	if _, err := m.Update(ctx, auth); err != nil {
+		log.WithError(err).Warnf("health probe: failed to update status of auth %s", id)
+	}
+}
+
+// handleUnauthorizedProbe decides whether a 401 probe confirms that the auth is revoked. After a
+// first rejection the credentials are refreshed, and the auth is disabled when that refresh fails
+// or when the probe following it is rejected again. It reports whether the auth was disabled.
+func (m *Manager) handleUnauthorizedProbe(ctx context.Context, id, reason string) bool {
+	if m.consecutiveUnauthorizedProbes(id) >= probeDisableAfter-1 {
+		return m.disableAfterProbe(ctx, id, reason)
+	}
+	if errRefresh := m.refreshAuth(ctx, id); errRefresh != nil {
+		return m.disableAfterProbe(ctx, id, reason+"; refresh failed: "+errRefresh.Error())
+	}
+	return false
+}
+
+// consecutiveUnauthorizedProbes counts the unauthorized probes at the end of the history.
+func (m *Manager) consecutiveUnauthorizedProbes(id string) int {
+	m.probes.mu.Lock()
+	defer m.probes.mu.Unlock()
+	history := m.probes.history[id]
+	count := 0
+	for i := len(history) - 1; i >= 0 && history[i].StatusCode == 401; i-- {
+		count++
+	}
+	return count
+}
+
+func (m *Manager) disableAfterProbe(ctx context.Context, id, reason string) bool {
+	auth, ok := m.GetByID(id)
+	if !ok || auth.Disabled {
+		return false
+	}
+	auth.Disabled = true
+	auth.Status = StatusDisabled
+	auth.StatusMessage = "disabled by health probe: " + reason
+	auth.UpdatedAt = time.Now()
+	if _, err := m.Update(ctx, auth); err != nil {
+		log.WithError(err).Warnf("health probe: failed to disable auth %s", id)
+		return false
+	}
+	log.Warnf("health probe: disabled %s auth %s after unauthorized responses: %s", auth.Provider, id, reason)
+	return true
+}
+
+func (m *Manager) recordProbe(id string, result ProbeResult) {
+	m.probes.mu.Lock()
+	defer m.probes.mu.Unlock()
+	if m.probes.history == nil {
+		m.probes.history = make(map[string][]ProbeResult)
+	}
+	history := append(m.probes.history[id], result)
+	if len(history) > probeHistorySize {
+		history = history[len(history)-probeHistorySize:]
+	}
+	m.probes.history[id] = history
+}
+
+// ProbeHistory returns the recent health probes of an auth, oldest first.
+func (m *Manager) ProbeHistory(id string) []ProbeResult {
+	if m == nil {
+		return nil
+	}
+	m.probes.mu.Lock()
+	defer m.probes.mu.Unlock()
+	return append([]ProbeResult(nil), m.probes.history[id]...)
+}
+
+// ProbeHistories returns the recent health probes of every probed auth, keyed by auth ID.
+func (m *Manager) ProbeHistories() map[string][]ProbeResult {
+	if m == nil {
+		return nil
+	}
+	m.probes.mu.Lock()
+	defer m.probes.mu.Unlock()
+	out := make(map[string][]ProbeResult, len(m.probes.history))
+	for id, history := range m.probes.history {
+		out[id] = append([]ProbeResult(nil), history...)
+	}
+	return out
+}
+
+// probeModel picks the configured probe model for the provider, or else the first model
+// registered for the auth that is not opted out.
+func probeModel(auth *Auth, cfg internalconfig.HealthProbeProvider) string {
+	if model := strings.TrimSpace(cfg.Model); model != "" {
+		return model
+	}
+	for _, info := range registry.GetGlobalRegistry().GetModelsForClient(auth.ID) {
+		if info == nil || info.ID == "" || util.MatchAnyWildcard(cfg.SkipModels, info.ID) {
+			continue
+		}
+		return info.ID
+	}
+	return ""
+}
+
+// authFlag reports whether key is set to a true value in the auth attributes or metadata.
+func authFlag(auth *Auth, key string) bool {
+	if auth.Attributes != nil {
+		if raw := strings.ToLower(strings.TrimSpace(auth.Attributes[key])); raw == "true" || raw == "1" {
+			return true
+		}
+	}
+	if auth.Metadata == nil {
+		return false
+	}
+	for _, k := range []string{key, strings.ReplaceAll(key, "_", "-")} {
+		switch v := auth.Metadata[k].(type) {
+		case bool:
+			if v {
+				return true
+			}
+		case string:
+			if raw := strings.ToLower(strings.TrimSpace(v)); raw == "true" || raw == "1" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalconfig "github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+)
+
+type probeStatusError int
+
+func (e probeStatusError) Error() string   { return "probe failed" }
+func (e probeStatusError) StatusCode() int { return int(e) }
+
+type probeExecutor struct {
+	provider   string
+	err        error
+	model      string
+	refreshErr error
+	refreshes  int
+}
+
+func (e *probeExecutor) Identifier() string { return e.provider }
+
+func (e *probeExecutor) Execute(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.model = req.Model
+	return cliproxyexecutor.Response{}, e.err
+}
+
+func (e *probeExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *probeExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.refreshes++
+	if e.refreshErr != nil {
+		return nil, e.refreshErr
+	}
+	return auth, nil
+}
+
+func (e *probeExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+type probeEndpointExecutor struct {
+	probeExecutor
+	probes int
+}
+
+func (e *probeEndpointExecutor) Probe(context.Context, *Auth) error {
+	e.probes++
+	return e.err
+}
+
+func TestManagerProbeAuth_CompletionProbeUsesConfiguredModel(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &probeExecutor{provider: "claude"}
+	m.RegisterExecutor(executor)
+	m.SetHealthProbeConfig(internalconfig.HealthProbeConfig{
+		Enabled:   true,
+		Providers: map[string]internalconfig.HealthProbeProvider{"claude": {Model: "claude-haiku"}},
+	})
+	if _, err := m.Register(context.Background(), &Auth{ID: "a1", Provider: "claude"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	result, err := m.ProbeAuth(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if !result.Success || result.Method != "completion" || result.Model != "claude-haiku" {
+		t.Fatalf("ProbeAuth() = %+v, want successful completion probe of claude-haiku", result)
+	}
+	if executor.model != "claude-haiku" {
+		t.Fatalf("executor model = %q, want claude-haiku", executor.model)
+	}
+	if history := m.ProbeHistory("a1"); len(history) != 1 {
+		t.Fatalf("ProbeHistory() len = %d, want 1", len(history))
+	}
+}
+
+func TestManagerProbeAuth_DisablesAfterRepeatedUnauthorized(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &probeEndpointExecutor{probeExecutor: probeExecutor{provider: "openai-compat", err: probeStatusError(401)}}
+	m.RegisterExecutor(executor)
+	if _, err := m.Register(context.Background(), &Auth{ID: "a1", Provider: "openai-compat"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	first, err := m.ProbeAuth(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if first.Success || first.Method != "endpoint" || first.StatusCode != 401 || first.Disabled {
+		t.Fatalf("first probe = %+v, want failed endpoint probe that does not disable", first)
+	}
+	if auth, _ := m.GetByID("a1"); auth.Disabled {
+		t.Fatalf("auth disabled after a single unauthorized probe")
+	}
+
+	second, err := m.ProbeAuth(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if !second.Disabled {
+		t.Fatalf("second probe = %+v, want auth disabled", second)
+	}
+	auth, _ := m.GetByID("a1")
+	if !auth.Disabled || auth.Status != StatusDisabled {
+		t.Fatalf("auth = disabled %t status %s, want disabled", auth.Disabled, auth.Status)
+	}
+	if executor.probes != 2 {
+		t.Fatalf("endpoint probes = %d, want 2", executor.probes)
+	}
+	if executor.refreshes != 1 {
+		t.Fatalf("refreshes = %d, want 1 after the first unauthorized probe", executor.refreshes)
+	}
+}
+
+func TestManagerProbeAuth_DisablesWhenRefreshFails(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &probeEndpointExecutor{probeExecutor: probeExecutor{
+		provider:   "claude",
+		err:        probeStatusError(401),
+		refreshErr: errors.New("invalid_grant"),
+	}}
+	m.RegisterExecutor(executor)
+	if _, err := m.Register(context.Background(), &Auth{ID: "a1", Provider: "claude"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	result, err := m.ProbeAuth(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if !result.Disabled || executor.refreshes != 1 {
+		t.Fatalf("probe = %+v after %d refreshes, want auth disabled after one failed refresh", result, executor.refreshes)
+	}
+	if auth, _ := m.GetByID("a1"); !auth.Disabled {
+		t.Fatalf("auth not disabled after a failed refresh")
+	}
+}
+
+func TestManagerProbeAuth_LeavesTrafficStateAlone(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &probeEndpointExecutor{probeExecutor: probeExecutor{provider: "codex"}}
+	m.RegisterExecutor(executor)
+	recoverAt := time.Now().Add(time.Hour)
+	if _, err := m.Register(context.Background(), &Auth{
+		ID: "a1", Provider: "codex", Status: StatusError, Unavailable: true, NextRetryAfter: recoverAt,
+		Quota: QuotaState{Exceeded: true, Reason: "quota", NextRecoverAt: recoverAt},
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if result, err := m.ProbeAuth(context.Background(), "a1"); err != nil || !result.Success {
+		t.Fatalf("ProbeAuth() = %+v, %v, want success", result, err)
+	}
+	auth, _ := m.GetByID("a1")
+	if !auth.Unavailable || !auth.Quota.Exceeded || !auth.NextRetryAfter.Equal(recoverAt) {
+		t.Fatalf("auth = %+v, want the quota cooldown set by traffic to survive a successful probe", auth)
+	}
+
+	for _, status := range []int{403, 429, 503} {
+		executor.err = probeStatusError(status)
+		if _, err := m.ProbeAuth(context.Background(), "a1"); err != nil {
+			t.Fatalf("ProbeAuth() error = %v", err)
+		}
+	}
+	auth, _ = m.GetByID("a1")
+	if auth.Disabled || !auth.NextRetryAfter.Equal(recoverAt) || auth.LastError != nil {
+		t.Fatalf("auth = %+v, want failing probes to leave the cooldown state untouched", auth)
+	}
+	if history := m.ProbeHistory("a1"); len(history) != 4 || history[3].StatusCode != 503 {
+		t.Fatalf("ProbeHistory() = %+v, want all four probes recorded", history)
+	}
+}
+
+func TestManagerProbeAuth_UpdatesStatusMessage(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &probeEndpointExecutor{probeExecutor: probeExecutor{provider: "codex", err: probeStatusError(503)}}
+	m.RegisterExecutor(executor)
+	if _, err := m.Register(context.Background(), &Auth{ID: "a1", Provider: "codex", Status: StatusActive}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if _, err := m.ProbeAuth(context.Background(), "a1"); err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	auth, _ := m.GetByID("a1")
+	if auth.Status != StatusError || auth.StatusMessage != "health probe failed: probe failed" {
+		t.Fatalf("auth status = %q/%q, want error/%q after a failed probe", auth.Status, auth.StatusMessage, "health probe failed: probe failed")
+	}
+	if auth.Unavailable || !auth.NextRetryAfter.IsZero() {
+		t.Fatalf("auth = %+v, want a failed probe to leave the cooldown alone", auth)
+	}
+
+	executor.err = nil
+	if _, err := m.ProbeAuth(context.Background(), "a1"); err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	auth, _ = m.GetByID("a1")
+	if auth.Status != StatusActive || auth.StatusMessage != "" {
+		t.Fatalf("auth status = %q/%q, want active with no message after a recovered probe", auth.Status, auth.StatusMessage)
+	}
+}
+
+func TestManagerProbeEligible_OptOut(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(&probeExecutor{provider: "gemini"})
+	m.RegisterExecutor(&probeExecutor{provider: "codex"})
+	m.SetHealthProbeConfig(internalconfig.HealthProbeConfig{
+		Enabled:   true,
+		Providers: map[string]internalconfig.HealthProbeProvider{"codex": {Disabled: true}},
+	})
+	settings := m.healthProbeSettings()
+
+	cases := []struct {
+		name string
+		auth *Auth
+		want bool
+	}{
+		{"eligible", &Auth{ID: "g1", Provider: "gemini"}, true},
+		{"provider opted out", &Auth{ID: "c1", Provider: "codex"}, false},
+		{"auth opted out", &Auth{ID: "g2", Provider: "gemini", Metadata: map[string]any{"disable-health-probe": true}}, false},
+		{"disabled auth", &Auth{ID: "g3", Provider: "gemini", Disabled: true}, false},
+		{"no executor", &Auth{ID: "x1", Provider: "unknown"}, false},
+	}
+	for _, tc := range cases {
+		if got := m.probeEligible(tc.auth, settings); got != tc.want {
+			t.Errorf("%s: probeEligible() = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestProbeModel_SkipModelsUseHedgingWildcards(t *testing.T) {
+	auth := &Auth{ID: "probe-skip", Provider: "openrouter"}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{
+		{ID: "openrouter/gemini-pro"},
+		{ID: "openrouter/gemini-flash"},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	// '*' spans '/' like in hedging rules, unlike path-style globbing.
+	got := probeModel(auth, internalconfig.HealthProbeProvider{SkipModels: []string{"*-pro"}})
+	if got != "openrouter/gemini-flash" {
+		t.Fatalf("probeModel() = %q, want %q", got, "openrouter/gemini-flash")
+	}
+}
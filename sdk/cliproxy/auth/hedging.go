@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+type hedgingRule struct {
+	patterns []string
+	delay    time.Duration
+}
+
+type hedgingTable struct {
+	rules []hedgingRule
+}
+
+// SetHedgingRules updates the model patterns that opt into hedged non-streaming execution.
+func (m *Manager) SetHedgingRules(rules []internalconfig.HedgingRule) {
+	if m == nil {
+		return
+	}
+	table := &hedgingTable{rules: make([]hedgingRule, 0, len(rules))}
+	for _, rule := range rules {
+		if rule.DelayMS <= 0 || len(rule.Models) == 0 {
+			continue
+		}
+		patterns := make([]string, 0, len(rule.Models))
+		for _, model := range rule.Models {
+			if trimmed := strings.ToLower(strings.TrimSpace(model)); trimmed != "" {
+				patterns = append(patterns, trimmed)
+			}
+		}
+		if len(patterns) == 0 {
+			continue
+		}
+		table.rules = append(table.rules, hedgingRule{patterns: patterns, delay: time.Duration(rule.DelayMS) * time.Millisecond})
+	}
+	m.hedgingRules.Store(table)
+}
+
+// hedgeDelayFor returns the hedge delay configured for the model, or zero when hedging is off.
+func (m *Manager) hedgeDelayFor(model string) time.Duration {
+	if m == nil {
+		return 0
+	}
+	table, _ := m.hedgingRules.Load().(*hedgingTable)
+	if table == nil || len(table.rules) == 0 {
+		return 0
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return 0
+	}
+	for _, rule := range table.rules {
+		for _, pattern := range rule.patterns {
+			if util.MatchWildcard(pattern, model) {
+				return rule.delay
+			}
+		}
+	}
+	return 0
+}
+
+// hedgeState is shared by sibling attempts of one request (hedges or stream continuations)
+// so they never pick the same auth.
+type hedgeState struct {
+	mu      sync.Mutex
+	claimed map[string]struct{}
+	// first is closed once any attempt has claimed an auth.
+	first     chan struct{}
+	firstOnce sync.Once
+}
+
+func newHedgeState() *hedgeState {
+	return &hedgeState{claimed: make(map[string]struct{}), first: make(chan struct{})}
+}
+
+type hedgeContextKey struct{}
+
+func hedgeStateFromContext(ctx context.Context) *hedgeState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(hedgeContextKey{}).(*hedgeState)
+	return state
+}
+
+// claim reserves the auth for the calling attempt; it returns false when another attempt owns it.
+func (h *hedgeState) claim(id string) bool {
+	if h == nil {
+		return true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.claimed[id]; ok {
+		return false
+	}
+	h.claimed[id] = struct{}{}
+	h.firstOnce.Do(func() { close(h.first) })
+	return true
+}
+
+func (h *hedgeState) isClaimed(id string) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.claimed[id]
+	return ok
+}
+
+// hedgeAborted reports whether an attempt failed because a competing hedge attempt won.
+func hedgeAborted(ctx context.Context) bool {
+	return hedgeStateFromContext(ctx) != nil && ctx.Err() != nil
+}
+
+type hedgeOutcome struct {
+	index int
+	resp  cliproxyexecutor.Response
+	err   error
+}
+
+// executeHedged runs fn and, if it has not returned within delay, races a second attempt
+// against it. The hedge starts only once the primary attempt has claimed an auth, so the
+// hedge always picks a different credential. The first success wins; the loser is cancelled
+// and its usage is kept out of billing but still counted against the daily usage caps,
+// because the upstream charged it.
+func (m *Manager) executeHedged(ctx context.Context, model string, delay time.Duration, fn func(context.Context) (cliproxyexecutor.Response, error)) (cliproxyexecutor.Response, error) {
+	state := newHedgeState()
+	hedgeCtx := context.WithValue(ctx, hedgeContextKey{}, state)
+	results := make(chan hedgeOutcome, 2)
+	var (
+		cancels  []context.CancelFunc
+		deferred []*coreusage.Deferred
+	)
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	start := func() {
+		attemptCtx, cancel := context.WithCancel(hedgeCtx)
+		attemptCtx, held := coreusage.WithDeferredPublish(attemptCtx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		deferred = append(deferred, held)
+		go func() {
+			resp, err := fn(attemptCtx)
+			results <- hedgeOutcome{index: index, resp: resp, err: err}
+		}()
+	}
+
+	start()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	var (
+		firstErr error
+		armed    <-chan struct{}
+	)
+	for pending > 0 {
+		select {
+		case <-ctx.Done():
+			for _, held := range deferred {
+				held.Flush(false)
+			}
+			return cliproxyexecutor.Response{}, ctx.Err()
+		case <-timer.C:
+			// Wait for the primary attempt to hold an auth so the hedge excludes it.
+			armed = state.first
+		case <-armed:
+			armed = nil
+			logEntryWithRequestID(ctx).Debugf("Hedging request for model %s after %s without response", model, delay)
+			start()
+			pending++
+		case outcome := <-results:
+			pending--
+			if outcome.err != nil {
+				if firstErr == nil {
+					firstErr = outcome.err
+				}
+				continue
+			}
+			hedged := len(cancels) > 1
+			for i, held := range deferred {
+				if i == outcome.index {
+					held.Flush(hedged)
+					continue
+				}
+				cancels[i]()
+				held.DiscardTo(usageCapPlugin{}.HandleUsage)
+			}
+			if hedged {
+				logEntryWithRequestID(ctx).Debugf("Hedged attempt %d won for model %s", outcome.index+1, model)
+			}
+			return outcome.resp, nil
+		}
+	}
+	for _, held := range deferred {
+		held.Flush(false)
+	}
+	return cliproxyexecutor.Response{}, firstErr
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalconfig "github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+func TestManagerHedgeDelayFor(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	m.SetHedgingRules([]internalconfig.HedgingRule{
+		{Models: []string{"claude-*-haiku-*"}, DelayMS: 800},
+		{Models: []string{"gpt-5-mini"}, DelayMS: 0},
+	})
+
+	if got := m.hedgeDelayFor("claude-3-5-haiku-20241022"); got != 800*time.Millisecond {
+		t.Fatalf("hedgeDelayFor(haiku) = %v, want 800ms", got)
+	}
+	if got := m.hedgeDelayFor("gpt-5-mini"); got != 0 {
+		t.Fatalf("hedgeDelayFor(gpt-5-mini) = %v, want 0", got)
+	}
+	if got := m.hedgeDelayFor("claude-sonnet-4"); got != 0 {
+		t.Fatalf("hedgeDelayFor(sonnet) = %v, want 0", got)
+	}
+}
+
+func TestManagerExecuteHedged_SecondAttemptWins(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	var calls atomic.Int32
+	primaryCancelled := make(chan struct{})
+	fn := func(ctx context.Context) (cliproxyexecutor.Response, error) {
+		if calls.Add(1) == 1 {
+			hedgeStateFromContext(ctx).claim("primary")
+			<-ctx.Done()
+			close(primaryCancelled)
+			return cliproxyexecutor.Response{}, ctx.Err()
+		}
+		return cliproxyexecutor.Response{Payload: []byte("hedge")}, nil
+	}
+
+	resp, err := m.executeHedged(context.Background(), "claude-haiku", 10*time.Millisecond, fn)
+	if err != nil {
+		t.Fatalf("executeHedged() error = %v", err)
+	}
+	if string(resp.Payload) != "hedge" {
+		t.Fatalf("executeHedged() payload = %q, want %q", resp.Payload, "hedge")
+	}
+	select {
+	case <-primaryCancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("primary attempt was not cancelled")
+	}
+}
+
+func TestManagerExecuteHedged_FastPrimarySkipsHedge(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	var calls atomic.Int32
+	fn := func(ctx context.Context) (cliproxyexecutor.Response, error) {
+		calls.Add(1)
+		return cliproxyexecutor.Response{}, errors.New("upstream failed")
+	}
+
+	if _, err := m.executeHedged(context.Background(), "claude-haiku", time.Second, fn); err == nil {
+		t.Fatalf("executeHedged() error = nil, want failure")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("attempts = %d, want 1", got)
+	}
+}
+
+// recordingUsagePlugin keeps the usage records published for one model.
+type recordingUsagePlugin struct {
+	model    string
+	sentinel string
+	mu       sync.Mutex
+	records  []coreusage.Record
+	once     sync.Once
+	done     chan struct{}
+}
+
+func (p *recordingUsagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	switch record.Model {
+	case p.model:
+		p.mu.Lock()
+		p.records = append(p.records, record)
+		p.mu.Unlock()
+	case p.sentinel:
+		p.once.Do(func() { close(p.done) })
+	}
+}
+
+func TestManagerExecuteHedged_DiscardsLosingAttemptUsage(t *testing.T) {
+	t.Parallel()
+
+	plugin := &recordingUsagePlugin{model: "hedge-usage-model", sentinel: "hedge-usage-sentinel", done: make(chan struct{})}
+	coreusage.RegisterPlugin(plugin)
+	publish := func(ctx context.Context, authID string) {
+		coreusage.PublishRecord(ctx, coreusage.Record{Model: plugin.model, AuthID: authID, Detail: coreusage.Detail{TotalTokens: 10}})
+	}
+
+	m := NewManager(nil, nil, nil)
+	fast := func(ctx context.Context) (cliproxyexecutor.Response, error) {
+		publish(ctx, "fast")
+		return cliproxyexecutor.Response{}, nil
+	}
+	if _, err := m.executeHedged(context.Background(), plugin.model, time.Second, fast); err != nil {
+		t.Fatalf("executeHedged(fast) error = %v", err)
+	}
+
+	var calls atomic.Int32
+	primaryPublished := make(chan struct{})
+	primaryDone := make(chan struct{})
+	slow := func(ctx context.Context) (cliproxyexecutor.Response, error) {
+		if calls.Add(1) == 1 {
+			hedgeStateFromContext(ctx).claim("primary")
+			publish(ctx, "primary")
+			close(primaryPublished)
+			<-ctx.Done()
+			// Usage reported by the losing attempt after cancellation is dropped as well.
+			publish(ctx, "primary")
+			close(primaryDone)
+			return cliproxyexecutor.Response{}, ctx.Err()
+		}
+		<-primaryPublished
+		publish(ctx, "hedge")
+		return cliproxyexecutor.Response{}, nil
+	}
+	if _, err := m.executeHedged(context.Background(), plugin.model, 10*time.Millisecond, slow); err != nil {
+		t.Fatalf("executeHedged(slow) error = %v", err)
+	}
+	<-primaryDone
+
+	// Records are dispatched in order, so the sentinel arrives after every record above.
+	coreusage.PublishRecord(context.Background(), coreusage.Record{Model: plugin.sentinel})
+	select {
+	case <-plugin.done:
+	case <-time.After(time.Second):
+		t.Fatalf("usage records were not dispatched")
+	}
+
+	plugin.mu.Lock()
+	defer plugin.mu.Unlock()
+	if len(plugin.records) != 2 {
+		t.Fatalf("records = %+v, want the fast and the winning hedge record only", plugin.records)
+	}
+	if got := plugin.records[0]; got.AuthID != "fast" || got.Hedged {
+		t.Fatalf("records[0] = %+v, want the non-hedged fast record", got)
+	}
+	if got := plugin.records[1]; got.AuthID != "hedge" || !got.Hedged {
+		t.Fatalf("records[1] = %+v, want the hedged winner record", got)
+	}
+}
+
+func TestManagerExecuteHedged_WaitsForPrimaryAuth(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(nil, nil, nil)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (cliproxyexecutor.Response, error) {
+		if calls.Add(1) == 1 {
+			// The primary only holds an auth after the hedge delay has elapsed.
+			<-release
+			hedgeStateFromContext(ctx).claim("primary")
+			<-ctx.Done()
+			return cliproxyexecutor.Response{}, ctx.Err()
+		}
+		if hedgeStateFromContext(ctx).claim("primary") {
+			t.Errorf("hedge attempt claimed the primary auth")
+		}
+		return cliproxyexecutor.Response{}, nil
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if got := calls.Load(); got != 1 {
+			t.Errorf("attempts before the primary claimed an auth = %d, want 1", got)
+		}
+		close(release)
+	}()
+
+	if _, err := m.executeHedged(context.Background(), "claude-haiku", time.Millisecond, fn); err != nil {
+		t.Fatalf("executeHedged() error = %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+}
+
+// hedgeAuthExecutor blocks on its first call until cancelled and records the auth of every call.
+type hedgeAuthExecutor struct {
+	mu    sync.Mutex
+	auths []string
+}
+
+func (e *hedgeAuthExecutor) Identifier() string { return "hedge-test" }
+
+func (e *hedgeAuthExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.auths = append(e.auths, auth.ID)
+	first := len(e.auths) == 1
+	e.mu.Unlock()
+	if first {
+		<-ctx.Done()
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *hedgeAuthExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *hedgeAuthExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *hedgeAuthExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestManagerExecute_HedgeUsesDifferentAuth(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &hedgeAuthExecutor{}
+	m.RegisterExecutor(executor)
+	m.SetHedgingRules([]internalconfig.HedgingRule{{Models: []string{"hedge-auth-model"}, DelayMS: 10}})
+	for _, id := range []string{"hedge-auth-a", "hedge-auth-b"} {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "hedge-test", Status: StatusActive}); err != nil {
+			t.Fatalf("Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "hedge-test", []*registry.ModelInfo{{ID: "hedge-auth-model"}})
+		authID := id
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+	}
+
+	resp, err := m.Execute(context.Background(), []string{"hedge-test"}, cliproxyexecutor.Request{Model: "hedge-auth-model"}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if len(executor.auths) != 2 || executor.auths[0] == executor.auths[1] {
+		t.Fatalf("attempted auths = %v, want two distinct auths", executor.auths)
+	}
+	if string(resp.Payload) != executor.auths[1] {
+		t.Fatalf("Execute() payload = %q, want the hedge auth %q", resp.Payload, executor.auths[1])
+	}
+}
+
+func TestManagerExecuteHedged_CountsLosingUsageAgainstCaps(t *testing.T) {
+	t.Parallel()
+
+	loser := &Auth{ID: "hedge-cap-loser", Index: "hedge-cap-loser", Attributes: map[string]string{AttrMaxTokensPerDay: "1000"}}
+	winner := &Auth{ID: "hedge-cap-winner", Index: "hedge-cap-winner", Attributes: map[string]string{AttrMaxTokensPerDay: "1000"}}
+	publish := func(ctx context.Context, auth *Auth, tokens int64) {
+		coreusage.PublishRecord(ctx, coreusage.Record{Model: "hedge-cap-model", AuthID: auth.ID, AuthIndex: auth.Index, Detail: coreusage.Detail{TotalTokens: tokens}})
+	}
+
+	before := UsageCapCounters(loser).TokensToday
+
+	m := NewManager(nil, nil, nil)
+	var calls atomic.Int32
+	primaryPublished := make(chan struct{})
+	primaryDone := make(chan struct{})
+	fn := func(ctx context.Context) (cliproxyexecutor.Response, error) {
+		if calls.Add(1) == 1 {
+			hedgeStateFromContext(ctx).claim(loser.ID)
+			publish(ctx, loser, 30)
+			close(primaryPublished)
+			<-ctx.Done()
+			publish(ctx, loser, 12)
+			close(primaryDone)
+			return cliproxyexecutor.Response{}, ctx.Err()
+		}
+		<-primaryPublished
+		hedgeStateFromContext(ctx).claim(winner.ID)
+		return cliproxyexecutor.Response{}, nil
+	}
+	if _, err := m.executeHedged(context.Background(), "hedge-cap-model", 10*time.Millisecond, fn); err != nil {
+		t.Fatalf("executeHedged() error = %v", err)
+	}
+	<-primaryDone
+
+	if got := UsageCapCounters(loser).TokensToday - before; got != 42 {
+		t.Fatalf("loser TokensToday = %d, want 42 from the discarded attempt", got)
+	}
+}
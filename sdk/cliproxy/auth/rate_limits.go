@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// LowRateLimitHeadroom is the share of a provider rate-limit window below which an auth is
+	// passed over in favour of credentials with more headroom.
+	LowRateLimitHeadroom = 0.05
+
+	// rateLimitSnapshotTTL is how long a snapshot without reset times is trusted.
+	rateLimitSnapshotTTL = 5 * time.Minute
+
+	// rateLimitSweepInterval is how often expired snapshots are pruned.
+	rateLimitSweepInterval = time.Minute
+)
+
+// RateLimitWindow is one provider rate-limit window as last reported in response headers.
+type RateLimitWindow struct {
+	// Name identifies the window, e.g. "requests", "tokens", "input-tokens" or "unified-5h".
+	Name string `json:"name"`
+	// Limit and Remaining are set for windows reported as counts.
+	Limit     *int64 `json:"limit,omitempty"`
+	Remaining *int64 `json:"remaining,omitempty"`
+	// UsedPercent is the share of the window already consumed, from 0 to 100.
+	UsedPercent float64 `json:"used_percent"`
+	// ResetAt is when the window refills, if the provider said so.
+	ResetAt time.Time `json:"reset_at,omitempty"`
+}
+
+// RateLimitSnapshot holds the rate-limit windows last reported for an auth and model.
+type RateLimitSnapshot struct {
+	Model      string            `json:"model"`
+	Windows    []RateLimitWindow `json:"windows"`
+	ObservedAt time.Time         `json:"observed_at"`
+	// Headroom is the smallest share of any window still available when the snapshot is read.
+	Headroom float64 `json:"headroom"`
+}
+
+// headroom returns the smallest remaining share across windows that have not reset by now.
+// Stale snapshots and windows past their reset report full headroom.
+func (s RateLimitSnapshot) headroom(now time.Time) float64 {
+	headroom := 1.0
+	for _, window := range s.Windows {
+		if window.ResetAt.IsZero() {
+			if now.Sub(s.ObservedAt) > rateLimitSnapshotTTL {
+				continue
+			}
+		} else if !now.Before(window.ResetAt) {
+			continue
+		}
+		if left := 1 - window.UsedPercent/100; left < headroom {
+			headroom = left
+		}
+	}
+	if headroom < 0 {
+		headroom = 0
+	}
+	return headroom
+}
+
+// expired reports whether no window of the snapshot still carries information: every window
+// has reset, or had no reset time and was observed longer than rateLimitSnapshotTTL ago.
+func (s RateLimitSnapshot) expired(now time.Time) bool {
+	if now.Sub(s.ObservedAt) <= rateLimitSnapshotTTL {
+		return false
+	}
+	for _, window := range s.Windows {
+		if !window.ResetAt.IsZero() && now.Before(window.ResetAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRateLimitHeaders extracts rate-limit windows from upstream response headers. It
+// understands the OpenAI x-ratelimit-* headers, the Anthropic anthropic-ratelimit-* headers
+// (including the unified subscription windows), the Codex x-codex-* usage headers and the
+// plain x-ratelimit-limit/remaining/reset triple used by GitHub.
+func ParseRateLimitHeaders(headers http.Header, now time.Time) []RateLimitWindow {
+	if len(headers) == 0 {
+		return nil
+	}
+	var windows []RateLimitWindow
+	add := func(window RateLimitWindow, ok bool) {
+		if ok {
+			windows = append(windows, window)
+		}
+	}
+
+	for _, kind := range []string{"requests", "tokens"} {
+		add(countWindow(kind, headers.Get("x-ratelimit-limit-"+kind), headers.Get("x-ratelimit-remaining-"+kind), headers.Get("x-ratelimit-reset-"+kind), now))
+	}
+	for _, kind := range []string{"requests", "tokens", "input-tokens", "output-tokens"} {
+		prefix := "anthropic-ratelimit-" + kind
+		add(countWindow(kind, headers.Get(prefix+"-limit"), headers.Get(prefix+"-remaining"), headers.Get(prefix+"-reset"), now))
+	}
+	for _, span := range []string{"5h", "7d"} {
+		prefix := "anthropic-ratelimit-unified-" + span
+		if utilization, ok := parseRateLimitFloat(headers.Get(prefix + "-utilization")); ok {
+			add(percentWindow("unified-"+span, utilization*100, headers.Get(prefix+"-reset"), now), true)
+		}
+	}
+	for _, name := range []string{"primary", "secondary"} {
+		prefix := "x-codex-" + name
+		if used, ok := parseRateLimitFloat(headers.Get(prefix + "-used-percent")); ok {
+			window := percentWindow("codex-"+name, used, "", now)
+			if seconds, okReset := parseRateLimitFloat(headers.Get(prefix + "-reset-after-seconds")); okReset {
+				window.ResetAt = now.Add(time.Duration(seconds * float64(time.Second)))
+			}
+			add(window, true)
+		}
+	}
+	if len(windows) == 0 {
+		add(countWindow("requests", headers.Get("x-ratelimit-limit"), headers.Get("x-ratelimit-remaining"), headers.Get("x-ratelimit-reset"), now))
+	}
+	return windows
+}
+
+func countWindow(name, rawLimit, rawRemaining, rawReset string, now time.Time) (RateLimitWindow, bool) {
+	limit, errLimit := strconv.ParseInt(strings.TrimSpace(rawLimit), 10, 64)
+	remaining, errRemaining := strconv.ParseInt(strings.TrimSpace(rawRemaining), 10, 64)
+	if errLimit != nil || errRemaining != nil || limit <= 0 {
+		return RateLimitWindow{}, false
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	window := RateLimitWindow{
+		Name:        name,
+		Limit:       &limit,
+		Remaining:   &remaining,
+		UsedPercent: float64(limit-remaining) / float64(limit) * 100,
+		ResetAt:     parseRateLimitReset(rawReset, now),
+	}
+	return window, true
+}
+
+func percentWindow(name string, used float64, rawReset string, now time.Time) RateLimitWindow {
+	if used < 0 {
+		used = 0
+	}
+	return RateLimitWindow{Name: name, UsedPercent: used, ResetAt: parseRateLimitReset(rawReset, now)}
+}
+
+func parseRateLimitFloat(raw string) (float64, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	return value, err == nil
+}
+
+// parseRateLimitReset accepts RFC 3339 timestamps, Unix timestamps, seconds from now and Go
+// style durations such as "6m0s" or "20ms".
+func parseRateLimitReset(raw string, now time.Time) time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t
+	}
+	if seconds, ok := parseRateLimitFloat(raw); ok {
+		if seconds > 1e9 {
+			return time.Unix(int64(seconds), 0)
+		}
+		return now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return now.Add(d)
+	}
+	return time.Time{}
+}
+
+// rateLimitTracker keeps the latest rate-limit snapshot per auth and model, shared by all
+// managers in the process. Expired snapshots are pruned as new ones arrive, and an auth's
+// snapshots are dropped when it is disabled or removed.
+type rateLimitTracker struct {
+	mu        sync.RWMutex
+	snapshots map[string]map[string]RateLimitSnapshot
+	lastSweep time.Time
+}
+
+var providerRateLimits = &rateLimitTracker{snapshots: make(map[string]map[string]RateLimitSnapshot)}
+
+func (t *rateLimitTracker) observe(authID, model string, windows []RateLimitWindow, now time.Time) {
+	if authID == "" || len(windows) == 0 {
+		return
+	}
+	t.mu.Lock()
+	models, ok := t.snapshots[authID]
+	if !ok {
+		models = make(map[string]RateLimitSnapshot)
+		t.snapshots[authID] = models
+	}
+	models[model] = RateLimitSnapshot{Model: model, Windows: windows, ObservedAt: now}
+	if now.Sub(t.lastSweep) >= rateLimitSweepInterval {
+		t.sweepLocked(now)
+	}
+	t.mu.Unlock()
+}
+
+// sweepLocked drops expired snapshots and auths left without any.
+func (t *rateLimitTracker) sweepLocked(now time.Time) {
+	t.lastSweep = now
+	for authID, models := range t.snapshots {
+		for model, snapshot := range models {
+			if snapshot.expired(now) {
+				delete(models, model)
+			}
+		}
+		if len(models) == 0 {
+			delete(t.snapshots, authID)
+		}
+	}
+}
+
+func (t *rateLimitTracker) forget(authID string) {
+	t.mu.Lock()
+	delete(t.snapshots, authID)
+	t.mu.Unlock()
+}
+
+// headroom returns the remaining share reported for authID and model, or 1 when unknown.
+func (t *rateLimitTracker) headroom(authID, model string, now time.Time) float64 {
+	t.mu.RLock()
+	snapshot, ok := t.snapshots[authID][model]
+	t.mu.RUnlock()
+	if !ok {
+		return 1
+	}
+	return snapshot.headroom(now)
+}
+
+type rateLimitTargetKey struct{}
+
+type rateLimitTarget struct {
+	authID string
+	model  string
+}
+
+// withRateLimitTarget records which auth and route model the upstream call in ctx is made for.
+func withRateLimitTarget(ctx context.Context, authID, model string) context.Context {
+	return context.WithValue(ctx, rateLimitTargetKey{}, rateLimitTarget{authID: authID, model: model})
+}
+
+// ObserveRateLimitHeaders records the rate-limit windows found in an upstream response for the
+// auth and model that ctx was executed with. Executors call it for every upstream response.
+func ObserveRateLimitHeaders(ctx context.Context, headers http.Header) {
+	if ctx == nil {
+		return
+	}
+	target, ok := ctx.Value(rateLimitTargetKey{}).(rateLimitTarget)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	providerRateLimits.observe(target.authID, target.model, ParseRateLimitHeaders(headers, now), now)
+}
+
+// RateLimitSnapshots returns the latest rate-limit snapshot of each model used with auth,
+// ordered by model.
+func RateLimitSnapshots(auth *Auth) []RateLimitSnapshot {
+	if auth == nil {
+		return nil
+	}
+	now := time.Now()
+	providerRateLimits.mu.RLock()
+	snapshots := make([]RateLimitSnapshot, 0, len(providerRateLimits.snapshots[auth.ID]))
+	for _, snapshot := range providerRateLimits.snapshots[auth.ID] {
+		if snapshot.expired(now) {
+			continue
+		}
+		snapshot.Headroom = snapshot.headroom(now)
+		snapshots = append(snapshots, snapshot)
+	}
+	providerRateLimits.mu.RUnlock()
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Model < snapshots[j].Model })
+	return snapshots
+}
+
+// preferRateLimitHeadroom drops auths whose reported headroom for model is below
+// LowRateLimitHeadroom, unless that would leave no candidate.
+func preferRateLimitHeadroom(auths []*Auth, model string, now time.Time) []*Auth {
+	if len(auths) < 2 {
+		return auths
+	}
+	preferred := make([]*Auth, 0, len(auths))
+	for _, auth := range auths {
+		if providerRateLimits.headroom(auth.ID, model, now) >= LowRateLimitHeadroom {
+			preferred = append(preferred, auth)
+		}
+	}
+	if len(preferred) == 0 {
+		return auths
+	}
+	return preferred
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	openai := http.Header{}
+	openai.Set("x-ratelimit-limit-requests", "500")
+	openai.Set("x-ratelimit-remaining-requests", "125")
+	openai.Set("x-ratelimit-reset-requests", "6m0s")
+	windows := ParseRateLimitHeaders(openai, now)
+	if len(windows) != 1 || windows[0].Name != "requests" || *windows[0].Remaining != 125 || windows[0].UsedPercent != 75 {
+		t.Fatalf("openai windows = %+v", windows)
+	}
+	if !windows[0].ResetAt.Equal(now.Add(6 * time.Minute)) {
+		t.Fatalf("openai reset = %v", windows[0].ResetAt)
+	}
+
+	anthropic := http.Header{}
+	anthropic.Set("anthropic-ratelimit-input-tokens-limit", "400000")
+	anthropic.Set("anthropic-ratelimit-input-tokens-remaining", "390000")
+	anthropic.Set("anthropic-ratelimit-input-tokens-reset", "2026-10-18T12:01:00Z")
+	anthropic.Set("anthropic-ratelimit-unified-5h-utilization", "0.97")
+	anthropic.Set("anthropic-ratelimit-unified-5h-reset", "1792346400")
+	windows = ParseRateLimitHeaders(anthropic, now)
+	if len(windows) != 2 || windows[0].Name != "input-tokens" || windows[1].Name != "unified-5h" {
+		t.Fatalf("anthropic windows = %+v", windows)
+	}
+	if !windows[0].ResetAt.Equal(now.Add(time.Minute)) || windows[1].ResetAt.Unix() != 1792346400 {
+		t.Fatalf("anthropic resets = %v, %v", windows[0].ResetAt, windows[1].ResetAt)
+	}
+
+	codex := http.Header{}
+	codex.Set("x-codex-primary-used-percent", "42")
+	codex.Set("x-codex-primary-reset-after-seconds", "600")
+	windows = ParseRateLimitHeaders(codex, now)
+	if len(windows) != 1 || windows[0].Name != "codex-primary" || windows[0].UsedPercent != 42 || windows[0].Limit != nil {
+		t.Fatalf("codex windows = %+v", windows)
+	}
+
+	if windows = ParseRateLimitHeaders(http.Header{"Content-Type": {"application/json"}}, now); len(windows) != 0 {
+		t.Fatalf("windows without rate-limit headers = %+v", windows)
+	}
+}
+
+func TestSelectorPick_PassesOverAuthsLowOnRateLimit(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("x-ratelimit-limit-requests", "100")
+	headers.Set("x-ratelimit-remaining-requests", "1")
+	headers.Set("x-ratelimit-reset-requests", "30s")
+	ObserveRateLimitHeaders(withRateLimitTarget(context.Background(), "rate-limit-a", "gpt-test"), headers)
+
+	auths := []*Auth{{ID: "rate-limit-a"}, {ID: "rate-limit-b"}}
+	for i := 0; i < 3; i++ {
+		got, err := (&RoundRobinSelector{}).Pick(context.Background(), "codex", "gpt-test", cliproxyexecutor.Options{}, auths)
+		if err != nil || got.ID != "rate-limit-b" {
+			t.Fatalf("Pick() = %v, %v, want rate-limit-b", got, err)
+		}
+	}
+	if got, _ := (&FillFirstSelector{}).Pick(context.Background(), "codex", "other-model", cliproxyexecutor.Options{}, auths); got.ID != "rate-limit-a" {
+		t.Fatalf("Pick() for another model = %s, want rate-limit-a", got.ID)
+	}
+	if got, _ := (&FillFirstSelector{}).Pick(context.Background(), "codex", "gpt-test", cliproxyexecutor.Options{}, auths[:1]); got == nil || got.ID != "rate-limit-a" {
+		t.Fatal("a low auth must still be picked when it is the only candidate")
+	}
+
+	snapshots := RateLimitSnapshots(&Auth{ID: "rate-limit-a"})
+	if len(snapshots) != 1 || snapshots[0].Model != "gpt-test" || math.Abs(snapshots[0].Headroom-0.01) > 1e-9 {
+		t.Fatalf("snapshots = %+v", snapshots)
+	}
+}
+
+func TestRateLimitTracker_PrunesExpiredSnapshots(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tracker := &rateLimitTracker{snapshots: make(map[string]map[string]RateLimitSnapshot)}
+	used := RateLimitWindow{Name: "requests", UsedPercent: 90}
+	weekly := RateLimitWindow{Name: "unified-7d", UsedPercent: 90, ResetAt: now.Add(7 * 24 * time.Hour)}
+
+	tracker.observe("stale-auth", "m1", []RateLimitWindow{used}, now)
+	tracker.observe("weekly-auth", "m1", []RateLimitWindow{weekly}, now)
+	later := now.Add(rateLimitSnapshotTTL + rateLimitSweepInterval)
+	tracker.observe("fresh-auth", "m1", []RateLimitWindow{used}, later)
+
+	if _, ok := tracker.snapshots["stale-auth"]; ok {
+		t.Fatalf("expired snapshot was kept: %+v", tracker.snapshots["stale-auth"])
+	}
+	if _, ok := tracker.snapshots["weekly-auth"]["m1"]; !ok {
+		t.Fatal("snapshot with a window that has not reset was pruned")
+	}
+	if _, ok := tracker.snapshots["fresh-auth"]["m1"]; !ok {
+		t.Fatal("fresh snapshot was pruned")
+	}
+}
+
+func TestManagerUpdate_ForgetsRateLimitsOfDisabledAuth(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("x-ratelimit-limit-requests", "100")
+	headers.Set("x-ratelimit-remaining-requests", "1")
+	ObserveRateLimitHeaders(withRateLimitTarget(context.Background(), "rate-limit-removed", "gpt-test"), headers)
+
+	m := NewManager(nil, nil, nil)
+	auth := &Auth{ID: "rate-limit-removed", Provider: "codex", Status: StatusActive}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if got := RateLimitSnapshots(auth); len(got) != 1 {
+		t.Fatalf("snapshots before disable = %+v, want one", got)
+	}
+	auth.Disabled = true
+	auth.Status = StatusDisabled
+	if _, err := m.Update(context.Background(), auth); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := RateLimitSnapshots(auth); len(got) != 0 {
+		t.Fatalf("snapshots after disable = %+v, want none", got)
+	}
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RefreshLeaser is implemented by stores shared between several proxy processes. Before calling
+// a provider's refresh the manager takes the lease for the auth ID, so providers that rotate
+// refresh tokens on every refresh are only ever refreshed by one process at a time.
+type RefreshLeaser interface {
+	// AcquireRefreshLease takes or renews the lease for id on behalf of holder. It reports false
+	// when another holder owns an unexpired lease.
+	AcquireRefreshLease(ctx context.Context, id, holder string, ttl time.Duration) (bool, error)
+	// ReleaseRefreshLease gives up the lease when holder still owns it.
+	ReleaseRefreshLease(ctx context.Context, id, holder string) error
+}
+
+// AuthReader is implemented by stores that can read the latest version of one record from the
+// shared backend, bypassing any local mirror.
+type AuthReader interface {
+	ReadAuth(ctx context.Context, id string) (*Auth, error)
+}
+
+const refreshLeaseTTL = 2 * time.Minute
+
+// refreshLeasePollInterval controls how often a process waiting on a peer's refresh reloads the record.
+var refreshLeasePollInterval = 2 * time.Second
+
+var leaseHolderOnce = sync.OnceValue(func() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "proxy"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+})
+
+func (m *Manager) refreshLeaser() RefreshLeaser {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	leaser, _ := m.store.(RefreshLeaser)
+	return leaser
+}
+
+// acquireRefreshLease coordinates a refresh of auth with other processes sharing the store. It
+// returns a release func and true when this process should refresh. It returns false when a
+// peer holds the lease or has already refreshed the record; the peer's result is adopted then.
+func (m *Manager) acquireRefreshLease(ctx context.Context, auth *Auth) (func(), bool) {
+	leaser := m.refreshLeaser()
+	if leaser == nil {
+		return func() {}, true
+	}
+	holder := leaseHolderOnce()
+	acquired, err := leaser.AcquireRefreshLease(ctx, auth.ID, holder, refreshLeaseTTL)
+	if err != nil {
+		log.Warnf("refresh lease for %s unavailable, retrying later: %v", auth.ID, err)
+		return nil, false
+	}
+	if !acquired {
+		log.Debugf("refresh of %s is held by another process, waiting for its result", auth.ID)
+		m.awaitPeerRefresh(ctx, auth)
+		return nil, false
+	}
+	release := func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if errRelease := leaser.ReleaseRefreshLease(releaseCtx, auth.ID, holder); errRelease != nil {
+			log.Warnf("release refresh lease for %s: %v", auth.ID, errRelease)
+		}
+	}
+	// A peer may have finished its refresh just before the lease became free.
+	if m.adoptPeerRefresh(ctx, auth) {
+		release()
+		return nil, false
+	}
+	return release, true
+}
+
+// awaitPeerRefresh polls the store until the lease holder's refreshed record shows up or the lease
+// would have expired. Without a result the pending backoff set by markRefreshPending lets the
+// next refresh check try again.
+func (m *Manager) awaitPeerRefresh(ctx context.Context, auth *Auth) {
+	deadline := time.Now().Add(refreshLeaseTTL)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(refreshLeasePollInterval):
+		}
+		if m.adoptPeerRefresh(ctx, auth) {
+			return
+		}
+	}
+	log.Debugf("no refreshed record for %s appeared before the lease expired", auth.ID)
+}
+
+// adoptPeerRefresh reloads auth from the store and installs it when another process stored new
+// credentials that no longer need a refresh.
+func (m *Manager) adoptPeerRefresh(ctx context.Context, auth *Auth) bool {
+	stored, err := m.readStoredAuth(ctx, auth.ID)
+	if err != nil {
+		log.Debugf("reload %s after peer refresh: %v", auth.ID, err)
+		return false
+	}
+	if stored == nil || metadataEqual(stored.Metadata, auth.Metadata) {
+		return false
+	}
+	now := time.Now()
+	candidate := auth.Clone()
+	candidate.Metadata = stored.Metadata
+	candidate.LastRefreshedAt = time.Time{}
+	candidate.NextRefreshAfter = time.Time{}
+	if m.shouldRefresh(candidate, now) {
+		return false
+	}
+	m.mu.RLock()
+	current := m.auths[auth.ID]
+	m.mu.RUnlock()
+	if current == nil {
+		return false
+	}
+	updated := current.Clone()
+	updated.Metadata = stored.Metadata
+	updated.LastRefreshedAt = now
+	updated.NextRefreshAfter = time.Time{}
+	updated.LastError = nil
+	updated.UpdatedAt = now
+	if _, errUpdate := m.Update(ctx, updated); errUpdate != nil {
+		log.Warnf("adopt refreshed %s: %v", auth.ID, errUpdate)
+		return false
+	}
+	log.Debugf("adopted credentials for %s refreshed by another process", auth.ID)
+	return true
+}
+
+func (m *Manager) readStoredAuth(ctx context.Context, id string) (*Auth, error) {
+	m.mu.RLock()
+	store := m.store
+	m.mu.RUnlock()
+	if store == nil {
+		return nil, nil
+	}
+	if reader, ok := store.(AuthReader); ok {
+		return reader.ReadAuth(ctx, id)
+	}
+	items, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item != nil && item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+func metadataEqual(a, b map[string]any) bool {
+	left, errLeft := json.Marshal(a)
+	right, errRight := json.Marshal(b)
+	return errLeft == nil && errRight == nil && bytes.Equal(left, right)
+}
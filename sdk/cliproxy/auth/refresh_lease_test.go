@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+)
+
+// leaseStore is a shared in-memory store with refresh leases, standing in for Postgres.
+type leaseStore struct {
+	mu      sync.Mutex
+	records map[string]*Auth
+	holder  string
+	expires time.Time
+}
+
+func (s *leaseStore) List(context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Auth, 0, len(s.records))
+	for _, record := range s.records {
+		out = append(out, record.Clone())
+	}
+	return out, nil
+}
+
+func (s *leaseStore) Save(_ context.Context, auth *Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[auth.ID] = auth.Clone()
+	return auth.ID, nil
+}
+
+func (s *leaseStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+func (s *leaseStore) AcquireRefreshLease(_ context.Context, _ string, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.holder != "" && s.holder != holder && time.Now().Before(s.expires) {
+		return false, nil
+	}
+	s.holder, s.expires = holder, time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *leaseStore) ReleaseRefreshLease(_ context.Context, _ string, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.holder == holder {
+		s.holder = ""
+	}
+	return nil
+}
+
+type rotatingRefreshExecutor struct {
+	explainStubExecutor
+	calls atomic.Int32
+}
+
+func (e *rotatingRefreshExecutor) Identifier() string { return "lease-test" }
+
+func (e *rotatingRefreshExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.calls.Add(1)
+	time.Sleep(30 * time.Millisecond)
+	auth.Metadata["refresh_token"] = "rotated"
+	auth.Metadata["last_refresh"] = time.Now().Format(time.RFC3339)
+	return auth, nil
+}
+
+func (e *rotatingRefreshExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func newLeaseTestManager(t *testing.T) (*Manager, *leaseStore, *rotatingRefreshExecutor) {
+	t.Helper()
+	previousPoll := refreshLeasePollInterval
+	refreshLeasePollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { refreshLeasePollInterval = previousPoll })
+
+	store := &leaseStore{records: make(map[string]*Auth)}
+	exec := &rotatingRefreshExecutor{}
+	m := NewManager(store, nil, nil)
+	m.RegisterExecutor(exec)
+	if _, err := m.Register(context.Background(), &Auth{ID: "lease-auth", Provider: "lease-test", Status: StatusActive, Metadata: map[string]any{
+		"type":                     "lease-test",
+		"refresh_token":            "original",
+		"refresh_interval_seconds": 3600,
+		"last_refresh":             time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+	}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return m, store, exec
+}
+
+func TestManagerRefreshAuth_LeaseHolderRefreshes(t *testing.T) {
+	m, store, exec := newLeaseTestManager(t)
+
+	m.refreshAuth(context.Background(), "lease-auth")
+
+	if got := exec.calls.Load(); got != 1 {
+		t.Fatalf("provider refresh calls = %d, want 1", got)
+	}
+	if store.holder != "" {
+		t.Fatalf("lease still held by %q after refresh", store.holder)
+	}
+	if got := store.records["lease-auth"].Metadata["refresh_token"]; got != "rotated" {
+		t.Fatalf("stored refresh_token = %v, want rotated", got)
+	}
+}
+
+func TestManagerRefreshAuth_WaitsForPeerAndAdoptsResult(t *testing.T) {
+	m, store, exec := newLeaseTestManager(t)
+	if ok, _ := store.AcquireRefreshLease(context.Background(), "lease-auth", "peer", refreshLeaseTTL); !ok {
+		t.Fatal("peer could not take the lease")
+	}
+	go func() {
+		// The peer finishes its refresh, stores the rotated token and releases the lease.
+		time.Sleep(50 * time.Millisecond)
+		store.mu.Lock()
+		record := store.records["lease-auth"].Clone()
+		store.mu.Unlock()
+		record.Metadata["refresh_token"] = "rotated-by-peer"
+		record.Metadata["last_refresh"] = time.Now().Format(time.RFC3339)
+		_, _ = store.Save(context.Background(), record)
+		_ = store.ReleaseRefreshLease(context.Background(), "lease-auth", "peer")
+	}()
+
+	m.refreshAuth(context.Background(), "lease-auth")
+
+	if got := exec.calls.Load(); got != 0 {
+		t.Fatalf("provider refresh calls = %d, want 0 while a peer holds the lease", got)
+	}
+	auth, _ := m.GetByID("lease-auth")
+	if got := auth.Metadata["refresh_token"]; got != "rotated-by-peer" {
+		t.Fatalf("refresh_token = %v, want the peer's rotated token", got)
+	}
+	if auth.LastRefreshedAt.IsZero() {
+		t.Fatal("LastRefreshedAt not set after adopting the peer's refresh")
+	}
+}
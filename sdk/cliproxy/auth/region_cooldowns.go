@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// regionCooldownBase is the first cooldown of a region that reported resource exhaustion
+	// without saying when to retry. Each further exhaustion doubles it up to regionCooldownMax.
+	regionCooldownBase = 30 * time.Second
+	regionCooldownMax  = 30 * time.Minute
+)
+
+// RegionCooldown is a region of an auth that reported resource exhaustion for a model and is
+// tried last until Until.
+type RegionCooldown struct {
+	Region string    `json:"region"`
+	Model  string    `json:"model"`
+	Until  time.Time `json:"until"`
+	// BackoffLevel counts the exhaustions in a row that were not followed by a success.
+	BackoffLevel int `json:"backoff_level,omitempty"`
+}
+
+// regionCooldownTracker keeps the cooldown of each region, per auth and model, for executors that
+// spread one credential across several regions. It is shared by all managers in the process.
+type regionCooldownTracker struct {
+	mu     sync.Mutex
+	states map[string]map[string]*RegionCooldown
+}
+
+var regionCooldowns = &regionCooldownTracker{states: make(map[string]map[string]*RegionCooldown)}
+
+func regionCooldownKey(region, model string) string {
+	return strings.ToLower(region) + "|" + model
+}
+
+// MarkRegionExhausted puts region of authID into cooldown for model after a 429 or 503. The
+// cooldown ends at the Retry-After of headers when present, otherwise it backs off
+// progressively. It returns when the region becomes preferred again.
+func MarkRegionExhausted(authID, region, model string, headers http.Header) time.Time {
+	if authID == "" || region == "" {
+		return time.Time{}
+	}
+	now := time.Now()
+	regionCooldowns.mu.Lock()
+	defer regionCooldowns.mu.Unlock()
+
+	regions, ok := regionCooldowns.states[authID]
+	if !ok {
+		regions = make(map[string]*RegionCooldown)
+		regionCooldowns.states[authID] = regions
+	}
+	key := regionCooldownKey(region, model)
+	state, ok := regions[key]
+	if !ok {
+		state = &RegionCooldown{Region: region, Model: model}
+		regions[key] = state
+	}
+	cooldown := regionCooldownBase << state.BackoffLevel
+	if cooldown <= 0 || cooldown > regionCooldownMax {
+		cooldown = regionCooldownMax
+	} else {
+		state.BackoffLevel++
+	}
+	state.Until = now.Add(cooldown)
+	if headers != nil {
+		if retryAt := parseRateLimitReset(headers.Get("Retry-After"), now); retryAt.After(now) {
+			state.Until = retryAt
+		}
+	}
+	return state.Until
+}
+
+// MarkRegionAvailable clears the cooldown of region for authID and model after a success.
+func MarkRegionAvailable(authID, region, model string) {
+	regionCooldowns.mu.Lock()
+	defer regionCooldowns.mu.Unlock()
+
+	regions, ok := regionCooldowns.states[authID]
+	if !ok {
+		return
+	}
+	delete(regions, regionCooldownKey(region, model))
+	if len(regions) == 0 {
+		delete(regionCooldowns.states, authID)
+	}
+}
+
+// forget drops the region cooldowns of an auth that was removed.
+func (t *regionCooldownTracker) forget(authID string) {
+	t.mu.Lock()
+	delete(t.states, authID)
+	t.mu.Unlock()
+}
+
+// OrderRegions returns regions in the order to try them for authID and model: regions without
+// a cooldown keep their configured order and come first, followed by cooling regions ordered by
+// the end of their cooldown.
+func OrderRegions(authID, model string, regions []string) []string {
+	if len(regions) < 2 {
+		return regions
+	}
+	now := time.Now()
+	regionCooldowns.mu.Lock()
+	states := regionCooldowns.states[authID]
+	until := make(map[string]time.Time, len(regions))
+	for _, region := range regions {
+		if state, ok := states[regionCooldownKey(region, model)]; ok && now.Before(state.Until) {
+			until[region] = state.Until
+		}
+	}
+	regionCooldowns.mu.Unlock()
+
+	ordered := make([]string, 0, len(regions))
+	var cooling []string
+	for _, region := range regions {
+		if _, ok := until[region]; ok {
+			cooling = append(cooling, region)
+			continue
+		}
+		ordered = append(ordered, region)
+	}
+	sort.SliceStable(cooling, func(i, j int) bool { return until[cooling[i]].Before(until[cooling[j]]) })
+	return append(ordered, cooling...)
+}
+
+// RegionCooldowns returns the regions of auth that are cooling down, ordered by region and model.
+func RegionCooldowns(auth *Auth) []RegionCooldown {
+	if auth == nil {
+		return nil
+	}
+	now := time.Now()
+	regionCooldowns.mu.Lock()
+	cooldowns := make([]RegionCooldown, 0, len(regionCooldowns.states[auth.ID]))
+	for _, state := range regionCooldowns.states[auth.ID] {
+		if now.Before(state.Until) {
+			cooldowns = append(cooldowns, *state)
+		}
+	}
+	regionCooldowns.mu.Unlock()
+	sort.Slice(cooldowns, func(i, j int) bool {
+		if cooldowns[i].Region != cooldowns[j].Region {
+			return cooldowns[i].Region < cooldowns[j].Region
+		}
+		return cooldowns[i].Model < cooldowns[j].Model
+	})
+	return cooldowns
+}
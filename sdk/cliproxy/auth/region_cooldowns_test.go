@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOrderRegions_TriesCoolingRegionsLast(t *testing.T) {
+	regions := []string{"us-central1", "europe-west4", "asia-northeast1"}
+	if got := OrderRegions("region-a", "gemini-2.5-pro", regions); !reflect.DeepEqual(got, regions) {
+		t.Fatalf("order without cooldowns = %v", got)
+	}
+
+	headers := http.Header{}
+	headers.Set("Retry-After", "120")
+	MarkRegionExhausted("region-a", "us-central1", "gemini-2.5-pro", headers)
+	MarkRegionExhausted("region-a", "europe-west4", "gemini-2.5-pro", nil)
+
+	want := []string{"asia-northeast1", "europe-west4", "us-central1"}
+	if got := OrderRegions("region-a", "gemini-2.5-pro", regions); !reflect.DeepEqual(got, want) {
+		t.Fatalf("order with cooldowns = %v, want %v", got, want)
+	}
+	if got := OrderRegions("region-a", "gemini-2.5-flash", regions); !reflect.DeepEqual(got, regions) {
+		t.Fatalf("cooldown leaked to another model: %v", got)
+	}
+
+	cooldowns := RegionCooldowns(&Auth{ID: "region-a"})
+	if len(cooldowns) != 2 || cooldowns[0].Region != "europe-west4" || cooldowns[1].Region != "us-central1" {
+		t.Fatalf("cooldowns = %+v", cooldowns)
+	}
+	if remaining := time.Until(cooldowns[1].Until); remaining < 110*time.Second || remaining > 120*time.Second {
+		t.Fatalf("retry-after cooldown ends in %v", remaining)
+	}
+
+	MarkRegionAvailable("region-a", "us-central1", "gemini-2.5-pro")
+	want = []string{"us-central1", "asia-northeast1", "europe-west4"}
+	if got := OrderRegions("region-a", "gemini-2.5-pro", regions); !reflect.DeepEqual(got, want) {
+		t.Fatalf("order after recovery = %v, want %v", got, want)
+	}
+	MarkRegionAvailable("region-a", "europe-west4", "gemini-2.5-pro")
+}
+
+func TestMarkRegionExhausted_BacksOffProgressively(t *testing.T) {
+	first := time.Until(MarkRegionExhausted("region-b", "us-east5", "m", nil))
+	second := time.Until(MarkRegionExhausted("region-b", "us-east5", "m", nil))
+	if first > regionCooldownBase || second <= regionCooldownBase || second > 2*regionCooldownBase {
+		t.Fatalf("cooldowns = %v then %v", first, second)
+	}
+	for i := 0; i < 20; i++ {
+		MarkRegionExhausted("region-b", "us-east5", "m", nil)
+	}
+	if last := time.Until(RegionCooldowns(&Auth{ID: "region-b"})[0].Until); last > regionCooldownMax {
+		t.Fatalf("cooldown exceeds max: %v", last)
+	}
+	MarkRegionAvailable("region-b", "us-east5", "m")
+	if cooldowns := RegionCooldowns(&Auth{ID: "region-b"}); len(cooldowns) != 0 {
+		t.Fatalf("cooldowns after recovery = %+v", cooldowns)
+	}
+}
+
+func TestManagerUpdate_ForgetsRegionCooldownsOfDisabledAuth(t *testing.T) {
+	MarkRegionExhausted("region-removed", "us-central1", "gemini-2.5-pro", nil)
+
+	m := NewManager(nil, nil, nil)
+	auth := &Auth{ID: "region-removed", Provider: "vertex", Status: StatusActive}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if got := RegionCooldowns(auth); len(got) != 1 {
+		t.Fatalf("cooldowns before disable = %+v, want one", got)
+	}
+	auth.Disabled = true
+	auth.Status = StatusDisabled
+	if _, err := m.Update(context.Background(), auth); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	regionCooldowns.mu.Lock()
+	_, kept := regionCooldowns.states[auth.ID]
+	regionCooldowns.mu.Unlock()
+	if kept {
+		t.Fatalf("region cooldowns of a removed auth were kept")
+	}
+}
@@ -31,6 +31,7 @@ const (
 	blockReasonNone blockReason = iota
 	blockReasonCooldown
 	blockReasonDisabled
+	blockReasonSaturated
 	blockReasonOther
 )
 
@@ -122,6 +123,8 @@ func collectAvailable(auths []*Auth, model string, now time.Time) (available []*
 	if len(available) > 1 {
 		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
 	}
+	// Pass over auths that are about to run out of provider rate limit while others have room.
+	available = preferRateLimitHeadroom(available, model, now)
 	return available, cooldownCount, earliest
 }
 
@@ -190,6 +193,14 @@ func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, block
 	if auth.Disabled || auth.Status == StatusDisabled {
 		return true, blockReasonDisabled, time.Time{}
 	}
+	// Self-imposed daily ceilings behave like a cooldown until the next day,
+	// while a saturated concurrency cap frees up as soon as a request completes.
+	if saturated, next := usageCapTracker.saturated(auth, now); saturated {
+		if !next.IsZero() {
+			return true, blockReasonCooldown, next
+		}
+		return true, blockReasonSaturated, time.Time{}
+	}
 	if model != "" {
 		if len(auth.ModelStates) > 0 {
 			if state, ok := auth.ModelStates[model]; ok && state != nil {
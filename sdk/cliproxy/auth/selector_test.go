@@ -5,7 +5,9 @@ import (
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
 	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
 )
 
@@ -111,3 +113,92 @@ func TestRoundRobinSelectorPick_Concurrent(t *testing.T) {
 	default:
 	}
 }
+
+func TestFillFirstSelectorPick_SkipsSaturatedAuth(t *testing.T) {
+	t.Parallel()
+
+	selector := &FillFirstSelector{}
+	capped := &Auth{ID: "caps-a", Attributes: map[string]string{AttrMaxConcurrent: "1"}}
+	auths := []*Auth{capped, {ID: "caps-b"}}
+
+	if !usageCapTracker.acquire(capped, time.Now()) {
+		t.Fatalf("acquire() = false, want true")
+	}
+	got, err := selector.Pick(context.Background(), "claude", "", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "caps-b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "caps-b")
+	}
+
+	usageCapTracker.release(capped.ID)
+	got, err = selector.Pick(context.Background(), "claude", "", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() after release error = %v", err)
+	}
+	if got.ID != "caps-a" {
+		t.Fatalf("Pick() after release auth.ID = %q, want %q", got.ID, "caps-a")
+	}
+}
+
+func TestRoundRobinSelectorPick_DailyCeilingCoolsDown(t *testing.T) {
+	t.Parallel()
+
+	selector := &RoundRobinSelector{}
+	capped := &Auth{ID: "caps-daily", Metadata: map[string]any{"max-requests-per-day": float64(1)}}
+
+	if !usageCapTracker.acquire(capped, time.Now()) {
+		t.Fatalf("acquire() = false, want true")
+	}
+	usageCapTracker.release(capped.ID)
+
+	_, err := selector.Pick(context.Background(), "codex", "gpt-5", cliproxyexecutor.Options{}, []*Auth{capped})
+	var cooldown *modelCooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("Pick() error = %v, want model cooldown", err)
+	}
+	if cooldown.resetIn <= 0 {
+		t.Fatalf("cooldown resetIn = %v, want > 0", cooldown.resetIn)
+	}
+}
+
+func TestUsageCaps_SeededDailyUsageSaturates(t *testing.T) {
+	now := time.Now()
+	SeedUsageCaps(now, map[string]UsageCapSeed{"caps-seed-index": {Requests: 3, Tokens: 900}})
+	t.Cleanup(func() { SeedUsageCaps(now, nil) })
+
+	seeded := &Auth{ID: "caps-seeded", Index: "caps-seed-index", Attributes: map[string]string{AttrMaxRequestsPerDay: "3"}}
+	snapshot := UsageCapCounters(seeded)
+	if snapshot.RequestsToday != 3 || snapshot.TokensToday != 900 {
+		t.Fatalf("UsageCapCounters() = %+v, want the seeded 3 requests and 900 tokens", snapshot)
+	}
+	if !snapshot.Saturated {
+		t.Fatalf("UsageCapCounters().Saturated = false, want true after a restart at the daily ceiling")
+	}
+	if usageCapTracker.acquire(seeded, now) {
+		t.Fatalf("acquire() = true, want false for a seeded auth at its ceiling")
+	}
+}
+
+func TestManagerExecuteCount_CountsAgainstDailyCeiling(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(explainStubExecutor{})
+	capped := &Auth{ID: "caps-count", Provider: "explain-test", Status: StatusActive, Attributes: map[string]string{AttrMaxRequestsPerDay: "1"}}
+	if _, err := m.Register(context.Background(), capped); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(capped.ID, capped.Provider, []*registry.ModelInfo{{ID: "caps-count-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(capped.ID) })
+
+	req := cliproxyexecutor.Request{Model: "caps-count-model"}
+	if _, err := m.ExecuteCount(context.Background(), []string{"explain-test"}, req, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("ExecuteCount() error = %v", err)
+	}
+	if got := UsageCapCounters(capped).RequestsToday; got != 1 {
+		t.Fatalf("RequestsToday = %d, want 1 after a token count", got)
+	}
+	if _, err := m.ExecuteCount(context.Background(), []string{"explain-test"}, req, cliproxyexecutor.Options{}); err == nil {
+		t.Fatalf("ExecuteCount() error = nil, want the daily ceiling to block a second token count")
+	}
+}
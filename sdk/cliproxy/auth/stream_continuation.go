@@ -0,0 +1,23 @@
+package auth
+
+import "context"
+
+type continuationContextKey struct{}
+
+// WithStreamContinuation marks ctx so that streaming attempts made with it never reuse an auth
+// that already served part of the same response. Handlers use it to resume a broken stream on
+// a different credential.
+func WithStreamContinuation(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, continuationContextKey{}, newHedgeState())
+}
+
+func continuationStateFromContext(ctx context.Context) *hedgeState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(continuationContextKey{}).(*hedgeState)
+	return state
+}
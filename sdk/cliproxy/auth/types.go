@@ -241,6 +241,13 @@ func (a *Auth) AccountInfo() (string, string) {
 		if v := a.Attributes["api_key"]; v != "" {
 			return "api_key", v
 		}
+		if v := a.Attributes["access_key_id"]; v != "" {
+			return "api_key", v
+		}
+		// Entra ID app registrations (Azure OpenAI) authenticate as the client.
+		if v := a.Attributes["client_id"]; v != "" {
+			return "oauth", v
+		}
 	}
 	return "", ""
 }
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/usage"
+)
+
+// Attribute keys recognised for self-imposed credential usage caps.
+const (
+	AttrMaxConcurrent     = "max_concurrent"
+	AttrMaxRequestsPerDay = "max_requests_per_day"
+	AttrMaxTokensPerDay   = "max_tokens_per_day"
+)
+
+// UsageCaps describes the optional concurrency and daily usage ceilings for an auth.
+// Zero values mean the corresponding cap is not enforced.
+type UsageCaps struct {
+	MaxConcurrent     int   `json:"max_concurrent,omitempty"`
+	MaxRequestsPerDay int64 `json:"max_requests_per_day,omitempty"`
+	MaxTokensPerDay   int64 `json:"max_tokens_per_day,omitempty"`
+}
+
+// Enabled reports whether at least one cap is configured.
+func (c UsageCaps) Enabled() bool {
+	return c.MaxConcurrent > 0 || c.MaxRequestsPerDay > 0 || c.MaxTokensPerDay > 0
+}
+
+// UsageCapSnapshot exposes the live counters tracked for an auth against its caps.
+type UsageCapSnapshot struct {
+	UsageCaps
+	InFlight      int       `json:"in_flight"`
+	Day           string    `json:"day"`
+	RequestsToday int64     `json:"requests_today"`
+	TokensToday   int64     `json:"tokens_today"`
+	Saturated     bool      `json:"saturated"`
+	ResetAt       time.Time `json:"reset_at"`
+}
+
+// UsageCaps resolves the configured caps from attributes (config-backed auths)
+// or metadata (auth JSON files). Attributes take precedence.
+func (a *Auth) UsageCaps() UsageCaps {
+	if a == nil {
+		return UsageCaps{}
+	}
+	return UsageCaps{
+		MaxConcurrent:     int(capValue(a, AttrMaxConcurrent)),
+		MaxRequestsPerDay: capValue(a, AttrMaxRequestsPerDay),
+		MaxTokensPerDay:   capValue(a, AttrMaxTokensPerDay),
+	}
+}
+
+func capValue(a *Auth, key string) int64 {
+	if a.Attributes != nil {
+		if raw := strings.TrimSpace(a.Attributes[key]); raw != "" {
+			if v, err := strconv.ParseInt(raw, 10, 64); err == nil && v > 0 {
+				return v
+			}
+		}
+	}
+	if a.Metadata == nil {
+		return 0
+	}
+	for _, k := range []string{key, strings.ReplaceAll(key, "_", "-")} {
+		raw, ok := a.Metadata[k]
+		if !ok {
+			continue
+		}
+		switch v := raw.(type) {
+		case float64:
+			if v > 0 {
+				return int64(v)
+			}
+		case int:
+			if v > 0 {
+				return int64(v)
+			}
+		case int64:
+			if v > 0 {
+				return v
+			}
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// UsageCapSeed is the usage an auth recorded earlier in the day, before the process started.
+type UsageCapSeed struct {
+	Requests int64
+	Tokens   int64
+}
+
+type capCounter struct {
+	index    string
+	inFlight int
+	day      string
+	requests int64
+	tokens   int64
+}
+
+// capTracker keeps live per-auth counters shared by all managers in the process. Counters
+// start from the seeds restored from persisted usage; replicas do not share counters.
+type capTracker struct {
+	mu       sync.Mutex
+	counters map[string]*capCounter
+	seedDay  string
+	seeds    map[string]UsageCapSeed
+}
+
+var usageCapTracker = &capTracker{counters: make(map[string]*capCounter)}
+
+func init() {
+	coreusage.RegisterPlugin(usageCapPlugin{})
+}
+
+func capDay(now time.Time) string {
+	return now.Format("2006-01-02")
+}
+
+func nextCapReset(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+// counterLocked returns the counter for id, rolling daily totals over when the day changes.
+// A counter starting on the seeded day begins from the seed recorded for its auth index.
+func (t *capTracker) counterLocked(id, index string, now time.Time) *capCounter {
+	c, ok := t.counters[id]
+	if !ok {
+		c = &capCounter{}
+		t.counters[id] = c
+	}
+	if c.index == "" {
+		c.index = index
+	}
+	if day := capDay(now); c.day != day {
+		c.day = day
+		c.requests = 0
+		c.tokens = 0
+		if seed, ok := t.seeds[c.index]; ok && c.index != "" && t.seedDay == day {
+			c.requests = seed.Requests
+			c.tokens = seed.Tokens
+		}
+	}
+	return c
+}
+
+// SeedUsageCaps restores the daily counters from the usage each auth index recorded earlier on
+// the day of now, so a restart does not reset the daily ceilings. Counters that already hold
+// more usage keep their values.
+func SeedUsageCaps(now time.Time, byIndex map[string]UsageCapSeed) {
+	t := usageCapTracker
+	day := capDay(now)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seedDay = day
+	t.seeds = byIndex
+	for _, c := range t.counters {
+		seed, ok := byIndex[c.index]
+		if !ok || c.index == "" || c.day != day {
+			continue
+		}
+		c.requests = max(c.requests, seed.Requests)
+		c.tokens = max(c.tokens, seed.Tokens)
+	}
+}
+
+// blockedLocked reports whether the counter exceeds the caps and, for daily ceilings, when it resets.
+func blockedLocked(c *capCounter, caps UsageCaps, now time.Time) (bool, time.Time) {
+	if c == nil {
+		return false, time.Time{}
+	}
+	if caps.MaxRequestsPerDay > 0 && c.requests >= caps.MaxRequestsPerDay {
+		return true, nextCapReset(now)
+	}
+	if caps.MaxTokensPerDay > 0 && c.tokens >= caps.MaxTokensPerDay {
+		return true, nextCapReset(now)
+	}
+	if caps.MaxConcurrent > 0 && c.inFlight >= caps.MaxConcurrent {
+		return true, time.Time{}
+	}
+	return false, time.Time{}
+}
+
+// saturated reports whether the auth currently exceeds one of its caps.
+func (t *capTracker) saturated(auth *Auth, now time.Time) (bool, time.Time) {
+	caps := auth.UsageCaps()
+	if !caps.Enabled() {
+		return false, time.Time{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return blockedLocked(t.counterLocked(auth.ID, auth.Index, now), caps, now)
+}
+
+// acquire reserves an in-flight slot and counts the request against the daily ceiling.
+// It returns false when the auth is saturated; callers must release acquired slots.
+func (t *capTracker) acquire(auth *Auth, now time.Time) bool {
+	caps := auth.UsageCaps()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.counterLocked(auth.ID, auth.Index, now)
+	if blocked, _ := blockedLocked(c, caps, now); blocked {
+		return false
+	}
+	c.inFlight++
+	c.requests++
+	return true
+}
+
+func (t *capTracker) release(id string) {
+	t.mu.Lock()
+	if c, ok := t.counters[id]; ok && c.inFlight > 0 {
+		c.inFlight--
+	}
+	t.mu.Unlock()
+}
+
+func (t *capTracker) addTokens(id, index string, tokens int64, now time.Time) {
+	if id == "" || tokens <= 0 {
+		return
+	}
+	t.mu.Lock()
+	t.counterLocked(id, index, now).tokens += tokens
+	t.mu.Unlock()
+}
+
+// UsageCapCounters returns the live cap counters for the given auth.
+func UsageCapCounters(auth *Auth) UsageCapSnapshot {
+	snapshot := UsageCapSnapshot{}
+	if auth == nil {
+		return snapshot
+	}
+	now := time.Now()
+	snapshot.UsageCaps = auth.UsageCaps()
+	snapshot.Day = capDay(now)
+	snapshot.ResetAt = nextCapReset(now)
+	usageCapTracker.mu.Lock()
+	c := usageCapTracker.counterLocked(auth.ID, auth.Index, now)
+	snapshot.InFlight = c.inFlight
+	snapshot.RequestsToday = c.requests
+	snapshot.TokensToday = c.tokens
+	usageCapTracker.mu.Unlock()
+	snapshot.Saturated, _ = usageCapTracker.saturated(auth, now)
+	return snapshot
+}
+
+// usageCapPlugin feeds token usage back into the daily token ceilings.
+type usageCapPlugin struct{}
+
+// HandleUsage implements coreusage.Plugin.
+func (usageCapPlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	total := record.Detail.TotalTokens
+	if total <= 0 {
+		total = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	ts := record.RequestedAt
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	usageCapTracker.addTokens(record.AuthID, record.AuthIndex, total, ts)
+}
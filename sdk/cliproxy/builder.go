@@ -216,6 +216,8 @@ func (b *Builder) Build() (*Service, error) {
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetOAuthModelMappings(b.cfg.OAuthModelMappings)
+	coreManager.SetHedgingRules(b.cfg.Routing.Hedging)
+	coreManager.SetHealthProbeConfig(b.cfg.HealthProbes)
 
 	service := &Service{
 		cfg:            b.cfg,
@@ -0,0 +1,277 @@
+package cliproxy
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/config"
+	"github.com/giofahreza/AIProxyAPI/internal/runtime/executor"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
+	"github.com/giofahreza/AIProxyAPI/internal/watcher/diff"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// modelDiscoveryTick is how often the discovery loop checks whether a provider is due for a
+// refresh; each provider's own interval comes from discovery-interval-seconds.
+const modelDiscoveryTick = 30 * time.Second
+
+// modelDiscoveryTimeout bounds a single model listing request.
+const modelDiscoveryTimeout = 30 * time.Second
+
+// discoveredModelSet is the last successful model listing of a provider and the IDs it was
+// registered under.
+type discoveredModelSet struct {
+	models []*ModelInfo
+	ids    []string
+}
+
+type modelFetcher func(ctx context.Context, auth *coreauth.Auth, cfg *config.Config) []*ModelInfo
+
+// startModelDiscovery periodically re-registers the models of providers that report their own
+// models (Ollama servers and openai-compatibility entries with discover-models) so models added
+// or removed upstream are picked up without a restart.
+func (s *Service) startModelDiscovery(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	s.modelDiscoveryCancel = cancel
+	go func() {
+		ticker := time.NewTicker(modelDiscoveryTick)
+		defer ticker.Stop()
+		lastRun := make(map[string]time.Time)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				seen := make(map[string]struct{})
+				for _, a := range s.coreManager.List() {
+					interval := s.modelDiscoveryInterval(a)
+					if a.Disabled || interval <= 0 {
+						continue
+					}
+					seen[a.ID] = struct{}{}
+					last, ok := lastRun[a.ID]
+					if !ok {
+						// Models were registered when the auth was added.
+						lastRun[a.ID] = now
+						continue
+					}
+					if now.Sub(last) < interval {
+						continue
+					}
+					lastRun[a.ID] = now
+					s.registerModelsForAuth(a)
+				}
+				for id := range lastRun {
+					if _, ok := seen[id]; !ok {
+						delete(lastRun, id)
+						s.forgetDiscoveredModels(id)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// modelDiscoveryInterval returns how often the auth's models are rediscovered, or zero when the
+// provider does not discover models.
+func (s *Service) modelDiscoveryInterval(a *coreauth.Auth) time.Duration {
+	if a == nil {
+		return 0
+	}
+	seconds := 0
+	if strings.EqualFold(a.Provider, "ollama") {
+		seconds = config.DefaultOllamaDiscoveryIntervalSeconds
+	} else if _, _, isCompat := openAICompatInfoFromAuth(a); !isCompat {
+		return 0
+	} else if compat := s.resolveConfigOpenAICompat(a); compat == nil || !compat.DiscoverModels {
+		return 0
+	} else {
+		seconds = compat.DiscoveryIntervalSeconds
+	}
+	if v, err := strconv.Atoi(a.Attributes["discovery_interval_seconds"]); err == nil && v > 0 {
+		seconds = v
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// fetchDiscoveredModels lists the auth's models upstream. When the listing fails it returns the
+// last successful result, or nil when there is none.
+func (s *Service) fetchDiscoveredModels(a *coreauth.Auth, fetch modelFetcher) []*ModelInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), modelDiscoveryTimeout)
+	discovered := fetch(ctx, a, s.cfg)
+	cancel()
+
+	s.discoveryMu.Lock()
+	defer s.discoveryMu.Unlock()
+	if s.discoveredModels == nil {
+		s.discoveredModels = make(map[string]*discoveredModelSet)
+	}
+	set := s.discoveredModels[a.ID]
+	if discovered == nil {
+		if set == nil {
+			return nil
+		}
+		log.Debugf("model discovery failed for %s; keeping previous models", a.ID)
+		return set.models
+	}
+	if set == nil {
+		set = &discoveredModelSet{}
+		s.discoveredModels[a.ID] = set
+	}
+	set.models = discovered
+	return discovered
+}
+
+// fetchCopilotModels lists the models of a Copilot account. A missing or expiring Copilot token is
+// refreshed through the auth manager, which persists it, rather than by the listing; an auth the
+// manager does not know yet falls back to the built-in list until its token is refreshed.
+func (s *Service) fetchCopilotModels(ctx context.Context, a *coreauth.Auth, cfg *config.Config) []*ModelInfo {
+	if executor.CopilotTokenNeedsRefresh(a) && s.coreManager != nil {
+		if err := s.coreManager.RefreshAuth(ctx, a.ID); err != nil {
+			log.Debugf("copilot model listing skipped for %s: %v", a.ID, err)
+			return nil
+		}
+		if refreshed, ok := s.coreManager.GetByID(a.ID); ok {
+			a = refreshed
+		}
+	}
+	return executor.FetchCopilotModels(ctx, a, cfg)
+}
+
+// recordDiscoveredModels logs how the models registered for a discovering auth changed since
+// the previous discovery run.
+func (s *Service) recordDiscoveredModels(a *coreauth.Auth, label string, models []*ModelInfo) {
+	ids := make([]string, 0, len(models))
+	for _, model := range models {
+		if model != nil {
+			ids = append(ids, model.ID)
+		}
+	}
+
+	s.discoveryMu.Lock()
+	set := s.discoveredModels[a.ID]
+	if set == nil {
+		s.discoveryMu.Unlock()
+		return
+	}
+	previous := set.ids
+	set.ids = ids
+	s.discoveryMu.Unlock()
+
+	if previous == nil {
+		log.Debugf("model discovery registered %d models for %s", len(ids), label)
+		return
+	}
+	if details := diff.DiffDiscoveredModels(label, previous, ids); len(details) > 0 {
+		log.Debugf("discovered model changes detected:")
+		for _, d := range details {
+			log.Debugf("  %s", d)
+		}
+	}
+}
+
+func (s *Service) forgetDiscoveredModels(authID string) {
+	s.discoveryMu.Lock()
+	delete(s.discoveredModels, authID)
+	s.discoveryMu.Unlock()
+}
+
+// buildOpenAICompatModels returns the models of an openai-compatibility provider: the configured
+// models, followed by the discovered ones that pass the include and exclude patterns. Discovered
+// models take the alias of a configured entry with the same name or of the first matching alias
+// rule.
+func buildOpenAICompatModels(compat *config.OpenAICompatibility, discovered []*ModelInfo) []*ModelInfo {
+	if compat == nil {
+		return nil
+	}
+	now := time.Now().Unix()
+	models := make([]*ModelInfo, 0, len(compat.Models)+len(discovered))
+	seen := make(map[string]struct{}, len(compat.Models)+len(discovered))
+	configured := make(map[string]struct{}, len(compat.Models))
+	add := func(info *ModelInfo) {
+		key := strings.ToLower(info.ID)
+		if _, exists := seen[key]; exists {
+			return
+		}
+		seen[key] = struct{}{}
+		models = append(models, info)
+	}
+	for i := range compat.Models {
+		m := compat.Models[i]
+		if compat.DiscoverModels && m.IsAliasRule() {
+			continue
+		}
+		// Use alias as model ID, fallback to name if alias is empty
+		modelID := m.Alias
+		if modelID == "" {
+			modelID = m.Name
+		}
+		if modelID == "" {
+			continue
+		}
+		configured[strings.ToLower(strings.TrimSpace(m.Name))] = struct{}{}
+		add(&ModelInfo{
+			ID:          modelID,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     compat.Name,
+			Type:        "openai-compatibility",
+			DisplayName: modelID,
+		})
+	}
+	if !compat.DiscoverModels {
+		return models
+	}
+	for _, found := range discovered {
+		if found == nil || found.ID == "" {
+			continue
+		}
+		name := strings.ToLower(found.ID)
+		if _, ok := configured[name]; ok {
+			continue
+		}
+		if len(compat.IncludeModels) > 0 && !matchAnyWildcard(compat.IncludeModels, name) {
+			continue
+		}
+		if matchAnyWildcard(compat.ExcludedModels, name) {
+			continue
+		}
+		modelID := found.ID
+		for i := range compat.Models {
+			if !compat.Models[i].IsAliasRule() {
+				continue
+			}
+			if alias, ok := compat.Models[i].ApplyAliasRule(found.ID); ok {
+				modelID = alias
+				break
+			}
+		}
+		created := found.Created
+		if created <= 0 {
+			created = now
+		}
+		add(&ModelInfo{
+			ID:            modelID,
+			Object:        "model",
+			Created:       created,
+			OwnedBy:       compat.Name,
+			Type:          "openai-compatibility",
+			DisplayName:   modelID,
+			ContextLength: found.ContextLength,
+		})
+	}
+	return models
+}
+
+// matchAnyWildcard matches a lowercased model name against configured patterns of any case.
+func matchAnyWildcard(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if util.MatchWildcard(strings.ToLower(pattern), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package cliproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	coreauth "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/giofahreza/AIProxyAPI/sdk/cliproxy/executor"
+	"github.com/giofahreza/AIProxyAPI/sdk/config"
+)
+
+func TestBuildOpenAICompatModels_FiltersAndAliasesDiscoveredModels(t *testing.T) {
+	compat := &config.OpenAICompatibility{
+		Name:           "acme",
+		DiscoverModels: true,
+		IncludeModels:  []string{"accounts/acme/models/*", "gpt-*"},
+		ExcludedModels: []string{"*-preview"},
+		Models: []config.OpenAICompatibilityModel{
+			{Name: "gpt-4o", Alias: "acme-4o"},
+			{Name: "accounts/acme/models/*", Alias: "acme-*"},
+		},
+	}
+	discovered := []*ModelInfo{
+		{ID: "gpt-4o", ContextLength: 128000},
+		{ID: "gpt-4.1", ContextLength: 1047576},
+		{ID: "gpt-5-preview"},
+		{ID: "accounts/acme/models/llama-70b"},
+		{ID: "whisper-1"},
+	}
+
+	out := buildOpenAICompatModels(compat, discovered)
+	got := make([]string, 0, len(out))
+	for _, info := range out {
+		got = append(got, info.ID)
+	}
+	want := []string{"acme-4o", "gpt-4.1", "acme-llama-70b"}
+	if len(got) != len(want) {
+		t.Fatalf("expected models %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected models %v, got %v", want, got)
+		}
+	}
+	if out[1].ContextLength != 1047576 {
+		t.Fatalf("expected discovered context length, got %d", out[1].ContextLength)
+	}
+}
+
+func TestBuildOpenAICompatModels_IgnoresDiscoveryWhenDisabled(t *testing.T) {
+	compat := &config.OpenAICompatibility{
+		Name:   "acme",
+		Models: []config.OpenAICompatibilityModel{{Name: "gpt-4o"}},
+	}
+	out := buildOpenAICompatModels(compat, []*ModelInfo{{ID: "gpt-4.1"}})
+	if len(out) != 1 || out[0].ID != "gpt-4o" {
+		t.Fatalf("expected only configured models, got %+v", out)
+	}
+}
+
+type copilotRefreshExecutor struct {
+	apiBase   string
+	refreshes int
+}
+
+func (e *copilotRefreshExecutor) Identifier() string { return "copilot" }
+
+func (e *copilotRefreshExecutor) Execute(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *copilotRefreshExecutor) ExecuteStream(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, nil
+}
+
+func (e *copilotRefreshExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	e.refreshes++
+	auth.Metadata["copilot_token"] = "tid=fresh"
+	auth.Metadata["copilot_api_base"] = e.apiBase
+	auth.Metadata["copilot_expire"] = time.Now().Add(time.Hour).Format(time.RFC3339)
+	return auth, nil
+}
+
+func (e *copilotRefreshExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestFetchCopilotModels_RefreshesThroughManager(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tid=fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":[{"id":"gpt-4.1","capabilities":{"type":"chat"}}]}`)
+	}))
+	defer srv.Close()
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	exec := &copilotRefreshExecutor{apiBase: srv.URL}
+	manager.RegisterExecutor(exec)
+	stale := &coreauth.Auth{ID: "copilot-discovery", Provider: "copilot", Metadata: map[string]any{
+		"github_token": "gho_x", "copilot_token": "tid=stale", "copilot_expire": time.Now().Add(-time.Minute).Format(time.RFC3339),
+	}}
+	if _, err := manager.Register(context.Background(), stale); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	s := &Service{cfg: &config.Config{}, coreManager: manager}
+
+	models := s.fetchCopilotModels(context.Background(), stale.Clone(), s.cfg)
+	if exec.refreshes != 1 || len(models) != 1 || models[0].ID != "gpt-4.1" {
+		t.Fatalf("refreshes = %d, models = %+v, want one refresh and the account's model list", exec.refreshes, models)
+	}
+	if stored, _ := manager.GetByID(stale.ID); stored.Metadata["copilot_token"] != "tid=fresh" {
+		t.Fatalf("stored copilot_token = %v, want the refreshed token kept by the manager", stored.Metadata["copilot_token"])
+	}
+}
+
+func TestRegisterModelsForAuth_CopilotFallsBackToBuiltInModels(t *testing.T) {
+	s := &Service{cfg: &config.Config{}, coreManager: coreauth.NewManager(nil, nil, nil)}
+	auth := &coreauth.Auth{ID: "copilot-fallback", Provider: "copilot", Metadata: map[string]any{"github_token": "gho_x"}}
+	s.registerModelsForAuth(auth)
+	t.Cleanup(func() { GlobalModelRegistry().UnregisterClient(auth.ID) })
+
+	got := registry.GetGlobalRegistry().GetModelsForClient(auth.ID)
+	if want := registry.GetCopilotModels(); len(got) != len(want) || len(got) == 0 {
+		t.Fatalf("registered %d models, want the %d built-in Copilot models", len(got), len(want))
+	}
+}
@@ -13,9 +13,11 @@ import (
 	"time"
 
 	"github.com/giofahreza/AIProxyAPI/internal/api"
+	"github.com/giofahreza/AIProxyAPI/internal/cluster"
 	"github.com/giofahreza/AIProxyAPI/internal/registry"
 	"github.com/giofahreza/AIProxyAPI/internal/runtime/executor"
-	_ "github.com/giofahreza/AIProxyAPI/internal/usage"
+	internalusage "github.com/giofahreza/AIProxyAPI/internal/usage"
+	"github.com/giofahreza/AIProxyAPI/internal/util"
 	"github.com/giofahreza/AIProxyAPI/internal/watcher"
 	"github.com/giofahreza/AIProxyAPI/internal/wsrelay"
 	sdkaccess "github.com/giofahreza/AIProxyAPI/sdk/access"
@@ -86,6 +88,18 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// modelDiscoveryCancel stops the periodic model discovery loop.
+	modelDiscoveryCancel context.CancelFunc
+
+	// discoveryMu guards discoveredModels.
+	discoveryMu sync.Mutex
+
+	// discoveredModels keeps the last model discovery result per auth ID.
+	discoveredModels map[string]*discoveredModelSet
+
+	// clusterNode shares auth state and request counters with peer replicas when cluster mode is on.
+	clusterNode *cluster.Node
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -300,6 +314,7 @@ func (s *Service) applyCoreAuthRemoval(ctx context.Context, id string) {
 		return
 	}
 	GlobalModelRegistry().UnregisterClient(id)
+	s.forgetDiscoveredModels(id)
 	if existing, ok := s.coreManager.GetByID(id); ok && existing != nil {
 		existing.Disabled = true
 		existing.Status = coreauth.StatusDisabled
@@ -373,6 +388,12 @@ func (s *Service) ensureExecutorsForAuth(a *coreauth.Auth) {
 		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
 	case "claude":
 		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
+	case "azure-openai":
+		s.coreManager.RegisterExecutor(executor.NewAzureOpenAIExecutor(s.cfg))
+	case "ollama":
+		s.coreManager.RegisterExecutor(executor.NewOllamaExecutor(s.cfg))
 	case "codex":
 		s.coreManager.RegisterExecutor(executor.NewCodexExecutor(s.cfg))
 	case "qwen":
@@ -440,6 +461,8 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 	}
 
+	s.startCluster(ctx)
+
 	tokenResult, err := s.tokenProvider.Load(ctx, s.cfg)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		return err
@@ -556,6 +579,8 @@ func (s *Service) Run(ctx context.Context) error {
 		s.cfgMu.Unlock()
 		if s.coreManager != nil {
 			s.coreManager.SetOAuthModelMappings(newCfg.OAuthModelMappings)
+			s.coreManager.SetHedgingRules(newCfg.Routing.Hedging)
+			s.coreManager.SetHealthProbeConfig(newCfg.HealthProbes)
 		}
 		s.rebindExecutors()
 	}
@@ -583,6 +608,8 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		s.coreManager.StartHealthProbes(context.Background())
+		s.startModelDiscovery(context.Background())
 	}
 
 	select {
@@ -620,6 +647,14 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopHealthProbes()
+		}
+		if s.modelDiscoveryCancel != nil {
+			s.modelDiscoveryCancel()
+		}
+		if s.clusterNode != nil {
+			s.clusterNode.Stop()
+			s.clusterNode = nil
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -658,6 +693,21 @@ func (s *Service) Shutdown(ctx context.Context) error {
 	return shutdownErr
 }
 
+// startCluster joins the replica cluster when enabled. Cluster mode needs a token store that can
+// broadcast state, which today is the Postgres store.
+func (s *Service) startCluster(ctx context.Context) {
+	if s.cfg == nil || !s.cfg.Cluster.Enabled || s.coreManager == nil {
+		return
+	}
+	backend, ok := sdkAuth.GetTokenStore().(cluster.Backend)
+	if !ok {
+		log.Warn("cluster mode is enabled but the auth store does not support it; configure the Postgres store (PGSTORE_DSN)")
+		return
+	}
+	s.clusterNode = cluster.NewNode(backend, s.coreManager, internalusage.GetRequestStatistics(), s.cfg.Cluster)
+	s.clusterNode.Start(ctx)
+}
+
 func (s *Service) ensureAuthDir() error {
 	info, err := os.Stat(s.cfg.AuthDir)
 	if err != nil {
@@ -751,6 +801,36 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			}
 		}
 		models = applyExcludedModels(models, excluded)
+	case "bedrock":
+		// Bedrock serves only the model IDs mapped in the credential's configuration.
+		if entry := s.resolveConfigBedrockKey(a); entry != nil {
+			models = buildBedrockConfigModels(entry)
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
+	case "azure-openai":
+		// Azure OpenAI serves only the models mapped to deployments on the resource.
+		if entry := s.resolveConfigAzureOpenAIKey(a); entry != nil {
+			models = buildAzureOpenAIConfigModels(entry)
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
+	case "ollama":
+		// Ollama servers report their own models; a configured list only filters and aliases them.
+		discovered := s.fetchDiscoveredModels(a, executor.FetchOllamaModels)
+		entry := s.resolveConfigOllamaKey(a)
+		if discovered == nil && (entry == nil || len(entry.Models) == 0) {
+			// Keep the registered models while the server has never answered.
+			log.Debugf("ollama model discovery failed for %s; keeping previous models", a.ID)
+			return
+		}
+		models = discovered
+		if entry != nil {
+			models = buildOllamaModels(entry, discovered)
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
+		s.recordDiscoveredModels(a, "ollama "+a.Label, models)
 	case "codex":
 		models = registry.GetOpenAIModels()
 		if entry := s.resolveConfigCodexKey(a); entry != nil {
@@ -769,7 +849,13 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		models = registry.GetIFlowModels()
 		models = applyExcludedModels(models, excluded)
 	case "copilot":
-		models = registry.GetCopilotModels()
+		// Copilot plans differ in the models they offer, so prefer the account's own list.
+		models = s.fetchDiscoveredModels(a, s.fetchCopilotModels)
+		if len(models) == 0 {
+			models = registry.GetCopilotModels()
+		} else {
+			s.recordDiscoveredModels(a, "copilot "+a.Label, models)
+		}
 		models = applyExcludedModels(models, excluded)
 	default:
 		// Handle OpenAI-compatibility providers by name using config
@@ -815,22 +901,13 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 				if strings.EqualFold(compat.Name, compatName) {
 					isCompatAuth = true
 					// Convert compatibility models to registry models
-					ms := make([]*ModelInfo, 0, len(compat.Models))
-					for j := range compat.Models {
-						m := compat.Models[j]
-						// Use alias as model ID, fallback to name if alias is empty
-						modelID := m.Alias
-						if modelID == "" {
-							modelID = m.Name
-						}
-						ms = append(ms, &ModelInfo{
-							ID:          modelID,
-							Object:      "model",
-							Created:     time.Now().Unix(),
-							OwnedBy:     compat.Name,
-							Type:        "openai-compatibility",
-							DisplayName: modelID,
-						})
+					var discovered []*ModelInfo
+					if compat.DiscoverModels {
+						discovered = s.fetchDiscoveredModels(a, executor.FetchOpenAICompatModels)
+					}
+					ms := buildOpenAICompatModels(compat, discovered)
+					if compat.DiscoverModels {
+						s.recordDiscoveredModels(a, compat.Name, ms)
 					}
 					// Register and return
 					if len(ms) > 0 {
@@ -904,6 +981,80 @@ func (s *Service) resolveConfigClaudeKey(auth *coreauth.Auth) *config.ClaudeKey
 	return nil
 }
 
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if auth == nil || auth.Attributes == nil || s.cfg == nil {
+		return nil
+	}
+	accessKey := strings.TrimSpace(auth.Attributes["access_key_id"])
+	region := strings.TrimSpace(auth.Attributes["region"])
+	endpoint := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if entry.AccessKeyID == accessKey && strings.EqualFold(entry.Region, region) && strings.EqualFold(entry.Endpoint, endpoint) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigAzureOpenAIKey(auth *coreauth.Auth) *config.AzureOpenAIKey {
+	if auth == nil || auth.Attributes == nil || s.cfg == nil {
+		return nil
+	}
+	endpoint := strings.TrimSpace(auth.Attributes["base_url"])
+	apiKey := strings.TrimSpace(auth.Attributes["api_key"])
+	clientID := strings.TrimSpace(auth.Attributes["client_id"])
+	for i := range s.cfg.AzureOpenAI {
+		entry := &s.cfg.AzureOpenAI[i]
+		if strings.EqualFold(entry.Endpoint, endpoint) && entry.APIKey == apiKey && (apiKey != "" || entry.ClientID == clientID) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigOpenAICompat(auth *coreauth.Auth) *config.OpenAICompatibility {
+	if auth == nil || s.cfg == nil {
+		return nil
+	}
+	candidates := make([]string, 0, 3)
+	if auth.Attributes != nil {
+		if v := strings.TrimSpace(auth.Attributes["compat_name"]); v != "" {
+			candidates = append(candidates, v)
+		}
+		if v := strings.TrimSpace(auth.Attributes["provider_key"]); v != "" {
+			candidates = append(candidates, v)
+		}
+	}
+	if v := strings.TrimSpace(auth.Provider); v != "" {
+		candidates = append(candidates, v)
+	}
+	for _, candidate := range candidates {
+		for i := range s.cfg.OpenAICompatibility {
+			compat := &s.cfg.OpenAICompatibility[i]
+			if strings.EqualFold(candidate, strings.TrimSpace(compat.Name)) {
+				return compat
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigOllamaKey(auth *coreauth.Auth) *config.OllamaKey {
+	if auth == nil || auth.Attributes == nil || s.cfg == nil {
+		return nil
+	}
+	baseURL := strings.TrimSpace(auth.Attributes["base_url"])
+	apiKey := strings.TrimSpace(auth.Attributes["api_key"])
+	for i := range s.cfg.Ollama {
+		entry := &s.cfg.Ollama[i]
+		if strings.EqualFold(entry.BaseURL, baseURL) && entry.APIKey == apiKey {
+			return entry
+		}
+	}
+	return nil
+}
+
 func (s *Service) resolveConfigGeminiKey(auth *coreauth.Auth) *config.GeminiKey {
 	if auth == nil || s.cfg == nil {
 		return nil
@@ -1026,7 +1177,7 @@ func applyExcludedModels(models []*ModelInfo, excluded []string) []*ModelInfo {
 		modelID := strings.ToLower(strings.TrimSpace(model.ID))
 		blocked := false
 		for _, pattern := range patterns {
-			if matchWildcard(pattern, modelID) {
+			if util.MatchWildcard(pattern, modelID) {
 				blocked = true
 				break
 			}
@@ -1080,50 +1231,6 @@ func applyModelPrefixes(models []*ModelInfo, prefix string, forceModelPrefix boo
 	return out
 }
 
-// matchWildcard performs case-insensitive wildcard matching where '*' matches any substring.
-func matchWildcard(pattern, value string) bool {
-	if pattern == "" {
-		return false
-	}
-
-	// Fast path for exact match (no wildcard present).
-	if !strings.Contains(pattern, "*") {
-		return pattern == value
-	}
-
-	parts := strings.Split(pattern, "*")
-	// Handle prefix.
-	if prefix := parts[0]; prefix != "" {
-		if !strings.HasPrefix(value, prefix) {
-			return false
-		}
-		value = value[len(prefix):]
-	}
-
-	// Handle suffix.
-	if suffix := parts[len(parts)-1]; suffix != "" {
-		if !strings.HasSuffix(value, suffix) {
-			return false
-		}
-		value = value[:len(value)-len(suffix)]
-	}
-
-	// Handle middle segments in order.
-	for i := 1; i < len(parts)-1; i++ {
-		segment := parts[i]
-		if segment == "" {
-			continue
-		}
-		idx := strings.Index(value, segment)
-		if idx < 0 {
-			return false
-		}
-		value = value[idx+len(segment):]
-	}
-
-	return true
-}
-
 type modelEntry interface {
 	GetName() string
 	GetAlias() string
@@ -1194,6 +1301,76 @@ func buildClaudeConfigModels(entry *config.ClaudeKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "anthropic", "claude")
 }
 
+func buildBedrockConfigModels(entry *config.BedrockKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "anthropic", "claude")
+}
+
+func buildAzureOpenAIConfigModels(entry *config.AzureOpenAIKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "azure", "openai")
+}
+
+// buildOllamaModels returns the discovered models, or, when the server entry lists models, only
+// those under their aliases with the discovered context length and thinking support. Context
+// lengths configured for the server or a model replace the discovered ones.
+func buildOllamaModels(entry *config.OllamaKey, discovered []*ModelInfo) []*ModelInfo {
+	if entry == nil {
+		return discovered
+	}
+	if len(entry.Models) == 0 {
+		if entry.ContextLength <= 0 {
+			return discovered
+		}
+		models := make([]*ModelInfo, 0, len(discovered))
+		for _, info := range discovered {
+			clone := *info
+			clone.ContextLength = entry.ContextLength
+			models = append(models, &clone)
+		}
+		return models
+	}
+	byName := make(map[string]*ModelInfo, len(discovered))
+	for _, info := range discovered {
+		byName[strings.ToLower(info.ID)] = info
+	}
+	names := make(map[string]string, len(entry.Models))
+	contextLengths := make(map[string]int, len(entry.Models))
+	for _, model := range entry.Models {
+		alias := model.Alias
+		if alias == "" {
+			alias = model.Name
+		}
+		names[strings.ToLower(alias)] = strings.ToLower(model.Name)
+		contextLengths[strings.ToLower(alias)] = model.ContextLength
+	}
+	models := buildConfigModels(entry.Models, "ollama", "ollama")
+	for _, info := range models {
+		name := names[strings.ToLower(info.ID)]
+		found, ok := byName[name]
+		if !ok && !strings.Contains(name, ":") {
+			found, ok = byName[name+":latest"]
+		}
+		if ok {
+			info.Name = found.Name
+			info.Created = found.Created
+			info.Description = found.Description
+			info.ContextLength = found.ContextLength
+			info.Thinking = found.Thinking
+		}
+		if ctxLen := contextLengths[strings.ToLower(info.ID)]; ctxLen > 0 {
+			info.ContextLength = ctxLen
+		} else if entry.ContextLength > 0 {
+			info.ContextLength = entry.ContextLength
+		}
+	}
+	return models
+}
+
 func buildCodexConfigModels(entry *config.CodexKey) []*ModelInfo {
 	if entry == nil {
 		return nil
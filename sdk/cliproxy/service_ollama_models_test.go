@@ -0,0 +1,83 @@
+package cliproxy
+
+import (
+	"testing"
+
+	"github.com/giofahreza/AIProxyAPI/internal/registry"
+	"github.com/giofahreza/AIProxyAPI/sdk/config"
+)
+
+func TestBuildOllamaModels_ServesDiscoveredWithoutConfiguredList(t *testing.T) {
+	discovered := []*ModelInfo{{ID: "qwen3:32b"}, {ID: "llama3.2:latest"}}
+	out := buildOllamaModels(&config.OllamaKey{BaseURL: "http://gpu-1:11434"}, discovered)
+	if len(out) != 2 {
+		t.Fatalf("expected 2 models, got %d", len(out))
+	}
+}
+
+func TestBuildOllamaModels_AliasesConfiguredModels(t *testing.T) {
+	thinking := &registry.ThinkingSupport{Levels: []string{"none", "low", "medium", "high"}}
+	discovered := []*ModelInfo{
+		{ID: "qwen3:32b", Name: "qwen3:32b", ContextLength: 40960, Thinking: thinking},
+		{ID: "llama3.2:latest", Name: "llama3.2:latest", ContextLength: 131072},
+		{ID: "unlisted:7b", Name: "unlisted:7b"},
+	}
+	entry := &config.OllamaKey{
+		BaseURL: "http://gpu-1:11434",
+		Models: []config.OllamaModel{
+			{Name: "qwen3:32b", Alias: "qwen"},
+			{Name: "llama3.2"},
+			{Name: "not-pulled"},
+		},
+	}
+
+	out := buildOllamaModels(entry, discovered)
+	if len(out) != 3 {
+		t.Fatalf("expected 3 models, got %d", len(out))
+	}
+	byID := make(map[string]*ModelInfo, len(out))
+	for _, info := range out {
+		byID[info.ID] = info
+	}
+	if qwen := byID["qwen"]; qwen == nil || qwen.ContextLength != 40960 || qwen.Thinking != thinking || qwen.Name != "qwen3:32b" {
+		t.Errorf("expected alias with discovered metadata, got %+v", qwen)
+	}
+	if llama := byID["llama3.2"]; llama == nil || llama.ContextLength != 131072 {
+		t.Errorf("expected untagged name to match :latest, got %+v", llama)
+	}
+	if missing := byID["not-pulled"]; missing == nil || missing.ContextLength != 0 {
+		t.Errorf("expected configured model without metadata, got %+v", missing)
+	}
+	if _, ok := byID["unlisted:7b"]; ok {
+		t.Error("expected unlisted model to be filtered out")
+	}
+}
+
+func TestBuildOllamaModels_ConfiguredContextLengthWins(t *testing.T) {
+	discovered := []*ModelInfo{
+		{ID: "qwen3:32b", ContextLength: 4096},
+		{ID: "llama3.2:latest", ContextLength: 4096},
+	}
+	serverWide := buildOllamaModels(&config.OllamaKey{ContextLength: 32768}, discovered)
+	if len(serverWide) != 2 || serverWide[0].ContextLength != 32768 || serverWide[1].ContextLength != 32768 {
+		t.Fatalf("expected server context length on every model, got %+v %+v", serverWide[0], serverWide[1])
+	}
+	if discovered[0].ContextLength != 4096 {
+		t.Fatal("expected discovered models to be left untouched")
+	}
+
+	entry := &config.OllamaKey{
+		ContextLength: 32768,
+		Models: []config.OllamaModel{
+			{Name: "qwen3:32b", ContextLength: 65536},
+			{Name: "llama3.2"},
+		},
+	}
+	byID := make(map[string]*ModelInfo)
+	for _, info := range buildOllamaModels(entry, discovered) {
+		byID[info.ID] = info
+	}
+	if byID["qwen3:32b"].ContextLength != 65536 || byID["llama3.2"].ContextLength != 32768 {
+		t.Fatalf("expected model then server context length, got %+v %+v", byID["qwen3:32b"], byID["llama3.2"])
+	}
+}
@@ -0,0 +1,120 @@
+package usage
+
+import (
+	"context"
+	"sync"
+)
+
+type deferredContextKey struct{}
+
+// Deferred buffers usage records published under a context until the caller
+// decides whether they should be billed. It is used by hedged requests so that
+// only the winning attempt reaches the plugins.
+type Deferred struct {
+	mu      sync.Mutex
+	items   []queueItem
+	manager *Manager
+	settled bool
+	discard bool
+	hedged  bool
+	sink    func(context.Context, Record)
+}
+
+// WithDeferredPublish returns a child context whose usage records are held by the returned Deferred.
+func WithDeferredPublish(ctx context.Context) (context.Context, *Deferred) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	d := &Deferred{}
+	return context.WithValue(ctx, deferredContextKey{}, d), d
+}
+
+func deferredFromContext(ctx context.Context) *Deferred {
+	if ctx == nil {
+		return nil
+	}
+	d, _ := ctx.Value(deferredContextKey{}).(*Deferred)
+	return d
+}
+
+// hold buffers the record while the outcome is pending and reports whether it was consumed.
+// Records arriving after Flush are marked and passed through; records arriving after Discard
+// go to the discard sink, if any, and never reach the plugins.
+func (d *Deferred) hold(m *Manager, ctx context.Context, record Record) (Record, bool) {
+	d.mu.Lock()
+	if !d.settled {
+		d.manager = m
+		d.items = append(d.items, queueItem{ctx: ctx, record: record})
+		d.mu.Unlock()
+		return record, true
+	}
+	if d.discard {
+		sink := d.sink
+		d.mu.Unlock()
+		if sink != nil {
+			sink(ctx, record)
+		}
+		return record, true
+	}
+	record.Hedged = record.Hedged || d.hedged
+	d.mu.Unlock()
+	return record, false
+}
+
+// Flush publishes the buffered records. When hedged is true each record is marked as
+// the winner of a hedged request.
+func (d *Deferred) Flush(hedged bool) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	if d.settled {
+		d.mu.Unlock()
+		return
+	}
+	d.settled = true
+	d.hedged = hedged
+	items := d.items
+	d.items = nil
+	manager := d.manager
+	d.mu.Unlock()
+	if manager == nil {
+		return
+	}
+	for _, item := range items {
+		record := item.record
+		record.Hedged = hedged
+		manager.enqueue(item.ctx, record)
+	}
+}
+
+// Discard drops the buffered records and any records published later under the same context.
+func (d *Deferred) Discard() {
+	d.DiscardTo(nil)
+}
+
+// DiscardTo keeps the buffered records and any records published later under the same context
+// away from the plugins, handing them to sink instead. Callers use it for usage the upstream
+// still charged but that must not be billed, such as the losing attempt of a hedged request.
+func (d *Deferred) DiscardTo(sink func(context.Context, Record)) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	if d.settled {
+		d.mu.Unlock()
+		return
+	}
+	d.settled = true
+	d.discard = true
+	d.sink = sink
+	items := d.items
+	d.items = nil
+	d.mu.Unlock()
+	if sink == nil {
+		return
+	}
+	for _, item := range items {
+		sink(item.ctx, item.record)
+	}
+}
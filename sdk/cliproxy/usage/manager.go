@@ -10,25 +10,33 @@ import (
 
 // Record contains the usage statistics captured for a single provider request.
 type Record struct {
-	Provider    string
-	Model       string
-	APIKey      string
-	AuthID      string
-	AuthIndex   string
-	Source      string
+	Provider  string
+	Model     string
+	APIKey    string
+	AuthID    string
+	AuthIndex string
+	Source    string
+	// EndUser identifies the person behind a shared API key, when the client supplied one.
+	EndUser     string
 	RequestedAt time.Time
 	Failed      bool
-	Detail      Detail
-	UserPrompt  string
+	// Hedged marks records produced by the winning attempt of a hedged request.
+	Hedged     bool
+	Detail     Detail
+	UserPrompt string
 }
 
-// Detail holds the token usage breakdown.
+// Detail holds the token usage breakdown. InputTokens counts the whole prompt; CachedTokens and
+// CacheWriteTokens are the parts of it read from and written to the provider's prompt cache.
 type Detail struct {
-	InputTokens     int64
-	OutputTokens    int64
-	ReasoningTokens int64
-	CachedTokens    int64
-	TotalTokens     int64
+	InputTokens      int64
+	OutputTokens     int64
+	ReasoningTokens  int64
+	CachedTokens     int64
+	CacheWriteTokens int64
+	TotalTokens      int64
+	// Images counts the images the request generated.
+	Images int64
 }
 
 // Plugin consumes usage records emitted by the proxy runtime.
@@ -110,6 +118,16 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	if m == nil {
 		return
 	}
+	if deferred := deferredFromContext(ctx); deferred != nil {
+		var held bool
+		if record, held = deferred.hold(m, ctx, record); held {
+			return
+		}
+	}
+	m.enqueue(ctx, record)
+}
+
+func (m *Manager) enqueue(ctx context.Context, record Record) {
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
 	m.mu.Lock()
@@ -26,6 +26,12 @@ type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
+type BedrockKey = internalconfig.BedrockKey
+type BedrockModel = internalconfig.BedrockModel
+type AzureOpenAIKey = internalconfig.AzureOpenAIKey
+type AzureOpenAIModel = internalconfig.AzureOpenAIModel
+type OllamaKey = internalconfig.OllamaKey
+type OllamaModel = internalconfig.OllamaModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
@@ -35,6 +41,8 @@ type TLS = internalconfig.TLSConfig
 const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
+
+	DefaultOllamaDiscoveryIntervalSeconds = internalconfig.DefaultOllamaDiscoveryIntervalSeconds
 )
 
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
@@ -2,6 +2,7 @@ package translator
 
 import (
 	"context"
+	"sort"
 	"sync"
 )
 
@@ -104,6 +105,59 @@ func (r *Registry) TranslateTokenCount(ctx context.Context, from, to Format, cou
 	return string(rawJSON)
 }
 
+// Pair describes the transforms registered for one client/upstream format combination.
+// From is the client-facing format and To the upstream format, matching Register.
+type Pair struct {
+	From      Format `json:"from"`
+	To        Format `json:"to"`
+	Request   bool   `json:"request"`
+	Stream    bool   `json:"stream"`
+	NonStream bool   `json:"non_stream"`
+}
+
+// Pairs lists every registered format combination, sorted by From then To.
+func (r *Registry) Pairs() []Pair {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index := make(map[[2]Format]*Pair)
+	lookup := func(from, to Format) *Pair {
+		key := [2]Format{from, to}
+		if pair, ok := index[key]; ok {
+			return pair
+		}
+		pair := &Pair{From: from, To: to}
+		index[key] = pair
+		return pair
+	}
+	for from, byTarget := range r.requests {
+		for to, fn := range byTarget {
+			if fn != nil {
+				lookup(from, to).Request = true
+			}
+		}
+	}
+	for from, byTarget := range r.responses {
+		for to, fn := range byTarget {
+			pair := lookup(from, to)
+			pair.Stream = fn.Stream != nil
+			pair.NonStream = fn.NonStream != nil
+		}
+	}
+
+	pairs := make([]Pair, 0, len(index))
+	for _, pair := range index {
+		pairs = append(pairs, *pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	return pairs
+}
+
 var defaultRegistry = NewRegistry()
 
 // Default exposes the package-level registry for shared use.
@@ -140,3 +194,8 @@ func TranslateNonStream(ctx context.Context, from, to Format, model string, orig
 func TranslateTokenCount(ctx context.Context, from, to Format, count int64, rawJSON []byte) string {
 	return defaultRegistry.TranslateTokenCount(ctx, from, to, count, rawJSON)
 }
+
+// Pairs lists the format combinations registered on the default registry.
+func Pairs() []Pair {
+	return defaultRegistry.Pairs()
+}